@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+)
+
+const (
+	// aliasCommand is the git alias "git-cc-ai alias install" configures.
+	aliasCommand = "!git-cc-ai commit"
+	// generateOnlyEnv marks the generating child of "git-cc-ai commit", so
+	// that it never runs commit itself whatever its name or arguments.
+	generateOnlyEnv = "GIT_AI_GENERATE_ONLY"
+)
+
+// generateOnly reports whether this process is the generating child of
+// "git-cc-ai commit".
+func generateOnly() bool {
+	return os.Getenv(generateOnlyEnv) == "1"
+}
+
+// invokedAsGitAI reports whether the binary was started under the git-ai
+// name (a link or copy next to git-cc-ai), in which case it behaves like
+// "git-cc-ai commit".
+func invokedAsGitAI() bool {
+	name := strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+	return name == "git-ai" && !generateOnly()
+}
+
+// runCommit implements "git-cc-ai commit": it generates the message with
+// the given flags and arguments, then runs git commit with it and opens the
+// editor, which is what the git ai alias runs. --amend, --signoff, -S and
+// --gpg-sign are passed on to git commit as well.
+func runCommit(args []string) int {
+	self, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot find the git-cc-ai executable: %v\n", err)
+		return exitFailure
+	}
+	args = gitSignSpellings(args)
+	var message bytes.Buffer
+	cmd := exec.Command(self, args...)
+	cmd.Env = append(os.Environ(), generateOnlyEnv+"=1")
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = &message
+	cmd.Stderr = os.Stderr
+	if err = cmd.Run(); err != nil {
+		return childExitCode(err)
+	}
+	if err = git.CommitEdit(message.String(), commitArgs(args)...); err != nil {
+		return childExitCode(err)
+	}
+	return 0
+}
+
+// commitArgs returns the git commit options among the flags in args, up
+// to "--": --amend, --signoff and the signing flags. args must have gone
+// through gitSignSpellings.
+func commitArgs(args []string) []string {
+	var (
+		out     []string
+		sign    bool
+		keyID   string
+		amend   bool
+		signoff bool
+	)
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || (hasValue && name != "gpg-sign" && value != "true" && value != "false") {
+			continue
+		}
+		switch name {
+		case "amend":
+			amend = value != "false"
+		case "signoff":
+			signoff = value != "false"
+		case "S":
+			sign = value != "false"
+		case "gpg-sign":
+			keyID = value
+		}
+	}
+	if amend {
+		out = append(out, "--amend")
+	}
+	if signoff {
+		out = append(out, "--signoff")
+	}
+	return append(out, signArgs(sign, keyID)...)
+}
+
+// childExitCode passes on the exit status of a failed child process; the
+// child already reported the failure on stderr.
+func childExitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	fmt.Fprintln(os.Stderr, err.Error())
+	return exitFailure
+}
+
+// runAlias implements "git-cc-ai alias install", which configures the git
+// alias that makes "git ai" generate a message and commit with it.
+func runAlias(args []string) int {
+	fs := flag.NewFlagSet("alias", flag.ContinueOnError)
+	var (
+		global bool
+		name   string
+		force  bool
+	)
+	fs.BoolVar(&global, "global", false, "configure the alias in the global git config instead of the repository's")
+	fs.StringVar(&name, "name", "ai", "alias name (git <name>)")
+	fs.BoolVar(&force, "force", false, "replace an existing alias of the same name")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: git-cc-ai alias install [--global] [--name ai] [--force]")
+		fs.PrintDefaults()
+	}
+	positional, err := parseArgs(fs, args, nil)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 1 || positional[0] != "install" {
+		fs.Usage()
+		return 2
+	}
+	if err = configureAlias(name, global, force); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return exitFailure
+	}
+	return 0
+}
+
+// configureAlias sets alias.<name> to aliasCommand, refusing to replace a
+// different alias unless force is set.
+func configureAlias(name string, global, force bool) error {
+	key := "alias." + name
+	switch existing := git.Config(key, ""); {
+	case existing == aliasCommand:
+		fmt.Fprintf(os.Stderr, "git %s is already configured\n", name)
+		return nil
+	case existing != "" && !force:
+		return fmt.Errorf("git %s is already an alias for %q; use --force to replace it", name, existing)
+	}
+	if err := git.SetConfig(key, aliasCommand, global); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "git %s now runs %s\n", name, strings.TrimPrefix(aliasCommand, "!"))
+	return nil
+}
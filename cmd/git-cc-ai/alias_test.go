@@ -0,0 +1,34 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCommitArgs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"none", []string{"-q", "fix the bug"}, nil},
+		{"amend and signoff", []string{"--amend", "--signoff"}, []string{"--amend", "--signoff"}},
+		{"sign with default key", []string{"-S"}, []string{"--gpg-sign"}},
+		{"gpg-sign without key", []string{"--gpg-sign", "note"}, []string{"--gpg-sign"}},
+		{"attached key", []string{"-SABC123"}, []string{"--gpg-sign=ABC123"}},
+		{"gpg-sign with key", []string{"--gpg-sign=ABC123"}, []string{"--gpg-sign=ABC123"}},
+		{"disabled", []string{"--amend=false", "--signoff=false"}, nil},
+		{"after double dash", []string{"--", "-S", "--amend"}, nil},
+		{"other values", []string{"--model=S", "--reject=--amend"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := commitArgs(gitSignSpellings(tt.args)); !slices.Equal(got, tt.want) {
+				t.Fatalf("commitArgs(%q) = %q, want %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// boolFlag is the interface flag uses to recognise flags without a value.
+type boolFlag interface {
+	IsBoolFlag() bool
+}
+
+// parseArgs parses args into fs and returns the positional arguments.
+// Unlike fs.Parse it accepts flags after positional arguments, expands
+// combined single-letter flags ("-ab" is "-a -b"), treats everything after
+// "--" as positional, and lets the flags named in optional be given without
+// a value, in which case they are set to the mapped default ("-m" alone
+// opens the model menu). In a combined group only the last letter may take
+// a value ("-qo file", not "-oq file").
+func parseArgs(fs *flag.FlagSet, args []string, optional map[string]string) ([]string, error) {
+	var (
+		flags      = make([]string, 0, len(args))
+		positional []string
+	)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			positional = append(positional, arg)
+			continue
+		}
+		names := []string{strings.TrimLeft(arg, "-")}
+		if expanded, ok := expandShort(fs, arg); ok {
+			names = expanded
+		}
+		for j, name := range names {
+			if strings.Contains(name, "=") {
+				flags = append(flags, "-"+name)
+				continue
+			}
+			last := j == len(names)-1
+			hasNext := last && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-")
+			if def, ok := optional[name]; ok {
+				if hasNext {
+					flags = append(flags, "-"+name+"="+args[i+1])
+					i++
+				} else {
+					flags = append(flags, "-"+name+"="+def)
+				}
+				continue
+			}
+			flags = append(flags, "-"+name)
+			f := fs.Lookup(name)
+			if f == nil {
+				continue // fs.Parse reports it
+			}
+			if b, ok := f.Value.(boolFlag); ok && b.IsBoolFlag() {
+				continue
+			}
+			if !last {
+				// "-oq" would otherwise parse as -o with the value "-q".
+				err := fmt.Errorf("-%s takes a value, so it must be the last letter of %s", name, arg)
+				fmt.Fprintln(fs.Output(), err)
+				fs.Usage()
+				return nil, err
+			}
+			if i+1 < len(args) {
+				flags = append(flags, args[i+1])
+				i++
+			}
+		}
+	}
+	if err := fs.Parse(flags); err != nil {
+		return nil, err
+	}
+	return positional, nil
+}
+
+// expandShort splits "-abc" into "a", "b", "c" when every letter is a
+// defined single-letter flag and "abc" itself is not a flag. A value may be
+// attached to the last letter with "=" ("-am=x").
+func expandShort(fs *flag.FlagSet, arg string) ([]string, bool) {
+	if strings.HasPrefix(arg, "--") || len(arg) < 3 {
+		return nil, false
+	}
+	body, value, hasValue := strings.Cut(arg[1:], "=")
+	if len(body) < 2 || fs.Lookup(body) != nil {
+		return nil, false
+	}
+	names := make([]string, 0, len(body))
+	for _, r := range body {
+		if fs.Lookup(string(r)) == nil {
+			return nil, false
+		}
+		names = append(names, string(r))
+	}
+	if hasValue {
+		names[len(names)-1] += "=" + value
+	}
+	return names, true
+}
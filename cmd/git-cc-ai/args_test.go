@@ -0,0 +1,113 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"slices"
+	"testing"
+)
+
+// testFlags mirrors the shapes of the main flag set: single-letter bools,
+// a single-letter flag with a value and long flags.
+type testFlags struct {
+	quiet, verbose, amend bool
+	output, model, reject string
+}
+
+func newTestFlagSet() (*flag.FlagSet, *testFlags) {
+	var f testFlags
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.BoolVar(&f.quiet, "q", false, "")
+	fs.BoolVar(&f.verbose, "v", false, "")
+	fs.BoolVar(&f.amend, "amend", false, "")
+	fs.StringVar(&f.output, "o", "", "")
+	fs.StringVar(&f.model, "m", "", "")
+	fs.StringVar(&f.model, "model", "", "")
+	fs.StringVar(&f.reject, "reject", "", "")
+	return fs, &f
+}
+
+func TestParseArgs(t *testing.T) {
+	t.Parallel()
+
+	optional := map[string]string{"m": "menu", "reject": "no reason"}
+	tests := []struct {
+		name       string
+		args       []string
+		want       testFlags
+		positional []string
+		wantErr    bool
+	}{
+		{name: "long with equals", args: []string{"--model=x"}, want: testFlags{model: "x"}},
+		{name: "long with space", args: []string{"--model", "x"}, want: testFlags{model: "x"}},
+		{name: "single dash long", args: []string{"-model", "x"}, want: testFlags{model: "x"}},
+		{name: "grouped bools", args: []string{"-qv"}, want: testFlags{quiet: true, verbose: true}},
+		{name: "grouped with value", args: []string{"-qo", "out.txt"}, want: testFlags{quiet: true, output: "out.txt"}},
+		{name: "grouped with attached value", args: []string{"-qo=out.txt"}, want: testFlags{quiet: true, output: "out.txt"}},
+		{name: "short with value", args: []string{"-o", "out.txt", "note"}, want: testFlags{output: "out.txt"}, positional: []string{"note"}},
+		{name: "optional without value", args: []string{"-m"}, want: testFlags{model: "menu"}},
+		{name: "optional before flag", args: []string{"-m", "-q"}, want: testFlags{model: "menu", quiet: true}},
+		{name: "optional with value", args: []string{"-m", "opus"}, want: testFlags{model: "opus"}},
+		{name: "long optional", args: []string{"--reject", "--amend"}, want: testFlags{reject: "no reason", amend: true}},
+		{name: "flags after positionals", args: []string{"fix", "the", "bug", "-q", "--amend"}, want: testFlags{quiet: true, amend: true}, positional: []string{"fix", "the", "bug"}},
+		{name: "double dash", args: []string{"-q", "--", "-v", "--amend"}, want: testFlags{quiet: true}, positional: []string{"-v", "--amend"}},
+		{name: "lone dash is positional", args: []string{"-"}, positional: []string{"-"}},
+		{name: "unknown long flag", args: []string{"--nope"}, wantErr: true},
+		{name: "unknown short flag", args: []string{"-x"}, wantErr: true},
+		{name: "unknown letter in group", args: []string{"-qx"}, wantErr: true},
+		{name: "value flag inside group", args: []string{"-oq", "out.txt"}, wantErr: true},
+		{name: "optional inside group", args: []string{"-mq"}, want: testFlags{model: "menu", quiet: true}},
+		{name: "missing value", args: []string{"--model"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fs, got := newTestFlagSet()
+			positional, err := parseArgs(fs, tt.args, optional)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseArgs(%q) succeeded, want an error", tt.args)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseArgs(%q): %v", tt.args, err)
+			}
+			if *got != tt.want {
+				t.Fatalf("parseArgs(%q) set %+v, want %+v", tt.args, *got, tt.want)
+			}
+			if !slices.Equal(positional, tt.positional) {
+				t.Fatalf("parseArgs(%q) positional = %q, want %q", tt.args, positional, tt.positional)
+			}
+		})
+	}
+}
+
+func TestExpandShort(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		arg  string
+		want []string
+		ok   bool
+	}{
+		{"-qv", []string{"q", "v"}, true},
+		{"-vq", []string{"v", "q"}, true},
+		{"-qo=x", []string{"q", "o=x"}, true},
+		{"-q", nil, false},
+		{"--qv", nil, false},
+		{"-model", nil, false},
+		{"-qx", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			t.Parallel()
+			fs, _ := newTestFlagSet()
+			got, ok := expandShort(fs, tt.arg)
+			if ok != tt.ok || !slices.Equal(got, tt.want) {
+				t.Fatalf("expandShort(%q) = %q, %v, want %q, %v", tt.arg, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/agentrc"
+	"github.com/dlnilsson/git-cc-ai/pkg/commit"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+// defaultAttribution is the trailer GIT_AI_ATTRIBUTION=true adds unless
+// GIT_AI_ATTRIBUTION_TRAILER replaces it.
+const defaultAttribution = "Assisted-by: git-ai ({model})"
+
+// attributionVars expands the variables only attribution trailers have:
+// the backend and model that wrote the message.
+func attributionVars(tmpl, backend, model string) string {
+	return strings.NewReplacer("{backend}", backend, "{model}", model).Replace(tmpl)
+}
+
+// validateAttribution checks an attribution trailer template like a
+// GIT_AI_FOOTER one, with {backend} and {model} allowed as well.
+func validateAttribution(tmpl string) error {
+	return commit.ValidateFooter(attributionVars(tmpl, "backend", "model"))
+}
+
+// resolveAttribution returns the attribution trailer template, or "" when
+// GIT_AI_ATTRIBUTION is not "true" (environment, then .agentrc). An invalid
+// GIT_AI_ATTRIBUTION_TRAILER is reported and the default used instead.
+func resolveAttribution(rc agentrc.Config) string {
+	if !strings.EqualFold(strings.TrimSpace(os.Getenv("GIT_AI_ATTRIBUTION")), "true") && !rc.Attribution {
+		return ""
+	}
+	tmpl := firstNonEmpty(strings.TrimSpace(os.Getenv("GIT_AI_ATTRIBUTION_TRAILER")), rc.AttributionTrailer)
+	if tmpl == "" {
+		return defaultAttribution
+	}
+	if err := validateAttribution(tmpl); err != nil {
+		fmt.Fprintf(ui.Status(), "warning: GIT_AI_ATTRIBUTION_TRAILER %q: %v; using %q\n", tmpl, err, defaultAttribution)
+		return defaultAttribution
+	}
+	return tmpl
+}
+
+// withAttribution adds the attribution trailer tmpl for a message written
+// by model of backend, replacing any trailer with the same token the
+// backend wrote itself. It is added last, after GIT_AI_FOOTER footers, so
+// policy checks find it in the footer block.
+func withAttribution(message, tmpl, backend, model string) string {
+	if tmpl == "" || strings.TrimSpace(message) == "" {
+		return message
+	}
+	footer, ok := commit.ExpandFooter(attributionVars(tmpl, backend, model), func(name, arg string) string {
+		return footerValue(name, arg, message)
+	})
+	if !ok {
+		ui.Debugf("attribution trailer left out: a variable is empty")
+		return message
+	}
+	return commit.AddTrailer(commit.RemoveTrailers(message, commit.FooterToken(footer)), footer)
+}
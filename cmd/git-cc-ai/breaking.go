@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/analyze"
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+// stagedBreaking runs the breaking-change heuristics on the staged diff.
+// Failures are left to the backend, which reads the diff itself.
+func stagedBreaking() []analyze.Finding {
+	diff, err := git.DiffStagedLines()
+	if err != nil {
+		return nil
+	}
+	findings := analyze.Breaking(diff)
+	for _, f := range findings {
+		ui.Debugf("breaking change heuristic: %s", f)
+	}
+	return findings
+}
+
+// warnUnmarkedBreaking warns when the heuristics found breaking changes but
+// message does not declare one.
+func warnUnmarkedBreaking(message string, findings []analyze.Finding) {
+	if len(findings) == 0 || strings.TrimSpace(message) == "" || analyze.Marked(message) {
+		return
+	}
+	names := make([]string, 0, len(findings))
+	for _, f := range findings {
+		names = append(names, f.String())
+	}
+	fmt.Fprintf(ui.Status(), "warning: the change looks breaking (%s) but the message has no \"!\" or BREAKING CHANGE footer\n", strings.Join(names, "; "))
+}
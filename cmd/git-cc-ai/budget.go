@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/agentrc"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers/claude"
+)
+
+// resolveBudget returns the spend limit in USD from GIT_AI_BUDGET or
+// .agentrc, or 0 for the backend default.
+func resolveBudget(rc agentrc.Config) float64 {
+	if v, err := strconv.ParseFloat(strings.TrimSpace(os.Getenv("GIT_AI_BUDGET")), 64); err == nil && v > 0 {
+		return v
+	}
+	if rc.Budget > 0 {
+		return rc.Budget
+	}
+	return 0
+}
+
+// clientBudget returns the budget for a serve request: the client's
+// budget when it is below the server's, which it can never raise. A server
+// budget of 0 stands for the backend default.
+func clientBudget(requested, server float64) float64 {
+	limit := server
+	if limit <= 0 {
+		limit = claude.DefaultBudgetUSD
+	}
+	if requested > 0 && requested < limit {
+		return requested
+	}
+	return server
+}
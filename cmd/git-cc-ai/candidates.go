@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/attempt"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+// runCandidates samples n messages from one backend concurrently and lets
+// the user pick one, regenerating the whole set on request. Regenerated sets
+// are told which candidates were passed over. Each sample starts a new
+// session.
+func runCandidates(ctx context.Context, backend, model string, n int, opts providers.Options) (string, error) {
+	// The samples run at once, so they must neither resume one session
+	// nor record theirs in .agentrc concurrently.
+	opts.SessionID, opts.OnSessionID = "", nil
+	specs := make([]compareSpec, n)
+	for i := range specs {
+		specs[i] = compareSpec{backend: backend, model: model}
+	}
+	var (
+		baseNote = opts.ExtraNote
+		rejected []attempt.Rejection
+	)
+	for {
+		messages, errs := runConcurrently(ctx, specs, opts, "Generating "+strconv.Itoa(n)+" candidates...")
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("candidate generation %w", providers.ErrInterrupted)
+		}
+		candidates := make([]ui.Candidate, 0, n)
+		for i, msg := range messages {
+			if errs[i] != nil {
+				fmt.Fprintf(ui.Status(), "candidate %d: %v\n", i+1, errs[i])
+				continue
+			}
+			if strings.TrimSpace(msg) == "" {
+				continue
+			}
+			candidates = append(candidates, ui.Candidate{Label: "Candidate " + strconv.Itoa(len(candidates)+1), Message: msg})
+		}
+		if len(candidates) == 0 {
+			return "", errors.New("no candidates were generated")
+		}
+		choice, err := ui.SelectCandidate(candidates)
+		if errors.Is(err, ui.ErrRegenerate) {
+			for _, c := range candidates {
+				rejected = append(rejected, attempt.Rejection{Message: c.Message, Reason: "passed over when picking a candidate"})
+			}
+			opts.ExtraNote = joinNotes(baseNote, attempt.Note(rejected))
+			continue
+		}
+		if errors.Is(err, ui.ErrNotInteractive) {
+			fmt.Fprintln(ui.Status(), "no terminal to pick from; using candidate 1")
+			return candidates[0].Message, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		return candidates[choice].Message, nil
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/agentrc"
+	"github.com/dlnilsson/git-cc-ai/pkg/commit"
+	"github.com/dlnilsson/git-cc-ai/pkg/commitlint"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+)
+
+// Exit codes of check-msg, suitable for commit-msg hooks.
+const (
+	checkExitValid   = 0
+	checkExitInvalid = 1
+	checkExitUsage   = 2
+)
+
+// runCheckMsg validates the commit message in a file (as passed to a
+// commit-msg hook) and optionally asks the backend to fix it in place.
+func runCheckMsg(args []string) int {
+	var (
+		format string
+		fix    bool
+		fs     = flag.NewFlagSet("check-msg", flag.ContinueOnError)
+	)
+	fs.StringVar(&format, "format", "text", "diagnostics format: text or json")
+	fs.BoolVar(&fix, "fix", false, "ask the backend to rewrite an invalid message and update the file")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: git-cc-ai check-msg [--fix] [--format text|json] <file>")
+		fs.PrintDefaults()
+	}
+	rest, err := parseArgs(fs, args, nil)
+	if err != nil {
+		return checkExitUsage
+	}
+	if len(rest) != 1 || (format != "text" && format != "json") {
+		fs.Usage()
+		return checkExitUsage
+	}
+	path := rest[0]
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return checkExitUsage
+	}
+
+	scopeMap, err := loadScopes()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return checkExitUsage
+	}
+	rc := agentrc.Load(agentrcPath())
+	cfg := lintConfig(resolveMaxSubject(0, rc), resolveTypes(rc), resolveScopePolicy(rc), scopeMap)
+	res := commitlint.Lint(string(data), cfg)
+	if !res.Valid && fix {
+		fixed, fixErr := fixMessage(string(data), res)
+		if fixErr == nil {
+			fixed, fixErr = reviewRewrite(string(data), fixed, func(string) (string, error) {
+				return fixMessage(string(data), res)
+			})
+		}
+		if fixErr != nil {
+			fmt.Fprintf(os.Stderr, "check-msg: fix failed: %v\n", fixErr)
+		} else if err = os.WriteFile(path, []byte(fixed+"\n"), 0o644); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return checkExitUsage
+		} else {
+			res = commitlint.Lint(fixed, cfg)
+		}
+	}
+
+	if format == "json" {
+		_ = json.NewEncoder(os.Stdout).Encode(res)
+	} else {
+		reportLint(res)
+	}
+	if !res.Valid {
+		return checkExitInvalid
+	}
+	return checkExitValid
+}
+
+// fixMessage regenerates the message from the staged diff, passing the
+// original text and its lint problems as extra context.
+func fixMessage(original string, res commitlint.Result) (string, error) {
+	rc := agentrc.Load(agentrcPath())
+	_, b, err := resolveBackend(os.Getenv("GIT_AI_BACKEND"), rc)
+	if err != nil {
+		return "", err
+	}
+	var note strings.Builder
+	note.WriteString("Rewrite this commit message so it follows the Conventional Commits rules. Keep its meaning.\n\n")
+	note.WriteString(commit.StripComments(original))
+	note.WriteString("\n\nProblems to fix:\n")
+	for _, p := range res.Problems() {
+		fmt.Fprintf(&note, "- %s [%s]\n", p.Message, p.Rule)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var reg providers.Registry
+	msg, err := b.Generate(ctx, &reg, providers.Options{
+		ExtraNote: note.String(),
+		Budget:    rc.Budget,
+		Tools:     resolveTools(rc),
+		ExtraArgs: resolveExtraArgs(),
+	})
+	if err != nil {
+		return "", err
+	}
+	return commit.StripComments(msg), nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/agentrc"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+)
+
+// ciTimeout is the hard timeout of --ci when --timeout is not given.
+const ciTimeout = 5 * time.Minute
+
+// ciMode is set by --ci: non-interactive runs for bots, with strict model
+// selection and exit codes.
+var ciMode bool
+
+var errTimeout = errors.New("timed out")
+
+// explicitModel returns the first model set by GIT_AI_MODEL_<BACKEND> or
+// GIT_AI_MODEL (environment, then .agentrc), whether or not the backend
+// offers it; --ci validates it like --model instead of falling back.
+func explicitModel(backend string, rc agentrc.Config) string {
+	for _, m := range modelSettings(backend, rc) {
+		if m = strings.TrimSpace(m); m != "" {
+			return m
+		}
+	}
+	return ""
+}
+
+// ciTemperature returns 0 for backends that take a temperature, so that
+// --ci runs are as repeatable as the backend allows; nil otherwise.
+func ciTemperature(b providers.Backend) *float64 {
+	if sb, ok := b.(providers.SamplingBackend); ok {
+		if temperature, _ := sb.SupportsSampling(); temperature {
+			return new(float64)
+		}
+	}
+	return nil
+}
+
+// withTimeout bounds ctx by timeout when it is positive.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// timeoutError replaces err with errTimeout when ctx ran out of time, as
+// the backends report the cancelled run as interrupted or failed.
+func timeoutError(ctx context.Context, err error, timeout time.Duration) error {
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("generation %w after %s", errTimeout, timeout)
+	}
+	return err
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/agentrc"
+	"github.com/dlnilsson/git-cc-ai/pkg/commit"
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+// Where the usage trailer goes (GIT_AI_USAGE).
+const (
+	usageComments = "comments" // comment lines in the message (default)
+	usageStderr   = "stderr"   // printed to stderr, never in the message
+	usageNotes    = "notes"    // a refs/notes/git-ai note on the commit
+	usageOff      = "off"      // not shown at all
+)
+
+var usageModes = []string{usageComments, usageStderr, usageNotes, usageOff}
+
+// notesRef is the notes ref usage metadata is attached under.
+const notesRef = "git-ai"
+
+// usageMode is the resolved GIT_AI_USAGE, or stderr with --usage-stderr.
+var usageMode = usageComments
+
+// resolveUsageMode returns GIT_AI_USAGE from the environment or .agentrc.
+// Unknown values are reported and the default is used.
+func resolveUsageMode(rc agentrc.Config) string {
+	mode := strings.ToLower(strings.TrimSpace(os.Getenv("GIT_AI_USAGE")))
+	if mode == "" {
+		mode = rc.Usage
+	}
+	switch {
+	case mode == "":
+		return usageComments
+	case slices.Contains(usageModes, mode):
+		return mode
+	}
+	fmt.Fprintf(ui.Status(), "warning: GIT_AI_USAGE %q is not one of %s; using %q\n", mode, strings.Join(usageModes, ", "), usageComments)
+	return usageComments
+}
+
+// usageNote returns the usage trailer of message without its comment
+// markers, as stored in git notes.
+func usageNote(message string) string {
+	_, comments := commit.SplitComments(message)
+	lines := make([]string, 0, len(comments))
+	for _, c := range comments {
+		if c = strings.TrimSpace(strings.TrimPrefix(c, "#")); c != "" {
+			lines = append(lines, c)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// addUsageNote attaches usage to the commit rev under refs/notes/git-ai.
+func addUsageNote(rev, usage string) error {
+	if strings.TrimSpace(usage) == "" {
+		return nil
+	}
+	return git.AddNote(notesRef, rev, usage+"\n")
+}
+
+// commentPrefix returns the comment prefix git strips from the message being
+// committed (core.commentChar), or "" when comment lines would be committed:
+// with core.commentChar=auto, where git picks a character no line starts
+// with, or a commit.cleanup mode that keeps comments.
+func commentPrefix() string {
+	switch git.Config("commit.cleanup", "default") {
+	case "default", "strip":
+	default:
+		return ""
+	}
+	if char := git.Config("core.commentChar", "#"); char != "auto" {
+		return char
+	}
+	return ""
+}
+
+// routeUsage splits the '#' usage trailer off message per GIT_AI_USAGE: it
+// is kept for comments, printed to stderr for stderr and dropped for notes
+// (the post-commit hook attaches it instead) and off.
+func routeUsage(message string) (string, []string) {
+	text, comments := commit.SplitComments(message)
+	switch usageMode {
+	case usageStderr:
+		for _, c := range comments {
+			fmt.Fprintln(ui.Status(), c)
+		}
+		return text, nil
+	case usageNotes, usageOff:
+		return text, nil
+	}
+	return text, comments
+}
+
+// withComments renders message for git: its '#' trailer lines use the
+// repository's comment prefix, or go to stderr when git would keep them or
+// with -q, and message lines starting with the prefix are escaped.
+func withComments(message string) string {
+	text, comments := routeUsage(message)
+	prefix := commentPrefix()
+	if prefix == "" || ui.IsQuiet() {
+		for _, c := range comments {
+			fmt.Fprintln(ui.Status(), c)
+		}
+		comments = nil
+	}
+	if prefix != "" && prefix != "#" {
+		text = commit.EscapeCommentChar(text, prefix)
+	}
+	if len(comments) == 0 {
+		return text
+	}
+	var b strings.Builder
+	b.WriteString(text)
+	b.WriteString("\n")
+	for _, c := range comments {
+		b.WriteString("\n" + prefix + strings.TrimPrefix(c, "#"))
+	}
+	return b.String()
+}
+
+// commentLine returns text as a comment line git drops from the message, or
+// an empty string (with text on stderr) when comments would be committed or
+// with -q.
+func commentLine(text string) string {
+	prefix := commentPrefix()
+	if prefix == "" || ui.IsQuiet() {
+		fmt.Fprintln(ui.Status(), text)
+		return ""
+	}
+	return prefix + " " + text + "\n"
+}
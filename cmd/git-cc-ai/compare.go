@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/ledger"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+// compareSpec is one backend:model pair requested via --compare.
+type compareSpec struct {
+	backend string
+	model   string
+}
+
+func (s compareSpec) String() string {
+	return s.backend + ":" + s.model
+}
+
+// parseCompareSpecs parses a comma-separated list of backend[:model] pairs.
+// Models may be abbreviated to any substring that identifies exactly one of
+// the backend's models (e.g. "claude:sonnet").
+func parseCompareSpecs(raw string) ([]compareSpec, error) {
+	parts := strings.Split(raw, ",")
+	specs := make([]compareSpec, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, model, _ := strings.Cut(part, ":")
+		b, ok := backends[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid --compare backend %q", name)
+		}
+		resolved, err := matchModel(b.Models(), strings.TrimSpace(model))
+		if err != nil {
+			return nil, fmt.Errorf("invalid --compare entry %q: %w", part, err)
+		}
+		if resolved == "" {
+			resolved = b.DefaultModel()
+		}
+		specs = append(specs, compareSpec{backend: name, model: resolved})
+	}
+	if len(specs) < 2 {
+		return nil, errors.New("--compare needs at least two backend:model pairs")
+	}
+	return specs, nil
+}
+
+// matchModel resolves want against models: an exact match wins, otherwise
+// want must be a substring of exactly one model. An empty want resolves to
+// an empty string (backend default).
+func matchModel(models []string, want string) (string, error) {
+	if want == "" || slices.Contains(models, want) {
+		return want, nil
+	}
+	var matches []string
+	for _, m := range models {
+		if strings.Contains(m, want) {
+			matches = append(matches, m)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("%w %q (available: %s)", providers.ErrInvalidModel, want, strings.Join(models, ", "))
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("%w: %q is ambiguous (matches: %s)", providers.ErrInvalidModel, want, strings.Join(matches, ", "))
+	}
+}
+
+// registryGroup forwards signals to every registry in a compare run.
+type registryGroup []*providers.Registry
+
+func (g registryGroup) ForwardSignal(sig os.Signal) {
+	for _, r := range g {
+		r.ForwardSignal(sig)
+	}
+}
+
+// runConcurrently runs every spec concurrently on the same staged diff and
+// returns the per-spec messages and errors in spec order.
+func runConcurrently(ctx context.Context, specs []compareSpec, opts providers.Options, spinnerMessage string) ([]string, []error) {
+	var (
+		group    = make(registryGroup, len(specs))
+		messages = make([]string, len(specs))
+		errs     = make([]error, len(specs))
+		wg       sync.WaitGroup
+	)
+	for i := range group {
+		group[i] = &providers.Registry{}
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	go func() {
+		for sig := range sigCh {
+			group.ForwardSignal(sig)
+		}
+	}()
+
+	if opts.ShowSpinner {
+		labels := make([]string, 0, len(specs))
+		for _, s := range specs {
+			if label := s.backend + " +" + s.model; !slices.Contains(labels, label) {
+				labels = append(labels, label)
+			}
+		}
+		stopSpinner := ui.StartSpinner(spinnerMessage, strings.Join(labels, ", "), group)
+		defer stopSpinner()
+	}
+
+	for i, spec := range specs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runOpts := opts
+			runOpts.Model = spec.model
+			runOpts.ShowSpinner = false
+			messages[i], errs[i] = backends[spec.backend].Generate(ctx, group[i], runOpts)
+		}()
+	}
+	wg.Wait()
+	return messages, errs
+}
+
+// runCompare runs every spec concurrently, lets the user pick a winner
+// side-by-side and records the choice in the ledger. It returns the picked
+// message and the spec that wrote it.
+func runCompare(ctx context.Context, specs []compareSpec, opts providers.Options) (string, compareSpec, error) {
+	messages, errs := runConcurrently(ctx, specs, opts, "Comparing models...")
+
+	var (
+		candidates = make([]ui.Candidate, 0, len(specs))
+		winners    = make([]compareSpec, 0, len(specs))
+		contenders = make([]string, 0, len(specs))
+	)
+	for i, spec := range specs {
+		contenders = append(contenders, spec.String())
+		if errs[i] != nil {
+			fmt.Fprintf(ui.Status(), "%s: %v\n", spec, errs[i])
+			continue
+		}
+		if strings.TrimSpace(messages[i]) == "" {
+			fmt.Fprintf(ui.Status(), "%s: empty response\n", spec)
+			continue
+		}
+		candidates = append(candidates, ui.Candidate{Label: spec.String(), Message: messages[i]})
+		winners = append(winners, spec)
+	}
+	if ctx.Err() != nil {
+		return "", compareSpec{}, fmt.Errorf("compare %w", providers.ErrInterrupted)
+	}
+	if len(candidates) == 0 {
+		return "", compareSpec{}, errors.New("all compared backends failed")
+	}
+
+	choice := 0
+	if len(candidates) > 1 {
+		var err error
+		choice, err = ui.SelectCandidateSideBySide(candidates)
+		switch {
+		case errors.Is(err, ui.ErrNotInteractive):
+			fmt.Fprintf(ui.Status(), "no terminal to pick from; using %s\n", candidates[0].Label)
+			choice = 0
+		case err != nil:
+			return "", compareSpec{}, err
+		}
+	}
+	recordGeneration(ledger.Entry{
+		Kind:       ledger.KindCompare,
+		Backend:    winners[choice].backend,
+		Model:      winners[choice].model,
+		Message:    candidates[choice].Message,
+		Contenders: contenders,
+	})
+	return candidates[choice].Message, winners[choice], nil
+}
@@ -0,0 +1,451 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/agentrc"
+	"github.com/dlnilsson/git-cc-ai/pkg/commit"
+	"github.com/dlnilsson/git-cc-ai/pkg/ghactions"
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/scopes"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+// Config sources, in increasing precedence.
+const (
+	sourceDefault = "default"
+	sourceRC      = ".agentrc"
+	sourceEnv     = "env"
+)
+
+type configValue struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+type configProblem struct {
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+type configReport struct {
+	Values   []configValue   `json:"values"`
+	Problems []configProblem `json:"problems"`
+}
+
+func (r *configReport) add(key, value, source string) {
+	r.Values = append(r.Values, configValue{Key: key, Value: value, Source: source})
+}
+
+func (r *configReport) errorf(format string, args ...any) {
+	r.Problems = append(r.Problems, configProblem{Level: "error", Message: fmt.Sprintf(format, args...)})
+}
+
+func (r *configReport) warnf(format string, args ...any) {
+	r.Problems = append(r.Problems, configProblem{Level: "warning", Message: fmt.Sprintf(format, args...)})
+}
+
+func (r *configReport) valid() bool {
+	return !slices.ContainsFunc(r.Problems, func(p configProblem) bool { return p.Level == "error" })
+}
+
+// runConfig implements "git-cc-ai config validate".
+func runConfig(args []string) int {
+	var (
+		format string
+		fs     = flag.NewFlagSet("config", flag.ContinueOnError)
+	)
+	fs.StringVar(&format, "format", "text", "output format: text or json")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: git-cc-ai config validate [--format text|json]")
+		fs.PrintDefaults()
+	}
+	if len(args) == 0 || args[0] != "validate" {
+		fs.Usage()
+		return 2
+	}
+	if rest, err := parseArgs(fs, args[1:], nil); err != nil || len(rest) > 0 || (format != "text" && format != "json") {
+		fs.Usage()
+		return 2
+	}
+
+	report, err := validateConfig(agentrcPath())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	if format == "json" {
+		_ = json.NewEncoder(os.Stdout).Encode(report)
+	} else {
+		for _, v := range report.Values {
+			fmt.Printf("%-24s %-28s %s\n", v.Key, v.Value, v.Source)
+		}
+		for _, p := range report.Problems {
+			if ghactions.Enabled() {
+				ghactions.Annotate(os.Stderr, p.Level, ".agentrc", p.Message)
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "%s: %s\n", p.Level, p.Message)
+		}
+	}
+	if !report.valid() {
+		return 1
+	}
+	return 0
+}
+
+// validateConfig resolves every setting from defaults, the .agentrc file at
+// rcPath and the environment, and reports problems with the result.
+func validateConfig(rcPath string) (configReport, error) {
+	report := configReport{Problems: []configProblem{}}
+	entries, err := agentrc.Entries(rcPath)
+	if err != nil {
+		return report, err
+	}
+
+	var (
+		rc       = map[string]agentrc.Entry{}
+		repeated []configValue
+	)
+	for _, e := range entries {
+		if isExtraArgsKey(e.Key) {
+			report.warnf("%s:%d: %s is only read from the environment; it is ignored", rcPath, e.Line, e.Key)
+			continue
+		}
+		if !slices.Contains(agentrc.Keys, e.Key) && !isBackendModelKey(e.Key) {
+			report.warnf("%s:%d: unknown key %s", rcPath, e.Line, e.Key)
+			continue
+		}
+		if e.Key == "GIT_AI_STRIP_PATTERN" {
+			if _, err := commit.CompilePatterns([]string{e.Value}); err != nil {
+				report.errorf("%s:%d: %v", rcPath, e.Line, err)
+			}
+			repeated = append(repeated, configValue{Key: e.Key, Value: e.Value, Source: fmt.Sprintf("%s:%d", rcPath, e.Line)})
+			continue
+		}
+		if e.Key == "GIT_AI_FOOTER" {
+			if err := commit.ValidateFooter(e.Value); err != nil {
+				report.errorf("%s:%d: GIT_AI_FOOTER %q: %v", rcPath, e.Line, e.Value, err)
+			}
+			repeated = append(repeated, configValue{Key: e.Key, Value: e.Value, Source: fmt.Sprintf("%s:%d", rcPath, e.Line)})
+			continue
+		}
+		if e.Key == "GIT_AI_SPINNER_MESSAGE" {
+			repeated = append(repeated, configValue{Key: e.Key, Value: e.Value, Source: fmt.Sprintf("%s:%d", rcPath, e.Line)})
+			continue
+		}
+		if prev, ok := rc[e.Key]; ok {
+			report.warnf("%s:%d: %s overrides the value on line %d", rcPath, e.Line, e.Key, prev.Line)
+		}
+		rc[e.Key] = e
+	}
+
+	// lookup returns the effective raw value of key and its source.
+	lookup := func(key string, fromEnv bool) (string, string) {
+		if v, ok := os.LookupEnv(key); ok && fromEnv && strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v), sourceEnv
+		}
+		if e, ok := rc[key]; ok {
+			return e.Value, sourceRC
+		}
+		return "", sourceDefault
+	}
+	where := func(key, source string) string {
+		if source == sourceRC {
+			return fmt.Sprintf("%s:%d", rcPath, rc[key].Line)
+		}
+		return source
+	}
+
+	name, source := lookup("GIT_AI_BACKEND", true)
+	backend, b, err := resolveBackend(name, agentrc.Config{})
+	switch {
+	case err != nil:
+		report.errorf("GIT_AI_BACKEND (%s): %v", where("GIT_AI_BACKEND", source), err)
+	case name == "":
+		source = "auto-detected"
+	case isAPIBackend(b):
+		if err := b.(providers.ConfiguredBackend).Configured(); err != nil {
+			report.errorf("backend %s is selected but not usable: %v", backend, err)
+		}
+	case !execInPath(backend):
+		report.errorf("backend %s is configured but %s is not in PATH", backend, backend)
+	}
+	report.add("GIT_AI_BACKEND", backend, where("GIT_AI_BACKEND", source))
+
+	model, source := lookup("GIT_AI_MODEL", true)
+	if b != nil {
+		switch {
+		case model == "":
+			model, source = b.DefaultModel(), "backend default"
+		case !slices.Contains(b.Models(), model):
+			report.errorf("GIT_AI_MODEL %q (%s) is not a %s model (available: %s); the default %s is used instead",
+				model, where("GIT_AI_MODEL", source), backend, strings.Join(b.Models(), ", "), b.DefaultModel())
+		}
+	}
+	report.add("GIT_AI_MODEL", model, where("GIT_AI_MODEL", source))
+
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		key := agentrc.BackendModelKey(name)
+		value, source := lookup(key, true)
+		if value == "" {
+			continue
+		}
+		if models := backends[name].Models(); !slices.Contains(models, value) {
+			report.errorf("%s %q (%s) is not a %s model (available: %s); it is ignored",
+				key, value, where(key, source), name, strings.Join(models, ", "))
+		}
+		report.add(key, value, where(key, source))
+	}
+
+	for _, name := range names {
+		key := agentrc.BackendExtraArgsKey(name)
+		value := strings.TrimSpace(os.Getenv(key))
+		if value == "" {
+			continue
+		}
+		if err := providers.ValidateExtraArgs(name, agentrc.SplitArgs(value)); err != nil {
+			report.errorf("%s (%s): %v; it is ignored", key, sourceEnv, err)
+		}
+		report.add(key, value, sourceEnv)
+	}
+
+	tools, source := lookup("GIT_AI_TOOLS", true)
+	if tools = strings.Trim(tools, `"'`); tools != "" && backend == "codex" && !slices.Contains([]string{providers.ToolsNone, providers.ToolsAll}, strings.ToLower(tools)) {
+		report.warnf("GIT_AI_TOOLS lists tools, but codex cannot restrict its shell; it keeps running in the read-only sandbox")
+	}
+	report.add("GIT_AI_TOOLS", tools, where("GIT_AI_TOOLS", source))
+
+	budget, source := lookup("GIT_AI_BUDGET", true)
+	if budget != "" {
+		if v, parseErr := strconv.ParseFloat(budget, 64); parseErr != nil || v <= 0 {
+			report.errorf("GIT_AI_BUDGET %q (%s) is not a positive number of USD; it is ignored", budget, where("GIT_AI_BUDGET", source))
+		} else if backend != "" && backend != "claude" {
+			report.warnf("GIT_AI_BUDGET is set but only the claude backend enforces a budget (backend: %s)", backend)
+		}
+	}
+	report.add("GIT_AI_BUDGET", budget, where("GIT_AI_BUDGET", source))
+
+	maxSubject, source := lookup("GIT_AI_MAX_SUBJECT", true)
+	if maxSubject != "" {
+		if v, parseErr := strconv.Atoi(maxSubject); parseErr != nil || v <= 0 {
+			report.errorf("GIT_AI_MAX_SUBJECT %q (%s) is not a positive integer; it is ignored", maxSubject, where("GIT_AI_MAX_SUBJECT", source))
+		}
+	} else {
+		maxSubject = strconv.Itoa(commit.DefaultMaxSubject)
+	}
+	report.add("GIT_AI_MAX_SUBJECT", maxSubject, where("GIT_AI_MAX_SUBJECT", source))
+
+	maxToolCalls, source := lookup("GIT_AI_MAX_TOOL_CALLS", true)
+	if maxToolCalls != "" {
+		if v, parseErr := strconv.Atoi(maxToolCalls); parseErr != nil || v <= 0 {
+			report.errorf("GIT_AI_MAX_TOOL_CALLS %q (%s) is not a positive integer; it is ignored", maxToolCalls, where("GIT_AI_MAX_TOOL_CALLS", source))
+		}
+	}
+	report.add("GIT_AI_MAX_TOOL_CALLS", maxToolCalls, where("GIT_AI_MAX_TOOL_CALLS", source))
+
+	reasoning, source := lookup("GIT_AI_REASONING", true)
+	if reasoning != "" {
+		switch {
+		case !slices.Contains(providers.ReasoningLevels, strings.ToLower(reasoning)):
+			report.errorf("GIT_AI_REASONING %q (%s) is not one of %s; it is ignored", reasoning, where("GIT_AI_REASONING", source), strings.Join(providers.ReasoningLevels, ", "))
+		case b != nil && !providers.SupportsReasoning(b):
+			report.warnf("GIT_AI_REASONING is set but the %s backend does not support a reasoning effort", backend)
+		}
+	}
+	report.add("GIT_AI_REASONING", reasoning, where("GIT_AI_REASONING", source))
+
+	usage, source := lookup("GIT_AI_USAGE", true)
+	if usage != "" && !slices.Contains(usageModes, strings.ToLower(usage)) {
+		report.errorf("GIT_AI_USAGE %q (%s) is not one of %s; it is ignored", usage, where("GIT_AI_USAGE", source), strings.Join(usageModes, ", "))
+	}
+	report.add("GIT_AI_USAGE", usage, where("GIT_AI_USAGE", source))
+
+	types, source := lookup("GIT_AI_TYPES", true)
+	if types != "" {
+		list := agentrc.ParseList(types)
+		for _, t := range list {
+			if t != strings.ToLower(t) || strings.ContainsAny(t, " ():!") {
+				report.errorf("GIT_AI_TYPES (%s) has the type %q; types must be lower-case words", where("GIT_AI_TYPES", source), t)
+			}
+		}
+		if noCC, _ := lookup("GIT_AI_NO_CC", true); strings.EqualFold(noCC, "true") {
+			report.warnf("GIT_AI_TYPES has no effect with GIT_AI_NO_CC=true")
+		}
+		types = strings.Join(list, ",")
+	}
+	report.add("GIT_AI_TYPES", types, where("GIT_AI_TYPES", source))
+
+	scopePolicy, source := lookup("GIT_AI_SCOPE", true)
+	if scopePolicy != "" && !slices.Contains(commit.ScopePolicies, strings.ToLower(scopePolicy)) {
+		report.errorf("GIT_AI_SCOPE %q (%s) is not one of %s; it is ignored", scopePolicy, where("GIT_AI_SCOPE", source), strings.Join(commit.ScopePolicies, ", "))
+	}
+	report.add("GIT_AI_SCOPE", scopePolicy, where("GIT_AI_SCOPE", source))
+
+	scopePicker, source := lookup("GIT_AI_SCOPE_PICKER", true)
+	if scopePicker != "" && !slices.Contains([]string{"on", "off", "true", "false"}, strings.ToLower(scopePicker)) {
+		report.errorf("GIT_AI_SCOPE_PICKER %q (%s) is not on or off; the picker stays on", scopePicker, where("GIT_AI_SCOPE_PICKER", source))
+	}
+	report.add("GIT_AI_SCOPE_PICKER", scopePicker, where("GIT_AI_SCOPE_PICKER", source))
+
+	diffContext, source := lookup("GIT_AI_CONTEXT", true)
+	if diffContext != "" && !slices.Contains(git.ContextModes, strings.ToLower(diffContext)) {
+		report.errorf("GIT_AI_CONTEXT %q (%s) is not one of %s; it is ignored", diffContext, where("GIT_AI_CONTEXT", source), strings.Join(git.ContextModes, ", "))
+	}
+	report.add("GIT_AI_CONTEXT", diffContext, where("GIT_AI_CONTEXT", source))
+
+	formatting, source := lookup("GIT_AI_FORMATTING", true)
+	if formatting != "" && !slices.Contains(formattingModes, strings.ToLower(formatting)) {
+		report.errorf("GIT_AI_FORMATTING %q (%s) is not one of %s; it is ignored", formatting, where("GIT_AI_FORMATTING", source), strings.Join(formattingModes, ", "))
+	}
+	report.add("GIT_AI_FORMATTING", formatting, where("GIT_AI_FORMATTING", source))
+
+	spinnerMode, source := lookup("GIT_AI_SPINNER", true)
+	if spinnerMode != "" && !slices.Contains(ui.SpinnerModes, strings.ToLower(spinnerMode)) {
+		report.errorf("GIT_AI_SPINNER %q (%s) is not one of %s; it is ignored", spinnerMode, where("GIT_AI_SPINNER", source), strings.Join(ui.SpinnerModes, ", "))
+	}
+	report.add("GIT_AI_SPINNER", spinnerMode, where("GIT_AI_SPINNER", source))
+
+	for _, key := range []string{"GIT_AI_COLOR_ACCENT", "GIT_AI_COLOR_REASONING"} {
+		c, source := lookup(key, true)
+		c = strings.Trim(c, `"'`)
+		if c != "" && !ui.ValidColor(c) {
+			report.errorf("%s %q (%s) is not an ANSI color number (0-255) or hex color; it is ignored", key, c, where(key, source))
+		}
+		report.add(key, c, where(key, source))
+	}
+
+	template, source := lookup("GIT_AI_TEMPLATE", true)
+	if template != "" {
+		if t, parseErr := resolveTemplate(template, agentrc.Config{}); parseErr != nil {
+			report.errorf("%v (%s); it is ignored", parseErr, where("GIT_AI_TEMPLATE", source))
+		} else {
+			template = strings.Join(t.Slots(), ",")
+		}
+	}
+	report.add("GIT_AI_TEMPLATE", template, where("GIT_AI_TEMPLATE", source))
+
+	attribution, source := lookup("GIT_AI_ATTRIBUTION_TRAILER", true)
+	if attribution = strings.Trim(attribution, `"'`); attribution != "" {
+		if err := validateAttribution(attribution); err != nil {
+			report.errorf("GIT_AI_ATTRIBUTION_TRAILER %q (%s): %v; the default is used", attribution, where("GIT_AI_ATTRIBUTION_TRAILER", source), err)
+		}
+	}
+	report.add("GIT_AI_ATTRIBUTION_TRAILER", attribution, where("GIT_AI_ATTRIBUTION_TRAILER", source))
+
+	footerOrder, source := lookup("GIT_AI_FOOTER_ORDER", true)
+	if footerOrder != "" {
+		footerOrder = strings.Join(agentrc.ParseList(footerOrder), ",")
+	}
+	report.add("GIT_AI_FOOTER_ORDER", footerOrder, where("GIT_AI_FOOTER_ORDER", source))
+
+	rateLimitWait, source := lookup("GIT_AI_RATE_LIMIT_WAIT", true)
+	if _, parseErr := parseRateLimitWait(rateLimitWait); parseErr != nil {
+		report.errorf("GIT_AI_RATE_LIMIT_WAIT %q (%s) is %v; it is ignored", rateLimitWait, where("GIT_AI_RATE_LIMIT_WAIT", source), parseErr)
+	}
+	report.add("GIT_AI_RATE_LIMIT_WAIT", rateLimitWait, where("GIT_AI_RATE_LIMIT_WAIT", source))
+
+	stallTimeout, source := lookup("GIT_AI_STALL_TIMEOUT", true)
+	if _, parseErr := parseRateLimitWait(stallTimeout); parseErr != nil {
+		report.errorf("GIT_AI_STALL_TIMEOUT %q (%s) is %v; it is ignored", stallTimeout, where("GIT_AI_STALL_TIMEOUT", source), parseErr)
+	}
+	report.add("GIT_AI_STALL_TIMEOUT", stallTimeout, where("GIT_AI_STALL_TIMEOUT", source))
+
+	flags := map[string]bool{}
+	for _, key := range []string{"GIT_AI_NO_CC", "GIT_AI_NO_SESSION", "GIT_AI_NO_GEMINI_RESUME", "GIT_AI_RISK", "GIT_AI_NO_BODY", "GIT_AI_METRICS", "GIT_AI_FEEDBACK", "GIT_AI_GERRIT", "GIT_AI_ATTRIBUTION", "GIT_AI_PERSONALIZE", "GIT_AI_STRUCTURED", "GIT_AI_DEEP_CONTEXT", "GIT_AI_PLAIN", "GIT_AI_ACCESSIBLE", "GIT_AI_OFFLINE"} {
+		value, source := lookup(key, true)
+		var (
+			lower    = strings.ToLower(value)
+			accepted = []string{"", "false", "true"}
+		)
+		flags[key] = lower == "true"
+		if key == "GIT_AI_PLAIN" || key == "GIT_AI_ACCESSIBLE" || key == "GIT_AI_OFFLINE" {
+			accepted = append(accepted, "0", "1", "yes", "no")
+			flags[key] = flags[key] || lower == "1" || lower == "yes"
+		}
+		if !slices.Contains(accepted, lower) {
+			report.warnf("%s %q (%s) is not \"true\" or \"false\"; it is treated as false", key, value, where(key, source))
+		}
+		report.add(key, value, where(key, source))
+	}
+
+	if flags["GIT_AI_PERSONALIZE"] && !flags["GIT_AI_FEEDBACK"] {
+		report.warnf("GIT_AI_PERSONALIZE=true only uses edits captured with GIT_AI_FEEDBACK=true and git-cc-ai hook install")
+	}
+	if flags["GIT_AI_STRUCTURED"] && b != nil && !providers.SupportsStructured(b) {
+		report.warnf("GIT_AI_STRUCTURED=true has no effect with the %s backend (codex only)", backend)
+	}
+	if _, ok := providers.DeepContextTools(backend); flags["GIT_AI_DEEP_CONTEXT"] && backend != "" && !ok {
+		report.warnf("GIT_AI_DEEP_CONTEXT=true has no effect with the %s backend, which cannot use tools", backend)
+	}
+	if v, _ := lookup("GIT_AI_ATTRIBUTION_TRAILER", true); v != "" && !flags["GIT_AI_ATTRIBUTION"] {
+		report.warnf("GIT_AI_ATTRIBUTION_TRAILER is only added with GIT_AI_ATTRIBUTION=true")
+	}
+	if flags["GIT_AI_NO_BODY"] && flags["GIT_AI_RISK"] {
+		report.warnf("GIT_AI_RISK=true has no effect with GIT_AI_NO_BODY=true (footers are dropped)")
+	}
+
+	for _, key := range []string{"CLAUDE_SESSION_ID", "GEMINI_SESSION_ID"} {
+		value, source := lookup(key, false)
+		report.add(key, value, where(key, source))
+		if value == "" {
+			continue
+		}
+		switch {
+		case flags["GIT_AI_NO_SESSION"]:
+			report.warnf("%s is set but GIT_AI_NO_SESSION=true disables resuming it", key)
+		case key == "GEMINI_SESSION_ID" && flags["GIT_AI_NO_GEMINI_RESUME"]:
+			report.warnf("%s is set but GIT_AI_NO_GEMINI_RESUME=true disables resuming it", key)
+		case backend != "" && !strings.HasPrefix(strings.ToLower(key), backend):
+			report.warnf("%s is set but the %s backend does not use it", key, backend)
+		}
+	}
+	if p := strings.TrimSpace(os.Getenv("GIT_AI_STRIP_PATTERN")); p != "" {
+		if _, err := commit.CompilePatterns([]string{p}); err != nil {
+			report.errorf("GIT_AI_STRIP_PATTERN (env): %v", err)
+		}
+		repeated = append(repeated, configValue{Key: "GIT_AI_STRIP_PATTERN", Value: p, Source: sourceEnv})
+	}
+	if f := strings.TrimSpace(os.Getenv("GIT_AI_FOOTER")); f != "" {
+		if err := commit.ValidateFooter(f); err != nil {
+			report.errorf("GIT_AI_FOOTER %q (env): %v", f, err)
+		}
+		repeated = append(repeated, configValue{Key: "GIT_AI_FOOTER", Value: f, Source: sourceEnv})
+	}
+	if msg := strings.TrimSpace(os.Getenv("GIT_AI_SPINNER_MESSAGE")); msg != "" {
+		repeated = append(repeated, configValue{Key: "GIT_AI_SPINNER_MESSAGE", Value: msg, Source: sourceEnv})
+	}
+	report.Values = append(report.Values, repeated...)
+
+	if scopeMap, err := loadScopes(); err != nil {
+		report.errorf("%v", err)
+	} else if scopeMap != nil {
+		report.add("scopes", strings.Join(scopeMap.Allowed(), ","), scopes.File)
+		if strings.EqualFold(scopePolicy, commit.ScopeForbidden) {
+			report.warnf("GIT_AI_SCOPE=forbidden ignores the scopes of %s", scopes.File)
+		}
+	}
+	return report, nil
+}
+
+// isBackendModelKey reports whether key is GIT_AI_MODEL_<BACKEND> for a
+// known backend.
+func isBackendModelKey(key string) bool {
+	name, ok := strings.CutPrefix(key, agentrc.ModelKeyPrefix)
+	_, known := backends[strings.ToLower(name)]
+	return ok && known
+}
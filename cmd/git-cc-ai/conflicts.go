@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/commit"
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+)
+
+const conflictsInstructions = `You explain how merge conflicts were resolved, for reviewers reading the commit.
+For every file below you get the diff from each side of the conflict to the resolved version.
+Write a paragraph starting with "Conflict resolution:" followed by one "- path: explanation" bullet per file that says which side's changes were kept, dropped or combined and why it matters.
+Wrap lines at 72 characters. Output only that paragraph and its bullets.`
+
+// sideLabels names HEAD and the other side of each operation for the prompt.
+var sideLabels = map[string][2]string{
+	"merge":       {"HEAD (the current branch)", "MERGE_HEAD (the branch being merged)"},
+	"rebase":      {"HEAD (the upstream being rebased onto)", "REBASE_HEAD (the commit being replayed)"},
+	"cherry-pick": {"HEAD (the current branch)", "CHERRY_PICK_HEAD (the commit being picked)"},
+	"revert":      {"HEAD (the current branch)", "REVERT_HEAD (the commit being reverted)"},
+}
+
+// runConflicts explains how the conflicts of the merge, rebase,
+// cherry-pick or revert in progress were resolved and appends the
+// explanation to the message the operation will commit with.
+func runConflicts(args []string) int {
+	var (
+		printOnly bool
+		noSpinner bool
+		fs        = flag.NewFlagSet("conflicts", flag.ContinueOnError)
+	)
+	fs.BoolVar(&printOnly, "print", false, "print the explanation without appending it to the commit message")
+	fs.BoolVar(&noSpinner, "no-spinner", false, "disable spinner while the backend runs")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: git-cc-ai conflicts [--print]")
+		fs.PrintDefaults()
+	}
+	if rest, err := parseArgs(fs, args, nil); err != nil || len(rest) > 0 {
+		fs.Usage()
+		return 2
+	}
+
+	explanation, op, err := explainConflicts(!noSpinner)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return exitCode(err)
+	}
+	fmt.Println(explanation)
+	if printOnly {
+		return 0
+	}
+	if err = appendToMessageFile(op.MessageFile, explanation); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "appended to the %s commit message (%s)\n", op.Kind, op.MessageFile)
+	return 0
+}
+
+// explainConflicts asks the backend to describe the resolution of every
+// conflicted path of the operation in progress.
+func explainConflicts(showSpinner bool) (string, git.Operation, error) {
+	op, err := git.InProgress()
+	if err != nil {
+		return "", op, err
+	}
+	unmerged, err := git.UnmergedPaths()
+	if err != nil {
+		return "", op, err
+	}
+	if len(unmerged) > 0 {
+		return "", op, fmt.Errorf("resolve and stage these paths first: %s", strings.Join(unmerged, ", "))
+	}
+	paths, err := op.ConflictedPaths()
+	if err != nil {
+		return "", op, err
+	}
+	if len(paths) == 0 {
+		return "", op, errors.New("git recorded no conflicts for this " + op.Kind)
+	}
+
+	labels := sideLabels[op.Kind]
+	var input strings.Builder
+	fmt.Fprintf(&input, "Operation: %s\n", op.Kind)
+	for _, path := range paths {
+		fmt.Fprintf(&input, "\nFile: %s\n", path)
+		for i, rev := range []string{"HEAD", op.Theirs} {
+			diff, err := git.ResolutionDiff(rev, path)
+			if err != nil {
+				return "", op, err
+			}
+			if strings.TrimSpace(diff) == "" {
+				diff = "(identical: this side was kept as is)\n"
+			}
+			fmt.Fprintf(&input, "\nDiff from %s to the resolution:\n%s", labels[i], git.CapDiff(diff))
+		}
+	}
+
+	out, err := runTask(providers.Task{Instructions: conflictsInstructions, Input: input.String()}, showSpinner)
+	if err != nil {
+		return "", op, err
+	}
+	return commit.Sanitize(out, nil), op, nil
+}
+
+// appendToMessageFile adds text to the end of the message in path, ahead
+// of git's comment lines.
+func appendToMessageFile(path, text string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	var (
+		char     = git.CommentChar()
+		lines    = strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+		split    = len(lines)
+		message  string
+		comments string
+	)
+	for i, line := range lines {
+		if strings.HasPrefix(line, char) {
+			split = i
+			break
+		}
+	}
+	message = strings.TrimSpace(strings.Join(lines[:split], "\n"))
+	if split < len(lines) {
+		comments = "\n" + strings.Join(lines[split:], "\n") + "\n"
+	}
+	if message != "" {
+		message += "\n\n"
+	}
+	return os.WriteFile(path, []byte(message+strings.TrimSpace(text)+"\n"+comments), 0o644)
+}
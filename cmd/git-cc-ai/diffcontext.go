@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/agentrc"
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+// resolveContext returns the diff context strategy from --context, then
+// GIT_AI_CONTEXT and .agentrc. Unknown values are reported and the hunk
+// context is kept.
+func resolveContext(value string, rc agentrc.Config) string {
+	mode := strings.ToLower(firstNonEmpty(value, os.Getenv("GIT_AI_CONTEXT"), rc.Context))
+	switch {
+	case mode == "":
+		return git.ContextHunk
+	case slices.Contains(git.ContextModes, mode):
+		return mode
+	}
+	fmt.Fprintf(ui.Status(), "warning: GIT_AI_CONTEXT %q is not one of %s; using %q\n", mode, strings.Join(git.ContextModes, ", "), git.ContextHunk)
+	return git.ContextHunk
+}
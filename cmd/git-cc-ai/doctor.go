@@ -0,0 +1,421 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/agentrc"
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+const (
+	// cliVersionTimeout bounds "<cli> --version" of a backend CLI.
+	cliVersionTimeout = 10 * time.Second
+	// authCheckTimeout bounds the no-op request that checks authentication.
+	authCheckTimeout = 90 * time.Second
+)
+
+// minGitVersion is the oldest git with everything git-cc-ai uses
+// (rev-parse --path-format=absolute for the hooks directory).
+var minGitVersion = []int{2, 31}
+
+// authHints tells how to log in to each CLI backend.
+var authHints = map[string]string{
+	"claude": "run claude and log in with /login, or set ANTHROPIC_API_KEY",
+	"codex":  "run codex login",
+	"gemini": "run gemini and sign in, or set GEMINI_API_KEY",
+}
+
+type doctorCheck struct {
+	Name   string `json:"name"`
+	Level  string `json:"level"`
+	Detail string `json:"detail"`
+	Hint   string `json:"hint,omitempty"`
+}
+
+type doctorReport struct {
+	Checks []doctorCheck `json:"checks"`
+}
+
+func (r *doctorReport) add(level, name, detail, hint string) {
+	r.Checks = append(r.Checks, doctorCheck{Name: name, Level: level, Detail: detail, Hint: hint})
+}
+
+func (r *doctorReport) count(level string) int {
+	n := 0
+	for _, c := range r.Checks {
+		if c.Level == level {
+			n++
+		}
+	}
+	return n
+}
+
+// runDoctor implements "git-cc-ai doctor": it checks the installation,
+// backends, authentication, git, hooks, configuration and terminal, and
+// prints a report to paste into support requests.
+func runDoctor(args []string) int {
+	var (
+		format string
+		noAuth bool
+		fs     = flag.NewFlagSet("doctor", flag.ContinueOnError)
+	)
+	fs.StringVar(&format, "format", "text", "output format: text or json")
+	fs.BoolVar(&noAuth, "no-auth", false, "skip the request that checks authentication of the selected backend")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: git-cc-ai doctor [--no-auth] [--format text|json]")
+		fs.PrintDefaults()
+	}
+	if rest, err := parseArgs(fs, args, nil); err != nil || len(rest) > 0 || (format != "text" && format != "json") {
+		fs.Usage()
+		return 2
+	}
+
+	rc := agentrc.Load(agentrcPath())
+	var r doctorReport
+	checkInstall(&r)
+	checkGit(&r)
+	checkBackends(&r)
+	backend, b := checkSelectedBackend(&r, rc)
+	switch {
+	case b == nil:
+	case noAuth:
+		r.add(ui.CheckSkip, "auth", "skipped (--no-auth)", "")
+	default:
+		checkAuth(&r, backend, b, rc)
+	}
+	checkConfig(&r)
+	checkAlias(&r)
+	checkHook(&r, rc)
+	checkTerminal(&r)
+
+	if format == "json" {
+		_ = json.NewEncoder(os.Stdout).Encode(r)
+	} else {
+		for _, c := range r.Checks {
+			fmt.Printf("%s %-18s %s\n", ui.CheckMark(c.Level), c.Name, c.Detail)
+			if c.Hint != "" {
+				fmt.Printf("    → %s\n", c.Hint)
+			}
+		}
+		switch failed, warned := r.count(ui.CheckFail), r.count(ui.CheckWarn); {
+		case failed > 0:
+			fmt.Printf("\n%d failed, %d warnings\n", failed, warned)
+		case warned > 0:
+			fmt.Printf("\n%d warnings\n", warned)
+		default:
+			fmt.Println("\neverything looks good")
+		}
+	}
+	if r.count(ui.CheckFail) > 0 {
+		return exitFailure
+	}
+	return 0
+}
+
+// checkInstall reports the version and path of git-cc-ai itself.
+func checkInstall(r *doctorReport) {
+	version, goVersion := "unknown", runtime.Version()
+	if info, ok := debug.ReadBuildInfo(); ok {
+		version = info.Main.Version
+	}
+	path, err := os.Executable()
+	if err != nil {
+		path = os.Args[0]
+	}
+	r.add(ui.CheckOK, "git-cc-ai", fmt.Sprintf("%s (%s, %s/%s) %s", version, goVersion, runtime.GOOS, runtime.GOARCH, path), "")
+}
+
+func checkGit(r *doctorReport) {
+	version, err := git.Version()
+	switch {
+	case err != nil:
+		r.add(ui.CheckFail, "git", err.Error(), "install git and make sure it is in PATH")
+		return
+	case !versionAtLeast(version, minGitVersion):
+		r.add(ui.CheckWarn, "git", fmt.Sprintf("%s is older than %d.%d", version, minGitVersion[0], minGitVersion[1]), "upgrade git; hooks and some commands may not work")
+	default:
+		r.add(ui.CheckOK, "git", version, "")
+	}
+	if root, err := git.TopLevel(); err != nil {
+		r.add(ui.CheckWarn, "repository", "not inside a git repository", "run git-cc-ai doctor in your repository to check its hooks and .agentrc")
+	} else {
+		r.add(ui.CheckOK, "repository", root, "")
+	}
+}
+
+// versionAtLeast reports whether the dotted version (e.g. "2.43.0" or
+// "2.39.3 (Apple Git-146)") is at least minimum.
+func versionAtLeast(version string, minimum []int) bool {
+	fields := strings.Fields(version)
+	if len(fields) == 0 {
+		return false
+	}
+	parts := strings.Split(fields[0], ".")
+	for i, want := range minimum {
+		if i >= len(parts) {
+			return false
+		}
+		got, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return false
+		}
+		if got != want {
+			return got > want
+		}
+	}
+	return true
+}
+
+// checkBackends reports every backend: whether its CLI is in PATH and runs,
+// or whether its API is configured.
+func checkBackends(r *doctorReport) {
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		check := "backend " + name
+		if cb, ok := backends[name].(providers.ConfiguredBackend); ok {
+			switch err := cb.Configured(); {
+			case err == nil:
+				r.add(ui.CheckOK, check, "API configured", "")
+			case errors.Is(err, providers.ErrNotConfigured):
+				r.add(ui.CheckSkip, check, strings.TrimPrefix(err.Error(), providers.ErrNotConfigured.Error()+": "), "")
+			default:
+				r.add(ui.CheckFail, check, err.Error(), "")
+			}
+			continue
+		}
+		path, err := exec.LookPath(name)
+		if err != nil {
+			r.add(ui.CheckSkip, check, "not found in PATH", "")
+			continue
+		}
+		version, err := cliVersion(path)
+		if err != nil {
+			r.add(ui.CheckFail, check, fmt.Sprintf("%s --version failed: %v", path, err), "reinstall or update the "+name+" CLI")
+			continue
+		}
+		r.add(ui.CheckOK, check, version+" ("+path+")", "")
+	}
+}
+
+// cliVersion returns the first line of "<path> --version".
+func cliVersion(path string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), cliVersionTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, path, "--version").CombinedOutput()
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("no answer within %s", cliVersionTimeout)
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	if err != nil {
+		if line != "" {
+			return "", fmt.Errorf("%w: %s", err, line)
+		}
+		return "", err
+	}
+	return line, nil
+}
+
+// checkSelectedBackend reports the backend generation would use and where
+// the choice comes from; nil when there is none.
+func checkSelectedBackend(r *doctorReport, rc agentrc.Config) (string, providers.Backend) {
+	backend, b, err := resolveBackend(os.Getenv("GIT_AI_BACKEND"), rc)
+	if err != nil {
+		r.add(ui.CheckFail, "selected backend", err.Error(), "install claude, gemini or codex, or configure an API backend (see git-cc-ai -h)")
+		return "", nil
+	}
+	source := "auto-detected"
+	switch {
+	case strings.TrimSpace(os.Getenv("GIT_AI_BACKEND")) != "":
+		source = "GIT_AI_BACKEND"
+	case rc.Backend != "":
+		source = agentrc.FileName
+	}
+	model := modelOrDefault(b, preferredModel(backend, b, rc))
+	r.add(ui.CheckOK, "selected backend", fmt.Sprintf("%s, model %s (%s)", backend, model, source), "")
+	return backend, b
+}
+
+// checkAuth sends a tiny task to the selected backend, which fails when it
+// is not logged in or its key is rejected.
+func checkAuth(r *doctorReport, backend string, b providers.Backend, rc agentrc.Config) {
+	ctx, cancel := context.WithTimeout(context.Background(), authCheckTimeout)
+	defer cancel()
+	start := time.Now()
+	out, err := b.Generate(ctx, &providers.Registry{}, providers.Options{
+		Model:         preferredModel(backend, b, rc),
+		Budget:        rc.Budget,
+		RateLimitWait: -1,
+		Task: &providers.Task{
+			Instructions: "This is a connectivity check. Reply with the single word OK.",
+			Input:        "ping",
+		},
+	})
+	hint := authHints[backend]
+	if hint == "" {
+		hint = "check the API key and endpoint settings of the " + backend + " backend (git-cc-ai -h)"
+	}
+	switch {
+	case ctx.Err() != nil:
+		r.add(ui.CheckFail, "auth", fmt.Sprintf("%s did not answer within %s", backend, authCheckTimeout), hint)
+	case errors.Is(err, providers.ErrRateLimited):
+		r.add(ui.CheckWarn, "auth", firstLine(err.Error()), "authenticated, but rate limited right now")
+	case err != nil:
+		r.add(ui.CheckFail, "auth", firstLine(err.Error()), hint)
+	case strings.TrimSpace(out) == "":
+		r.add(ui.CheckWarn, "auth", backend+" answered with an empty response", "")
+	default:
+		r.add(ui.CheckOK, "auth", fmt.Sprintf("%s answered in %s", backend, time.Since(start).Round(100*time.Millisecond)), "")
+	}
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
+
+// checkConfig summarizes "git-cc-ai config validate".
+func checkConfig(r *doctorReport) {
+	path := agentrcPath()
+	report, err := validateConfig(path)
+	if err != nil {
+		r.add(ui.CheckFail, "config", err.Error(), "")
+		return
+	}
+	var errs, warnings []string
+	for _, p := range report.Problems {
+		if p.Level == "error" {
+			errs = append(errs, p.Message)
+		} else {
+			warnings = append(warnings, p.Message)
+		}
+	}
+	switch {
+	case len(errs) > 0:
+		r.add(ui.CheckFail, "config", strings.Join(errs, "; "), "run git-cc-ai config validate for details")
+	case len(warnings) > 0:
+		r.add(ui.CheckWarn, "config", strings.Join(warnings, "; "), "run git-cc-ai config validate for details")
+	default:
+		detail := "defaults and environment (no " + agentrc.FileName + ")"
+		if _, err := os.Stat(path); err == nil {
+			detail = path
+		}
+		r.add(ui.CheckOK, "config", detail, "")
+	}
+}
+
+// checkAlias reports what "git ai" runs.
+func checkAlias(r *doctorReport) {
+	switch existing := git.Config("alias.ai", ""); {
+	case existing == aliasCommand:
+		r.add(ui.CheckOK, "git ai", "alias for "+strings.TrimPrefix(aliasCommand, "!"), "")
+	case existing != "":
+		r.add(ui.CheckWarn, "git ai", fmt.Sprintf("alias for %q", existing), "git-cc-ai alias install --force")
+	default:
+		if path, err := exec.LookPath("git-ai"); err == nil {
+			r.add(ui.CheckOK, "git ai", "runs "+path, "")
+		} else {
+			r.add(ui.CheckSkip, "git ai", "not set up", "git-cc-ai install")
+		}
+	}
+}
+
+// checkHook reports the post-commit hook, which only matters when metrics,
+// feedback or usage notes are on.
+func checkHook(r *doctorReport, rc agentrc.Config) {
+	dir, err := git.HooksDir()
+	if err != nil {
+		r.add(ui.CheckSkip, "post-commit hook", "not inside a git repository", "")
+		return
+	}
+	path := filepath.Join(dir, "post-commit")
+	if installed, err := hookCallsGitCCAI(path); err != nil {
+		r.add(ui.CheckFail, "post-commit hook", err.Error(), "")
+		return
+	} else if installed {
+		r.add(ui.CheckOK, "post-commit hook", path, "")
+		return
+	}
+	var needed []string
+	if metricsEnabled(rc) {
+		needed = append(needed, "GIT_AI_METRICS")
+	}
+	if feedbackEnabled(rc) {
+		needed = append(needed, "GIT_AI_FEEDBACK")
+	}
+	if resolveUsageMode(rc) == usageNotes {
+		needed = append(needed, "GIT_AI_USAGE=notes")
+	}
+	if len(needed) > 0 {
+		r.add(ui.CheckWarn, "post-commit hook", "not installed, so "+strings.Join(needed, ", ")+" records nothing", "git-cc-ai hook install")
+		return
+	}
+	r.add(ui.CheckSkip, "post-commit hook", "not installed (only needed for GIT_AI_METRICS, GIT_AI_FEEDBACK or GIT_AI_USAGE=notes)", "")
+}
+
+// hookCallsGitCCAI reports whether the hook at path runs git-cc-ai's
+// post-commit, installed by git-cc-ai or added to an existing hook.
+func hookCallsGitCCAI(path string) (bool, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == hookMarker || (!strings.HasPrefix(line, "#") && strings.Contains(line, "git-cc-ai hook post-commit")) {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// checkTerminal reports whether the spinner and the interactive menus are
+// available.
+func checkTerminal(r *doctorReport) {
+	term := os.Getenv("TERM")
+	var colors []string
+	for _, key := range []string{"COLORTERM", "NO_COLOR"} {
+		if v, ok := os.LookupEnv(key); ok {
+			colors = append(colors, key+"="+v)
+		}
+	}
+	detail := "TERM=" + term
+	if len(colors) > 0 {
+		detail += ", " + strings.Join(colors, ", ")
+	}
+	switch forced := strings.TrimSpace(os.Getenv("GIT_AI_PLAIN")); {
+	case ui.Plain() && slices.Contains([]string{"1", "true", "yes"}, strings.ToLower(forced)):
+		r.add(ui.CheckOK, "terminal", "plain output forced by GIT_AI_PLAIN ("+detail+")", "")
+	case ui.Plain():
+		r.add(ui.CheckWarn, "terminal", "stderr is not a terminal: plain progress lines, no spinner or menus ("+detail+")", "run git-cc-ai from an interactive terminal for the spinner and model menu")
+	case term == "" || term == "dumb":
+		r.add(ui.CheckWarn, "terminal", "stderr is a terminal without capabilities ("+detail+")", "set TERM, e.g. TERM=xterm-256color")
+	default:
+		r.add(ui.CheckOK, "terminal", "stderr is a terminal ("+detail+")", "")
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/analyze"
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+// maxSymbolFiles caps the Go files parsed for the symbol summary.
+const maxSymbolFiles = 50
+
+// stagedGoSymbols returns the prompt note listing the exported Go
+// declarations the staged changes add, remove or modify, comparing the
+// HEAD and index versions of each Go file. Files that cannot be read or
+// parsed are skipped.
+func stagedGoSymbols() string {
+	changes, err := git.StagedChanges()
+	if err != nil {
+		return ""
+	}
+	var (
+		symbols []analyze.SymbolChange
+		parsed  int
+	)
+	for _, c := range changes {
+		if !strings.HasSuffix(c.Path, ".go") || strings.HasSuffix(c.Path, "_test.go") {
+			continue
+		}
+		if parsed++; parsed > maxSymbolFiles {
+			break
+		}
+		var oldSrc, newSrc []byte
+		if c.Status != "added" && c.Status != "copied" {
+			oldPath := c.Path
+			if c.OldPath != "" {
+				oldPath = c.OldPath
+			}
+			if oldSrc, err = git.Blob(git.BaseRev(), oldPath); err != nil {
+				ui.Debugf("go symbols: %v", err)
+				continue
+			}
+		}
+		if c.Status != "deleted" {
+			if newSrc, err = git.Blob("", c.Path); err != nil {
+				ui.Debugf("go symbols: %v", err)
+				continue
+			}
+		}
+		changed, err := analyze.GoSymbols(c.Path, oldSrc, newSrc)
+		if err != nil {
+			ui.Debugf("go symbols: %v", err)
+			continue
+		}
+		symbols = append(symbols, changed...)
+	}
+	return analyze.SymbolNote(symbols)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"os/exec"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+)
+
+// Exit codes for the common failure modes, so wrapper scripts and hooks can
+// branch on them instead of matching stderr.
+const (
+	exitFailure        = 1
+	exitNoStaged       = 3
+	exitBackendMissing = 4
+	exitBudget         = 5
+	exitInvalidModel   = 6
+	exitRateLimited    = 7
+	exitTimeout        = 124
+	exitInterrupted    = 130
+)
+
+// exitCode maps err to the process exit code.
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errTimeout), errors.Is(err, providers.ErrStalled):
+		return exitTimeout
+	case errors.Is(err, providers.ErrInterrupted):
+		return exitInterrupted
+	case errors.Is(err, providers.ErrNoStagedChanges):
+		return exitNoStaged
+	case errors.Is(err, providers.ErrBackendMissing), errors.Is(err, exec.ErrNotFound), errors.Is(err, providers.ErrNotConfigured), errors.Is(err, errOffline):
+		return exitBackendMissing
+	case errors.Is(err, providers.ErrBudgetExceeded), errors.Is(err, providers.ErrToolCallLimit):
+		return exitBudget
+	case errors.Is(err, providers.ErrInvalidModel):
+		return exitInvalidModel
+	case errors.Is(err, providers.ErrRateLimited):
+		return exitRateLimited
+	default:
+		return exitFailure
+	}
+}
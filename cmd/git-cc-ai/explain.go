@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+const explainInstructions = `Explain in plain language what the git commits below do, using their messages and diff.
+%s
+Output Markdown only, without restating these instructions.`
+
+// explainAudiences holds the audience-specific part of explainInstructions.
+var explainAudiences = map[string]string{
+	"reviewer":  "The reader is a code reviewer: describe the behaviour change, how it is implemented, and anything risky or surprising worth a closer look. Reference files where it helps.",
+	"changelog": "The reader is a user of the project: write a few changelog bullets about user-visible changes and leave out internal refactoring.",
+	"manager":   "The reader is a non-technical manager: say in a short paragraph what changed and why it matters, without code, file names or jargon.",
+}
+
+const explainChunkInstructions = `Summarize the part of a git diff below for someone explaining the change later.
+Output one line per changed file in the form "path: summary", where the summary says in at most 25 words what changed.
+Output only those lines.`
+
+// explainChunkBytes is the diff size above which explain summarizes each
+// directory chunk separately before explaining the whole.
+const explainChunkBytes = 256 * 1024
+
+// runExplain prints a plain-language explanation of a commit or range.
+func runExplain(args []string) int {
+	var (
+		audience  string
+		raw       bool
+		noSpinner bool
+		fs        = flag.NewFlagSet("explain", flag.ContinueOnError)
+	)
+	fs.StringVar(&audience, "audience", "reviewer", "who the explanation is for: reviewer, changelog or manager")
+	fs.BoolVar(&raw, "raw", false, "print the explanation as Markdown instead of rendering it")
+	fs.BoolVar(&noSpinner, "no-spinner", false, "disable spinner while the backend runs")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: git-cc-ai explain [--audience reviewer|changelog|manager] [--raw] <sha|range>")
+		fs.PrintDefaults()
+	}
+	rest, err := parseArgs(fs, args, nil)
+	if err != nil || len(rest) != 1 {
+		fs.Usage()
+		return 2
+	}
+	guidance, ok := explainAudiences[audience]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "invalid --audience %q (want reviewer, changelog or manager)\n", audience)
+		return 2
+	}
+	rev := rest[0]
+
+	logRange := rev
+	if !strings.Contains(rev, "..") {
+		logRange = rev + "^!"
+	}
+	entries, err := git.Log(logRange)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return exitCode(err)
+	}
+	if len(entries) == 0 {
+		fmt.Fprintf(os.Stderr, "no commits in %s\n", rev)
+		return 1
+	}
+	diff, err := git.RevDiff(rev)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return exitCode(err)
+	}
+
+	changes, err := explainChanges(diff, !noSpinner)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return exitCode(err)
+	}
+	var input strings.Builder
+	input.WriteString("Commits:\n")
+	for _, e := range entries {
+		fmt.Fprintf(&input, "- %s %s\n", e.Hash[:7], strings.ReplaceAll(e.Message, "\n", "\n  "))
+	}
+	input.WriteString("\n")
+	input.WriteString(changes)
+
+	explanation, err := runTask(providers.Task{
+		Instructions: fmt.Sprintf(explainInstructions, guidance),
+		Input:        input.String(),
+	}, !noSpinner)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return exitCode(err)
+	}
+	if !raw {
+		explanation = ui.RenderMarkdown(explanation)
+	}
+	fmt.Println(strings.TrimRight(explanation, "\n"))
+	return 0
+}
+
+// explainChanges returns the diff section of the explain prompt. Large
+// diffs are summarized per directory chunk first so the final prompt stays
+// small.
+func explainChanges(diff string, showSpinner bool) (string, error) {
+	if len(diff) <= explainChunkBytes {
+		return "Diff:\n" + git.CapDiff(diff), nil
+	}
+	chunks := git.ChunkDiff(diff)
+	summaries := make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		summary, err := runTask(providers.Task{Instructions: explainChunkInstructions, Input: chunk.Diff}, showSpinner)
+		if err != nil {
+			return "", err
+		}
+		summaries = append(summaries, summary)
+	}
+	return "Per-file summaries of the diff:\n" + strings.Join(summaries, "\n") + "\n", nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/agentrc"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+// resolveExtraArgs returns the extra CLI flags per backend from
+// GIT_AI_EXTRA_ARGS_<BACKEND>, which is only read from the environment,
+// like GIT_AI_KEY_CMD. Flags outside the backend's allowlist are reported
+// and the setting is ignored.
+func resolveExtraArgs() map[string][]string {
+	extra := map[string][]string{}
+	for _, backend := range providers.ExtraArgsBackends() {
+		key := agentrc.BackendExtraArgsKey(backend)
+		args := agentrc.SplitArgs(strings.TrimSpace(os.Getenv(key)))
+		if len(args) == 0 {
+			continue
+		}
+		if err := providers.ValidateExtraArgs(backend, args); err != nil {
+			fmt.Fprintf(ui.Status(), "warning: %s: %v; it is ignored\n", key, err)
+			continue
+		}
+		extra[backend] = args
+	}
+	return extra
+}
+
+// isExtraArgsKey reports whether key is GIT_AI_EXTRA_ARGS_<BACKEND> for a
+// known backend.
+func isExtraArgsKey(key string) bool {
+	name, ok := strings.CutPrefix(key, agentrc.ExtraArgsKeyPrefix)
+	_, known := backends[strings.ToLower(name)]
+	return ok && known
+}
+
+// resolveTools returns the tool access of the model from GIT_AI_TOOLS or
+// .agentrc: providers.ToolsNone unless configured otherwise.
+func resolveTools(rc agentrc.Config) string {
+	tools := firstNonEmpty(strings.TrimSpace(os.Getenv("GIT_AI_TOOLS")), rc.Tools)
+	switch strings.ToLower(tools) {
+	case "", providers.ToolsNone:
+		return providers.ToolsNone
+	case providers.ToolsAll:
+		return providers.ToolsAll
+	}
+	return tools
+}
+
+// defaultMaxToolCalls caps the tool calls of a deep context run unless
+// GIT_AI_MAX_TOOL_CALLS says otherwise.
+const defaultMaxToolCalls = 10
+
+// resolveDeepContext applies deep context mode (--deep-context,
+// GIT_AI_DEEP_CONTEXT or .agentrc) for backend: the read-only tool preset,
+// unless GIT_AI_TOOLS chose tools already, and the investigation note. It
+// returns the tools, the tool call limit (GIT_AI_MAX_TOOL_CALLS, which
+// applies to every run when set) and the note, which is empty outside deep
+// context mode.
+func resolveDeepContext(deep bool, backend string, tools string, rc agentrc.Config) (string, int, string) {
+	deep = deep || strings.EqualFold(strings.TrimSpace(os.Getenv("GIT_AI_DEEP_CONTEXT")), "true") || rc.DeepContext
+	maxCalls := rc.MaxToolCalls
+	if v, err := strconv.Atoi(strings.TrimSpace(os.Getenv("GIT_AI_MAX_TOOL_CALLS"))); err == nil && v > 0 {
+		maxCalls = v
+	}
+	if !deep {
+		return tools, maxCalls, ""
+	}
+	preset, ok := providers.DeepContextTools(backend)
+	if !ok {
+		fmt.Fprintf(ui.Status(), "warning: the %s backend cannot use tools; deep context is ignored\n", backend)
+		return tools, maxCalls, ""
+	}
+	if tools == providers.ToolsNone {
+		tools = preset
+	}
+	if maxCalls == 0 {
+		maxCalls = defaultMaxToolCalls
+	}
+	return tools, maxCalls, providers.DeepContextNote(maxCalls)
+}
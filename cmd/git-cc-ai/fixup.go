@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/commit"
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+const squashInstructions = `The staged diff below will be squashed into an earlier commit, whose message is given.
+Write one to three sentences saying what the diff adds to or changes in that commit, wrapped at 72 characters, for the author to merge into the message when squashing.
+Output only those sentences.`
+
+// runFixup commits the staged changes as a fixup! or squash! commit of the
+// recent commit they most likely belong to, for git rebase --autosquash.
+func runFixup(args []string) int {
+	var (
+		squash    bool
+		yes       bool
+		list      bool
+		noSpinner bool
+		limit     int
+		fs        = flag.NewFlagSet("fixup", flag.ContinueOnError)
+	)
+	fs.BoolVar(&squash, "squash", false, "create a squash! commit with a generated note instead of a fixup! commit")
+	fs.BoolVar(&yes, "yes", false, "use the best target without asking")
+	fs.BoolVar(&list, "list", false, "only list the candidate targets, best first")
+	fs.BoolVar(&noSpinner, "no-spinner", false, "disable spinner while the backend runs")
+	fs.IntVar(&limit, "limit", 20, "number of recent commits to consider")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: git-cc-ai fixup [--squash] [--yes] [--list] [--limit n] [<sha>]")
+		fs.PrintDefaults()
+	}
+	rest, err := parseArgs(fs, args, nil)
+	if err != nil || len(rest) > 1 || limit < 1 {
+		fs.Usage()
+		return 2
+	}
+
+	if changes, err := git.StagedChanges(); err != nil || len(changes) == 0 {
+		fmt.Fprintln(os.Stderr, "nothing staged to fix up")
+		return 1
+	}
+	var target git.FixupTarget
+	if len(rest) == 1 {
+		if target.Hash, err = git.ResolveCommit(rest[0]); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 1
+		}
+	} else {
+		targets, err := git.FixupTargets(limit)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 1
+		}
+		if len(targets) == 0 {
+			fmt.Fprintln(os.Stderr, "none of the recent unpushed commits touches the staged changes; pass the target commit")
+			return 1
+		}
+		labels := make([]string, len(targets))
+		for i, t := range targets {
+			labels[i] = fixupLabel(t)
+		}
+		if list {
+			fmt.Println(strings.Join(labels, "\n"))
+			return 0
+		}
+		i := 0
+		if !yes {
+			i, err = ui.SelectOption("Select the commit to fix up:", labels)
+			if errors.Is(err, ui.ErrNotInteractive) {
+				fmt.Fprintf(os.Stderr, "candidate targets, best first:\n%s\npass --yes to use the first or the commit to fix up\n", strings.Join(labels, "\n"))
+				return 2
+			}
+			if err != nil {
+				return 1
+			}
+		}
+		target = targets[i]
+	}
+
+	var note string
+	if squash {
+		if note, err = squashNote(target.Hash, !noSpinner); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return exitCode(err)
+		}
+	}
+	if err = git.CommitFixup(target.Hash, squash, note); err != nil {
+		return childExitCode(err)
+	}
+	return 0
+}
+
+// fixupLabel describes a target in the picker: abbreviated hash, subject
+// and why it was picked.
+func fixupLabel(t git.FixupTarget) string {
+	var why []string
+	if t.Lines > 0 {
+		why = append(why, plural(t.Lines, "line"))
+	}
+	if t.Files > 0 {
+		why = append(why, plural(t.Files, "file"))
+	}
+	return fmt.Sprintf("%s %s (%s)", t.Hash[:min(len(t.Hash), 7)], t.Subject, strings.Join(why, ", "))
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// squashNote asks the backend what the staged changes add to the commit
+// hash, for the body of the squash! commit.
+func squashNote(hash string, showSpinner bool) (string, error) {
+	_, original, err := git.CommitTree(hash)
+	if err != nil {
+		return "", err
+	}
+	diff, err := git.DiffStaged()
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(diff) == "" {
+		return "", providers.ErrNoStagedChanges
+	}
+	out, err := runTask(providers.Task{
+		Instructions: squashInstructions,
+		Input:        "Message of the earlier commit:\n" + original + "\n\nStaged diff:\n" + diff,
+	}, showSpinner)
+	if err != nil {
+		return "", err
+	}
+	return commit.Sanitize(out, nil), nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/agentrc"
+	"github.com/dlnilsson/git-cc-ai/pkg/commit"
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+// ticketKey matches an issue key such as ABC-123 in a branch name.
+var ticketKey = regexp.MustCompile(`[A-Z][A-Z0-9]+-[0-9]+`)
+
+// footerTemplates returns the GIT_AI_FOOTER lines from .agentrc plus the
+// one in the environment. Invalid templates are reported and skipped.
+func footerTemplates(rc agentrc.Config) []string {
+	templates := rc.Footers
+	if f := strings.TrimSpace(os.Getenv("GIT_AI_FOOTER")); f != "" {
+		templates = append(templates[:len(templates):len(templates)], f)
+	}
+	valid := make([]string, 0, len(templates))
+	for _, t := range templates {
+		if err := commit.ValidateFooter(t); err != nil {
+			fmt.Fprintf(ui.Status(), "warning: GIT_AI_FOOTER %q: %v; it is ignored\n", t, err)
+			continue
+		}
+		valid = append(valid, t)
+	}
+	return valid
+}
+
+// withFooters adds the expanded footer templates to message. A footer
+// whose token the message already carries is kept as it is, and one with
+// a variable that expands to nothing (e.g. {mr_url} outside a merge
+// request pipeline) is left out.
+func withFooters(message string, templates []string) string {
+	if strings.TrimSpace(message) == "" {
+		return message
+	}
+	for _, t := range templates {
+		token := commit.FooterToken(t)
+		if hasFooter(commit.StripComments(message), token) {
+			continue
+		}
+		footer, ok := commit.ExpandFooter(t, func(name, arg string) string {
+			return footerValue(name, arg, message)
+		})
+		if !ok {
+			ui.Debugf("footer %s left out: a variable is empty", token)
+			continue
+		}
+		message = commit.AddTrailer(message, footer)
+	}
+	return message
+}
+
+// footerOrder returns GIT_AI_FOOTER_ORDER from the environment, or else
+// from .agentrc: the footer tokens in the order footers are written, with
+// "*" for the ones it does not name.
+func footerOrder(rc agentrc.Config) []string {
+	if v := strings.TrimSpace(os.Getenv("GIT_AI_FOOTER_ORDER")); v != "" {
+		return agentrc.ParseList(v)
+	}
+	return rc.FooterOrder
+}
+
+// withTrailers adds the --trailer values to message, then drops repeated
+// footers and orders them (see commit.NormalizeFooters). A trailer replaces
+// the footers with its token the backend wrote, except Refs, whose
+// references are merged; several trailers with one token are all kept.
+func withTrailers(message string, trailers, order []string) string {
+	if strings.TrimSpace(message) == "" {
+		return message
+	}
+	replaced := map[string]bool{}
+	for _, t := range trailers {
+		token := strings.ToLower(commit.FooterToken(t))
+		if token != "refs" && !replaced[token] {
+			message = commit.RemoveTrailers(message, token)
+			replaced[token] = true
+		}
+	}
+	for _, t := range trailers {
+		message = commit.AddTrailer(message, t)
+	}
+	return commit.NormalizeFooters(message, order)
+}
+
+// footerValue resolves the footer variable name (with its argument, if
+// any) for message.
+func footerValue(name, arg, message string) string {
+	switch name {
+	case "gen":
+		id, err := git.ChangeID(commit.StripComments(message))
+		if err != nil {
+			fmt.Fprintf(ui.Status(), "warning: cannot generate a Change-Id: %v\n", err)
+		}
+		return id
+	case "date":
+		if arg == "" {
+			arg = time.DateOnly
+		}
+		return time.Now().Format(arg)
+	case "branch":
+		return git.CurrentBranch()
+	case "ticket":
+		return ticketKey.FindString(git.CurrentBranch())
+	case "mr_url":
+		return mergeRequestURL()
+	case "env":
+		return os.Getenv(arg)
+	}
+	return ""
+}
+
+// mergeRequestURL returns the merge or pull request the run belongs to:
+// GIT_AI_MR_URL, or the one of a GitLab merge request pipeline or a GitHub
+// Actions pull_request run.
+func mergeRequestURL() string {
+	if u := strings.TrimSpace(os.Getenv("GIT_AI_MR_URL")); u != "" {
+		return u
+	}
+	if project, iid := os.Getenv("CI_MERGE_REQUEST_PROJECT_URL"), os.Getenv("CI_MERGE_REQUEST_IID"); project != "" && iid != "" {
+		return project + "/-/merge_requests/" + iid
+	}
+	if ref := os.Getenv("GITHUB_REF"); strings.HasPrefix(ref, "refs/pull/") {
+		number, _, _ := strings.Cut(strings.TrimPrefix(ref, "refs/pull/"), "/")
+		return os.Getenv("GITHUB_SERVER_URL") + "/" + os.Getenv("GITHUB_REPOSITORY") + "/pull/" + number
+	}
+	return ""
+}
+
+// commitMsg is the commit-msg hook installed by "git-cc-ai hook install
+// --change-id": like Gerrit's hook, it adds a Change-Id footer to the
+// message in path unless it already has one, is empty, or is a fixup! or
+// squash! commit.
+func commitMsg(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	text := strings.TrimSpace(commit.StripComments(string(data)))
+	subject := commit.Subject(text)
+	if text == "" || hasFooter(text, "Change-Id") || strings.HasPrefix(subject, "fixup!") || strings.HasPrefix(subject, "squash!") {
+		return nil
+	}
+	id, err := git.ChangeID(text)
+	if err != nil {
+		return err
+	}
+	return git.AddTrailerToFile(path, "Change-Id: "+id)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/agentrc"
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+// Handling of formatting-only changes (GIT_AI_FORMATTING).
+const (
+	// formattingLocal writes a style: message without the backend.
+	formattingLocal = "local"
+	// formattingNote tells the backend the change is formatting only.
+	formattingNote = "note"
+	// formattingOff does not check for formatting-only changes.
+	formattingOff = "off"
+)
+
+var formattingModes = []string{formattingLocal, formattingNote, formattingOff}
+
+const formattingOnlyNote = `The staged changes only touch whitespace and blank lines (git diff -w shows no change), e.g. from running a formatter: describe them as a style change and do not claim any change in behaviour.`
+
+// resolveFormatting returns GIT_AI_FORMATTING from the environment or
+// .agentrc. Unknown values are reported and the default is used.
+func resolveFormatting(rc agentrc.Config) string {
+	mode := strings.ToLower(firstNonEmpty(os.Getenv("GIT_AI_FORMATTING"), rc.Formatting))
+	switch {
+	case mode == "":
+		return formattingLocal
+	case slices.Contains(formattingModes, mode):
+		return mode
+	}
+	fmt.Fprintf(ui.Status(), "warning: GIT_AI_FORMATTING %q is not one of %s; using %q\n", mode, strings.Join(formattingModes, ", "), formattingLocal)
+	return formattingLocal
+}
+
+// stagedFormattingOnly returns the staged paths when the staged changes
+// only touch whitespace and blank lines, and nil otherwise.
+func stagedFormattingOnly() []string {
+	changes, err := git.StagedChanges()
+	if err != nil {
+		return nil
+	}
+	if only, err := git.FormattingOnly(changes); err != nil || !only {
+		return nil
+	}
+	ui.Debugf("the staged changes are formatting only")
+	return git.ChangedPaths(changes)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/agentrc"
+	"github.com/dlnilsson/git-cc-ai/pkg/commit"
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+// changeIDLine matches a Gerrit Change-Id footer.
+var changeIDLine = regexp.MustCompile(`(?m)^Change-Id: (I[0-9a-f]{40})\s*$`)
+
+// changeIDPolicy says which Change-Id a generated message gets.
+type changeIDPolicy struct {
+	// keep is the Change-Id of the commit being amended; Gerrit opens a
+	// new change when it differs.
+	keep string
+	// generate adds a new Change-Id (GIT_AI_GERRIT=true without Gerrit's
+	// commit-msg hook, which would add it when git commits).
+	generate bool
+	// replace drops any Change-Id the backend made up (--amend or Gerrit
+	// mode).
+	replace bool
+}
+
+// gerritEnabled reports whether Gerrit mode is on (GIT_AI_GERRIT).
+func gerritEnabled(rc agentrc.Config) bool {
+	return strings.EqualFold(strings.TrimSpace(os.Getenv("GIT_AI_GERRIT")), "true") || rc.Gerrit
+}
+
+// resolveChangeID returns the Change-Id policy of the run. With amend it
+// keeps the Change-Id of HEAD, whether or not Gerrit mode is on.
+func resolveChangeID(rc agentrc.Config, amend bool) changeIDPolicy {
+	p := changeIDPolicy{replace: amend || gerritEnabled(rc)}
+	if amend {
+		if _, message, err := git.CommitTree("HEAD"); err == nil {
+			if m := changeIDLine.FindStringSubmatch(message); m != nil {
+				p.keep = m[1]
+			}
+		}
+	}
+	if p.keep == "" && gerritEnabled(rc) {
+		if path, ok := gerritHook(); ok {
+			ui.Debugf("Change-Id left to the commit-msg hook %s", path)
+		} else {
+			p.generate = true
+		}
+	}
+	return p
+}
+
+// apply replaces any Change-Id footer of message with the one of the
+// policy.
+func (p changeIDPolicy) apply(message string) string {
+	if strings.TrimSpace(message) == "" || !p.replace {
+		return message
+	}
+	message = commit.RemoveTrailers(message, "Change-Id")
+	id := p.keep
+	if id == "" && p.generate {
+		var err error
+		if id, err = git.ChangeID(commit.StripComments(message)); err != nil {
+			fmt.Fprintf(ui.Status(), "warning: cannot generate a Change-Id: %v\n", err)
+			return message
+		}
+	}
+	if id == "" {
+		return message
+	}
+	return commit.AddTrailer(message, "Change-Id: "+id)
+}
+
+// gerritHook returns the commit-msg hook that adds Change-Ids when it is
+// Gerrit's (or another hook doing the same) rather than git-cc-ai's.
+func gerritHook() (string, bool) {
+	dir, err := git.HooksDir()
+	if err != nil {
+		return "", false
+	}
+	path := filepath.Join(dir, "commit-msg")
+	data, err := os.ReadFile(path)
+	if err != nil || strings.Contains(string(data), hookMarker) {
+		return "", false
+	}
+	return path, strings.Contains(string(data), "Change-Id")
+}
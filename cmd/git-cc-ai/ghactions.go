@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/commit"
+	"github.com/dlnilsson/git-cc-ai/pkg/commitlint"
+	"github.com/dlnilsson/git-cc-ai/pkg/ghactions"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+// lintLevel maps a commitlint severity to an annotation level.
+func lintLevel(s commitlint.Severity) string {
+	if s == commitlint.SeverityError {
+		return ghactions.Error
+	}
+	return ghactions.Warning
+}
+
+// summarizeGeneration annotates the workflow run with the subject of the
+// generated message and writes the message and its usage to the job
+// summary. It does nothing outside GitHub Actions.
+func summarizeGeneration(backend, model, message string) {
+	if !ghactions.Enabled() || strings.TrimSpace(message) == "" {
+		return
+	}
+	text := strings.TrimSpace(commit.StripComments(message))
+	subject, _, _ := strings.Cut(text, "\n")
+	ghactions.Annotate(os.Stderr, ghactions.Notice, "git-cc-ai", subject)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "### Commit message\n\nGenerated by `%s`", backend)
+	if model != "" {
+		fmt.Fprintf(&b, " with `%s`", model)
+	}
+	b.WriteString(".\n\n")
+	b.WriteString(ghactions.CodeBlock("text", text))
+	if usage := usageNote(message); usage != "" {
+		b.WriteString("\n")
+		for line := range strings.SplitSeq(usage, "\n") {
+			b.WriteString("- " + line + "\n")
+		}
+	}
+	if err := ghactions.AppendSummary(b.String()); err != nil {
+		fmt.Fprintf(ui.Status(), "warning: %v\n", err)
+	}
+}
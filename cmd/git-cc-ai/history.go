@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/commit"
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/ledger"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+const defaultHistoryLimit = 20
+
+// costTracker adds up the cost of the backend calls of a run for the
+// ledger, estimating it from list prices when a backend reports tokens
+// only. Candidates and chunk summaries report concurrently.
+type costTracker struct {
+	mu  sync.Mutex
+	usd float64
+}
+
+// attach records the usage callback of opts, keeping any listener already
+// set (e.g. --progress json).
+func (c *costTracker) attach(opts *providers.Options) {
+	onUsage := opts.OnUsage
+	opts.OnUsage = func(u providers.Usage) {
+		cost := u.CostUSD
+		if cost == 0 {
+			cost, _ = providers.EstimateCost(u.Model, u.InputTokens, u.OutputTokens)
+		}
+		c.mu.Lock()
+		c.usd += cost
+		c.mu.Unlock()
+		if onUsage != nil {
+			onUsage(u)
+		}
+	}
+}
+
+func (c *costTracker) total() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.usd
+}
+
+// historyEntry is a past generation as listed by git-cc-ai history.
+type historyEntry struct {
+	Index     int       `json:"index"`
+	Time      time.Time `json:"time"`
+	Kind      string    `json:"kind"`
+	Backend   string    `json:"backend"`
+	Model     string    `json:"model"`
+	CostUSD   float64   `json:"cost_usd,omitempty"`
+	Committed bool      `json:"committed"`
+	Message   string    `json:"message"`
+}
+
+// runHistory implements "git-cc-ai history": the messages generated in this
+// repository, newest first, from the local ledger. A message can be printed
+// again or committed through the editor, e.g. after an aborted commit.
+func runHistory(args []string) int {
+	var (
+		format string
+		limit  int
+		edit   bool
+		fs     = flag.NewFlagSet("history", flag.ContinueOnError)
+	)
+	fs.StringVar(&format, "format", "text", "output format: text or json")
+	fs.IntVar(&limit, "limit", defaultHistoryLimit, "number of generations to list")
+	fs.BoolVar(&edit, "edit", false, "commit the staged changes with message N, opening the editor on it")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: git-cc-ai history [--limit N] [--format text|json] [[--edit] N]")
+		fs.PrintDefaults()
+	}
+	rest, err := parseArgs(fs, args, nil)
+	if err != nil || len(rest) > 1 || limit < 1 || (format != "text" && format != "json") || (edit && len(rest) == 0) {
+		fs.Usage()
+		return 2
+	}
+
+	entries, err := loadHistory(limit)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	if len(rest) == 1 {
+		n, err := strconv.Atoi(rest[0])
+		if err != nil || n < 1 || n > len(entries) {
+			fmt.Fprintf(os.Stderr, "no generation %s in the history (1-%d)\n", rest[0], len(entries))
+			return 2
+		}
+		return reuseMessage(entries[n-1], edit)
+	}
+	if format == "json" {
+		if entries == nil {
+			entries = []historyEntry{}
+		}
+		_ = json.NewEncoder(os.Stdout).Encode(entries)
+		return 0
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(os.Stderr, "no generations recorded in this repository yet")
+		return 0
+	}
+	if ui.Plain() {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "#\tTIME\tMODEL\tCOST\tCOMMITTED\tSUBJECT")
+		for _, e := range entries {
+			fmt.Fprintln(w, strings.Join(historyColumns(e), "\t"))
+		}
+		_ = w.Flush()
+		return 0
+	}
+	return browseHistory(entries)
+}
+
+// loadHistory returns up to limit generations with a message, newest first.
+// A generation counts as committed when a commit made since the oldest of
+// them has the same subject.
+func loadHistory(limit int) ([]historyEntry, error) {
+	recorded, err := ledger.Read()
+	if err != nil {
+		return nil, err
+	}
+	var entries []historyEntry
+	for _, e := range slices.Backward(recorded) {
+		if len(entries) == limit {
+			break
+		}
+		message := strings.TrimSpace(commit.StripComments(e.Message))
+		if message == "" {
+			continue
+		}
+		entries = append(entries, historyEntry{
+			Index:   len(entries) + 1,
+			Time:    e.Time,
+			Kind:    e.Kind,
+			Backend: e.Backend,
+			Model:   e.Model,
+			CostUSD: e.CostUSD,
+			Message: message,
+		})
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	commits, err := git.LogSince(entries[len(entries)-1].Time)
+	if err != nil {
+		return nil, err
+	}
+	subjects := make(map[string]bool, len(commits))
+	for _, c := range commits {
+		subjects[commit.Subject(c.Message)] = true
+	}
+	for i := range entries {
+		entries[i].Committed = subjects[commit.Subject(entries[i].Message)]
+	}
+	return entries, nil
+}
+
+// historyColumns returns the table cells of e.
+func historyColumns(e historyEntry) []string {
+	cost := "-"
+	if e.CostUSD > 0 {
+		cost = fmt.Sprintf("$%.4f", e.CostUSD)
+	}
+	committed := "no"
+	if e.Committed {
+		committed = "yes"
+	}
+	return []string{
+		strconv.Itoa(e.Index),
+		e.Time.Local().Format("2006-01-02 15:04"),
+		e.Backend + ":" + e.Model,
+		cost,
+		committed,
+		commit.Subject(e.Message),
+	}
+}
+
+// browseHistory lets the user pick a past message and what to do with it.
+func browseHistory(entries []historyEntry) int {
+	rows := make([][]string, len(entries))
+	widths := make([]int, 5)
+	for i, e := range entries {
+		rows[i] = historyColumns(e)
+		for j := range widths {
+			widths[j] = max(widths[j], len(rows[i][j]))
+		}
+	}
+	options := make([]string, len(rows))
+	for i, cells := range rows {
+		var b strings.Builder
+		for j, w := range widths {
+			fmt.Fprintf(&b, "%-*s  ", w, cells[j])
+		}
+		b.WriteString(cells[len(cells)-1])
+		options[i] = b.String()
+	}
+	i, err := ui.SelectOption("Pick a past message (# time model cost committed subject):", options)
+	if err != nil {
+		return 0
+	}
+	action, err := ui.SelectOption(commit.Subject(entries[i].Message), []string{
+		"Commit the staged changes with it (opens the editor)",
+		"Print it",
+	})
+	if err != nil {
+		return 0
+	}
+	return reuseMessage(entries[i], action == 0)
+}
+
+// reuseMessage prints the message of e, or commits the staged changes with
+// it through the editor when edit is set.
+func reuseMessage(e historyEntry, edit bool) int {
+	if !edit {
+		fmt.Fprintln(os.Stdout, e.Message)
+		return 0
+	}
+	if err := git.CommitEdit(e.Message + "\n"); err != nil {
+		return childExitCode(err)
+	}
+	return 0
+}
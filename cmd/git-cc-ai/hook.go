@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/agentrc"
+	"github.com/dlnilsson/git-cc-ai/pkg/attempt"
+	"github.com/dlnilsson/git-cc-ai/pkg/feedback"
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/metrics"
+)
+
+// recentEdits is how many captured edits personalization looks at.
+const recentEdits = 20
+
+// hookMarker identifies hook scripts written by "git-cc-ai hook install".
+const hookMarker = "# installed by git-cc-ai"
+
+const postCommitHook = `#!/bin/sh
+` + hookMarker + `
+git-cc-ai hook post-commit || true
+`
+
+const commitMsgHook = `#!/bin/sh
+` + hookMarker + `
+git-cc-ai hook commit-msg "$1"
+`
+
+const hookUsage = "usage: git-cc-ai hook install [--change-id]|post-commit|commit-msg <file>"
+
+// runHook implements "git-cc-ai hook install" and the "git-cc-ai hook
+// post-commit" and "git-cc-ai hook commit-msg" entry points the installed
+// hooks call.
+func runHook(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, hookUsage)
+		return 2
+	}
+	switch {
+	case args[0] == "install" && len(args) == 1:
+		path, err := installPostCommitHook()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "installed %s\n", path)
+		if rc := agentrcLoad(); !metricsEnabled(rc) && !feedbackEnabled(rc) && resolveUsageMode(rc) != usageNotes {
+			fmt.Fprintln(os.Stderr, "the hook records nothing until GIT_AI_METRICS=true, GIT_AI_FEEDBACK=true or GIT_AI_USAGE=notes is set (environment or .agentrc)")
+		}
+		return 0
+	case args[0] == "install" && len(args) == 2 && strings.TrimLeft(args[1], "-") == "change-id":
+		path, err := installHook("commit-msg", commitMsgHook, `git-cc-ai hook commit-msg "$1"`)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "installed %s\n", path)
+		return 0
+	case args[0] == "post-commit" && len(args) == 1:
+		// Hooks must never get in the way of a commit.
+		if err := postCommit(); err != nil {
+			fmt.Fprintf(os.Stderr, "git-cc-ai post-commit: %v\n", err)
+		}
+		return 0
+	case args[0] == "commit-msg" && len(args) == 2:
+		// A missing Change-Id would make Gerrit reject the push, so
+		// failures abort the commit.
+		if err := commitMsg(args[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "git-cc-ai commit-msg: %v\n", err)
+			return 1
+		}
+		return 0
+	default:
+		fmt.Fprintln(os.Stderr, hookUsage)
+		return 2
+	}
+}
+
+// installPostCommitHook writes the post-commit hook, refusing to replace a
+// hook that git-cc-ai did not install.
+func installPostCommitHook() (string, error) {
+	return installHook("post-commit", postCommitHook, "git-cc-ai hook post-commit || true")
+}
+
+// installHook writes script as the hook name, refusing to replace a hook
+// that git-cc-ai did not install; the error then suggests adding line to
+// it instead.
+func installHook(name, script, line string) (string, error) {
+	dir, err := git.HooksDir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, name)
+	existing, err := os.ReadFile(path)
+	switch {
+	case err == nil && strings.Contains(string(existing), hookMarker):
+		return path, nil
+	case err == nil:
+		return "", fmt.Errorf("%s already exists; add this line to it instead:\n  %s", path, line)
+	case !errors.Is(err, os.ErrNotExist):
+		return "", err
+	}
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, []byte(script), 0o755)
+}
+
+// postCommit records whether the new commit used a generated message and,
+// with feedback capture on, how the message was edited. With
+// GIT_AI_USAGE=notes the usage of the generation is attached as a note.
+func postCommit() error {
+	rc := agentrcLoad()
+	notes := resolveUsageMode(rc) == usageNotes
+	if !metricsEnabled(rc) && !feedbackEnabled(rc) && !notes {
+		return nil
+	}
+	tree, message, err := git.CommitTree("HEAD")
+	if err != nil {
+		return err
+	}
+	if metricsEnabled(rc) {
+		events, err := metrics.Read()
+		if err != nil {
+			return err
+		}
+		if e, ok := metrics.Outcome(events, tree, message); ok {
+			if err = metrics.Append(e); err != nil {
+				return err
+			}
+		}
+	}
+	if !feedbackEnabled(rc) && !notes {
+		return nil
+	}
+	last, err := attempt.Load()
+	if err != nil || last == nil || last.Tree != tree {
+		return err
+	}
+	if notes {
+		if err = addUsageNote("HEAD", last.Usage); err != nil {
+			return err
+		}
+	}
+	if feedbackEnabled(rc) {
+		if e, ok := feedback.NewEdit(last.Message, message); ok {
+			return feedback.Append(e)
+		}
+	}
+	return nil
+}
+
+// feedbackEnabled reports whether edits to generated messages are captured
+// (GIT_AI_FEEDBACK).
+func feedbackEnabled(rc agentrc.Config) bool {
+	return strings.EqualFold(strings.TrimSpace(os.Getenv("GIT_AI_FEEDBACK")), "true") || rc.Feedback
+}
+
+// personalNote returns guidance derived from the recent edits when
+// personalization is on (GIT_AI_PERSONALIZE).
+func personalNote(rc agentrc.Config) string {
+	if !strings.EqualFold(strings.TrimSpace(os.Getenv("GIT_AI_PERSONALIZE")), "true") && !rc.Personalize {
+		return ""
+	}
+	edits, err := feedback.Recent(recentEdits)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read feedback: %v\n", err)
+		return ""
+	}
+	return feedback.Note(edits)
+}
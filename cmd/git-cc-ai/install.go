@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// The git-ai wrapper scripts "git-cc-ai install" writes; the same as the
+// ones in scripts/.
+const (
+	gitAIScript = `#!/usr/bin/env bash
+# Kept for existing installs and aliases: git-cc-ai commit generates the
+# message and opens it in the editor for git commit.
+exec git-cc-ai commit "$@"
+`
+	gitAICmd = "@echo off\r\n" +
+		"powershell -NoProfile -ExecutionPolicy Bypass -File \"%~dp0git-ai.ps1\" %*\r\n"
+	gitAIPowerShell = `param(
+    [Parameter(ValueFromRemainingArguments = $true)]
+    [string[]]$ArgsList
+)
+
+# Kept for existing installs and aliases: git-cc-ai commit generates the
+# message and opens it in the editor for git commit.
+& git-cc-ai commit @ArgsList
+exit $LASTEXITCODE
+`
+)
+
+// runInstall implements "git-cc-ai install": it writes the git-ai wrapper
+// next to the binary (or into --dir), configures the git alias globally
+// and, with --link, links both into ~/.local/bin.
+func runInstall(args []string) int {
+	fs := flag.NewFlagSet("install", flag.ContinueOnError)
+	var (
+		dir     string
+		link    bool
+		local   bool
+		name    string
+		force   bool
+		noAlias bool
+	)
+	fs.StringVar(&dir, "dir", "", "directory for the git-ai wrapper (default: the directory of git-cc-ai)")
+	fs.BoolVar(&link, "link", false, "symlink git-cc-ai and the git-ai wrapper into ~/.local/bin")
+	fs.BoolVar(&local, "local", false, "configure the alias in the repository's git config instead of the global one")
+	fs.StringVar(&name, "name", "ai", "alias name (git <name>)")
+	fs.BoolVar(&force, "force", false, "replace an existing alias, wrapper or link")
+	fs.BoolVar(&noAlias, "no-alias", false, "do not configure the git alias")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: git-cc-ai install [--dir DIR] [--link] [--local] [--name ai] [--force] [--no-alias]")
+		fs.PrintDefaults()
+	}
+	positional, err := parseArgs(fs, args, nil)
+	if err != nil {
+		return 2
+	}
+	if len(positional) != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	self, err := os.Executable()
+	if err == nil {
+		self, err = filepath.EvalSymlinks(self)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot find the git-cc-ai executable: %v\n", err)
+		return exitFailure
+	}
+	if dir == "" {
+		dir = filepath.Dir(self)
+	}
+	wrappers, err := writeWrappers(dir, self, force)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return exitFailure
+	}
+	if link {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cannot find the home directory: %v\n", err)
+			return exitFailure
+		}
+		binDir := filepath.Join(home, ".local", "bin")
+		for _, target := range append([]string{self}, wrappers...) {
+			if err = linkInto(binDir, target, force); err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				return exitFailure
+			}
+		}
+		if !onPath(binDir) {
+			fmt.Fprintf(os.Stderr, "warning: %s is not on your PATH\n", binDir)
+		}
+	}
+	if !noAlias {
+		if err = configureAlias(name, !local, force); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return exitFailure
+		}
+	}
+	return 0
+}
+
+// writeWrappers writes the git-ai wrapper of the platform into dir and
+// returns the paths written: the bash script, or the .cmd launcher and the
+// PowerShell script it runs on Windows. A git-ai that is a link or copy of
+// the binary self already works and is left alone.
+func writeWrappers(dir, self string, force bool) ([]string, error) {
+	files := []struct{ name, content string }{{"git-ai", gitAIScript}}
+	if runtime.GOOS == "windows" {
+		files = []struct{ name, content string }{{"git-ai.cmd", gitAICmd}, {"git-ai.ps1", gitAIPowerShell}}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	if linked := filepath.Join(dir, "git-ai"+filepath.Ext(self)); sameFile(linked, self) {
+		fmt.Fprintf(os.Stderr, "%s already runs git-cc-ai\n", linked)
+		return nil, nil
+	}
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		path := filepath.Join(dir, f.name)
+		paths = append(paths, path)
+		existing, err := os.ReadFile(path)
+		switch {
+		case err == nil && bytes.Equal(existing, []byte(f.content)):
+			fmt.Fprintf(os.Stderr, "%s is up to date\n", path)
+			continue
+		case err == nil && !force:
+			return nil, fmt.Errorf("%s already exists; use --force to replace it", path)
+		case err != nil && !errors.Is(err, os.ErrNotExist):
+			return nil, err
+		}
+		if err = os.WriteFile(path, []byte(f.content), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		// WriteFile keeps the mode of an existing file.
+		if err = os.Chmod(path, 0o755); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(os.Stderr, "wrote %s\n", path)
+	}
+	return paths, nil
+}
+
+// linkInto symlinks target into dir under its own name. A link to target,
+// or target itself, is left alone; anything else is only replaced with
+// force.
+func linkInto(dir, target string, force bool) error {
+	path := filepath.Join(dir, filepath.Base(target))
+	if info, err := os.Lstat(path); err == nil {
+		if dest, err := os.Readlink(path); err == nil && dest == target {
+			fmt.Fprintf(os.Stderr, "%s is up to date\n", path)
+			return nil
+		}
+		if info.Mode().IsRegular() && sameFile(path, target) {
+			return nil
+		}
+		if !force {
+			return fmt.Errorf("%s already exists; use --force to replace it", path)
+		}
+		if err = os.Remove(path); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	if err := os.Symlink(target, path); err != nil {
+		return fmt.Errorf("failed to link %s: %w", path, err)
+	}
+	fmt.Fprintf(os.Stderr, "linked %s -> %s\n", path, target)
+	return nil
+}
+
+// sameFile reports whether a and b exist and are the same file once links
+// are followed.
+func sameFile(a, b string) bool {
+	ai, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bi, err := os.Stat(b)
+	return err == nil && os.SameFile(ai, bi)
+}
+
+// onPath reports whether dir is one of the PATH entries.
+func onPath(dir string) bool {
+	for _, p := range filepath.SplitList(os.Getenv("PATH")) {
+		if filepath.Clean(p) == filepath.Clean(dir) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,22 +2,36 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"os/exec"
 	"os/signal"
+	"path/filepath"
+	"regexp"
 	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/dlnilsson/git-cc-ai/pkg/agentrc"
+	"github.com/dlnilsson/git-cc-ai/pkg/analyze"
+	"github.com/dlnilsson/git-cc-ai/pkg/attempt"
+	"github.com/dlnilsson/git-cc-ai/pkg/commit"
+	"github.com/dlnilsson/git-cc-ai/pkg/commitlint"
+	"github.com/dlnilsson/git-cc-ai/pkg/ghactions"
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/ledger"
 	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers/azure"
 	"github.com/dlnilsson/git-cc-ai/pkg/providers/claude"
 	"github.com/dlnilsson/git-cc-ai/pkg/providers/codex"
 	"github.com/dlnilsson/git-cc-ai/pkg/providers/gemini"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers/mistral"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers/vertex"
 	"github.com/dlnilsson/git-cc-ai/pkg/ui"
 )
 
@@ -26,55 +40,281 @@ const (
 	errInvalidModelFmt = "invalid model %q (use -m for interactive pick, or one of: %s)\n"
 )
 
-func injectBareM() {
-	args := os.Args
-	var out []string
-	for i := 0; i < len(args); i++ {
-		out = append(out, args[i])
-		if args[i] != "-m" {
-			continue
-		}
-		next := i + 1
-		if next >= len(args) || strings.HasPrefix(args[next], "-") {
-			out = append(out, menuSentinel)
-			continue
-		}
-		out = append(out, args[next])
-		i = next
-	}
-	os.Args = out
-}
-
 func printHelp() {
 	const help = `git-cc-ai — generate conventional commit messages from staged changes.
 
 The tool runs an AI backend on your staged diff and prints a conventional commit
-message to stdout. Use it with git commit (e.g. via the git-ai script) and
-optionally edit the message in your editor before committing.
+message to stdout. git-cc-ai commit (or git ai, after git-cc-ai alias
+install) runs git commit with the message and opens your editor on it.
 
 Requirements:
-  Claude, Gemini or Codex must be installed and on your PATH.
-  The backend is auto-detected (claude preferred) or set via GIT_AI_BACKEND.
+  Claude, Gemini or Codex must be installed and on your PATH, or an API
+  backend (Mistral, Azure OpenAI) configured. The backend is auto-detected
+  (claude preferred) or set via GIT_AI_BACKEND.
 
 Backends:
   claude   Anthropic Claude CLI (preferred when found in PATH)
   gemini   Google Gemini CLI
   codex    OpenAI Codex CLI
+  mistral  Mistral API (codestral, mistral-large, ...): needs
+           MISTRAL_API_KEY; MISTRAL_BASE_URL overrides the endpoint (e.g.
+           https://codestral.mistral.ai/v1 for codestral keys)
+  azure    Azure OpenAI: needs AZURE_OPENAI_ENDPOINT, AZURE_OPENAI_API_KEY
+           and AZURE_OPENAI_DEPLOYMENT (comma-separated deployments, the
+           first is the default, -m picks another); AZURE_OPENAI_API_VERSION
+           overrides the api-version
+  vertex   Google Vertex AI (Gemini models, no gemini CLI): uses
+           application default credentials (gcloud auth
+           application-default login or GOOGLE_APPLICATION_CREDENTIALS)
+           and needs GOOGLE_CLOUD_PROJECT unless the credentials name a
+           project; GOOGLE_CLOUD_LOCATION picks the region (default
+           us-central1), VERTEX_BASE_URL overrides the endpoint
 
 Environment:
+  Each variable can also be set in .agentrc (export KEY=value) in the
+  repository root, which is found from any subdirectory or worktree.
   GIT_AI_BACKEND: backend provider (auto-detected from PATH if unset).
   GIT_AI_MODEL:   model name (overridden by -m / --model flags).
+  GIT_AI_MODEL_<BACKEND>: model for one backend (GIT_AI_MODEL_CLAUDE,
+                     GIT_AI_MODEL_CODEX, ...), preferred over GIT_AI_MODEL
+                     when that backend runs; models a backend does not
+                     offer are skipped.
   GIT_AI_NO_CC:      set to "true" to use standard commit style instead of
-                     Conventional Commits.
-  GIT_AI_NO_SESSION: set to "true" to skip resuming a CLAUDE_SESSION_ID.
-  GIT_AI_BUDGET:     maximum spend in USD per run (default: 1.0).
+                     Conventional Commits (overridden by --no-cc / --cc).
+  GIT_AI_NO_SESSION: set to "true" to skip resuming a CLAUDE_SESSION_ID or
+                     GEMINI_SESSION_ID.
+  GIT_AI_NO_GEMINI_RESUME: set to "true" to stop resuming and persisting
+                     gemini sessions in .agentrc.
+  GIT_AI_BUDGET:     maximum spend in USD per run (default: 1.0); with
+                     --compare or --candidates it applies to each run, so
+                     the total can be a multiple of it.
+  GIT_AI_RISK:       set to "true" to append Risk/Affects/Migration footers.
+  GIT_AI_PLAIN:      set to "1" to force plain output (no spinner or menus);
+                     automatic when stderr is not a terminal.
+  GIT_AI_ACCESSIBLE: set to "1" to replace the animated spinner with plain
+                     status lines repeated every 10s, for screen readers
+                     and dumb terminals (automatic when TERM=dumb).
+  GIT_AI_NO_BODY:    set to "true" to generate only a subject line.
+  GIT_AI_MAX_SUBJECT: maximum subject length (default 72); longer subjects
+                     are shortened by the backend or truncated.
+  GIT_AI_TYPES:      allowed commit types, e.g. feat,fix,docs,revert,deps
+                     (default: the commitlint conventional set); the backend
+                     is told the list and asked again for a subject with
+                     another type.
+  GIT_AI_SCOPE:      optional (default), required (a missing scope is
+                     asked for again with candidates from the changed
+                     paths) or forbidden (scopes are removed).
+  GIT_AI_SCOPE_PICKER: set to "off" to not ask which scope to use when the
+                     staged changes span several candidate scopes (the
+                     picker shows each with its file count; --scope picks
+                     one up front).
+  GIT_AI_TEMPLATE:   message template whose {slots} the backend fills, with
+                     \n for newlines, or a file holding it, e.g.
+                     "{type}({scope}): {subject}\n\n{body}\n\nJira: {ticket}".
+  GIT_AI_CONTEXT:    diff context: hunk (default, three lines), function
+                     (whole enclosing functions, git diff -W) or auto
+                     (whole functions only where the diff stays small).
+  GIT_AI_FORMATTING: what to do when the staged changes only touch
+                     whitespace: local (default; write a style: message
+                     without the backend), note (tell the backend) or off.
+                     Python, YAML, Makefile and Markdown files and
+                     whitespace inside strings always go to the backend.
+  GIT_AI_SPINNER:    spinner personality: fun (default), professional (no
+                     jokes, e.g. for screen-shared demos) or off.
+  GIT_AI_SPINNER_MESSAGE: custom spinner status message (repeat the key in
+                     .agentrc for more; they replace the built-in ones).
+  GIT_AI_COLOR_ACCENT: color of the spinner, menu cursor and selected
+                     candidate, as an ANSI number or hex (default 205).
+  GIT_AI_COLOR_REASONING: color of the reasoning text and preview borders
+                     (default 241). NO_COLOR or CLICOLOR=0 turn colors off.
+  GIT_AI_TOOLS:      tools the model may use: none (default; no tools, and
+                     codex runs in its read-only sandbox), all (the CLI's
+                     defaults) or a list, e.g. "Bash(git log:*),Read".
+  GIT_AI_DEEP_CONTEXT: set to "true" to let the backend investigate the
+                     repository with read-only tools (like --deep-context).
+  GIT_AI_MAX_TOOL_CALLS: stop a run after this many tool calls (default 10
+                     with deep context; unset means no limit otherwise).
+  GIT_AI_EXTRA_ARGS_<BACKEND>: extra CLI flags for codex, claude or gemini
+                     (e.g. GIT_AI_EXTRA_ARGS_CODEX="-c model_verbosity=low"),
+                     checked against an allowlist of safe flags; read from
+                     the environment only, never from .agentrc.
+  GIT_AI_METRICS:    set to "true" to record local-only metrics (latency,
+                     regenerations, acceptance) for git-cc-ai stats.
+  GIT_AI_FEEDBACK:   set to "true" to store edits made to generated messages
+                     (captured by the post-commit hook).
+  GIT_AI_PERSONALIZE: set to "true" to tell the backend how you usually
+                     edit its messages, based on the captured edits.
+  GIT_AI_REASONING:  reasoning effort: low (fast, cheap), medium or high
+                     (large refactors); codex and claude only.
+  GIT_AI_STRUCTURED: set to "true" to request the message as JSON fields
+                     (type, scope, subject, ...) and assemble it locally;
+                     codex only.
+  GIT_AI_USAGE:      where the token/cost/model trailer goes: "comments"
+                     in the message (default), "stderr" only, "notes" as a
+                     refs/notes/git-ai note on the commit (post-commit hook
+                     or --per-dir --commit), or "off" to drop it.
+  GIT_AI_RATE_LIMIT_WAIT: total time to wait for rate limits (429, quota)
+                     to clear before failing (default 2m; 90s, 5m, or
+                     "off"); the provider's retry-after hint is honoured.
+  GIT_AI_OFFLINE:    set to 1 to refuse every backend that sends the diff
+                     over the network (all of them in this build); only the
+                     local message for formatting-only changes is written.
+  GIT_AI_STALL_TIMEOUT: how long a backend may run without any output
+                     before it is stopped and retried once, then failed
+                     as stalled (default 5m; 90s, 10m, or "off").
+  GIT_AI_FOOTER:     footer added to generated messages, with variables
+                     {gen} (a Gerrit Change-Id), {date} or {date:layout}
+                     (Go time layout, local time), {branch}, {ticket} (an
+                     ABC-123 key from the branch), {mr_url} (GIT_AI_MR_URL,
+                     GitLab MR or GitHub PR pipelines) and {env:NAME}, e.g.
+                     "Change-Id: {gen}"; repeat the key in .agentrc for more.
+                     Footers with an empty variable are left out.
+  GIT_AI_GERRIT:     set to "true" to end generated messages with a Gerrit
+                     Change-Id, unless Gerrit's commit-msg hook is installed
+                     to add it; --amend keeps the Change-Id of HEAD.
+  GIT_AI_ATTRIBUTION: set to "true" to end messages written by a backend
+                     with an AI-assistance trailer, by default
+                     "Assisted-by: git-ai ({model})".
+  GIT_AI_ATTRIBUTION_TRAILER: that trailer, with {backend}, {model} and the
+                     GIT_AI_FOOTER variables, e.g.
+                     "Generated-by: {backend}/{model}".
+  GIT_AI_FOOTER_ORDER: footer tokens in the order footers are written, with
+                     * for the others, e.g. "*,Signed-off-by,BREAKING CHANGE";
+                     repeated footers are always dropped.
+  GIT_AI_STRIP_PATTERN: regexp for extra boilerplate lines to drop from
+                     messages (repeat the key in .agentrc for more).
+  GIT_AI_KEY_CMD:    command printing the API key of the mistral and azure
+                     backends when their key variable is unset, e.g.
+                     "pass show mistral"; GIT_AI_KEY_NAME tells it which key
+                     is wanted and <KEY>_CMD (MISTRAL_API_KEY_CMD, ...)
+                     overrides it per key. Without either, the key is read
+                     from the OS keychain (service git-ai, account the
+                     variable name). Environment only, never .agentrc.
+  GITHUB_ACTIONS:    when "true", errors and lint problems are printed as
+                     workflow annotations and the message and usage are
+                     added to GITHUB_STEP_SUMMARY.
+
+Scopes:
+  .git-ai/scopes.yaml maps path prefixes to scopes for monorepos. The scope
+  of the staged paths is given to the backend and generated messages are
+  linted against the mapped scopes. Changes spanning several scopes get a
+  comma-separated scope unless "fallback" names one:
+    scopes:
+      services/payments: payments
+      web: ui
+    fallback: multi
+
+Commands:
+  alias install [--global] [--name ai] [--force]
+                  configure the git alias so that git ai runs git-cc-ai
+                  commit.
+  check-msg [--fix] [--format text|json] <file>
+                  lint a commit message file (e.g. from a commit-msg hook);
+                  exits 1 on errors, --fix asks the backend to rewrite it.
+  commit [flags] [context...]
+                  generate the message with the given flags, then run
+                  git commit with it and open the editor; --amend,
+                  --signoff, -S[keyid] and --gpg-sign[=keyid] are passed
+                  on to git commit. The binary does the same when it is
+                  installed or linked as git-ai.
+  conflicts [--print]
+                  after resolving the conflicts of a merge, rebase or
+                  cherry-pick, describe how each file was resolved and
+                  append it to the message the commit will use.
+  config validate [--format text|json]
+                  print the effective configuration (defaults, .agentrc,
+                  environment) with the source of each value and report
+                  unknown keys, invalid values and conflicting settings.
+  doctor [--no-auth] [--format text|json]
+                  check the installation for support requests: backend CLIs
+                  and API settings, authentication (one tiny request to the
+                  selected backend), git version, alias and hooks,
+                  configuration and terminal; exits 1 when a check fails.
+  explain [--audience reviewer|changelog|manager] [--raw] <sha|range>
+                  explain in plain language what a commit or range does,
+                  for a reviewer (default), a changelog or a manager.
+  fixup [--squash] [--yes] [--list] [--limit n] [<sha>]
+                  commit the staged changes as fixup! (or squash!, with a
+                  generated note) of the recent unpushed commit that last
+                  changed the staged lines, picked from a menu.
+  history [--limit n] [--format text|json] [[--edit] n]
+                  list the messages generated in this repository, newest
+                  first, with model, cost and whether they were committed,
+                  and pick one to print or commit; n prints message n and
+                  --edit commits the staged changes with it in the editor.
+  hook install    install a post-commit hook that records whether the
+                  generated message was committed (GIT_AI_METRICS=true)
+                  and how it was edited (GIT_AI_FEEDBACK=true).
+  hook install --change-id
+                  install a commit-msg hook that adds a Gerrit Change-Id
+                  footer to every commit lacking one, like Gerrit's own.
+  install [--dir DIR] [--link] [--local] [--name ai] [--force]
+                  write the git-ai wrapper next to the binary, configure
+                  the global git ai alias and, with --link, symlink both
+                  into ~/.local/bin.
+  last [--events]
+                  print the transcript saved by --save-transcript: reasoning
+                  and tool use, message and usage; --events prints the raw
+                  backend events (NDJSON).
+  mr [--target branch] [--remote name] [--create [--draft]]
+                  draft a GitLab merge request title and description from
+                  the branch's commits and diff; --create opens it with
+                  glab, or the GitLab API and GITLAB_TOKEN.
+  release [--from tag] [--to rev] [--publish] <tag>
+                  draft Markdown release notes from the commits since the
+                  previous tag; --publish runs gh release create.
+  revert [--reason text] [--mainline n] [--print] [<sha>]
+                  revert a commit with git revert --no-commit and commit
+                  it with a generated revert: message, including the
+                  "This reverts commit" line and a Refs footer; without a
+                  sha, finish a revert stopped on conflicts.
+  review [--raw]  critique the staged diff like a code reviewer (bugs,
+                  missing tests, risky changes), rendered as Markdown.
+  review-comment [--label label] [--blocking|--non-blocking] [--note text]
+                 [--staged] [- | path[:line]]
+                  draft a Conventional Comments review note (praise,
+                  nitpick, suggestion, issue, ...) on a diff hunk from
+                  stdin, the hunk of git diff covering path:line, or the
+                  diff of path.
+  semver [--format text|json] [<range>]
+                  report the next semantic version bump (major/minor/patch)
+                  implied by the conventional commits in range (default:
+                  latest tag..HEAD) and the commits that drove it.
+  serve --stdio   run a JSON-RPC 2.0 server on stdin/stdout for editor
+                  plugins (methods: generate, cancel, models; "progress"
+                  notifications carry reasoning updates).
+  serve --http :8080
+                  serve POST /v1/commit-message (diff and options in,
+                  message and usage out) authenticated with the bearer
+                  tokens in GIT_AI_SERVE_TOKENS. A client budget can only
+                  lower GIT_AI_BUDGET.
+  stats [--format text|json]
+                  show acceptance rate, latency and regenerations per
+                  backend/model from the local metrics.
+  tag [--from tag] [--to rev] [--create [-s]] <tag>
+                  draft an annotated tag message from the commits since the
+                  previous tag; --create runs git tag -a -F -.
+
+Exit codes:
+  0 success, 1 other failure, 3 no staged changes, 4 backend CLI missing,
+  API backend not configured or refused by GIT_AI_OFFLINE, 5 budget or tool
+  call limit exceeded, 6 invalid model, 7 rate limited, 124 timed out
+  (--timeout, --ci) or backend stalled, 130 interrupted.
 
 Get started:
   1. Stage your changes: git add ...
-  2. Run: git ai (or git-cc-ai if not using a git alias)
+  2. Run: git ai (set up by git-cc-ai install), or git-cc-ai commit
   3. The backend drafts a conventional commit message and opens your editor so
      you can confirm or edit, then commit.
 
+Arguments that are not flags are passed to the backend as extra context;
+flags may come before or after them and everything after -- is context:
+  git-cc-ai --risk fixes the retry loop -- -m is not a flag here
+A lone - reads the context from stdin, as does --note-file -:
+  gh issue view 42 | git-cc-ai - closes the flaky upload issue
+-m without a value opens the model menu (type to filter; the model last
+picked in the repository is preselected); -m sonnet and -m=sonnet pick one.
+
 Flags:
 `
 	fmt.Fprint(os.Stderr, help)
@@ -82,39 +322,31 @@ Flags:
 	fmt.Fprintln(os.Stderr)
 }
 
+var backends = map[string]providers.Backend{
+	"codex":   codex.Backend{},
+	"claude":  claude.Backend{},
+	"gemini":  gemini.Backend{},
+	"mistral": mistral.Backend{},
+	"azure":   azure.Backend{},
+	"vertex":  vertex.Backend{},
+}
+
 func execInPath(name string) bool {
 	_, err := exec.LookPath(name)
 	return err == nil
 }
 
-func main() {
-	var (
-		mFlag     string
-		model     string
-		noSpinner bool
-		skillPath string
-		extraNote string
-	)
-
-	injectBareM()
-	flag.StringVar(&skillPath, "skill-path", "", "path to SKILL.md (optional, used for prompt)")
-	flag.BoolVar(&noSpinner, "no-spinner", false, "disable spinner while the backend runs")
-	flag.StringVar(&model, "model", "", "model name (overrides -m)")
-	flag.StringVar(&mFlag, "m", "", "model name, or no value for interactive selection")
-	flag.Usage = printHelp
-	flag.Parse()
-	if flag.NArg() > 0 {
-		extraNote = strings.Join(flag.Args(), " ")
-	}
-
-	rc := agentrc.Load(".agentrc")
+// isAPIBackend reports whether b calls an API rather than a CLI in PATH.
+func isAPIBackend(b providers.Backend) bool {
+	_, ok := b.(providers.ConfiguredBackend)
+	return ok
+}
 
-	backends := map[string]providers.Backend{
-		"codex":  codex.Backend{},
-		"claude": claude.Backend{},
-		"gemini": gemini.Backend{},
-	}
-	backend := strings.TrimSpace(os.Getenv("GIT_AI_BACKEND"))
+// resolveBackend picks the backend named by name (typically GIT_AI_BACKEND),
+// falling back to .agentrc and then to the first supported CLI in PATH. In
+// offline mode (GIT_AI_OFFLINE) a cloud backend is an error.
+func resolveBackend(name string, rc agentrc.Config) (string, providers.Backend, error) {
+	backend := strings.TrimSpace(name)
 	if backend == "" {
 		backend = rc.Backend
 	}
@@ -126,9 +358,14 @@ func main() {
 			backend = "gemini"
 		case execInPath("codex"):
 			backend = "codex"
+		case mistral.Backend{}.Configured() == nil:
+			backend = "mistral"
+		case azure.Backend{}.Configured() == nil:
+			backend = "azure"
+		case vertex.Backend{}.Configured() == nil:
+			backend = "vertex"
 		default:
-			fmt.Fprintln(os.Stderr, "no supported backend found in PATH (install claude, gemini or codex)")
-			os.Exit(1)
+			return "", nil, providers.ErrBackendMissing
 		}
 	}
 	b, ok := backends[backend]
@@ -138,19 +375,484 @@ func main() {
 			available = append(available, name)
 		}
 		sort.Strings(available)
-		fmt.Fprintf(os.Stderr, "invalid GIT_AI_BACKEND value %q (available: %s)\n", backend, strings.Join(available, ", "))
-		os.Exit(1)
+		return "", nil, fmt.Errorf("invalid GIT_AI_BACKEND value %q (available: %s)", backend, strings.Join(available, ", "))
+	}
+	if err := checkOffline(backend, rc); err != nil {
+		return "", nil, err
+	}
+	return backend, b, nil
+}
+
+// resolveNoCC reports whether standard commit style is used instead of
+// Conventional Commits: --no-cc or --cc, then GIT_AI_NO_CC or .agentrc.
+func resolveNoCC(noCCFlag, ccFlag bool, rc agentrc.Config) bool {
+	switch {
+	case noCCFlag:
+		return true
+	case ccFlag:
+		return false
+	}
+	return strings.EqualFold(strings.TrimSpace(os.Getenv("GIT_AI_NO_CC")), "true") || rc.NoCC
+}
+
+// commitStyle names the commit style noCC selects.
+func commitStyle(noCC bool) string {
+	if noCC {
+		return "standard"
+	}
+	return "conventional"
+}
+
+// preferredModel returns the configured model for backend: the first of
+// GIT_AI_MODEL_<BACKEND> (environment, then .agentrc) and GIT_AI_MODEL
+// (environment, then .agentrc) that b supports, or "" for the default. A
+// model meant for another backend is skipped, so switching backends keeps
+// the preference for each.
+func preferredModel(backend string, b providers.Backend, rc agentrc.Config) string {
+	for _, m := range modelSettings(backend, rc) {
+		if m = strings.TrimSpace(m); m != "" && slices.Contains(b.Models(), m) {
+			return m
+		}
+	}
+	return ""
+}
+
+// modelSettings returns the model settings for backend in order of
+// precedence.
+func modelSettings(backend string, rc agentrc.Config) []string {
+	return []string{
+		os.Getenv(agentrc.BackendModelKey(backend)),
+		rc.BackendModels[backend],
+		os.Getenv("GIT_AI_MODEL"),
+		rc.Model,
+	}
+}
+
+func main() {
+	var (
+		mFlag       string
+		model       string
+		noSpinner   bool
+		skillPath   string
+		extraNote   string
+		compare     string
+		candidates  int
+		stream      bool
+		risk        bool
+		plain       bool
+		progress    string
+		maxSubject  int
+		subjectOnly bool
+		perDir      bool
+		amend       bool
+		doCommit    bool
+		signoff     bool
+		sign        bool
+		gpgSign     string
+		noteFile    string
+		reject      string
+		temperature *float64
+		seed        *int64
+		trailers    []string
+		scope       string
+		twoStage    bool
+		structured  bool
+		deepContext bool
+		template    string
+		diffContext string
+		draftModel  string
+		quiet       bool
+		verbose     bool
+		saveRun     bool
+		parallel    int
+		noCCFlag    bool
+		ccFlag      bool
+		ci          bool
+		usageToErr  bool
+		timeout     time.Duration
+	)
+
+	ui.SetPlain(ui.DetectPlain())
+	ui.SetAccessible(ui.DetectAccessible())
+	display := agentrcLoad()
+	applySpinner(display)
+	applyTheme(display)
+	if invokedAsGitAI() {
+		os.Exit(runCommit(os.Args[1:]))
+	}
+	if len(os.Args) > 1 && !generateOnly() {
+		switch os.Args[1] {
+		case "commit":
+			os.Exit(runCommit(os.Args[2:]))
+		case "alias":
+			os.Exit(runAlias(os.Args[2:]))
+		case "install":
+			os.Exit(runInstall(os.Args[2:]))
+		case "serve":
+			runServe(os.Args[2:])
+			return
+		case "check-msg":
+			os.Exit(runCheckMsg(os.Args[2:]))
+		case "config":
+			os.Exit(runConfig(os.Args[2:]))
+		case "semver":
+			os.Exit(runSemver(os.Args[2:]))
+		case "release":
+			os.Exit(runRelease(os.Args[2:]))
+		case "mr":
+			os.Exit(runMR(os.Args[2:]))
+		case "tag":
+			os.Exit(runTag(os.Args[2:]))
+		case "conflicts":
+			os.Exit(runConflicts(os.Args[2:]))
+		case "review":
+			os.Exit(runReview(os.Args[2:]))
+		case "review-comment":
+			os.Exit(runReviewComment(os.Args[2:]))
+		case "explain":
+			os.Exit(runExplain(os.Args[2:]))
+		case "fixup":
+			os.Exit(runFixup(os.Args[2:]))
+		case "revert":
+			os.Exit(runRevert(os.Args[2:]))
+		case "last":
+			os.Exit(runLast(os.Args[2:]))
+		case "stats":
+			os.Exit(runStats(os.Args[2:]))
+		case "history":
+			os.Exit(runHistory(os.Args[2:]))
+		case "hook":
+			os.Exit(runHook(os.Args[2:]))
+		case "doctor":
+			os.Exit(runDoctor(os.Args[2:]))
+		}
+	}
+
+	flag.BoolVar(&quiet, "q", false, "quiet: print only the message on stdout, no spinner, progress or warnings")
+	flag.StringVar(&outputFile, "o", "", "also write the message, without usage comments, atomically to this file (with -q, stdout stays empty)")
+	flag.StringVar(&outputFile, "output-file", "", "same as -o")
+	flag.BoolVar(&verbose, "v", false, "verbose: print every reasoning update and which backend, model and context are used")
+	flag.StringVar(&skillPath, "skill-path", "", "path to SKILL.md (optional, used for prompt)")
+	flag.BoolVar(&noSpinner, "no-spinner", false, "disable spinner while the backend runs")
+	flag.StringVar(&model, "model", "", "model name (overrides -m)")
+	flag.StringVar(&mFlag, "m", "", "model name, or no value for interactive selection")
+	flag.StringVar(&compare, "compare", "", "run several backend:model pairs concurrently and pick the best message (e.g. claude:sonnet,codex:gpt-5.2-codex); each run gets the full GIT_AI_BUDGET")
+	flag.IntVar(&candidates, "candidates", 1, "number of alternative messages to generate and pick from; each one gets the full GIT_AI_BUDGET")
+	flag.BoolVar(&stream, "stream", false, "render the message below the spinner as it is generated (claude, gemini)")
+	flag.BoolVar(&noCCFlag, "no-cc", false, "use standard commit style instead of Conventional Commits (overrides GIT_AI_NO_CC and .agentrc)")
+	flag.BoolVar(&ccFlag, "cc", false, "use Conventional Commits even when GIT_AI_NO_CC or .agentrc asks for standard style")
+	flag.StringVar(&scope, "scope", "", "use this Conventional Commits scope instead of letting the backend or the scope picker choose")
+	flag.BoolVar(&risk, "risk", false, "append Risk/Affects/Migration footers classifying the change")
+	flag.BoolVar(&ci, "ci", false, "non-interactive mode for bots: plain output, no spinner, menus or sessions, an explicit model, temperature 0, a hard --timeout (default 5m), and exit 1 for an empty or non-conforming message")
+	flag.DurationVar(&timeout, "timeout", 0, "abort generation after this long, e.g. 2m (exit code 124)")
+	flag.BoolVar(&plain, "plain", false, "plain line output: no spinner or interactive menus (auto when stderr is not a terminal)")
+	flag.StringVar(&progress, "progress", "", `set to "json" to write NDJSON progress events to stderr instead of the spinner`)
+	flag.IntVar(&maxSubject, "max-subject", 0, "maximum subject length; longer subjects are shortened (default 72, or GIT_AI_MAX_SUBJECT)")
+	flag.BoolVar(&subjectOnly, "subject-only", false, "generate a single subject line without body (or GIT_AI_NO_BODY=true)")
+	flag.BoolVar(&perDir, "per-dir", false, "generate one message per top-level directory and print them as a plan")
+	flag.BoolVar(&doCommit, "commit", false, "with --per-dir, create one commit per directory from its staged changes")
+	flag.BoolVar(&signoff, "signoff", false, "add a Signed-off-by trailer for user.name/user.email")
+	flag.BoolVar(&sign, "S", false, "with --commit or the commit command, GPG/SSH-sign the commits with the default key (commit.gpgsign is honoured anyway)")
+	flag.StringVar(&gpgSign, "gpg-sign", "", "with --commit or the commit command, sign the commits with this key id (-S<keyid> works too)")
+	flag.BoolVar(&usageToErr, "usage-stderr", false, "print the usage trailer (tokens, cost) to stderr instead of as comment lines in the message")
+	flag.BoolVar(&amend, "amend", false, "describe the commit git commit --amend would create (HEAD plus the staged changes) and keep its Change-Id")
+	flag.BoolVar(&deepContext, "deep-context", false, "let the backend investigate the repository with read-only tools (git log, git show, file reads) before writing; or GIT_AI_DEEP_CONTEXT=true")
+	flag.BoolVar(&structured, "structured", false, "request the message as JSON fields and assemble it locally (codex; or GIT_AI_STRUCTURED=true)")
+	flag.StringVar(&template, "template", "", `message template whose {slots} the backend fills, or a file holding it (or GIT_AI_TEMPLATE), e.g. "{type}({scope}): {subject}\n\n{body}\n\nJira: {ticket}"`)
+	flag.StringVar(&diffContext, "context", "", "diff context: hunk (default), auto (whole functions where the diff stays small) or function (git diff -W); or GIT_AI_CONTEXT")
+	flag.BoolVar(&twoStage, "two-stage", false, "summarize each directory with the draft model, then write the message from the summaries only")
+	flag.StringVar(&draftModel, "draft-model", "", "with --two-stage or --parallel-chunks, the cheap model for summaries and the draft (default: the backend default)")
+	flag.IntVar(&parallel, "parallel-chunks", 0, "summarize directory chunks with up to N concurrent draft-model calls, then write the message from the summaries")
+	flag.BoolVar(&saveRun, "save-transcript", false, "save the reasoning, raw backend events, message and usage to .git/git-ai/last-run.json (see git-cc-ai last)")
+	flag.StringVar(&noteFile, "note-file", "", `read extra context for the prompt from a file ("-" for stdin)`)
+	flag.StringVar(&reject, "reject", "", `regenerate, telling the backend the last message was rejected and why (e.g. --reject "too vague")`)
+	flag.Func("temperature", "sampling temperature for backends that support it (codex); lower is more deterministic", func(s string) error {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil || v < 0 || v > 2 {
+			return errors.New("must be a number between 0 and 2")
+		}
+		temperature = &v
+		return nil
+	})
+	flag.Func("seed", "sampling seed for backends that support it (codex), for reproducible messages", func(s string) error {
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+		seed = &v
+		return nil
+	})
+	flag.Func("trailer", `add a "Token: value" footer (repeatable); it replaces the backend's footers with that token, Refs are merged`, func(s string) error {
+		if commit.FooterToken(s) == "" {
+			return errors.New(`must look like "Token: value"`)
+		}
+		trailers = append(trailers, strings.TrimSpace(s))
+		return nil
+	})
+	flag.Usage = printHelp
+	notes, err := parseArgs(flag.CommandLine, gitSignSpellings(os.Args[1:]), map[string]string{"m": menuSentinel, "reject": rejectNoReason})
+	if err != nil {
+		os.Exit(2)
+	}
+	if ci {
+		ciMode, plain, noSpinner = true, true, true
+		if timeout == 0 {
+			timeout = ciTimeout
+		}
+	}
+	if plain {
+		ui.SetPlain(true)
+	}
+	switch {
+	case quiet && verbose:
+		fmt.Fprintln(os.Stderr, "-q and -v cannot be combined")
+		os.Exit(2)
+	case outputFile != "" && perDir:
+		fmt.Fprintln(os.Stderr, "-o cannot be combined with --per-dir")
+		os.Exit(2)
+	case amend && perDir:
+		fmt.Fprintln(os.Stderr, "--amend cannot be combined with --per-dir")
+		os.Exit(2)
+	case quiet:
+		ui.SetVerbosity(ui.Quiet)
+	case verbose:
+		ui.SetVerbosity(ui.Verbose)
+	}
+	switch progress {
+	case "":
+	case "json":
+		jsonProgress = newProgressWriter(os.Stderr)
+		noSpinner = true
+	default:
+		fmt.Fprintf(os.Stderr, "invalid --progress value %q (supported: json)\n", progress)
+		os.Exit(2)
+	}
+	if ci && (strings.TrimSpace(compare) != "" || candidates > 1) {
+		fmt.Fprintln(os.Stderr, "--ci cannot be combined with --compare or --candidates, which ask you to pick a message")
+		os.Exit(2)
+	}
+	if noCCFlag && ccFlag {
+		fmt.Fprintln(os.Stderr, "--no-cc and --cc cannot be combined")
+		os.Exit(2)
+	}
+	if generateOnly() && !doCommit {
+		// runCommit passes these on to git commit.
+		sign, gpgSign, signoff = false, "", false
+	}
+	if (sign || gpgSign != "") && !doCommit {
+		fmt.Fprintln(os.Stderr, "-S and --gpg-sign apply to --commit; otherwise git commit signs according to commit.gpgsign")
+		os.Exit(2)
+	}
+	if doCommit && !perDir {
+		fmt.Fprintln(os.Stderr, "--commit requires --per-dir")
+		os.Exit(2)
+	}
+	if perDir && (strings.TrimSpace(compare) != "" || candidates > 1) {
+		fmt.Fprintln(os.Stderr, "--per-dir cannot be combined with --compare or --candidates")
+		os.Exit(2)
+	}
+	if (twoStage || parallel > 0) && (perDir || strings.TrimSpace(compare) != "" || candidates > 1) {
+		fmt.Fprintln(os.Stderr, "--two-stage and --parallel-chunks cannot be combined with --per-dir, --compare or --candidates")
+		os.Exit(2)
+	}
+	if parallel < 0 {
+		fmt.Fprintln(os.Stderr, "--parallel-chunks must not be negative")
+		os.Exit(2)
+	}
+	if saveRun && (perDir || strings.TrimSpace(compare) != "") {
+		fmt.Fprintln(os.Stderr, "--save-transcript cannot be combined with --per-dir or --compare")
+		os.Exit(2)
+	}
+	if extraNote, err = readExtraNote(notes, noteFile); err != nil {
+		reportError(err)
+		os.Exit(exitFailure)
+	}
+
+	rcPath := agentrcPath()
+	if !ciMode && jsonProgress == nil && needsOnboarding(rcPath) {
+		onboard(rcPath)
+	}
+	rc := agentrc.Load(rcPath)
+
+	noCC := resolveNoCC(noCCFlag, ccFlag, rc)
+	risk = risk || strings.EqualFold(strings.TrimSpace(os.Getenv("GIT_AI_RISK")), "true") || rc.Risk
+	subjectOnly = subjectOnly || strings.EqualFold(strings.TrimSpace(os.Getenv("GIT_AI_NO_BODY")), "true") || rc.NoBody
+	if subjectOnly && risk {
+		fmt.Fprintln(ui.Status(), "warning: Risk footers are not added in subject-only mode")
+		risk = false
+	}
+	structured = structured || strings.EqualFold(strings.TrimSpace(os.Getenv("GIT_AI_STRUCTURED")), "true") || rc.Structured
+	noSession := ciMode || strings.EqualFold(strings.TrimSpace(os.Getenv("GIT_AI_NO_SESSION")), "true") || rc.NoSession
+
+	budget := resolveBudget(rc)
+
+	maxSubject = resolveMaxSubject(maxSubject, rc)
+	types := resolveTypes(rc)
+	scopePolicy := resolveScopePolicy(rc)
+	tmpl, err := resolveTemplate(template, rc)
+	if err != nil {
+		reportError(err)
+		os.Exit(exitFailure)
+	}
+	if tmpl != nil && structured {
+		fmt.Fprintln(ui.Status(), "warning: a message template replaces structured output")
+		structured = false
+	}
+	git.SetContext(resolveContext(diffContext, rc))
+	if amend {
+		if err = git.SetAmend(); err != nil {
+			reportError(err)
+			os.Exit(exitFailure)
+		}
+	}
+	changeID := resolveChangeID(rc, amend)
+	reasoning := resolveReasoning(rc)
+	extraArgs := resolveExtraArgs()
+	tools := resolveTools(rc)
+	rateLimitWait := resolveRateLimitWait(rc)
+	stallTimeout := resolveStallTimeout(rc)
+	usageMode = resolveUsageMode(rc)
+	if usageToErr {
+		usageMode = usageStderr
+	}
+	strip, err := stripPatterns(rc)
+	if err != nil {
+		reportError(err)
+		os.Exit(exitFailure)
+	}
+	footers := footerTemplates(rc)
+	attribution := resolveAttribution(rc)
+	order := footerOrder(rc)
+	scopeMap, err := loadScopes()
+	if err != nil {
+		reportError(err)
+		os.Exit(exitFailure)
+	}
+	var (
+		breaking       []analyze.Finding
+		symbols        string
+		formatting     = resolveFormatting(rc)
+		formattingNote string
+	)
+	if !perDir && formatting != formattingOff {
+		if paths := stagedFormattingOnly(); paths != nil {
+			if formatting == formattingLocal && (noCC || len(types) == 0 || slices.Contains(types, "style")) {
+				message := commit.FormattingSubject(paths, noCC)
+				message = enforcePolicies(context.Background(), nil, nil, providers.Options{NoCC: noCC, Types: types, ScopePolicy: scopePolicy, Scopes: scopeMap}, message, maxSubject)
+				if signoff {
+					if message, err = withSignoff(message); err != nil {
+						reportError(err)
+						os.Exit(exitFailure)
+					}
+				}
+				message = withTrailers(withFooters(changeID.apply(message), footers), trailers, order)
+				fmt.Fprintln(ui.Status(), "the staged changes only touch whitespace; wrote the message without the backend (GIT_AI_FORMATTING=note asks it)")
+				summarizeGeneration("local", "", message)
+				if !emitMessage(message, noCC, lintConfig(maxSubject, types, scopePolicy, scopeMap)) && ciMode {
+					os.Exit(exitFailure)
+				}
+				return
+			}
+			formattingNote = formattingOnlyNote
+		}
+	}
+	if scope = strings.TrimSpace(scope); scope != "" && noCC {
+		fmt.Fprintln(ui.Status(), "warning: --scope has no effect with standard commit style")
+	}
+	if scope == "" && !noCC && !perDir && !ciMode && jsonProgress == nil && !ui.IsQuiet() && scopePolicy != commit.ScopeForbidden && scopePickerEnabled(rc) {
+		var noScope bool
+		if scope, noScope = pickScope(providers.Options{Scopes: scopeMap, ScopePolicy: scopePolicy}); noScope {
+			scopePolicy = commit.ScopeForbidden
+		}
+	}
+	if !perDir {
+		symbols = stagedGoSymbols()
+		if !noCC {
+			breaking = stagedBreaking()
+		}
+	}
+
+	if strings.TrimSpace(compare) != "" {
+		specs, err := parseCompareSpecs(compare)
+		for _, spec := range specs {
+			if err == nil {
+				err = checkOffline(spec.backend, rc)
+			}
+		}
+		if err != nil {
+			reportError(err)
+			os.Exit(exitCode(err))
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		compareOpts := providers.Options{
+			SkillPath:     skillPath,
+			ExtraNote:     joinNotes(extraNote, formattingNote, symbols, analyze.Note(breaking)),
+			ShowSpinner:   !noSpinner,
+			NoCC:          noCC,
+			Risk:          risk,
+			Budget:        budget,
+			RateLimitWait: rateLimitWait,
+			StallTimeout:  stallTimeout,
+			MaxSubject:    maxSubject,
+			SubjectOnly:   subjectOnly,
+			StripPatterns: strip,
+			Scopes:        scopeMap,
+			Types:         types,
+			ScopePolicy:   scopePolicy,
+			Scope:         scope,
+			Temperature:   temperature,
+			Seed:          seed,
+			Reasoning:     reasoning,
+			Structured:    structured,
+			Template:      tmpl,
+			Tools:         tools,
+			ExtraArgs:     extraArgs,
+		}
+		if jsonProgress != nil {
+			jsonProgress.attach(&compareOpts)
+		}
+		message, winner, err := runCompare(ctx, specs, compareOpts)
+		if err != nil {
+			reportError(err)
+			os.Exit(exitCode(err))
+		}
+		message = enforcePolicies(ctx, nil, nil, compareOpts, message, maxSubject)
+		if signoff {
+			if message, err = withSignoff(message); err != nil {
+				reportError(err)
+				os.Exit(exitFailure)
+			}
+		}
+		message = withAttribution(withFooters(changeID.apply(message), footers), attribution, winner.backend, winner.model)
+		message = withTrailers(message, trailers, order)
+		warnUnmarkedBreaking(message, breaking)
+		summarizeGeneration("compare", "", message)
+		emitMessage(message, noCC, lintConfig(maxSubject, types, scopePolicy, scopeMap))
+		return
+	}
+
+	backend, b, err := resolveBackend(os.Getenv("GIT_AI_BACKEND"), rc)
+	if err != nil {
+		reportError(err)
+		os.Exit(exitCode(err))
 	}
 
 	// --model flag is explicit user intent — validate strictly.
-	// GIT_AI_MODEL / .agentrc is a soft preference — silently fall back to
-	// the provider default when the model doesn't match.
+	// GIT_AI_MODEL(_<BACKEND>) / .agentrc is a soft preference — silently
+	// fall back to the provider default when the model doesn't match,
+	// except under --ci, which requires a model and validates it strictly.
 	modelFromFlag := strings.TrimSpace(model) != "" || strings.TrimSpace(mFlag) != ""
-	if envModel := strings.TrimSpace(os.Getenv("GIT_AI_MODEL")); envModel != "" && !modelFromFlag {
-		model = envModel
-	}
-	if rc.Model != "" && strings.TrimSpace(model) == "" && !modelFromFlag {
-		model = rc.Model
+	switch {
+	case modelFromFlag:
+	case ciMode:
+		if model = explicitModel(backend, rc); model == "" {
+			fmt.Fprintf(os.Stderr, "--ci needs an explicit model: pass --model or set GIT_AI_MODEL (one of: %s)\n", strings.Join(b.Models(), ", "))
+			os.Exit(2)
+		}
+		modelFromFlag = true
+	default:
+		model = preferredModel(backend, b, rc)
 	}
 
 	availableModels := b.Models()
@@ -160,14 +862,18 @@ func main() {
 		if !slices.Contains(availableModels, model) {
 			if modelFromFlag {
 				fmt.Fprintf(os.Stderr, errInvalidModelFmt, model, strings.Join(availableModels, ", "))
-				os.Exit(1)
+				os.Exit(exitInvalidModel)
 			}
 			model = ""
 		}
 	case strings.TrimSpace(mFlag) == "":
 		// No model specified — provider will use its default.
 	case mFlag == menuSentinel:
-		selected, err := ui.SelectModelMenu(availableModels)
+		selected, err := selectModel(backend, b)
+		if errors.Is(err, ui.ErrNotInteractive) {
+			fmt.Fprintf(os.Stderr, "-m without a value needs a terminal; pass a model (one of: %s)\n", strings.Join(availableModels, ", "))
+			os.Exit(1)
+		}
 		if err != nil {
 			os.Exit(1)
 		}
@@ -176,14 +882,23 @@ func main() {
 		candidate := strings.TrimSpace(mFlag)
 		if !slices.Contains(availableModels, candidate) {
 			fmt.Fprintf(os.Stderr, errInvalidModelFmt, candidate, strings.Join(availableModels, ", "))
-			os.Exit(1)
+			os.Exit(exitInvalidModel)
 		}
 		model = candidate
 	}
 
+	if draftModel = strings.TrimSpace(draftModel); draftModel == "" {
+		draftModel = b.DefaultModel()
+	} else if !slices.Contains(availableModels, draftModel) {
+		fmt.Fprintf(os.Stderr, errInvalidModelFmt, draftModel, strings.Join(availableModels, ", "))
+		os.Exit(exitInvalidModel)
+	}
+
 	var registry providers.Registry
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
+	ctx, cancelTimeout := withTimeout(ctx, timeout)
+	defer cancelTimeout()
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
 	defer signal.Stop(sigCh)
@@ -194,38 +909,290 @@ func main() {
 		}
 	}()
 
-	noCC := strings.EqualFold(strings.TrimSpace(os.Getenv("GIT_AI_NO_CC")), "true") || rc.NoCC
-	noSession := strings.EqualFold(strings.TrimSpace(os.Getenv("GIT_AI_NO_SESSION")), "true") || rc.NoSession
+	var (
+		sessionID   string
+		onSessionID func(string)
+	)
+	switch backend {
+	case "claude":
+		if !noSession {
+			sessionID = rc.SessionID
+		}
+	case "gemini":
+		noGeminiResume := strings.EqualFold(strings.TrimSpace(os.Getenv("GIT_AI_NO_GEMINI_RESUME")), "true") || rc.NoGeminiResume
+		if !noSession && !noGeminiResume {
+			sessionID = rc.GeminiSessionID
+			onSessionID = func(id string) {
+				if id == rc.GeminiSessionID {
+					return
+				}
+				if err := agentrc.Set(rcPath, "GEMINI_SESSION_ID", id); err != nil {
+					fmt.Fprintf(ui.Status(), "failed to persist gemini session: %v\n", err)
+				}
+			}
+		}
+	}
 
-	var budget float64
-	if v, err := strconv.ParseFloat(strings.TrimSpace(os.Getenv("GIT_AI_BUDGET")), 64); err == nil && v > 0 {
-		budget = v
-	} else if rc.Budget > 0 {
-		budget = rc.Budget
+	var (
+		tree     = stagedTree()
+		rejected = rejections(reject, tree)
+		personal string
+	)
+	if ciMode {
+		if temperature == nil {
+			temperature = ciTemperature(b)
+		}
+	} else {
+		personal = personalNote(rc)
 	}
+	tools, maxToolCalls, deepNote := resolveDeepContext(deepContext, backend, tools, rc)
+	baseNote := joinNotes(extraNote, formattingNote, symbols, analyze.Note(breaking), personal, deepNote)
+	opts := providers.Options{
+		SkillPath:     skillPath,
+		ExtraNote:     joinNotes(baseNote, attempt.Note(rejected)),
+		Model:         model,
+		SessionID:     sessionID,
+		ShowSpinner:   !noSpinner,
+		Stream:        stream,
+		NoCC:          noCC,
+		Risk:          risk,
+		Budget:        budget,
+		RateLimitWait: rateLimitWait,
+		StallTimeout:  stallTimeout,
+		MaxSubject:    maxSubject,
+		SubjectOnly:   subjectOnly,
+		OnSessionID:   onSessionID,
+		StripPatterns: strip,
+		Scopes:        scopeMap,
+		Types:         types,
+		ScopePolicy:   scopePolicy,
+		Scope:         scope,
+		Temperature:   temperature,
+		Seed:          seed,
+		Reasoning:     reasoning,
+		Structured:    structured,
+		Template:      tmpl,
+		Tools:         tools,
+		MaxToolCalls:  maxToolCalls,
+		ExtraArgs:     extraArgs,
+	}
+	unsupported := providers.UnsupportedSampling(b, opts)
+	if reasoning != "" && !providers.SupportsReasoning(b) {
+		unsupported = append(unsupported, "GIT_AI_REASONING")
+	}
+	if structured && !providers.SupportsStructured(b) {
+		unsupported = append(unsupported, "structured output")
+	}
+	if len(unsupported) > 0 {
+		fmt.Fprintf(ui.Status(), "warning: the %s backend ignores %s\n", backend, strings.Join(unsupported, ", "))
+	}
+	ui.Debugf("backend %s, model %s, session %q, .agentrc %s", backend, modelOrDefault(b, model), sessionID, rcPath)
+	ui.Debugf("commit style: %s", commitStyle(noCC))
+	if jsonProgress != nil {
+		jsonProgress.attach(&opts)
+	}
+	var recorder *transcriptRecorder
+	if saveRun {
+		recorder = newTranscriptRecorder(backend, modelOrDefault(b, model))
+		recorder.attach(&opts)
+	}
+	var (
+		cost    costTracker
+		partial partialRecorder
+	)
+	cost.attach(&opts)
+	partial.attach(&opts)
+	if perDir {
+		if err = runPerDir(ctx, &registry, b, backend, opts, maxSubject, lintConfig(maxSubject, types, scopePolicy, scopeMap), commitMode{commit: doCommit, signoff: signoff, footers: footers, changeID: changeID, attribute: attribution, trailers: trailers, order: order, args: signArgs(sign, gpgSign)}); err != nil {
+			reportError(err)
+			os.Exit(exitCode(err))
+		}
+		return
+	}
+	var (
+		message  string
+		start    = time.Now()
+		generate = func() (string, error) {
+			switch {
+			case candidates > 1:
+				return runCandidates(ctx, backend, modelOrDefault(b, model), candidates, opts)
+			case twoStage:
+				return runTwoStage(ctx, &registry, b, opts, draftModel, parallel)
+			case parallel > 0:
+				return runParallelChunks(ctx, &registry, b, opts, draftModel, parallel)
+			default:
+				return b.Generate(ctx, &registry, opts)
+			}
+		}
+	)
+	if message, err = generate(); err == nil {
+		message = enforcePolicies(ctx, &registry, b, opts, message, maxSubject)
+	}
+	if err == nil && len(rejected) > 0 && !ciMode && jsonProgress == nil {
+		// A regeneration: show what changed from the rejected message.
+		message, err = reviewRewrite(rejected[len(rejected)-1].Message, message, func(passed string) (string, error) {
+			rejected = append(rejected, attempt.Rejection{Message: commit.StripComments(passed), Reason: "turned down in the diff view"})
+			opts.ExtraNote = joinNotes(baseNote, attempt.Note(rejected))
+			next, err := generate()
+			if err != nil {
+				return "", err
+			}
+			return enforcePolicies(ctx, &registry, b, opts, next, maxSubject), nil
+		})
+	}
+	if errors.Is(err, providers.ErrInterrupted) && !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		// Ctrl-C: keep what was streamed so far if the user wants it.
+		interactive := !ciMode && jsonProgress == nil && !ui.IsQuiet()
+		if kept := keepPartial(partial.message(opts), noCC, interactive, backend, modelOrDefault(b, model)); kept != "" {
+			message, err = kept, nil
+		}
+	}
+	if err = timeoutError(ctx, err, timeout); err != nil {
+		if recorder != nil {
+			recorder.save("", err)
+		}
+		if !ui.IsQuiet() && !ciMode {
+			fmt.Fprint(os.Stdout, "\n\n\n"+commentLine("something went wrong "+err.Error())) //nolint:errcheck
+		}
+		reportError(err)
+		os.Exit(exitCode(err))
+	}
+	if signoff && strings.TrimSpace(message) != "" {
+		if message, err = withSignoff(message); err != nil {
+			reportError(err)
+			os.Exit(exitFailure)
+		}
+	}
+	message = withAttribution(withFooters(changeID.apply(message), footers), attribution, backend, modelOrDefault(b, model))
+	message = withTrailers(message, trailers, order)
+	ui.Debugf("generated in %s", time.Since(start).Round(time.Millisecond))
+	if recorder != nil {
+		recorder.save(message, nil)
+	}
+	saveAttempt(tree, message, rejected)
+	recordMetrics(rc, generationEvent(backend, modelOrDefault(b, model), tree, commit.StripComments(message), time.Since(start), len(rejected)))
+	if strings.TrimSpace(message) != "" {
+		recordGeneration(ledger.Entry{Kind: ledger.KindGenerate, Backend: backend, Model: modelOrDefault(b, model), Message: message, CostUSD: cost.total()})
+	}
+	warnUnmarkedBreaking(message, breaking)
+	if risk && !hasFooter(message, "Risk") {
+		fmt.Fprintln(ui.Status(), "warning: backend did not add the requested Risk footer")
+	}
+	summarizeGeneration(backend, modelOrDefault(b, model), message)
+	if !emitMessage(message, noCC, lintConfig(maxSubject, types, scopePolicy, scopeMap)) && ciMode {
+		os.Exit(exitFailure)
+	}
+}
 
-	var sessionID string
-	if !noSession {
-		sessionID = rc.SessionID
+// agentrcPath returns the .agentrc in the repository root, so the tool
+// behaves the same from any subdirectory or linked worktree. Outside a
+// repository the working directory is used.
+func agentrcPath() string {
+	if root, err := git.TopLevel(); err == nil {
+		return filepath.Join(root, agentrc.FileName)
 	}
+	return agentrc.FileName
+}
 
-	message, err := b.Generate(ctx, &registry, providers.Options{
-		SkillPath:   skillPath,
-		ExtraNote:   extraNote,
-		Model:       model,
-		SessionID:   sessionID,
-		ShowSpinner: !noSpinner,
-		NoCC:        noCC,
-		Budget:      budget,
-	})
-	if err != nil {
-		fmt.Fprintf(os.Stdout, "\n\n\n# something went wrong %s\n", err.Error()) //nolint:errcheck
-		fmt.Fprintln(os.Stderr, err.Error())                                     //nolint:errcheck
-		os.Exit(1)
+// stripPatterns compiles the GIT_AI_STRIP_PATTERN lines from .agentrc plus
+// the one in the environment.
+func stripPatterns(rc agentrc.Config) ([]*regexp.Regexp, error) {
+	patterns := rc.StripPatterns
+	if p := strings.TrimSpace(os.Getenv("GIT_AI_STRIP_PATTERN")); p != "" {
+		patterns = append(slices.Clone(patterns), p)
 	}
+	return commit.CompilePatterns(patterns)
+}
+
+// hasFooter reports whether message carries a footer with the given token.
+func hasFooter(message, token string) bool {
+	for _, f := range commitlint.Parse(message).Footer {
+		if strings.HasPrefix(f, token+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// emitMessage lints and prints the final message to stdout, and writes it
+// to the -o file. With -q and -o stdout stays empty. It reports whether the
+// message is usable: not empty and, for conventional commits, free of lint
+// errors.
+func emitMessage(message string, noCC bool, lint commitlint.Config) bool {
 	if strings.TrimSpace(message) == "" {
-		fmt.Print("\n\n# something went wrong\n")
+		switch {
+		case ciMode:
+			reportError(errors.New("the backend returned an empty message"))
+		case !ui.IsQuiet():
+			fmt.Print("\n\n" + commentLine("something went wrong"))
+		}
+		return false
+	}
+	ok := true
+	if !noCC {
+		res := commitlint.Lint(message, lint)
+		reportLint(res)
+		ok = len(res.Errors) == 0
+	}
+	if outputFile != "" {
+		if err := writeMessageFile(outputFile, message); err != nil {
+			reportError(fmt.Errorf("failed to write %s: %w", outputFile, err))
+			os.Exit(exitFailure)
+		}
+		if ui.IsQuiet() {
+			return ok
+		}
+	}
+	if ciMode {
+		// git commit -F keeps comment lines unless the message is edited.
+		fmt.Print(strings.TrimSpace(commit.StripComments(message)))
+		return ok
+	}
+	fmt.Print(strings.TrimSpace(withComments(message)))
+	return ok
+}
+
+// recordGeneration appends e to the usage ledger. Failures (e.g. running
+// outside a repository) are not fatal.
+func recordGeneration(e ledger.Entry) {
+	if err := ledger.Append(e); err != nil {
+		fmt.Fprintf(ui.Status(), "failed to update usage ledger: %v\n", err)
+	}
+}
+
+func modelOrDefault(b providers.Backend, model string) string {
+	if strings.TrimSpace(model) != "" {
+		return model
+	}
+	return b.DefaultModel()
+}
+
+// reportLint prints commitlint problems for the generated message to stderr.
+// Problems never fail the run; the user still reviews the message in the
+// editor before committing.
+func reportLint(res commitlint.Result) {
+	for _, p := range res.Problems() {
+		if jsonProgress != nil {
+			jsonProgress.emit(progressEvent{Phase: "lint", Message: fmt.Sprintf("%s: %s [%s]", p.Severity, p.Message, p.Rule)})
+			continue
+		}
+		if ghactions.Enabled() {
+			ghactions.Annotate(os.Stderr, lintLevel(p.Severity), "commitlint "+p.Rule, p.Message)
+			continue
+		}
+		fmt.Fprintf(ui.Status(), "%s: %s [%s]\n", p.Severity, p.Message, p.Rule)
+	}
+}
+
+// reportError prints err to stderr, as an "error" event under --progress json.
+func reportError(err error) {
+	if jsonProgress != nil {
+		jsonProgress.emit(progressEvent{Phase: "error", Message: err.Error()})
+		return
+	}
+	if ghactions.Enabled() {
+		ghactions.Annotate(os.Stderr, ghactions.Error, "git-cc-ai", err.Error())
 		return
 	}
-	fmt.Print(strings.TrimSpace(message))
+	fmt.Fprintln(os.Stderr, err.Error())
 }
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+// lastModelFile holds the model last picked from the menu, per backend.
+const lastModelFile = "last-model.json"
+
+// selectModel shows the model menu for backend, with the model last picked
+// in this repository preselected, and remembers the pick.
+func selectModel(backend string, b providers.Backend) (string, error) {
+	last := loadLastModels()
+	preselect := last[backend]
+	if preselect == "" {
+		preselect = b.DefaultModel()
+	}
+	model, err := ui.SelectModelMenu(modelChoices(b), preselect)
+	if err != nil {
+		return "", err
+	}
+	last[backend] = model
+	saveLastModels(last)
+	return model, nil
+}
+
+// modelChoices describes the models of b for the menus.
+func modelChoices(b providers.Backend) []ui.ModelChoice {
+	models := b.Models()
+	choices := make([]ui.ModelChoice, len(models))
+	for i, m := range models {
+		info, _ := providers.DescribeModel(m)
+		choices[i] = ui.ModelChoice{Name: m, Tier: info.Tier, ContextWindow: info.ContextWindow, Default: m == b.DefaultModel()}
+	}
+	return choices
+}
+
+func lastModelPath() (string, error) {
+	dir, err := git.CommonDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "git-ai", lastModelFile), nil
+}
+
+// loadLastModels returns the last picked model per backend; it is empty
+// when none was saved or the file cannot be read.
+func loadLastModels() map[string]string {
+	last := map[string]string{}
+	path, err := lastModelPath()
+	if err != nil {
+		return last
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &last)
+	}
+	return last
+}
+
+// saveLastModels writes last; failures are ignored since the preselection
+// is only a convenience.
+func saveLastModels(last map[string]string) {
+	path, err := lastModelPath()
+	if err != nil {
+		return
+	}
+	data, err := json.MarshalIndent(last, "", "  ")
+	if err != nil {
+		return
+	}
+	if os.MkdirAll(filepath.Dir(path), 0o755) == nil {
+		_ = os.WriteFile(path, append(data, '\n'), 0o644)
+	}
+}
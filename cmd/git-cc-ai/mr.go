@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/commit"
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/gitlab"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+)
+
+const mrInstructions = `You write GitLab merge request descriptions.
+Using the commits (grouped by Conventional Commit type and scope) and the diff below, write a merge request for a reviewer.
+The first line is the title: at most 72 characters, in the style of the commit subjects, without a trailing period.
+After one blank line, write the description in Markdown: a short "## Summary" of what changes and why, "## Changes" with the notable changes as bullets, and "## How to test" when the diff suggests how; add "## Breaking changes" only when something breaks.
+Output only the title and the description.`
+
+// runMR drafts a GitLab merge request title and description from the
+// commits of the current branch and their diff, and optionally opens the
+// merge request with glab or the GitLab API.
+func runMR(args []string) int {
+	var (
+		target    string
+		remote    string
+		create    bool
+		draft     bool
+		noSpinner bool
+		fs        = flag.NewFlagSet("mr", flag.ContinueOnError)
+	)
+	fs.StringVar(&target, "target", "", "target branch (default: the remote's default branch, or main)")
+	fs.StringVar(&remote, "remote", "origin", "remote the branch is pushed to")
+	fs.BoolVar(&create, "create", false, "open the merge request with glab, or the GitLab API and GITLAB_TOKEN when glab is not installed")
+	fs.BoolVar(&draft, "draft", false, "with --create, open it as a draft")
+	fs.BoolVar(&noSpinner, "no-spinner", false, "disable spinner while the backend runs")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: git-cc-ai mr [--target branch] [--remote name] [--create [--draft]]")
+		fs.PrintDefaults()
+	}
+	rest, err := parseArgs(fs, args, nil)
+	if err != nil || len(rest) > 0 || (draft && !create) {
+		fs.Usage()
+		return 2
+	}
+	source := git.CurrentBranch()
+	if source == "" {
+		fmt.Fprintln(os.Stderr, "HEAD is detached; check out the branch of the merge request")
+		return 1
+	}
+	if target == "" {
+		target = firstNonEmpty(git.RemoteHead(remote), "main")
+	}
+	base := target
+	if _, err = git.ResolveCommit(remote + "/" + target); err == nil {
+		base = remote + "/" + target
+	}
+
+	entries, err := git.Log(base + "..HEAD")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	if len(entries) == 0 {
+		fmt.Fprintf(os.Stderr, "no commits on %s that are not in %s\n", source, base)
+		return 1
+	}
+	diff, err := git.RevDiff(base + "...HEAD")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+
+	var input strings.Builder
+	fmt.Fprintf(&input, "Source branch: %s\nTarget branch: %s\n\nCommits:\n", source, target)
+	input.WriteString(groupCommits(entries))
+	input.WriteString("Diff:\n" + git.CapDiff(diff))
+
+	out, err := runTask(providers.Task{Instructions: mrInstructions, Input: input.String()}, !noSpinner)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return exitCode(err)
+	}
+	title := commit.Subject(out)
+	_, description, _ := strings.Cut(strings.TrimSpace(out), "\n")
+	description = strings.TrimSpace(description)
+	if !create {
+		fmt.Printf("%s\n\n%s\n", title, description)
+		return 0
+	}
+	mr := gitlab.MergeRequest{SourceBranch: source, TargetBranch: target, Title: title, Description: description}
+	if err = createMR(remote, mr, draft); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	return 0
+}
+
+// createMR opens mr with glab when it is in PATH, and through the GitLab
+// API with the first token of gitlab.TokenEnvs otherwise. The source branch
+// must already be pushed.
+func createMR(remote string, mr gitlab.MergeRequest, draft bool) error {
+	if _, err := exec.LookPath("glab"); err == nil {
+		args := []string{"mr", "create", "--yes",
+			"--source-branch", mr.SourceBranch, "--target-branch", mr.TargetBranch,
+			"--title", mr.Title, "--description", mr.Description}
+		if draft {
+			args = append(args, "--draft")
+		}
+		cmd := exec.Command("glab", args...)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err = cmd.Run(); err != nil {
+			return fmt.Errorf("glab mr create failed: %w", err)
+		}
+		return nil
+	}
+
+	var token string
+	for _, env := range gitlab.TokenEnvs {
+		if token = strings.TrimSpace(os.Getenv(env)); token != "" {
+			break
+		}
+	}
+	if token == "" {
+		return fmt.Errorf("install glab or set %s to create the merge request", strings.Join(gitlab.TokenEnvs, " or "))
+	}
+	project, err := gitlab.ParseRemote(git.Config("remote."+remote+".url", ""))
+	if err != nil {
+		return err
+	}
+	if u := firstNonEmpty(os.Getenv("GITLAB_URL"), os.Getenv("CI_SERVER_URL")); u != "" {
+		project.BaseURL = u
+	}
+	if draft {
+		mr.Title = "Draft: " + mr.Title
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	webURL, err := gitlab.CreateMergeRequest(ctx, project, token, mr)
+	if err != nil {
+		return err
+	}
+	fmt.Println(webURL)
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// readExtraNote builds the extra context from the positional arguments and
+// --note-file. A "-" argument or note file reads stdin (once); the pieces
+// are joined in order with blank lines between them.
+func readExtraNote(args []string, noteFile string) (string, error) {
+	var (
+		parts    = make([]string, 0, 2)
+		words    = make([]string, 0, len(args))
+		readOnce bool
+	)
+	readStdin := func() (string, error) {
+		if readOnce {
+			return "", errors.New("stdin can only be used once for the extra note")
+		}
+		readOnce = true
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read extra note from stdin: %w", err)
+		}
+		return string(data), nil
+	}
+	add := func(text string) {
+		if text = strings.TrimSpace(text); text != "" {
+			parts = append(parts, text)
+		}
+	}
+
+	for _, arg := range args {
+		if arg != "-" {
+			words = append(words, arg)
+			continue
+		}
+		add(strings.Join(words, " "))
+		words = words[:0]
+		text, err := readStdin()
+		if err != nil {
+			return "", err
+		}
+		add(text)
+	}
+	add(strings.Join(words, " "))
+
+	switch noteFile {
+	case "":
+	case "-":
+		text, err := readStdin()
+		if err != nil {
+			return "", err
+		}
+		add(text)
+	default:
+		data, err := os.ReadFile(noteFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read note file: %w", err)
+		}
+		add(string(data))
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
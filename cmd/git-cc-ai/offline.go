@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/agentrc"
+)
+
+// errOffline is returned when GIT_AI_OFFLINE is set and a backend would
+// send the diff over the network.
+var errOffline = errors.New("GIT_AI_OFFLINE is set")
+
+// localBackends lists the backends that run without network access. Every
+// backend of this build calls a cloud model, so offline mode only allows
+// the local message for formatting-only changes (GIT_AI_FORMATTING=local).
+var localBackends []string
+
+// offlineMode reports whether GIT_AI_OFFLINE is set in the environment or
+// .agentrc. Either can turn it on; neither can turn the other off.
+func offlineMode(rc agentrc.Config) bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("GIT_AI_OFFLINE"))) {
+	case "1", "true", "yes":
+		return true
+	}
+	return rc.Offline
+}
+
+// checkOffline returns errOffline when offline mode is on and backend is
+// not a local backend.
+func checkOffline(backend string, rc agentrc.Config) error {
+	if !offlineMode(rc) || slices.Contains(localBackends, backend) {
+		return nil
+	}
+	return fmt.Errorf("%w: the %s backend sends the diff to a cloud service; offline, only the local message for formatting-only changes is available", errOffline, backend)
+}
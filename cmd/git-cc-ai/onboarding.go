@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/agentrc"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+// onboardingOrder lists the backends in the first-run wizard, in the order
+// resolveBackend detects them.
+var onboardingOrder = []string{"claude", "gemini", "codex", "mistral", "azure", "vertex"}
+
+// onboardedMarker returns the file that records that the first-run wizard
+// was shown, so it is shown once per user rather than once per repository.
+func onboardedMarker() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "git-cc-ai", "onboarded"), nil
+}
+
+// needsOnboarding reports whether this is a first run: no .agentrc at
+// rcPath, no backend or model in the environment and no wizard shown
+// before.
+func needsOnboarding(rcPath string) bool {
+	if ui.IsQuiet() || strings.TrimSpace(os.Getenv("GIT_AI_BACKEND")) != "" || strings.TrimSpace(os.Getenv("GIT_AI_MODEL")) != "" {
+		return false
+	}
+	if _, err := os.Stat(rcPath); !errors.Is(err, os.ErrNotExist) {
+		return false
+	}
+	marker, err := onboardedMarker()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(marker)
+	return errors.Is(err, os.ErrNotExist)
+}
+
+// onboard runs the first-run wizard and writes the choices to the .agentrc
+// at rcPath. Without a terminal nothing happens and the wizard is offered
+// again next time; otherwise, cancelled or not, it is not shown again.
+func onboard(rcPath string) {
+	setupBackends := make([]ui.SetupBackend, 0, len(onboardingOrder))
+	for _, name := range onboardingOrder {
+		b := backends[name]
+		detected := execInPath(name)
+		if cb, ok := b.(providers.ConfiguredBackend); ok {
+			detected = cb.Configured() == nil
+		}
+		setupBackends = append(setupBackends, ui.SetupBackend{Name: name, Detected: detected, Models: modelChoices(b)})
+	}
+	setup, err := ui.Onboard(setupBackends)
+	if errors.Is(err, ui.ErrNotInteractive) {
+		return
+	}
+	if marker, markerErr := onboardedMarker(); markerErr == nil && os.MkdirAll(filepath.Dir(marker), 0o755) == nil {
+		_ = os.WriteFile(marker, nil, 0o644)
+	}
+	if errors.Is(err, ui.ErrSetupCancelled) {
+		fmt.Fprintf(ui.Status(), "setup skipped; set GIT_AI_BACKEND and friends in %s to configure git-cc-ai\n", agentrc.FileName)
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(ui.Status(), "warning: setup failed: %v\n", err)
+		return
+	}
+	settings := [][2]string{{"GIT_AI_BACKEND", setup.Backend}}
+	if setup.Model != "" {
+		settings = append(settings, [2]string{agentrc.BackendModelKey(setup.Backend), setup.Model})
+	}
+	if setup.Standard {
+		settings = append(settings, [2]string{"GIT_AI_NO_CC", "true"})
+	}
+	if setup.InstallHook {
+		if path, err := installPostCommitHook(); err != nil {
+			fmt.Fprintf(ui.Status(), "warning: %v\n", err)
+		} else {
+			fmt.Fprintf(ui.Status(), "installed %s\n", path)
+			settings = append(settings, [2]string{"GIT_AI_FEEDBACK", "true"})
+		}
+	}
+	for _, kv := range settings {
+		if err := agentrc.Set(rcPath, kv[0], kv[1]); err != nil {
+			fmt.Fprintf(ui.Status(), "warning: could not save %s: %v\n", rcPath, err)
+			return
+		}
+	}
+	fmt.Fprintf(ui.Status(), "saved your setup to %s\n", rcPath)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/commit"
+)
+
+// outputFile is set by -o/--output-file: the message is also written there,
+// without the usage comments.
+var outputFile string
+
+// writeMessageFile writes message, without comment lines, to path through a
+// temporary file in the same directory that is renamed over it, so readers
+// such as git or a CI step never see a partial message.
+func writeMessageFile(path, message string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.WriteString(strings.TrimSpace(commit.StripComments(message)) + "\n"); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Chmod(0o644); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/ccparse"
+	"github.com/dlnilsson/git-cc-ai/pkg/ledger"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+// partialRecorder keeps the message streamed so far, so a run interrupted
+// with Ctrl-C does not lose it. Backends call it from their reader
+// goroutines.
+type partialRecorder struct {
+	mu   sync.Mutex
+	text string
+}
+
+// attach records the streamed text from the progress callback of opts,
+// keeping any listener already set.
+func (r *partialRecorder) attach(opts *providers.Options) {
+	onProgress := opts.OnProgress
+	opts.OnProgress = func(p providers.Progress) {
+		if p.Partial != "" {
+			r.mu.Lock()
+			r.text = p.Partial
+			r.mu.Unlock()
+		}
+		if onProgress != nil {
+			onProgress(p)
+		}
+	}
+}
+
+// message returns the streamed text formatted like a finished message.
+func (r *partialRecorder) message(opts providers.Options) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if strings.TrimSpace(r.text) == "" {
+		return ""
+	}
+	return strings.TrimSpace(opts.FormatMessage(r.text))
+}
+
+// completeMessage reports whether a partial message already has a header
+// and a body: a Conventional Commits header unless noCC is set.
+func completeMessage(msg string, noCC bool) bool {
+	if noCC {
+		subject, body, _ := strings.Cut(msg, "\n")
+		return strings.TrimSpace(subject) != "" && strings.TrimSpace(body) != ""
+	}
+	c, err := ccparse.Parse(msg)
+	return err == nil && c.Body != ""
+}
+
+// keepPartial handles a partial message after Ctrl-C: it returns the
+// message when the user chooses to commit with it, or "" after saving it
+// to the history ledger or discarding it. Using it is only offered when
+// the message is complete; without interactive the message is saved.
+func keepPartial(msg string, noCC, interactive bool, backend, model string) string {
+	if msg == "" {
+		return ""
+	}
+	save := func() {
+		recordGeneration(ledger.Entry{Kind: ledger.KindPartial, Backend: backend, Model: model, Message: msg})
+		fmt.Fprintln(ui.Status(), "saved the partial message; see git-cc-ai history")
+	}
+	if !interactive {
+		save()
+		return ""
+	}
+	const (
+		use     = "Use it"
+		keep    = "Save it to the history"
+		discard = "Discard it"
+	)
+	options := []string{keep, discard}
+	if completeMessage(msg, noCC) {
+		options = []string{use, keep, discard}
+	}
+	fmt.Fprintf(ui.Status(), "\nInterrupted; the message so far:\n\n  %s\n\n", strings.ReplaceAll(msg, "\n", "\n  "))
+	choice, err := ui.SelectOption("Keep the partial message?", options)
+	if errors.Is(err, ui.ErrNotInteractive) {
+		save()
+		return ""
+	}
+	if err != nil {
+		return ""
+	}
+	switch options[choice] {
+	case use:
+		return msg
+	case keep:
+		save()
+	}
+	return ""
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/commit"
+	"github.com/dlnilsson/git-cc-ai/pkg/commitlint"
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/ledger"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+// dirGroup is the set of staged changes under one top-level directory.
+type dirGroup struct {
+	dir     string
+	changes []git.FileChange
+	message string
+}
+
+// groupByTopDir groups changes by the first path component of their
+// (post-rename) path; files in the repository root form the "." group.
+func groupByTopDir(changes []git.FileChange) []*dirGroup {
+	byDir := map[string]*dirGroup{}
+	for _, c := range changes {
+		dir := "."
+		if top, _, ok := strings.Cut(c.Path, "/"); ok {
+			dir = top
+		}
+		if byDir[dir] == nil {
+			byDir[dir] = &dirGroup{dir: dir}
+		}
+		byDir[dir].changes = append(byDir[dir].changes, c)
+	}
+	groups := make([]*dirGroup, 0, len(byDir))
+	for _, g := range byDir {
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].dir < groups[j].dir })
+	return groups
+}
+
+// commitMode controls what happens to the generated messages.
+type commitMode struct {
+	commit    bool           // create the commits (--commit)
+	signoff   bool           // add a Signed-off-by trailer (--signoff)
+	footers   []string       // GIT_AI_FOOTER templates
+	changeID  changeIDPolicy // Gerrit Change-Id (GIT_AI_GERRIT)
+	attribute string         // attribution trailer (GIT_AI_ATTRIBUTION)
+	trailers  []string       // --trailer values
+	order     []string       // footer order (GIT_AI_FOOTER_ORDER)
+	args      []string       // extra git commit options (signing)
+}
+
+// runPerDir generates one message per top-level directory of the staged
+// changes and prints them as a plan. With mode.commit every group is
+// committed separately once all messages are generated.
+func runPerDir(ctx context.Context, reg *providers.Registry, b providers.Backend, backend string, opts providers.Options, maxSubject int, lint commitlint.Config, mode commitMode) error {
+	changes, err := git.StagedChanges()
+	if err != nil {
+		return err
+	}
+	groups := groupByTopDir(changes)
+	if len(groups) == 0 {
+		return providers.ErrNoStagedChanges
+	}
+
+	for _, g := range groups {
+		o := opts
+		// Each group is an independent conversation.
+		o.SessionID, o.OnSessionID = "", nil
+		if o.Diff, err = git.DiffStagedPaths(git.ChangedPaths(g.changes)); err != nil {
+			return err
+		}
+		message, err := b.Generate(ctx, reg, o)
+		if err != nil {
+			return fmt.Errorf("%s: %w", g.dir, err)
+		}
+		g.message = strings.TrimSpace(enforcePolicies(ctx, reg, b, o, message, maxSubject))
+		if g.message == "" {
+			return fmt.Errorf("%s: backend returned an empty message", g.dir)
+		}
+		if mode.signoff {
+			if g.message, err = withSignoff(g.message); err != nil {
+				return err
+			}
+		}
+		g.message = withFooters(mode.changeID.apply(g.message), mode.footers)
+		g.message = withAttribution(g.message, mode.attribute, backend, modelOrDefault(b, o.Model))
+		g.message = withTrailers(g.message, mode.trailers, mode.order)
+		recordGeneration(ledger.Entry{Kind: ledger.KindGenerate, Backend: backend, Model: modelOrDefault(b, o.Model), Message: g.message})
+		if !opts.NoCC {
+			reportLint(commitlint.Lint(g.message, lint))
+		}
+		plan := g.message
+		if usageMode == usageStderr || usageMode == usageOff {
+			plan, _ = routeUsage(g.message)
+		}
+		fmt.Printf("# %s (%d files)\n%s\n\n", g.dir, len(g.changes), plan)
+	}
+
+	if !mode.commit {
+		return nil
+	}
+	for _, g := range groups {
+		if err := git.CommitPaths(commit.StripComments(g.message), git.ChangedPaths(g.changes), mode.args...); err != nil {
+			return fmt.Errorf("committing %s: %w", g.dir, err)
+		}
+		if usageMode == usageNotes {
+			if err := addUsageNote("HEAD", usageNote(g.message)); err != nil {
+				fmt.Fprintf(ui.Status(), "%s: %v\n", g.dir, err)
+			}
+		}
+		fmt.Fprintf(ui.Status(), "committed %s: %s\n", g.dir, commit.Subject(g.message))
+	}
+	return nil
+}
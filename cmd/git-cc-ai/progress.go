@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+)
+
+// progressEvent is one NDJSON line written by --progress json.
+type progressEvent struct {
+	Time         time.Time `json:"time"`
+	Phase        string    `json:"phase"`
+	Backend      string    `json:"backend,omitempty"`
+	Model        string    `json:"model,omitempty"`
+	DiffBytes    int       `json:"diff_bytes,omitempty"`
+	Reasoning    string    `json:"reasoning,omitempty"`
+	InputTokens  int       `json:"input_tokens,omitempty"`
+	OutputTokens int       `json:"output_tokens,omitempty"`
+	WaitSeconds  float64   `json:"wait_seconds,omitempty"`
+	CostUSD      float64   `json:"cost_usd,omitempty"`
+	Message      string    `json:"message,omitempty"`
+}
+
+// progressWriter emits progressEvents as NDJSON. It is safe for concurrent
+// use so compare/candidate runs can share one writer.
+type progressWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// jsonProgress is set when --progress json is active.
+var jsonProgress *progressWriter
+
+func newProgressWriter(w io.Writer) *progressWriter {
+	return &progressWriter{enc: json.NewEncoder(w)}
+}
+
+func (p *progressWriter) emit(e progressEvent) {
+	e.Time = time.Now().UTC()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_ = p.enc.Encode(e)
+}
+
+// attach routes the progress and usage callbacks of opts to p.
+func (p *progressWriter) attach(opts *providers.Options) {
+	opts.OnProgress = func(pr providers.Progress) {
+		p.emit(progressEvent{
+			Phase:        pr.Phase,
+			DiffBytes:    pr.DiffBytes,
+			Reasoning:    pr.Reasoning,
+			OutputTokens: pr.OutputTokens,
+			WaitSeconds:  pr.Wait.Seconds(),
+		})
+	}
+	opts.OnUsage = func(u providers.Usage) {
+		p.emit(progressEvent{
+			Phase:        "done",
+			Backend:      u.Backend,
+			Model:        u.Model,
+			InputTokens:  u.InputTokens,
+			OutputTokens: u.OutputTokens,
+			CostUSD:      u.CostUSD,
+		})
+	}
+}
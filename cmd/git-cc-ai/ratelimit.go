@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/agentrc"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+var errRateLimitWait = errors.New(`not a duration (e.g. 90s or 5m), a number of seconds or "off"`)
+
+// parseRateLimitWait parses a GIT_AI_RATE_LIMIT_WAIT value into
+// Options.RateLimitWait: "0" and "off" disable retries (-1), "" keeps the
+// default (0).
+func parseRateLimitWait(value string) (time.Duration, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch value {
+	case "":
+		return 0, nil
+	case "0", "off", "false":
+		return -1, nil
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return d, nil
+	}
+	return 0, errRateLimitWait
+}
+
+// resolveRateLimitWait returns how long to wait in total for rate limits
+// from GIT_AI_RATE_LIMIT_WAIT or .agentrc. Invalid values are reported and
+// the default is used.
+func resolveRateLimitWait(rc agentrc.Config) time.Duration {
+	value := os.Getenv("GIT_AI_RATE_LIMIT_WAIT")
+	if strings.TrimSpace(value) == "" {
+		value = rc.RateLimitWait
+	}
+	wait, err := parseRateLimitWait(value)
+	if err != nil {
+		fmt.Fprintf(ui.Status(), "warning: GIT_AI_RATE_LIMIT_WAIT %q is %v; using the default\n", value, err)
+	}
+	return wait
+}
+
+// resolveStallTimeout returns how long a backend may run without output
+// from GIT_AI_STALL_TIMEOUT or .agentrc, which take the same values as
+// GIT_AI_RATE_LIMIT_WAIT ("off" disables the watchdog). Invalid values are
+// reported and the default is used.
+func resolveStallTimeout(rc agentrc.Config) time.Duration {
+	value := os.Getenv("GIT_AI_STALL_TIMEOUT")
+	if strings.TrimSpace(value) == "" {
+		value = rc.StallTimeout
+	}
+	timeout, err := parseRateLimitWait(value)
+	if err != nil {
+		fmt.Fprintf(ui.Status(), "warning: GIT_AI_STALL_TIMEOUT %q is %v; using the default\n", value, err)
+	}
+	return timeout
+}
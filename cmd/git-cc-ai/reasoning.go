@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/agentrc"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+// resolveReasoning returns the reasoning effort from GIT_AI_REASONING or
+// .agentrc. Unknown levels are reported and ignored.
+func resolveReasoning(rc agentrc.Config) string {
+	level := strings.ToLower(strings.TrimSpace(os.Getenv("GIT_AI_REASONING")))
+	if level == "" {
+		level = rc.Reasoning
+	}
+	if level != "" && !slices.Contains(providers.ReasoningLevels, level) {
+		fmt.Fprintf(ui.Status(), "warning: GIT_AI_REASONING %q is not one of %s; it is ignored\n", level, strings.Join(providers.ReasoningLevels, ", "))
+		return ""
+	}
+	return level
+}
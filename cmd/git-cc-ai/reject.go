@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/attempt"
+	"github.com/dlnilsson/git-cc-ai/pkg/commit"
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+// rejectNoReason is the --reject value when it is given without an
+// objection.
+const rejectNoReason = "true"
+
+// rejections returns the messages rejected so far for the staged state
+// tree: the last attempt (with the objection from --reject) and the ones it
+// already carried. Without --reject, or when the staged changes moved on,
+// the next attempt starts fresh.
+func rejections(reject, tree string) []attempt.Rejection {
+	if reject == "" {
+		return nil
+	}
+	last, err := attempt.Load()
+	switch {
+	case err != nil:
+		fmt.Fprintf(ui.Status(), "warning: cannot read the last attempt: %v\n", err)
+		return nil
+	case last == nil:
+		fmt.Fprintln(ui.Status(), "warning: --reject: no previous attempt to reject")
+		return nil
+	case last.Tree != tree:
+		fmt.Fprintln(ui.Status(), "warning: --reject: the staged changes differ from the last attempt; starting fresh")
+		return nil
+	}
+	if reject == rejectNoReason {
+		reject = ""
+	}
+	return last.Reject(reject)
+}
+
+// saveAttempt records message as the last attempt for tree, keeping its
+// usage trailer apart for git notes.
+func saveAttempt(tree, message string, rejected []attempt.Rejection) {
+	text := commit.StripComments(message)
+	if tree == "" || text == "" {
+		return
+	}
+	if err := attempt.Save(attempt.Attempt{Tree: tree, Message: text, Usage: usageNote(message), Rejected: rejected}); err != nil {
+		fmt.Fprintf(ui.Status(), "failed to save the last attempt: %v\n", err)
+	}
+}
+
+// stagedTree returns the tree of the staged state, or an empty string
+// outside a repository.
+func stagedTree() string {
+	tree, err := git.IndexTree()
+	if err != nil {
+		return ""
+	}
+	return tree
+}
+
+// joinNotes joins the non-empty extra-context parts with blank lines.
+func joinNotes(parts ...string) string {
+	kept := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			kept = append(kept, p)
+		}
+	}
+	return strings.Join(kept, "\n\n")
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/commitlint"
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+)
+
+const releaseNotesInstructions = `You write release notes for a software project.
+Using the commits below (already grouped by Conventional Commit type and scope), write concise, human-readable release notes in Markdown.
+Start with a one-paragraph summary, then sections such as "Breaking changes", "Features", "Fixes" and "Other changes"; omit empty sections.
+Describe user-visible impact rather than implementation details, merge related commits into single bullets, and skip purely internal noise.
+Output only the Markdown release notes.`
+
+// typeOrder controls the order of commit groups in the release notes input.
+var typeOrder = []string{"feat", "fix", "perf", "refactor", "docs", "test", "build", "ci", "chore", "style", "revert"}
+
+// runRelease drafts release notes for tag from the commits since the
+// previous tag and optionally publishes them with the GitHub CLI.
+func runRelease(args []string) int {
+	var (
+		from      string
+		to        string
+		publish   bool
+		noSpinner bool
+		fs        = flag.NewFlagSet("release", flag.ContinueOnError)
+	)
+	fs.StringVar(&from, "from", "", "previous tag (default: latest tag reachable from --to)")
+	fs.StringVar(&to, "to", "HEAD", "last revision included in the release")
+	fs.BoolVar(&publish, "publish", false, "create the GitHub release with gh release create --notes-file -")
+	fs.BoolVar(&noSpinner, "no-spinner", false, "disable spinner while the backend runs")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: git-cc-ai release [--from tag] [--to rev] [--publish] <tag>")
+		fs.PrintDefaults()
+	}
+	rest, err := parseArgs(fs, args, nil)
+	if err != nil || len(rest) != 1 {
+		fs.Usage()
+		return 2
+	}
+	tag := rest[0]
+	if from == "" {
+		from = git.LatestTag(to)
+	}
+	revRange := to
+	if from != "" {
+		revRange = from + ".." + to
+	}
+
+	entries, err := git.Log(revRange)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	if len(entries) == 0 {
+		fmt.Fprintf(os.Stderr, "no commits in %s\n", revRange)
+		return 1
+	}
+
+	var input strings.Builder
+	fmt.Fprintf(&input, "Release: %s\nPrevious release: %s\n\n", tag, firstNonEmpty(from, "(none)"))
+	input.WriteString(groupCommits(entries))
+
+	notes, err := runTask(providers.Task{Instructions: releaseNotesInstructions, Input: input.String()}, !noSpinner)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return exitCode(err)
+	}
+	if !publish {
+		fmt.Println(notes)
+		return 0
+	}
+	cmd := exec.Command("gh", "release", "create", tag, "--title", tag, "--notes-file", "-")
+	cmd.Stdin = strings.NewReader(notes + "\n")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err = cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "gh release create failed: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// groupCommits renders entries as Markdown lists grouped by type and scope,
+// with breaking changes and non-conventional commits in their own groups.
+func groupCommits(entries []git.LogEntry) string {
+	groups := map[string][]string{}
+	for _, e := range entries {
+		m := commitlint.Parse(e.Message)
+		key := strings.ToLower(m.Type)
+		switch {
+		case m.Breaking:
+			key = "breaking"
+		case key == "":
+			key = "other"
+		}
+		line := m.Subject
+		if m.Scope != "" {
+			line = "(" + m.Scope + ") " + line
+		}
+		if len(e.Hash) >= 7 {
+			line += " [" + e.Hash[:7] + "]"
+		}
+		groups[key] = append(groups[key], line)
+	}
+
+	keys := make([]string, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		return groupRank(a) - groupRank(b)
+	})
+
+	var b strings.Builder
+	for _, k := range keys {
+		lines := groups[k]
+		slices.Sort(lines)
+		fmt.Fprintf(&b, "## %s\n", k)
+		for _, l := range lines {
+			fmt.Fprintf(&b, "- %s\n", l)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func groupRank(key string) int {
+	switch key {
+	case "breaking":
+		return -1
+	case "other":
+		return len(typeOrder) + 1
+	}
+	if i := slices.Index(typeOrder, key); i != -1 {
+		return i
+	}
+	return len(typeOrder)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/commit"
+	"github.com/dlnilsson/git-cc-ai/pkg/commitlint"
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+)
+
+const revertInstructions = `Write the Conventional Commits message for a commit that reverts an earlier commit.
+You get the message of the reverted commit and the staged diff that undoes it.
+The subject line is "revert: " followed by the subject of the reverted commit, e.g. "revert: feat(api): add export".
+The body says in a few sentences what the revert undoes and, when a reason is given, why; wrap it at 72 characters.
+Do not add footers; the tool adds "This reverts commit <hash>." and a Refs footer.
+Output only the commit message.`
+
+// runRevert reverts a commit with git revert --no-commit and commits the
+// result with a generated revert: message, opening the editor on it.
+// Without a commit it finishes a revert that stopped on conflicts.
+func runRevert(args []string) int {
+	var (
+		printOnly bool
+		noSpinner bool
+		reason    string
+		mainline  int
+		fs        = flag.NewFlagSet("revert", flag.ContinueOnError)
+	)
+	fs.BoolVar(&printOnly, "print", false, "print the message and leave the revert staged for git commit instead of committing")
+	fs.BoolVar(&noSpinner, "no-spinner", false, "disable spinner while the backend runs")
+	fs.StringVar(&reason, "reason", "", "why the commit is reverted, for the message body")
+	fs.IntVar(&mainline, "mainline", 0, "parent number to revert a merge commit against (git revert --mainline)")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: git-cc-ai revert [--reason text] [--mainline n] [--print] [<sha>]")
+		fs.PrintDefaults()
+	}
+	rest, err := parseArgs(fs, args, nil)
+	if err != nil || len(rest) > 1 {
+		fs.Usage()
+		return 2
+	}
+
+	rev := "REVERT_HEAD"
+	if len(rest) == 1 {
+		rev = rest[0]
+	} else if op, opErr := git.InProgress(); opErr != nil || op.Kind != "revert" {
+		fmt.Fprintln(os.Stderr, "no revert in progress; pass the commit to revert")
+		return 2
+	}
+	hash, err := git.ResolveCommit(rev)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	if len(rest) == 1 {
+		if err = git.Revert(hash, mainline); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			fmt.Fprintln(os.Stderr, "resolve and stage the conflicts, then run git-cc-ai revert again without a commit")
+			return 1
+		}
+	}
+
+	message, err := revertMessage(hash, reason, !noSpinner)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return exitCode(err)
+	}
+	if printOnly {
+		fmt.Println(message)
+		if op, opErr := git.InProgress(); opErr == nil {
+			if err = os.WriteFile(op.MessageFile, []byte(message+"\n"), 0o644); err != nil {
+				fmt.Fprintln(os.Stderr, err.Error())
+				return 1
+			}
+		}
+		return 0
+	}
+	if err = git.CommitEdit(message); err != nil {
+		return childExitCode(err)
+	}
+	return 0
+}
+
+// revertMessage generates the revert: message for the staged revert of
+// hash. The subject falls back to "revert: <original subject>" and the
+// footers git revert records are added when the backend left them out.
+func revertMessage(hash, reason string, showSpinner bool) (string, error) {
+	unmerged, err := git.UnmergedPaths()
+	if err != nil {
+		return "", err
+	}
+	if len(unmerged) > 0 {
+		return "", fmt.Errorf("resolve and stage these paths first: %s", strings.Join(unmerged, ", "))
+	}
+	_, original, err := git.CommitTree(hash)
+	if err != nil {
+		return "", err
+	}
+	diff, err := git.DiffStaged()
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(diff) == "" {
+		return "", errors.New("the revert staged no changes")
+	}
+
+	var input strings.Builder
+	fmt.Fprintf(&input, "Reverted commit %s:\n%s\n\n", hash, original)
+	if reason = strings.TrimSpace(reason); reason != "" {
+		fmt.Fprintf(&input, "Reason for the revert: %s\n\n", reason)
+	}
+	fmt.Fprintf(&input, "Staged diff of the revert:\n%s", diff)
+	out, err := runTask(providers.Task{Instructions: revertInstructions, Input: input.String()}, showSpinner)
+	if err != nil {
+		return "", err
+	}
+
+	message := commit.Sanitize(out, nil)
+	if commitlint.Parse(commit.Subject(message)).Type != "revert" {
+		message = commit.ReplaceSubject(message, "revert: "+commit.Subject(original))
+	}
+	return commit.RevertFooters(message, hash), nil
+}
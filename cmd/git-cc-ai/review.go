@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/commit"
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+const reviewInstructions = `You are a senior engineer reviewing a staged change right before it is committed.
+Write a short code review in Markdown: likely bugs, missing or outdated tests, risky changes (security, data loss, compatibility, performance) and anything that looks unfinished.
+Reference files and lines where you can, put the most important findings first, and skip style nitpicks and praise.
+If nothing stands out, say so in one sentence.
+Output only the review.`
+
+// runReview prints a code-review style critique of the staged diff.
+func runReview(args []string) int {
+	var (
+		raw       bool
+		noSpinner bool
+		fs        = flag.NewFlagSet("review", flag.ContinueOnError)
+	)
+	fs.BoolVar(&raw, "raw", false, "print the review as Markdown instead of rendering it")
+	fs.BoolVar(&noSpinner, "no-spinner", false, "disable spinner while the backend runs")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: git-cc-ai review [--raw]")
+		fs.PrintDefaults()
+	}
+	if rest, err := parseArgs(fs, args, nil); err != nil || len(rest) > 0 {
+		fs.Usage()
+		return 2
+	}
+
+	diff, err := git.DiffStaged()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return exitCode(err)
+	}
+	if strings.TrimSpace(diff) == "" {
+		fmt.Fprintln(os.Stderr, providers.ErrNoStagedChanges.Error())
+		return exitNoStaged
+	}
+	changes, err := git.StagedChanges()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+
+	input := commit.ChangesSection(git.FormatChanges(changes)) + "Staged diff:\n" + git.CapDiff(diff)
+	review, err := runTask(providers.Task{Instructions: reviewInstructions, Input: input}, !noSpinner)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return exitCode(err)
+	}
+	if !raw {
+		review = ui.RenderMarkdown(review)
+	}
+	fmt.Println(strings.TrimRight(review, "\n"))
+	return 0
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+// conventionalLabels are the labels of the Conventional Comments
+// specification (https://conventionalcomments.org).
+var conventionalLabels = []string{"praise", "nitpick", "suggestion", "issue", "todo", "question", "thought", "chore", "note"}
+
+// conventionalComment matches the first line of a conventional comment:
+// label, optional (decorations) and the subject.
+var conventionalComment = regexp.MustCompile(`^(\w+)(?: \(([\w, -]+)\))?: \S`)
+
+const reviewCommentInstructions = `You write one code review comment on the diff hunk below, in the Conventional Comments format:
+
+<label> [(decorations)]: <subject>
+
+[discussion]
+
+The label is one of: praise (something done well), nitpick (trivial, preference-based), suggestion (a concrete improvement), issue (a problem that should be fixed), todo (a small necessary change), question (something unclear), thought (an idea for later), chore (a process task) or note (information for the reader).
+%s
+The subject is one sentence. The discussion, when useful, explains why and may show the suggested code in a fenced block; keep it short and specific to the hunk, and refer to lines by their content.
+Output only the comment.`
+
+// runReviewComment drafts a Conventional Comments review note on a diff
+// hunk read from stdin or selected from git diff.
+func runReviewComment(args []string) int {
+	var (
+		label       string
+		note        string
+		staged      bool
+		blocking    bool
+		nonBlocking bool
+		raw         bool
+		noSpinner   bool
+		fs          = flag.NewFlagSet("review-comment", flag.ContinueOnError)
+	)
+	fs.StringVar(&label, "label", "", "comment label: "+strings.Join(conventionalLabels, ", ")+" (default: chosen by the backend)")
+	fs.StringVar(&note, "note", "", "what the comment should point out, e.g. \"the error is swallowed\"")
+	fs.BoolVar(&staged, "staged", false, "select the hunk from the staged diff instead of the unstaged one")
+	fs.BoolVar(&blocking, "blocking", false, "mark the comment (blocking)")
+	fs.BoolVar(&nonBlocking, "non-blocking", false, "mark the comment (non-blocking)")
+	fs.BoolVar(&raw, "raw", false, "print the comment as Markdown instead of rendering it")
+	fs.BoolVar(&noSpinner, "no-spinner", false, "disable spinner while the backend runs")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: git-cc-ai review-comment [--label label] [--blocking|--non-blocking] [--note text] [--staged] [- | path[:line]]")
+		fs.PrintDefaults()
+	}
+	rest, err := parseArgs(fs, args, nil)
+	if err != nil || len(rest) > 1 || (blocking && nonBlocking) {
+		fs.Usage()
+		return 2
+	}
+	if label != "" && !slices.Contains(conventionalLabels, label) {
+		fmt.Fprintf(os.Stderr, "invalid --label %q (want one of: %s)\n", label, strings.Join(conventionalLabels, ", "))
+		return 2
+	}
+
+	hunk, err := reviewCommentHunk(rest, staged)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return exitCode(err)
+	}
+
+	var guidance []string
+	if label != "" {
+		guidance = append(guidance, fmt.Sprintf("Use the label %q.", label))
+	}
+	switch {
+	case blocking:
+		guidance = append(guidance, `Add the decoration "(blocking)".`)
+	case nonBlocking:
+		guidance = append(guidance, `Add the decoration "(non-blocking)".`)
+	default:
+		guidance = append(guidance, `Add a decoration only when it helps: "(blocking)" for issues that must be fixed before merging, "(non-blocking)" otherwise.`)
+	}
+	input := "Diff hunk:\n" + git.CapDiff(hunk)
+	if note = strings.TrimSpace(note); note != "" {
+		input = "The reviewer wants to point out: " + note + "\n\n" + input
+	}
+	task := providers.Task{Instructions: fmt.Sprintf(reviewCommentInstructions, strings.Join(guidance, "\n")), Input: input}
+	out, err := runTask(task, !noSpinner)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return exitCode(err)
+	}
+	if m := conventionalComment.FindStringSubmatch(out); m == nil || !slices.Contains(conventionalLabels, m[1]) {
+		fmt.Fprintln(ui.Status(), "warning: the backend did not start the comment with a conventional label")
+	} else if label != "" && m[1] != label {
+		fmt.Fprintf(ui.Status(), "warning: the backend used the label %q instead of %q\n", m[1], label)
+	}
+	if !raw {
+		out = ui.RenderMarkdown(out)
+	}
+	fmt.Println(strings.TrimRight(out, "\n"))
+	return 0
+}
+
+// reviewCommentHunk returns the diff to comment on: stdin for "-", the hunk
+// covering path:line, the diff of path, or the whole git diff without
+// arguments.
+func reviewCommentHunk(args []string, staged bool) (string, error) {
+	if len(args) == 1 && args[0] == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the hunk from stdin: %w", err)
+		}
+		if strings.TrimSpace(string(data)) == "" {
+			return "", errors.New("stdin is empty; pipe a diff hunk to comment on")
+		}
+		return string(data), nil
+	}
+	var (
+		file  string
+		line  int
+		paths []string
+	)
+	if len(args) == 1 {
+		file = args[0]
+		if p, l, ok := strings.Cut(file, ":"); ok {
+			n, err := strconv.Atoi(l)
+			if err != nil || n < 1 {
+				return "", fmt.Errorf("invalid line in %q (want path:line)", args[0])
+			}
+			file, line = p, n
+		}
+		paths = []string{file}
+	}
+	diff, err := git.DiffPaths(staged, paths...)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(diff) == "" {
+		return "", errors.New("no changes to comment on; pass a hunk on stdin with -, or use --staged")
+	}
+	if line == 0 {
+		return diff, nil
+	}
+	// git diff names files relative to the repository root.
+	hunk := git.SelectHunk(diff, path.Clean(git.Prefix()+file), line)
+	if hunk == "" {
+		return "", fmt.Errorf("no hunk of %s covers line %d", file, line)
+	}
+	return hunk, nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/commit"
+	"github.com/dlnilsson/git-cc-ai/pkg/feedback"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+// reviewRewrite shows a word diff from previous to message and lets the
+// user accept it, decline it (ui.ErrDeclined) or ask next for another
+// candidate, which is passed the one turned down. The diff is always taken
+// against previous. Without a terminal, or in quiet mode, message is
+// accepted as is.
+func reviewRewrite(previous, message string, next func(passed string) (string, error)) (string, error) {
+	if ui.IsQuiet() {
+		return message, nil
+	}
+	previous = commit.StripComments(previous)
+	for {
+		changes := feedback.WordDiff(previous, commit.StripComments(message))
+		spans := make([]ui.DiffSpan, len(changes))
+		for i, c := range changes {
+			spans[i] = ui.DiffSpan{Op: c.Op, Text: c.Text}
+		}
+		title := "Changes from the previous message:"
+		if len(changes) == 1 && changes[0].Op == ' ' {
+			title = "The new message is the same as the previous one:"
+		}
+		err := ui.ReviewRewrite(title, spans)
+		switch {
+		case errors.Is(err, ui.ErrRegenerate):
+			if message, err = next(message); err != nil {
+				return "", err
+			}
+		case errors.Is(err, ui.ErrNotInteractive):
+			return message, nil
+		case err != nil:
+			return "", err
+		default:
+			return message, nil
+		}
+	}
+}
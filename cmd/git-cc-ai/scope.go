@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/agentrc"
+	"github.com/dlnilsson/git-cc-ai/pkg/commit"
+	"github.com/dlnilsson/git-cc-ai/pkg/commitlint"
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+const addScopeInstructions = `Add a scope to the Conventional Commits subject line below, naming the area of the codebase the change is about.
+Candidate scopes derived from the changed paths: %s.
+Keep the type, any "!" and the description exactly as they are.
+Output only the new subject line.`
+
+// resolveScopePolicy returns GIT_AI_SCOPE from the environment or .agentrc.
+// Unknown values are reported and scopes stay optional.
+func resolveScopePolicy(rc agentrc.Config) string {
+	policy := strings.ToLower(strings.TrimSpace(os.Getenv("GIT_AI_SCOPE")))
+	if policy == "" {
+		policy = rc.Scope
+	}
+	switch {
+	case policy == "":
+		return commit.ScopeOptional
+	case slices.Contains(commit.ScopePolicies, policy):
+		return policy
+	}
+	fmt.Fprintf(ui.Status(), "warning: GIT_AI_SCOPE %q is not one of %s; using %q\n", policy, strings.Join(commit.ScopePolicies, ", "), commit.ScopeOptional)
+	return commit.ScopeOptional
+}
+
+// enforceScope applies opts.ScopePolicy to message. A forbidden scope is
+// removed. A missing required scope is asked from the backend, when b is
+// set, with the candidates from the changed paths; without an answer a
+// single candidate is used as is, otherwise the message is returned for the
+// linter to report.
+func enforceScope(ctx context.Context, reg *providers.Registry, b providers.Backend, opts providers.Options, message string) string {
+	if opts.NoCC {
+		return message
+	}
+	subject := commit.Subject(message)
+	parsed := commitlint.Parse(subject)
+	switch {
+	case parsed.Type == "":
+		return message
+	case opts.ScopePolicy == commit.ScopeForbidden:
+		if parsed.Scope == "" {
+			return message
+		}
+		return commit.ReplaceSubject(message, commit.SetScope(subject, ""))
+	case opts.Scope != "":
+		if parsed.Scope == opts.Scope {
+			return message
+		}
+		return commit.ReplaceSubject(message, commit.SetScope(subject, opts.Scope))
+	case opts.ScopePolicy != commit.ScopeRequired || parsed.Scope != "":
+		return message
+	}
+
+	candidates := scopeCandidates(opts)
+	if b != nil {
+		hint := strings.Join(candidates, ", ")
+		if hint == "" {
+			hint = "none"
+		}
+		fixed, err := b.Generate(ctx, reg, providers.Options{
+			Model:        opts.Model,
+			ShowSpinner:  opts.ShowSpinner,
+			Budget:       opts.Budget,
+			Tools:        opts.Tools,
+			MaxToolCalls: opts.MaxToolCalls,
+			ExtraArgs:    opts.ExtraArgs,
+			Task: &providers.Task{
+				Instructions: fmt.Sprintf(addScopeInstructions, hint),
+				Input:        subject,
+			},
+		})
+		fixed = commit.Subject(commit.Sanitize(fixed, opts.StripPatterns))
+		scope := commitlint.Parse(fixed).Scope
+		switch {
+		case err != nil:
+			fmt.Fprintf(ui.Status(), "adding a scope failed: %v\n", err)
+		case scope != "" && (opts.Scopes == nil || slices.Contains(candidates, scope)):
+			return commit.ReplaceSubject(message, fixed)
+		}
+	}
+	if len(candidates) == 1 {
+		return commit.ReplaceSubject(message, commit.SetScope(subject, candidates[0]))
+	}
+	return message
+}
+
+// scopeCandidates returns the scopes a required scope may take: the mapped
+// scope, the scopes of the scope map, or scopes derived from the changed
+// paths.
+func scopeCandidates(opts providers.Options) []string {
+	changes := git.ParseChanges(opts.Diff)
+	if opts.Diff == "" {
+		changes, _ = git.StagedChanges()
+	}
+	switch scope, allowed := opts.ScopeFor(changes); {
+	case scope != "":
+		return []string{scope}
+	case len(allowed) > 0:
+		return allowed
+	}
+	return commit.CandidateScopes(git.ChangedPaths(changes))
+}
+
+// Scope picker answers other than a scope.
+const (
+	pickNoScope  = "(no scope)"
+	pickAnyScope = "(let the backend decide)"
+)
+
+// scopePickerEnabled reports whether the scope picker may open: not with
+// GIT_AI_SCOPE_PICKER=off (environment, then .agentrc).
+func scopePickerEnabled(rc agentrc.Config) bool {
+	v := strings.ToLower(strings.TrimSpace(os.Getenv("GIT_AI_SCOPE_PICKER")))
+	if v == "" {
+		v = rc.ScopePicker
+	}
+	return v != "off" && v != "false"
+}
+
+// pickScope asks which scope the message gets when the staged changes span
+// several candidate scopes: those of the scope map, or the ones derived from
+// the changed paths. It returns the scope, "" with noScope set for a
+// message without one, or "" when the backend should decide (also when
+// there is nothing to pick or no terminal).
+func pickScope(opts providers.Options) (scope string, noScope bool) {
+	changes, err := git.StagedChanges()
+	if err != nil {
+		return "", false
+	}
+	scopeOf := commit.PathScope
+	if opts.Scopes != nil {
+		scopeOf = opts.Scopes.Scope
+	}
+	counts := commit.CountScopes(git.ChangedPaths(changes), scopeOf)
+	if len(counts) < 2 {
+		return "", false
+	}
+	options := make([]string, 0, len(counts)+2)
+	for _, c := range counts {
+		options = append(options, fmt.Sprintf("%s (%s)", c.Scope, plural(c.Files, "file")))
+	}
+	if opts.Scopes != nil && opts.Scopes.Fallback != "" {
+		options = append(options, opts.Scopes.Fallback+" (fallback)")
+	}
+	if opts.ScopePolicy != commit.ScopeRequired {
+		options = append(options, pickNoScope)
+	}
+	options = append(options, pickAnyScope)
+	i, err := ui.SelectOption("The staged changes span several scopes; pick one:", options)
+	if err != nil {
+		return "", false
+	}
+	switch choice := options[i]; {
+	case choice == pickNoScope:
+		return "", true
+	case choice == pickAnyScope:
+		return "", false
+	case i < len(counts):
+		return counts[i].Scope, false
+	}
+	return opts.Scopes.Fallback, false
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/semver"
+)
+
+type semverReport struct {
+	Range   string          `json:"range"`
+	Current string          `json:"current,omitempty"`
+	Next    string          `json:"next,omitempty"`
+	Bump    semver.Bump     `json:"bump"`
+	Commits []semver.Driver `json:"commits"`
+}
+
+// runSemver reports the next version bump implied by the conventional
+// commits in a range (default: latest tag..HEAD).
+func runSemver(args []string) int {
+	var (
+		format string
+		fs     = flag.NewFlagSet("semver", flag.ContinueOnError)
+	)
+	fs.StringVar(&format, "format", "text", "output format: text or json")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: git-cc-ai semver [--format text|json] [<range>]")
+		fs.PrintDefaults()
+	}
+	rest, err := parseArgs(fs, args, nil)
+	if err != nil || len(rest) > 1 || (format != "text" && format != "json") {
+		fs.Usage()
+		return 2
+	}
+
+	var report semverReport
+	if len(rest) == 1 {
+		report.Range = rest[0]
+	}
+	if report.Range == "" {
+		report.Range = "HEAD"
+		if tag := git.LatestTag("HEAD"); tag != "" {
+			report.Range = tag + "..HEAD"
+		}
+	}
+	if base, _, ok := strings.Cut(report.Range, ".."); ok {
+		report.Current = base
+	}
+
+	entries, err := git.Log(report.Range)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	report.Bump, report.Commits = semver.Analyze(entries)
+	if report.Commits == nil {
+		report.Commits = []semver.Driver{}
+	}
+	if v, ok := semver.ParseVersion(report.Current); ok {
+		report.Next = v.Apply(report.Bump).String()
+	}
+
+	if format == "json" {
+		_ = json.NewEncoder(os.Stdout).Encode(report)
+		return 0
+	}
+	fmt.Printf("bump: %s\n", report.Bump)
+	if report.Next != "" {
+		fmt.Printf("next: %s (from %s)\n", report.Next, report.Current)
+	}
+	for _, d := range report.Commits {
+		fmt.Printf("  %-5s %.12s %s\n", d.Bump, d.Hash, d.Header)
+	}
+	return 0
+}
@@ -0,0 +1,415 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/agentrc"
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/ndjson"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+	rpcCancelled      = -32800
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+// generateParams are the inputs of a "generate" request. Zero values fall
+// back to the same env/.agentrc resolution the CLI uses.
+type generateParams struct {
+	Diff      string `json:"diff,omitempty"`
+	Backend   string `json:"backend,omitempty"`
+	Model     string `json:"model,omitempty"`
+	ExtraNote string `json:"extraNote,omitempty"`
+	SkillPath string `json:"skillPath,omitempty"`
+	NoCC      *bool  `json:"noCC,omitempty"`
+	NoBody    *bool  `json:"noBody,omitempty"`
+	// Structured requests JSON output assembled locally (codex only).
+	Structured *bool `json:"structured,omitempty"`
+	// Template is the text of a message template (default:
+	// GIT_AI_TEMPLATE). File names are refused, so clients cannot read
+	// files on the server.
+	Template string `json:"template,omitempty"`
+	// Budget lowers the server's budget (GIT_AI_BUDGET); it cannot raise
+	// it.
+	Budget float64 `json:"budget,omitempty"`
+	// Temperature and Seed are honoured by backends with sampling controls.
+	Temperature *float64 `json:"temperature,omitempty"`
+	Seed        *int64   `json:"seed,omitempty"`
+	// ReasoningEffort is low, medium or high (default: GIT_AI_REASONING).
+	ReasoningEffort string `json:"reasoningEffort,omitempty"`
+}
+
+type generateResult struct {
+	Message string           `json:"message"`
+	Backend string           `json:"backend"`
+	Model   string           `json:"model"`
+	Usage   *providers.Usage `json:"usage,omitempty"`
+}
+
+type cancelParams struct {
+	ID json.RawMessage `json:"id"`
+}
+
+type modelsParams struct {
+	Backend string `json:"backend,omitempty"`
+}
+
+type modelsResult struct {
+	Backend string   `json:"backend"`
+	Default string   `json:"default"`
+	Models  []string `json:"models"`
+}
+
+type progressParams struct {
+	ID           json.RawMessage `json:"id"`
+	Phase        string          `json:"phase"`
+	DiffBytes    int             `json:"diffBytes,omitempty"`
+	Reasoning    string          `json:"reasoning,omitempty"`
+	OutputTokens int             `json:"outputTokens,omitempty"`
+	WaitSeconds  float64         `json:"waitSeconds,omitempty"`
+}
+
+// resolve turns request params into a backend and generation options,
+// applying the same env/.agentrc defaults as the CLI.
+func (p generateParams) resolve(rc agentrc.Config) (string, providers.Backend, providers.Options, error) {
+	name, b, err := resolveBackend(firstNonEmpty(p.Backend, os.Getenv("GIT_AI_BACKEND")), rc)
+	if err != nil {
+		return "", nil, providers.Options{}, err
+	}
+	model, err := matchModel(b.Models(), strings.TrimSpace(p.Model))
+	if err != nil {
+		return "", nil, providers.Options{}, err
+	}
+	noCC := rc.NoCC || strings.EqualFold(strings.TrimSpace(os.Getenv("GIT_AI_NO_CC")), "true")
+	if p.NoCC != nil {
+		noCC = *p.NoCC
+	}
+	subjectOnly := rc.NoBody || strings.EqualFold(strings.TrimSpace(os.Getenv("GIT_AI_NO_BODY")), "true")
+	if p.NoBody != nil {
+		subjectOnly = *p.NoBody
+	}
+	structured := rc.Structured || strings.EqualFold(strings.TrimSpace(os.Getenv("GIT_AI_STRUCTURED")), "true")
+	if p.Structured != nil {
+		structured = *p.Structured
+	}
+	tmpl, err := resolveTemplate("", rc)
+	if p.Template != "" {
+		tmpl, err = inlineTemplate(p.Template)
+	}
+	if err != nil {
+		return "", nil, providers.Options{}, err
+	}
+	if tmpl != nil {
+		structured = false
+	}
+	budget := clientBudget(p.Budget, resolveBudget(rc))
+	strip, err := stripPatterns(rc)
+	if err != nil {
+		return "", nil, providers.Options{}, err
+	}
+	reasoning := strings.ToLower(strings.TrimSpace(p.ReasoningEffort))
+	if reasoning == "" {
+		reasoning = resolveReasoning(rc)
+	} else if !slices.Contains(providers.ReasoningLevels, reasoning) {
+		return "", nil, providers.Options{}, fmt.Errorf("reasoningEffort %q is not one of %s", reasoning, strings.Join(providers.ReasoningLevels, ", "))
+	}
+	return name, b, providers.Options{
+		Diff:          p.Diff,
+		SkillPath:     p.SkillPath,
+		ExtraNote:     p.ExtraNote,
+		Model:         modelOrDefault(b, model),
+		NoCC:          noCC,
+		Budget:        budget,
+		MaxSubject:    resolveMaxSubject(0, rc),
+		Types:         resolveTypes(rc),
+		ScopePolicy:   resolveScopePolicy(rc),
+		SubjectOnly:   subjectOnly,
+		StripPatterns: strip,
+		Temperature:   p.Temperature,
+		Seed:          p.Seed,
+		Reasoning:     reasoning,
+		Structured:    structured,
+		Template:      tmpl,
+	}, nil
+}
+
+// stdioServer speaks newline-delimited JSON-RPC 2.0 over stdin/stdout so
+// editor plugins can keep one process alive across requests.
+type stdioServer struct {
+	rc      agentrc.Config
+	out     io.Writer
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	running map[string]func()
+	wg      sync.WaitGroup
+}
+
+func runServe(args []string) {
+	var (
+		stdio    bool
+		httpAddr string
+		fs       = flag.NewFlagSet("serve", flag.ExitOnError)
+	)
+	fs.BoolVar(&stdio, "stdio", false, "serve JSON-RPC 2.0 over stdin/stdout (one message per line)")
+	fs.StringVar(&httpAddr, "http", "", "serve POST /v1/commit-message on this address (e.g. :8080)")
+	_, _ = parseArgs(fs, args, nil)
+	if stdio == (httpAddr != "") {
+		fmt.Fprintln(os.Stderr, "serve: specify exactly one transport (--stdio or --http addr)")
+		os.Exit(2)
+	}
+
+	git.SetContext(resolveContext("", agentrc.Load(agentrcPath())))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if httpAddr != "" {
+		if err := serveHTTP(ctx, httpAddr, agentrc.Load(agentrcPath())); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+	srv := &stdioServer{
+		rc:      agentrc.Load(agentrcPath()),
+		out:     os.Stdout,
+		running: map[string]func(){},
+	}
+	if err := srv.serve(ctx, os.Stdin); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
+
+func (s *stdioServer) serve(ctx context.Context, in io.Reader) error {
+	defer s.wg.Wait()
+	defer s.cancelAll()
+	reader := ndjson.NewReader(in)
+	for line := range reader.Lines() {
+		s.handle(ctx, []byte(line))
+	}
+	return reader.Err()
+}
+
+func (s *stdioServer) handle(ctx context.Context, line []byte) {
+	var req rpcRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		s.replyError(nil, rpcParseError, err.Error())
+		return
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		s.replyError(req.ID, rpcInvalidRequest, "invalid request")
+		return
+	}
+	if len(req.ID) == 0 {
+		// A notification gets no response, so only cancel is worth acting
+		// on.
+		var p cancelParams
+		if req.Method == "cancel" && json.Unmarshal(req.Params, &p) == nil {
+			s.cancel(p.ID)
+		}
+		return
+	}
+	switch req.Method {
+	case "models":
+		var p modelsParams
+		if !s.decodeParams(req, &p) {
+			return
+		}
+		name, b, err := resolveBackend(firstNonEmpty(p.Backend, os.Getenv("GIT_AI_BACKEND")), s.rc)
+		if err != nil {
+			s.replyError(req.ID, rpcInvalidParams, err.Error())
+			return
+		}
+		s.reply(req.ID, modelsResult{Backend: name, Default: b.DefaultModel(), Models: b.Models()})
+	case "generate":
+		var p generateParams
+		if !s.decodeParams(req, &p) {
+			return
+		}
+		s.generate(ctx, req.ID, p)
+	case "cancel":
+		var p cancelParams
+		if !s.decodeParams(req, &p) {
+			return
+		}
+		s.reply(req.ID, map[string]bool{"cancelled": s.cancel(p.ID)})
+	default:
+		s.replyError(req.ID, rpcMethodNotFound, "method not found: "+req.Method)
+	}
+}
+
+// cancel stops the running request id and reports whether there was one.
+func (s *stdioServer) cancel(id json.RawMessage) bool {
+	s.mu.Lock()
+	cancel, ok := s.running[string(id)]
+	s.mu.Unlock()
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+func (s *stdioServer) decodeParams(req rpcRequest, v any) bool {
+	if len(req.Params) == 0 {
+		return true
+	}
+	if err := json.Unmarshal(req.Params, v); err != nil {
+		s.replyError(req.ID, rpcInvalidParams, err.Error())
+		return false
+	}
+	return true
+}
+
+// generate runs a backend in the background so "cancel" requests can be
+// processed while it is in flight. Ids must be unique among the running
+// requests, or they could not be cancelled.
+func (s *stdioServer) generate(ctx context.Context, id json.RawMessage, p generateParams) {
+	// Requests are handled one at a time, so no other generate can take the
+	// id between this check and the registration below.
+	s.mu.Lock()
+	_, busy := s.running[string(id)]
+	s.mu.Unlock()
+	if busy {
+		s.replyError(id, rpcInvalidRequest, "request id "+string(id)+" is already running")
+		return
+	}
+	name, b, opts, err := p.resolve(s.rc)
+	if err != nil {
+		s.replyError(id, rpcInvalidParams, err.Error())
+		return
+	}
+
+	var (
+		reg         providers.Registry
+		key         = string(id)
+		runCtx, end = context.WithCancel(ctx)
+	)
+	cancel := func() {
+		reg.ForwardSignal(os.Interrupt)
+		end()
+	}
+	s.mu.Lock()
+	s.running[key] = cancel
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(s.running, key)
+			s.mu.Unlock()
+			end()
+		}()
+		var usage *providers.Usage
+		opts.OnUsage = func(u providers.Usage) { usage = &u }
+		opts.OnProgress = func(pr providers.Progress) {
+			s.notify("progress", progressParams{
+				ID:           id,
+				Phase:        pr.Phase,
+				DiffBytes:    pr.DiffBytes,
+				Reasoning:    pr.Reasoning,
+				OutputTokens: pr.OutputTokens,
+				WaitSeconds:  pr.Wait.Seconds(),
+			})
+		}
+		message, genErr := b.Generate(runCtx, &reg, opts)
+		switch {
+		case runCtx.Err() != nil:
+			s.replyError(id, rpcCancelled, "request cancelled")
+		case genErr != nil:
+			s.replyError(id, rpcInternalError, genErr.Error())
+		default:
+			message = enforcePolicies(runCtx, nil, nil, opts, message, opts.MaxSubject)
+			s.reply(id, generateResult{Message: strings.TrimSpace(message), Backend: name, Model: opts.Model, Usage: usage})
+		}
+	}()
+}
+
+func (s *stdioServer) cancelAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, cancel := range s.running {
+		cancel()
+	}
+}
+
+func (s *stdioServer) reply(id json.RawMessage, result any) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		s.replyError(id, rpcInternalError, err.Error())
+		return
+	}
+	s.write(rpcResponse{JSONRPC: "2.0", ID: id, Result: data})
+}
+
+func (s *stdioServer) replyError(id json.RawMessage, code int, message string) {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	s.write(rpcResponse{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: message}})
+}
+
+func (s *stdioServer) notify(method string, params any) {
+	s.write(rpcNotification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (s *stdioServer) write(v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_, _ = s.out.Write(append(data, '\n'))
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
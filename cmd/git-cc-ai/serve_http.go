@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/agentrc"
+	"github.com/dlnilsson/git-cc-ai/pkg/commit"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+)
+
+// maxRequestBytes bounds the JSON body of a commit-message request.
+const maxRequestBytes = 4 << 20
+
+type httpResponse struct {
+	Message string           `json:"message"`
+	Backend string           `json:"backend"`
+	Model   string           `json:"model"`
+	Usage   *providers.Usage `json:"usage,omitempty"`
+}
+
+type httpError struct {
+	Error string `json:"error"`
+}
+
+// serveHTTP exposes POST /v1/commit-message for clients without their own
+// provider credentials. Requests must carry one of the bearer tokens listed
+// in GIT_AI_SERVE_TOKENS (comma-separated).
+func serveHTTP(ctx context.Context, addr string, rc agentrc.Config) error {
+	var tokens []string
+	for t := range strings.SplitSeq(os.Getenv("GIT_AI_SERVE_TOKENS"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tokens = append(tokens, t)
+		}
+	}
+	if len(tokens) == 0 {
+		return errors.New("serve --http requires GIT_AI_SERVE_TOKENS to be set")
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /v1/commit-message", requireToken(tokens, commitMessageHandler(rc)))
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	fmt.Fprintf(os.Stderr, "git-cc-ai listening on %s\n", addr)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func requireToken(tokens []string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if ok {
+			for _, t := range tokens {
+				if subtle.ConstantTimeCompare([]byte(got), []byte(t)) == 1 {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+		}
+		writeJSON(w, http.StatusUnauthorized, httpError{Error: "unauthorized"})
+	})
+}
+
+func commitMessageHandler(rc agentrc.Config) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p generateParams
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&p); err != nil {
+			writeJSON(w, http.StatusBadRequest, httpError{Error: err.Error()})
+			return
+		}
+		if strings.TrimSpace(p.Diff) == "" {
+			writeJSON(w, http.StatusBadRequest, httpError{Error: "diff is required"})
+			return
+		}
+		// Local paths are meaningless to remote clients.
+		p.SkillPath = ""
+		name, b, opts, err := p.resolve(rc)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, httpError{Error: err.Error()})
+			return
+		}
+
+		var (
+			reg   providers.Registry
+			usage *providers.Usage
+		)
+		opts.OnUsage = func(u providers.Usage) { usage = &u }
+		message, err := b.Generate(r.Context(), &reg, opts)
+		if err != nil {
+			writeJSON(w, http.StatusBadGateway, httpError{Error: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, httpResponse{
+			Message: commit.StripComments(enforcePolicies(r.Context(), nil, nil, opts, message, opts.MaxSubject)),
+			Backend: name,
+			Model:   opts.Model,
+			Usage:   usage,
+		})
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/agentrc"
+)
+
+func TestRequireToken(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"missing", "", http.StatusUnauthorized},
+		{"wrong", "Bearer nope", http.StatusUnauthorized},
+		{"not bearer", "secret", http.StatusUnauthorized},
+		{"correct", "Bearer secret", http.StatusNoContent},
+		{"second token", "Bearer other", http.StatusNoContent},
+	}
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	h := requireToken([]string{"secret", "other"}, next)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			req := httptest.NewRequest(http.MethodPost, "/v1/commit-message", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommitMessageHandlerRejects(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"empty diff", `{"diff":"  \n"}`, "diff is required"},
+		{"no diff", `{}`, "diff is required"},
+		{"too large", `{"diff":"` + strings.Repeat("x", maxRequestBytes) + `"}`, "request body too large"},
+		{"not json", `diff`, "invalid character"},
+	}
+	h := commitMessageHandler(agentrc.Config{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/commit-message", strings.NewReader(tt.body)))
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+			}
+			if !strings.Contains(rec.Body.String(), tt.want) {
+				t.Fatalf("body = %q, want it to mention %q", rec.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestClientBudget(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name              string
+		requested, server float64
+		want              float64
+	}{
+		{"none", 0, 0, 0},
+		{"server only", 0, 2, 2},
+		{"lower than server", 0.5, 2, 0.5},
+		{"higher than server", 5, 2, 2},
+		{"lower than default", 0.25, 0, 0.25},
+		{"higher than default", 5, 0, 0},
+		{"negative", -1, 2, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := clientBudget(tt.requested, tt.server); got != tt.want {
+				t.Fatalf("clientBudget(%v, %v) = %v, want %v", tt.requested, tt.server, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/agentrc"
+)
+
+// serveLines runs the stdio server over input and returns the decoded
+// response lines.
+func serveLines(t *testing.T, input string) []rpcResponse {
+	t.Helper()
+	return serveWith(t, &stdioServer{rc: agentrc.Config{}, running: map[string]func(){}}, input)
+}
+
+// serveWith runs srv over input and returns the decoded response lines.
+func serveWith(t *testing.T, srv *stdioServer, input string) []rpcResponse {
+	t.Helper()
+	var out bytes.Buffer
+	srv.out = &out
+	if err := srv.serve(context.Background(), strings.NewReader(input)); err != nil {
+		t.Fatalf("serve: %v", err)
+	}
+	var responses []rpcResponse
+	for line := range strings.SplitSeq(strings.TrimSuffix(out.String(), "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		var resp rpcResponse
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Fatalf("response %q is not one JSON object per line: %v", line, err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+func TestStdioServerErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		line   string
+		wantID string
+		code   int
+	}{
+		{"malformed json", `{"jsonrpc":"2.0",`, "null", rpcParseError},
+		{"not an object", `[1,2]`, "null", rpcParseError},
+		{"wrong version", `{"jsonrpc":"1.0","id":1,"method":"models"}`, "1", rpcInvalidRequest},
+		{"missing method", `{"jsonrpc":"2.0","id":2}`, "2", rpcInvalidRequest},
+		{"unknown method", `{"jsonrpc":"2.0","id":"a","method":"nope"}`, `"a"`, rpcMethodNotFound},
+		{"params of the wrong type", `{"jsonrpc":"2.0","id":3,"method":"generate","params":[]}`, "3", rpcInvalidParams},
+		{"unknown backend", `{"jsonrpc":"2.0","id":4,"method":"models","params":{"backend":"nope"}}`, "4", rpcInvalidParams},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			responses := serveLines(t, tt.line+"\n")
+			if len(responses) != 1 {
+				t.Fatalf("got %d responses, want 1", len(responses))
+			}
+			resp := responses[0]
+			if resp.JSONRPC != "2.0" || string(resp.ID) != tt.wantID {
+				t.Fatalf("response envelope = %q id %s, want 2.0 id %s", resp.JSONRPC, resp.ID, tt.wantID)
+			}
+			if resp.Error == nil || resp.Error.Code != tt.code {
+				t.Fatalf("error = %+v, want code %d", resp.Error, tt.code)
+			}
+			if resp.Result != nil {
+				t.Fatalf("error response carries a result: %s", resp.Result)
+			}
+		})
+	}
+}
+
+func TestStdioServerFraming(t *testing.T) {
+	t.Parallel()
+
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"cancel","params":{"id":42}}`,
+		``,
+		`{"jsonrpc":"2.0","id":2,"method":"nope"}` + "\r",
+		`   `,
+		`{"jsonrpc":"2.0","id":3,"method":"cancel","params":{"id":"x"}}`,
+	}, "\n")
+	responses := serveLines(t, input)
+	if len(responses) != 3 {
+		t.Fatalf("got %d responses, want one per request: %+v", len(responses), responses)
+	}
+	for i, want := range []string{"1", "2", "3"} {
+		if string(responses[i].ID) != want {
+			t.Fatalf("response %d has id %s, want %s", i, responses[i].ID, want)
+		}
+	}
+	if got := string(responses[0].Result); got != `{"cancelled":false}` {
+		t.Fatalf("cancel of an unknown request = %s, want cancelled false", got)
+	}
+	if responses[1].Error == nil || responses[1].Error.Code != rpcMethodNotFound {
+		t.Fatalf("CRLF line was not handled: %+v", responses[1])
+	}
+}
+
+func TestStdioServerNotifications(t *testing.T) {
+	t.Parallel()
+
+	cancelled := false
+	srv := &stdioServer{rc: agentrc.Config{}, running: map[string]func(){"7": func() { cancelled = true }}}
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","method":"generate","params":{"backend":"nope"}}`,
+		`{"jsonrpc":"2.0","method":"models"}`,
+		`{"jsonrpc":"2.0","method":"cancel","params":{"id":7}}`,
+	}, "\n")
+	if responses := serveWith(t, srv, input); len(responses) != 0 {
+		t.Fatalf("notifications were answered: %+v", responses)
+	}
+	if !cancelled {
+		t.Fatal("cancel notification did not cancel request 7")
+	}
+}
+
+func TestStdioServerDuplicateID(t *testing.T) {
+	t.Parallel()
+
+	cancelled := false
+	srv := &stdioServer{rc: agentrc.Config{}, running: map[string]func(){"7": func() { cancelled = true }}}
+	responses := serveWith(t, srv, `{"jsonrpc":"2.0","id":7,"method":"generate","params":{"backend":"nope"}}`)
+	if len(responses) != 1 || responses[0].Error == nil || responses[0].Error.Code != rpcInvalidRequest {
+		t.Fatalf("responses = %+v, want one invalid request error", responses)
+	}
+	if string(responses[0].ID) != "7" {
+		t.Fatalf("response id = %s, want 7", responses[0].ID)
+	}
+	// serve cancels what is still running when the input ends; the first
+	// request 7 must still be registered for that.
+	if !cancelled {
+		t.Fatal("the running request 7 lost its cancel func")
+	}
+}
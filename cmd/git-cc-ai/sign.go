@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/commit"
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+)
+
+// signArgs returns the git commit signing options for -S (sign with the
+// default key) and --gpg-sign=<keyid>. Without either, commit.gpgsign
+// decides.
+func signArgs(sign bool, keyID string) []string {
+	switch {
+	case keyID != "":
+		return []string{"--gpg-sign=" + keyID}
+	case sign:
+		return []string{"--gpg-sign"}
+	}
+	return nil
+}
+
+// gitSignSpellings rewrites git commit's spellings of the signing flags,
+// up to "--", to the ones the flag package reads: "-S<keyid>" becomes
+// "--gpg-sign=<keyid>" and "--gpg-sign" without a key becomes "-S".
+func gitSignSpellings(args []string) []string {
+	out := make([]string, 0, len(args))
+	for i, arg := range args {
+		if arg == "--" {
+			return append(out, args[i:]...)
+		}
+		switch {
+		case arg == "--gpg-sign", arg == "-gpg-sign":
+			arg = "-S"
+		case len(arg) > 2 && strings.HasPrefix(arg, "-S") && arg[2] != '=':
+			arg = "--gpg-sign=" + arg[2:]
+		}
+		out = append(out, arg)
+	}
+	return out
+}
+
+// withSignoff adds the committer's Signed-off-by trailer to message.
+func withSignoff(message string) (string, error) {
+	trailer, err := git.SignoffTrailer()
+	if err != nil {
+		return "", err
+	}
+	return commit.AddTrailer(message, trailer), nil
+}
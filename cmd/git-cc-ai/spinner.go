@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/agentrc"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+// applySpinner configures the spinner from GIT_AI_SPINNER and
+// GIT_AI_SPINNER_MESSAGE (environment or .agentrc). An unknown mode is
+// reported and the default is used.
+func applySpinner(rc agentrc.Config) {
+	mode := strings.ToLower(firstNonEmpty(os.Getenv("GIT_AI_SPINNER"), rc.Spinner))
+	if mode != "" && !slices.Contains(ui.SpinnerModes, mode) {
+		fmt.Fprintf(ui.Status(), "warning: GIT_AI_SPINNER %q is not one of %s; using %q\n", mode, strings.Join(ui.SpinnerModes, ", "), ui.SpinnerFun)
+	}
+	messages := rc.SpinnerMessages
+	if msg := strings.TrimSpace(os.Getenv("GIT_AI_SPINNER_MESSAGE")); msg != "" {
+		messages = []string{msg}
+	}
+	ui.SetSpinner(mode, messages)
+}
+
+// applyTheme turns colors off for NO_COLOR or CLICOLOR=0 and sets the
+// theme from GIT_AI_COLOR_ACCENT and GIT_AI_COLOR_REASONING (environment
+// or .agentrc). Invalid colors are reported and the default is used.
+func applyTheme(rc agentrc.Config) {
+	ui.SetColor(ui.DetectColor())
+	t := ui.Theme{
+		Accent:    strings.TrimSpace(firstNonEmpty(os.Getenv("GIT_AI_COLOR_ACCENT"), rc.ColorAccent)),
+		Reasoning: strings.TrimSpace(firstNonEmpty(os.Getenv("GIT_AI_COLOR_REASONING"), rc.ColorReasoning)),
+	}
+	for _, c := range []configValue{{Key: "GIT_AI_COLOR_ACCENT", Value: t.Accent}, {Key: "GIT_AI_COLOR_REASONING", Value: t.Reasoning}} {
+		if c.Value != "" && !ui.ValidColor(c.Value) {
+			fmt.Fprintf(ui.Status(), "warning: %s %q is not an ANSI color number or hex color; using the default\n", c.Key, c.Value)
+		}
+	}
+	ui.SetTheme(t)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/agentrc"
+	"github.com/dlnilsson/git-cc-ai/pkg/metrics"
+)
+
+// metricsEnabled reports whether local metrics are opted in via
+// GIT_AI_METRICS or .agentrc.
+func metricsEnabled(rc agentrc.Config) bool {
+	return strings.EqualFold(strings.TrimSpace(os.Getenv("GIT_AI_METRICS")), "true") || rc.Metrics
+}
+
+func agentrcLoad() agentrc.Config {
+	return agentrc.Load(agentrcPath())
+}
+
+// recordMetrics appends a generation event when metrics are enabled.
+// Failures are reported but never fail the run.
+func recordMetrics(rc agentrc.Config, e metrics.Event) {
+	if !metricsEnabled(rc) || e.Tree == "" {
+		return
+	}
+	if err := metrics.Append(e); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to record metrics: %v\n", err)
+	}
+}
+
+// generationEvent builds the metrics event for a generated message.
+func generationEvent(backend, model, tree, message string, latency time.Duration, regenerations int) metrics.Event {
+	subject, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+	return metrics.Event{
+		Kind:          metrics.KindGenerate,
+		Backend:       backend,
+		Model:         model,
+		Tree:          tree,
+		LatencyMS:     latency.Milliseconds(),
+		Regenerations: regenerations,
+		MessageHash:   metrics.Hash(message),
+		SubjectHash:   metrics.Hash(subject),
+	}
+}
+
+// runStats implements "git-cc-ai stats": acceptance rate, latency and
+// regenerations per backend/model from the local metrics file.
+func runStats(args []string) int {
+	var (
+		format string
+		fs     = flag.NewFlagSet("stats", flag.ContinueOnError)
+	)
+	fs.StringVar(&format, "format", "text", "output format: text or json")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: git-cc-ai stats [--format text|json]")
+		fs.PrintDefaults()
+	}
+	if rest, err := parseArgs(fs, args, nil); err != nil || len(rest) > 0 || (format != "text" && format != "json") {
+		fs.Usage()
+		return 2
+	}
+
+	events, err := metrics.Read()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	summary := metrics.Summarize(events)
+	if format == "json" {
+		_ = json.NewEncoder(os.Stdout).Encode(summary)
+		return 0
+	}
+	if len(summary) == 0 {
+		fmt.Fprintln(os.Stderr, "no metrics recorded yet; set GIT_AI_METRICS=true and run git-cc-ai hook install")
+		return 0
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "BACKEND\tMODEL\tGENERATED\tCOMMITTED\tACCEPTED\tEDITED\tAVG LATENCY\tREGENERATIONS")
+	for _, s := range summary {
+		accepted := "-"
+		if s.Commits > 0 {
+			accepted = fmt.Sprintf("%.0f%%", s.AcceptRate*100)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%d\t%.1fs\t%d\n",
+			s.Backend, s.Model, s.Generations, s.Commits, accepted, s.Edited,
+			float64(s.AvgLatencyMS)/1000, s.Regenerations)
+	}
+	_ = w.Flush()
+	return 0
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/agentrc"
+	"github.com/dlnilsson/git-cc-ai/pkg/commit"
+	"github.com/dlnilsson/git-cc-ai/pkg/commitlint"
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/scopes"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+const shortenSubjectInstructions = `Shorten the Git commit subject line below to at most %d characters.
+Keep any Conventional Commits type, scope and "!" prefix exactly as it is, keep the imperative mood and do not end with a period.
+Output only the new subject line.`
+
+// resolveMaxSubject returns the subject length limit: the --max-subject
+// flag, then GIT_AI_MAX_SUBJECT, then .agentrc, then the default.
+func resolveMaxSubject(flagValue int, rc agentrc.Config) int {
+	if flagValue > 0 {
+		return flagValue
+	}
+	if v, err := strconv.Atoi(strings.TrimSpace(os.Getenv("GIT_AI_MAX_SUBJECT"))); err == nil && v > 0 {
+		return v
+	}
+	if rc.MaxSubject > 0 {
+		return rc.MaxSubject
+	}
+	return commit.DefaultMaxSubject
+}
+
+// lintConfig returns the default commitlint rules with the header length
+// limit set to maxSubject, the types restricted to types when set, the
+// scope required or forbidden by scopePolicy and, when the repository has a
+// scope map, the scope restricted to its scopes.
+func lintConfig(maxSubject int, types []string, scopePolicy string, scopeMap *scopes.Map) commitlint.Config {
+	cfg := commitlint.DefaultConfig()
+	rule := cfg["header-max-length"]
+	rule.Limit = maxSubject
+	cfg["header-max-length"] = rule
+	if len(types) > 0 {
+		cfg["type-enum"] = commitlint.RuleConfig{Severity: commitlint.SeverityError, Values: types}
+	}
+	switch scopePolicy {
+	case commit.ScopeRequired:
+		cfg["scope-empty"] = commitlint.RuleConfig{Severity: commitlint.SeverityError, Condition: commitlint.ConditionNever}
+	case commit.ScopeForbidden:
+		cfg["scope-empty"] = commitlint.RuleConfig{Severity: commitlint.SeverityError, Condition: commitlint.ConditionAlways}
+		return cfg
+	}
+	if scopeMap != nil {
+		cfg["scope-enum"] = commitlint.RuleConfig{Severity: commitlint.SeverityError, Values: scopeMap.Allowed()}
+	}
+	return cfg
+}
+
+// loadScopes reads the repository's scope map. Returns nil (no error)
+// outside a repository or when the file does not exist.
+func loadScopes() (*scopes.Map, error) {
+	root, err := git.TopLevel()
+	if err != nil {
+		return nil, nil
+	}
+	return scopes.Load(filepath.Join(root, scopes.File))
+}
+
+// enforcePolicies applies the configured commit types, the scope policy and
+// the subject length limit to a generated message, in that order since the
+// first two may lengthen the subject.
+func enforcePolicies(ctx context.Context, reg *providers.Registry, b providers.Backend, opts providers.Options, message string, maxSubject int) string {
+	message = enforceType(ctx, reg, b, opts, message)
+	message = enforceScope(ctx, reg, b, opts, message)
+	return enforceSubject(ctx, reg, b, opts, message, maxSubject)
+}
+
+// enforceSubject shortens message's subject to maxSubject columns. When b
+// is set the backend is asked for a shorter subject first; otherwise, or
+// when its answer is still too long, the subject is truncated.
+func enforceSubject(ctx context.Context, reg *providers.Registry, b providers.Backend, opts providers.Options, message string, maxSubject int) string {
+	subject := commit.Subject(message)
+	if subject == "" || commit.SubjectWidth(subject) <= maxSubject {
+		return message
+	}
+	if b != nil {
+		short, err := b.Generate(ctx, reg, providers.Options{
+			Model:        opts.Model,
+			ShowSpinner:  opts.ShowSpinner,
+			Budget:       opts.Budget,
+			Tools:        opts.Tools,
+			MaxToolCalls: opts.MaxToolCalls,
+			ExtraArgs:    opts.ExtraArgs,
+			Task: &providers.Task{
+				Instructions: fmt.Sprintf(shortenSubjectInstructions, maxSubject),
+				Input:        subject,
+			},
+		})
+		short = commit.Subject(commit.Sanitize(short, opts.StripPatterns))
+		switch {
+		case err != nil:
+			fmt.Fprintf(ui.Status(), "shortening subject failed: %v\n", err)
+		case short != "" && commit.SubjectWidth(short) <= maxSubject:
+			return commit.ReplaceSubject(message, short)
+		}
+	}
+	return commit.ReplaceSubject(message, commit.TruncateSubject(subject, maxSubject))
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/agentrc"
+	"github.com/dlnilsson/git-cc-ai/pkg/commit"
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+)
+
+const tagMessageInstructions = `You write annotated Git tag messages.
+Using the commits below (already grouped by Conventional Commit type and scope), write the message for the tag named in the input.
+Start with a one-line summary of the release (at most 72 characters, no trailing period), then a blank line and a short list of the notable changes as "- " bullets, breaking changes first.
+Merge related commits, describe user-visible impact, and leave out purely internal noise.
+Output only the tag message, without Markdown headers or code fences.`
+
+// runTag drafts an annotated tag message from the commits since the
+// previous tag and optionally creates the tag with it.
+func runTag(args []string) int {
+	var (
+		from      string
+		to        string
+		create    bool
+		sign      bool
+		noSpinner bool
+		fs        = flag.NewFlagSet("tag", flag.ContinueOnError)
+	)
+	fs.StringVar(&from, "from", "", "previous tag (default: latest tag reachable from --to)")
+	fs.StringVar(&to, "to", "HEAD", "revision to tag")
+	fs.BoolVar(&create, "create", false, "create the annotated tag with git tag -a -F -")
+	fs.BoolVar(&sign, "s", false, "with --create, sign the tag (tag.gpgSign is honoured anyway)")
+	fs.BoolVar(&noSpinner, "no-spinner", false, "disable spinner while the backend runs")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: git-cc-ai tag [--from tag] [--to rev] [--create [-s]] <tag>")
+		fs.PrintDefaults()
+	}
+	rest, err := parseArgs(fs, args, nil)
+	if err != nil || len(rest) != 1 || (sign && !create) {
+		fs.Usage()
+		return 2
+	}
+	tag := rest[0]
+	if from == "" {
+		from = git.LatestTag(to)
+	}
+	revRange := to
+	if from != "" {
+		revRange = from + ".." + to
+	}
+
+	entries, err := git.Log(revRange)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	if len(entries) == 0 {
+		fmt.Fprintf(os.Stderr, "no commits in %s\n", revRange)
+		return 1
+	}
+
+	var input strings.Builder
+	fmt.Fprintf(&input, "Tag: %s\nPrevious tag: %s\n\n", tag, firstNonEmpty(from, "(none)"))
+	input.WriteString(groupCommits(entries))
+
+	out, err := runTask(providers.Task{Instructions: tagMessageInstructions, Input: input.String()}, !noSpinner)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return exitCode(err)
+	}
+	strip, err := stripPatterns(agentrc.Load(agentrcPath()))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	message := commit.WrapMessage(commit.Sanitize(out, strip), commit.BodyLineWidth)
+	if !create {
+		fmt.Println(message)
+		return 0
+	}
+	if err = git.CreateTag(tag, to, message, sign); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "created tag %s: %s\n", tag, commit.Subject(message))
+	return 0
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/agentrc"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+)
+
+// runTask sends a free-form task to the configured backend, using the same
+// backend/model/budget resolution as commit generation.
+func runTask(task providers.Task, showSpinner bool) (string, error) {
+	rc := agentrc.Load(agentrcPath())
+	backend, b, err := resolveBackend(os.Getenv("GIT_AI_BACKEND"), rc)
+	if err != nil {
+		return "", err
+	}
+	model := preferredModel(backend, b, rc)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var registry providers.Registry
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	go func() {
+		for sig := range sigCh {
+			registry.ForwardSignal(sig)
+			registry.StopSpinnerIfSet()
+		}
+	}()
+
+	out, err := b.Generate(ctx, &registry, providers.Options{
+		Model:       model,
+		ShowSpinner: showSpinner,
+		Budget:      rc.Budget,
+		Tools:       resolveTools(rc),
+		ExtraArgs:   resolveExtraArgs(),
+		Task:        &task,
+	})
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(out) == "" {
+		return "", errors.New("backend returned empty response")
+	}
+	return strings.TrimSpace(out), nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/agentrc"
+	"github.com/dlnilsson/git-cc-ai/pkg/commit"
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+)
+
+// resolveTemplate returns the message template from --template, then
+// GIT_AI_TEMPLATE and .agentrc, or nil when none is set. The value is the
+// template itself or the path of a file holding it; relative paths are
+// tried from the working directory, then from the repository root.
+func resolveTemplate(value string, rc agentrc.Config) (*commit.Template, error) {
+	value = firstNonEmpty(value, os.Getenv("GIT_AI_TEMPLATE"), rc.Template)
+	if value == "" {
+		return nil, nil
+	}
+	text := value
+	if data, ok := readTemplateFile(value); ok {
+		text = string(data)
+	}
+	t, err := commit.ParseTemplate(text)
+	if err != nil {
+		return nil, fmt.Errorf("GIT_AI_TEMPLATE: %w", err)
+	}
+	return t, nil
+}
+
+// errTemplateFile is returned for a template from a serve request that
+// looks like a file name.
+var errTemplateFile = errors.New("template must be the template text, not a file name")
+
+// inlineTemplate parses value, a template sent by a serve client, without
+// ever reading it from a file as GIT_AI_TEMPLATE may be.
+func inlineTemplate(value string) (*commit.Template, error) {
+	if !strings.ContainsAny(value, "{\n") {
+		return nil, errTemplateFile
+	}
+	t, err := commit.ParseTemplate(value)
+	if err != nil {
+		return nil, fmt.Errorf("template: %w", err)
+	}
+	return t, nil
+}
+
+// readTemplateFile reads the template file named by value, if there is one.
+func readTemplateFile(value string) ([]byte, bool) {
+	if strings.ContainsAny(value, "{\n") {
+		return nil, false
+	}
+	candidates := []string{value}
+	if root, err := git.TopLevel(); err == nil && !filepath.IsAbs(value) {
+		candidates = append(candidates, filepath.Join(root, value))
+	}
+	for _, p := range candidates {
+		if data, err := os.ReadFile(p); err == nil {
+			return data, true
+		}
+	}
+	return nil, false
+}
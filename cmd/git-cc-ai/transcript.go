@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/commit"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/transcript"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+// transcriptRecorder collects the reasoning and raw backend events of a run
+// for --save-transcript. Backends call it from their reader goroutines.
+type transcriptRecorder struct {
+	mu sync.Mutex
+	t  transcript.Transcript
+}
+
+func newTranscriptRecorder(backend, model string) *transcriptRecorder {
+	return &transcriptRecorder{t: transcript.Transcript{Backend: backend, Model: model}}
+}
+
+// attach records the progress and event callbacks of opts, keeping any
+// listener already set (e.g. --progress json).
+func (r *transcriptRecorder) attach(opts *providers.Options) {
+	onProgress := opts.OnProgress
+	opts.OnProgress = func(p providers.Progress) {
+		if p.Phase == providers.PhaseReasoning {
+			r.mu.Lock()
+			r.t.AddReasoning(p.Reasoning)
+			r.mu.Unlock()
+		}
+		if onProgress != nil {
+			onProgress(p)
+		}
+	}
+	opts.OnEvent = func(line string) {
+		r.mu.Lock()
+		r.t.Events = append(r.t.Events, line)
+		r.mu.Unlock()
+	}
+}
+
+// save writes the transcript with the final message, or the error the run
+// failed with.
+func (r *transcriptRecorder) save(message string, runErr error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.t.Message = commit.StripComments(message)
+	r.t.Usage = usageNote(message)
+	if runErr != nil {
+		r.t.Error = runErr.Error()
+	}
+	if err := transcript.Save(r.t); err != nil {
+		fmt.Fprintf(ui.Status(), "failed to save the transcript: %v\n", err)
+	}
+}
+
+// runLast prints the transcript saved by the previous --save-transcript run.
+func runLast(args []string) int {
+	var (
+		events bool
+		fs     = flag.NewFlagSet("last", flag.ContinueOnError)
+	)
+	fs.BoolVar(&events, "events", false, "print the raw backend events (NDJSON) instead")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: git-cc-ai last [--events]")
+		fs.PrintDefaults()
+	}
+	if rest, err := parseArgs(fs, args, nil); err != nil || len(rest) > 0 {
+		fs.Usage()
+		return 2
+	}
+
+	t, err := transcript.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	if t == nil {
+		fmt.Fprintln(os.Stderr, "no transcript saved yet; run git-cc-ai --save-transcript first")
+		return 1
+	}
+	if events {
+		for _, line := range t.Events {
+			fmt.Println(line)
+		}
+		return 0
+	}
+	fmt.Print(t.Format())
+	return 0
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/commit"
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+const summarizeInstructions = `Summarize the staged git diff below for someone writing its commit message.
+Output one line per changed file in the form "path: summary", where the summary says in at most 25 words what changed and why it matters.
+Output only those lines.`
+
+// draftNote introduces the first-pass draft in the final stage's prompt.
+const draftNote = "A cheaper model drafted this message from the same summaries; keep what is accurate and improve the rest:\n"
+
+// runTwoStage generates the message in two passes: draftModel summarizes
+// each directory chunk of the diff and drafts a message from the
+// summaries, then opts.Model rewrites the draft from the summaries alone.
+// When both models are the same the draft is the final message.
+func runTwoStage(ctx context.Context, reg *providers.Registry, b providers.Backend, opts providers.Options, draftModel string, workers int) (string, error) {
+	chunks, err := diffChunks(opts)
+	if err != nil {
+		return "", err
+	}
+	summaries, err := summarizeChunks(ctx, b, opts, chunks, draftModel, workers)
+	if err != nil {
+		return "", err
+	}
+	opts.Summaries = summaries
+
+	draftOpts := opts
+	draftOpts.Model = draftModel
+	if draftModel == modelOrDefault(b, opts.Model) {
+		return b.Generate(ctx, reg, draftOpts)
+	}
+	draftOpts.OnSessionID = nil
+	draftOpts.OnUsage = nil
+	draft, err := b.Generate(ctx, reg, draftOpts)
+	if err != nil {
+		return "", err
+	}
+	opts.ExtraNote = joinNotes(opts.ExtraNote, draftNote+strings.TrimSpace(commit.StripComments(draft)))
+	return b.Generate(ctx, reg, opts)
+}
+
+// runParallelChunks summarizes the directory chunks of the diff with
+// draftModel, up to workers at a time, and writes the message from the
+// summaries in one final call with opts.Model. This replaces sending every
+// chunk through one session, which is slow for very large changes. A diff
+// with a single chunk is sent as is.
+func runParallelChunks(ctx context.Context, reg *providers.Registry, b providers.Backend, opts providers.Options, draftModel string, workers int) (string, error) {
+	chunks, err := diffChunks(opts)
+	if err != nil {
+		return "", err
+	}
+	if len(chunks) < 2 {
+		return b.Generate(ctx, reg, opts)
+	}
+	if opts.Summaries, err = summarizeChunks(ctx, b, opts, chunks, draftModel, workers); err != nil {
+		return "", err
+	}
+	return b.Generate(ctx, reg, opts)
+}
+
+// diffChunks returns the directory chunks of opts.Diff, or of the staged
+// diff when it is unset.
+func diffChunks(opts providers.Options) ([]git.DiffChunk, error) {
+	var (
+		chunks []git.DiffChunk
+		err    error
+	)
+	if opts.Diff != "" {
+		chunks = git.ChunkDiff(opts.Diff)
+	} else if chunks, err = git.DiffStagedChunks(); err != nil {
+		return nil, err
+	}
+	if len(chunks) == 0 {
+		return nil, providers.ErrNoStagedChanges
+	}
+	return chunks, nil
+}
+
+// summarizeChunks asks model for per-file summaries of every chunk, running
+// up to workers calls concurrently, and returns them joined in chunk order,
+// one file per line.
+func summarizeChunks(ctx context.Context, b providers.Backend, opts providers.Options, chunks []git.DiffChunk, model string, workers int) (string, error) {
+	var (
+		group     = make(registryGroup, len(chunks))
+		summaries = make([]string, len(chunks))
+		errs      = make([]error, len(chunks))
+		slots     = make(chan struct{}, max(workers, 1))
+		active    = make([]bool, len(chunks))
+		done      int
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+	)
+	// report marks chunk i running or not, counts it when summarized and
+	// shows which directories are in progress.
+	report := func(i int, running, summarized bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		active[i] = running
+		if summarized {
+			done++
+		}
+		var current []string
+		for j, ok := range active {
+			if ok {
+				current = append(current, chunks[j].Dir)
+			}
+		}
+		ui.SendSpinnerProgress(ui.SpinnerProgress{Done: done, Total: len(chunks), Label: "directories summarized", Current: strings.Join(current, ", ")})
+	}
+	for i := range group {
+		group[i] = &providers.Registry{}
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	go func() {
+		for sig := range sigCh {
+			group.ForwardSignal(sig)
+		}
+	}()
+
+	if opts.ShowSpinner {
+		stopSpinner := ui.StartSpinner(fmt.Sprintf("Summarizing %d directories...", len(chunks)), "+"+model, group)
+		defer stopSpinner()
+	}
+
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			report(i, true, false)
+			summaries[i], errs[i] = b.Generate(ctx, group[i], providers.Options{
+				Model:        model,
+				Budget:       opts.Budget,
+				Tools:        opts.Tools,
+				MaxToolCalls: opts.MaxToolCalls,
+				ExtraArgs:    opts.ExtraArgs,
+				Reasoning:    providers.ReasoningLow,
+				Task: &providers.Task{
+					Instructions: summarizeInstructions,
+					Input:        chunk.Diff,
+				},
+			})
+			report(i, false, errs[i] == nil)
+		}()
+	}
+	wg.Wait()
+
+	var out strings.Builder
+	for i, summary := range summaries {
+		if errs[i] != nil {
+			return "", errs[i]
+		}
+		out.WriteString(strings.TrimSpace(summary))
+		out.WriteByte('\n')
+	}
+	return out.String(), nil
+}
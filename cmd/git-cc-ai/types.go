@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/agentrc"
+	"github.com/dlnilsson/git-cc-ai/pkg/commit"
+	"github.com/dlnilsson/git-cc-ai/pkg/commitlint"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+const fixTypeInstructions = `Rewrite the Conventional Commits subject line below so that its type is one of: %s.
+Pick the type that best describes the change, keep the scope, any "!" and the description exactly as they are.
+Output only the new subject line.`
+
+// resolveTypes returns the allowed commit types: GIT_AI_TYPES, then
+// .agentrc. Nil means the commitlint defaults apply and the prompt does not
+// restrict the type.
+func resolveTypes(rc agentrc.Config) []string {
+	if types := agentrc.ParseList(os.Getenv("GIT_AI_TYPES")); len(types) > 0 {
+		return types
+	}
+	return rc.Types
+}
+
+// enforceType makes the type of message one of opts.Types. A type that
+// only differs in case is lowered; otherwise, when b is set, the backend is
+// asked to pick an allowed type for the subject. A message that still uses
+// another type is returned unchanged for the linter to report.
+func enforceType(ctx context.Context, reg *providers.Registry, b providers.Backend, opts providers.Options, message string) string {
+	if opts.NoCC || len(opts.Types) == 0 {
+		return message
+	}
+	subject := commit.Subject(message)
+	typ := commitlint.Parse(subject).Type
+	if typ == "" || slices.Contains(opts.Types, typ) {
+		return message
+	}
+	if lower := strings.ToLower(typ); slices.Contains(opts.Types, lower) {
+		return commit.ReplaceSubject(message, lower+strings.TrimPrefix(subject, typ))
+	}
+	if b == nil {
+		return message
+	}
+	fixed, err := b.Generate(ctx, reg, providers.Options{
+		Model:        opts.Model,
+		ShowSpinner:  opts.ShowSpinner,
+		Budget:       opts.Budget,
+		Tools:        opts.Tools,
+		MaxToolCalls: opts.MaxToolCalls,
+		ExtraArgs:    opts.ExtraArgs,
+		Task: &providers.Task{
+			Instructions: fmt.Sprintf(fixTypeInstructions, strings.Join(opts.Types, ", ")),
+			Input:        subject,
+		},
+	})
+	fixed = commit.Subject(commit.Sanitize(fixed, opts.StripPatterns))
+	switch {
+	case err != nil:
+		fmt.Fprintf(ui.Status(), "fixing the commit type failed: %v\n", err)
+	case slices.Contains(opts.Types, commitlint.Parse(fixed).Type):
+		return commit.ReplaceSubject(message, fixed)
+	}
+	return message
+}
@@ -2,18 +2,177 @@ package agentrc
 
 import (
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 )
 
+const (
+	// FileName is the name of the config file in the repository root.
+	FileName = ".agentrc"
+	// ModelKeyPrefix starts the keys that set the model of one backend,
+	// such as GIT_AI_MODEL_CLAUDE.
+	ModelKeyPrefix = "GIT_AI_MODEL_"
+	// ExtraArgsKeyPrefix starts the keys that pass extra CLI flags to one
+	// backend, such as GIT_AI_EXTRA_ARGS_CODEX. Like GIT_AI_KEY_CMD they
+	// are only read from the environment, so a cloned repository cannot
+	// set them.
+	ExtraArgsKeyPrefix = "GIT_AI_EXTRA_ARGS_"
+)
+
 // Config holds values parsed from a .agentrc file.
 type Config struct {
-	SessionID string
-	Backend   string
-	Model     string
-	NoCC      bool
-	NoSession bool
-	Budget    float64 // GIT_AI_BUDGET — max spend in USD (0 means unset)
+	SessionID       string
+	GeminiSessionID string
+	Backend         string
+	Model           string
+	NoCC            bool
+	NoSession       bool
+	NoGeminiResume  bool
+	Risk            bool
+	NoBody          bool
+	Metrics         bool    // GIT_AI_METRICS — record local usage metrics
+	Offline         bool    // GIT_AI_OFFLINE — refuse backends that use the network
+	Feedback        bool    // GIT_AI_FEEDBACK — store edits to generated messages
+	Gerrit          bool    // GIT_AI_GERRIT — give generated messages a Gerrit Change-Id
+	Attribution     bool    // GIT_AI_ATTRIBUTION — add an AI-assistance trailer
+	Personalize     bool    // GIT_AI_PERSONALIZE — feed recent edit patterns into the prompt
+	Structured      bool    // GIT_AI_STRUCTURED — request the message as structured JSON
+	Budget          float64 // GIT_AI_BUDGET — max spend in USD (0 means unset)
+	MaxSubject      int     // GIT_AI_MAX_SUBJECT — subject length limit (0 means unset)
+	Reasoning       string  // GIT_AI_REASONING — reasoning effort: low, medium or high
+	Usage           string  // GIT_AI_USAGE — where the usage trailer goes: comments, stderr, notes or off
+	RateLimitWait   string  // GIT_AI_RATE_LIMIT_WAIT — total wait for rate limits before giving up
+	StallTimeout    string  // GIT_AI_STALL_TIMEOUT — how long a backend may run without output
+	// BackendModels maps a lower-case backend name to its model from the
+	// GIT_AI_MODEL_<BACKEND> keys.
+	BackendModels map[string]string
+	// StripPatterns collects every GIT_AI_STRIP_PATTERN line: regexps for
+	// extra boilerplate lines to drop from generated messages.
+	StripPatterns []string
+	// Footers collects every GIT_AI_FOOTER line: footer templates such as
+	// "Change-Id: {gen}" added to generated messages.
+	Footers []string
+	// FooterOrder is GIT_AI_FOOTER_ORDER: footer tokens in the order
+	// footers are written, "*" standing for the others.
+	FooterOrder []string
+	// AttributionTrailer is GIT_AI_ATTRIBUTION_TRAILER, the trailer
+	// template GIT_AI_ATTRIBUTION adds instead of the default.
+	AttributionTrailer string
+	// Types lists the allowed commit types from GIT_AI_TYPES; nil keeps
+	// the commitlint defaults.
+	Types []string
+	// Scope is the scope policy from GIT_AI_SCOPE: optional, required or
+	// forbidden.
+	Scope string
+	// ScopePicker is GIT_AI_SCOPE_PICKER: "off" keeps the scope picker
+	// from opening.
+	ScopePicker string
+	// Template is the message template from GIT_AI_TEMPLATE: the template
+	// itself, with "\n" for newlines, or the path of a file holding it.
+	Template string
+	// Context is the diff context strategy from GIT_AI_CONTEXT: hunk, auto
+	// or function.
+	Context string
+	// Formatting is GIT_AI_FORMATTING: local, note or off.
+	Formatting string
+	// Spinner is the spinner personality from GIT_AI_SPINNER: fun,
+	// professional or off.
+	Spinner string
+	// SpinnerMessages collects every GIT_AI_SPINNER_MESSAGE line: custom
+	// status messages shown instead of the built-in ones.
+	SpinnerMessages []string
+	// ColorAccent and ColorReasoning are the theme colors from
+	// GIT_AI_COLOR_ACCENT and GIT_AI_COLOR_REASONING: ANSI color numbers
+	// or hex values.
+	ColorAccent    string
+	ColorReasoning string
+	// Tools is GIT_AI_TOOLS: none, all or a list of tools the model may
+	// use.
+	Tools string
+	// DeepContext is GIT_AI_DEEP_CONTEXT: let the model investigate the
+	// repository with read-only tools.
+	DeepContext bool
+	// MaxToolCalls is GIT_AI_MAX_TOOL_CALLS, the tool call limit of a run
+	// (0 means unset).
+	MaxToolCalls int
+}
+
+// Keys lists the keys Load understands.
+var Keys = []string{
+	"CLAUDE_SESSION_ID",
+	"GEMINI_SESSION_ID",
+	"GIT_AI_BACKEND",
+	"GIT_AI_MODEL",
+	"GIT_AI_NO_CC",
+	"GIT_AI_NO_SESSION",
+	"GIT_AI_NO_GEMINI_RESUME",
+	"GIT_AI_RISK",
+	"GIT_AI_NO_BODY",
+	"GIT_AI_BUDGET",
+	"GIT_AI_STRIP_PATTERN",
+	"GIT_AI_FOOTER",
+	"GIT_AI_FOOTER_ORDER",
+	"GIT_AI_MAX_SUBJECT",
+	"GIT_AI_METRICS",
+	"GIT_AI_OFFLINE",
+	"GIT_AI_FEEDBACK",
+	"GIT_AI_GERRIT",
+	"GIT_AI_ATTRIBUTION",
+	"GIT_AI_ATTRIBUTION_TRAILER",
+	"GIT_AI_PERSONALIZE",
+	"GIT_AI_REASONING",
+	"GIT_AI_STRUCTURED",
+	"GIT_AI_USAGE",
+	"GIT_AI_RATE_LIMIT_WAIT",
+	"GIT_AI_STALL_TIMEOUT",
+	"GIT_AI_TYPES",
+	"GIT_AI_SCOPE",
+	"GIT_AI_SCOPE_PICKER",
+	"GIT_AI_TEMPLATE",
+	"GIT_AI_CONTEXT",
+	"GIT_AI_FORMATTING",
+	"GIT_AI_SPINNER",
+	"GIT_AI_SPINNER_MESSAGE",
+	"GIT_AI_COLOR_ACCENT",
+	"GIT_AI_COLOR_REASONING",
+	"GIT_AI_TOOLS",
+	"GIT_AI_DEEP_CONTEXT",
+	"GIT_AI_MAX_TOOL_CALLS",
+}
+
+// Entry is one KEY=value assignment in a .agentrc file.
+type Entry struct {
+	Line  int // 1-based line number
+	Key   string
+	Value string
+}
+
+// Entries returns every assignment in the .agentrc file at path in file
+// order, including keys Load does not understand. Blank lines and comments
+// are skipped. Returns nil (no error) if the file does not exist.
+func Entries(path string) ([]Entry, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		entries = append(entries, Entry{Line: i + 1, Key: strings.TrimSpace(key), Value: strings.TrimSpace(value)})
+	}
+	return entries, nil
 }
 
 // Load reads a .agentrc file and returns its parsed configuration.
@@ -29,18 +188,132 @@ func Load(path string) Config {
 		if after, ok := cutEnvValue(line, "CLAUDE_SESSION_ID"); ok {
 			cfg.SessionID = strings.TrimSpace(after)
 		}
+		if after, ok := cutEnvValue(line, "GEMINI_SESSION_ID"); ok {
+			cfg.GeminiSessionID = strings.TrimSpace(after)
+		}
 		if after, ok := cutEnvValue(line, "GIT_AI_BACKEND"); ok {
 			cfg.Backend = strings.TrimSpace(after)
 		}
 		if after, ok := cutEnvValue(line, "GIT_AI_MODEL"); ok {
 			cfg.Model = strings.TrimSpace(after)
 		}
+		if backend, model, ok := cutBackendKey(line, ModelKeyPrefix); ok {
+			if cfg.BackendModels == nil {
+				cfg.BackendModels = map[string]string{}
+			}
+			cfg.BackendModels[backend] = model
+		}
 		if after, ok := cutEnvValue(line, "GIT_AI_NO_CC"); ok {
 			cfg.NoCC = strings.EqualFold(strings.TrimSpace(after), "true")
 		}
 		if after, ok := cutEnvValue(line, "GIT_AI_NO_SESSION"); ok {
 			cfg.NoSession = strings.EqualFold(strings.TrimSpace(after), "true")
 		}
+		if after, ok := cutEnvValue(line, "GIT_AI_NO_GEMINI_RESUME"); ok {
+			cfg.NoGeminiResume = strings.EqualFold(strings.TrimSpace(after), "true")
+		}
+		if after, ok := cutEnvValue(line, "GIT_AI_NO_BODY"); ok {
+			cfg.NoBody = strings.EqualFold(strings.TrimSpace(after), "true")
+		}
+		if after, ok := cutEnvValue(line, "GIT_AI_METRICS"); ok {
+			cfg.Metrics = strings.EqualFold(strings.TrimSpace(after), "true")
+		}
+		if after, ok := cutEnvValue(line, "GIT_AI_OFFLINE"); ok {
+			switch strings.ToLower(strings.TrimSpace(after)) {
+			case "1", "true", "yes":
+				cfg.Offline = true
+			}
+		}
+		if after, ok := cutEnvValue(line, "GIT_AI_FEEDBACK"); ok {
+			cfg.Feedback = strings.EqualFold(strings.TrimSpace(after), "true")
+		}
+		if after, ok := cutEnvValue(line, "GIT_AI_GERRIT"); ok {
+			cfg.Gerrit = strings.EqualFold(strings.TrimSpace(after), "true")
+		}
+		if after, ok := cutEnvValue(line, "GIT_AI_ATTRIBUTION"); ok {
+			cfg.Attribution = strings.EqualFold(strings.TrimSpace(after), "true")
+		}
+		if after, ok := cutEnvValue(line, "GIT_AI_ATTRIBUTION_TRAILER"); ok {
+			cfg.AttributionTrailer = strings.Trim(strings.TrimSpace(after), `"'`)
+		}
+		if after, ok := cutEnvValue(line, "GIT_AI_PERSONALIZE"); ok {
+			cfg.Personalize = strings.EqualFold(strings.TrimSpace(after), "true")
+		}
+		if after, ok := cutEnvValue(line, "GIT_AI_STRUCTURED"); ok {
+			cfg.Structured = strings.EqualFold(strings.TrimSpace(after), "true")
+		}
+		if after, ok := cutEnvValue(line, "GIT_AI_RISK"); ok {
+			cfg.Risk = strings.EqualFold(strings.TrimSpace(after), "true")
+		}
+		if after, ok := cutEnvValue(line, "GIT_AI_STRIP_PATTERN"); ok && strings.TrimSpace(after) != "" {
+			cfg.StripPatterns = append(cfg.StripPatterns, strings.TrimSpace(after))
+		}
+		if after, ok := cutEnvValue(line, "GIT_AI_FOOTER"); ok && strings.TrimSpace(after) != "" {
+			cfg.Footers = append(cfg.Footers, strings.TrimSpace(after))
+		}
+		if after, ok := cutEnvValue(line, "GIT_AI_FOOTER_ORDER"); ok {
+			cfg.FooterOrder = ParseList(after)
+		}
+		if after, ok := cutEnvValue(line, "GIT_AI_MAX_SUBJECT"); ok {
+			if v, err := strconv.Atoi(strings.TrimSpace(after)); err == nil && v > 0 {
+				cfg.MaxSubject = v
+			}
+		}
+		if after, ok := cutEnvValue(line, "GIT_AI_REASONING"); ok {
+			cfg.Reasoning = strings.ToLower(strings.TrimSpace(after))
+		}
+		if after, ok := cutEnvValue(line, "GIT_AI_USAGE"); ok {
+			cfg.Usage = strings.ToLower(strings.TrimSpace(after))
+		}
+		if after, ok := cutEnvValue(line, "GIT_AI_RATE_LIMIT_WAIT"); ok {
+			cfg.RateLimitWait = strings.TrimSpace(after)
+		}
+		if after, ok := cutEnvValue(line, "GIT_AI_STALL_TIMEOUT"); ok {
+			cfg.StallTimeout = strings.TrimSpace(after)
+		}
+		if after, ok := cutEnvValue(line, "GIT_AI_TYPES"); ok {
+			cfg.Types = ParseList(after)
+		}
+		if after, ok := cutEnvValue(line, "GIT_AI_SCOPE"); ok {
+			cfg.Scope = strings.ToLower(strings.TrimSpace(after))
+		}
+		if after, ok := cutEnvValue(line, "GIT_AI_SCOPE_PICKER"); ok {
+			cfg.ScopePicker = strings.ToLower(strings.TrimSpace(after))
+		}
+		if after, ok := cutEnvValue(line, "GIT_AI_TEMPLATE"); ok {
+			cfg.Template = strings.TrimSpace(after)
+		}
+		if after, ok := cutEnvValue(line, "GIT_AI_CONTEXT"); ok {
+			cfg.Context = strings.ToLower(strings.TrimSpace(after))
+		}
+		if after, ok := cutEnvValue(line, "GIT_AI_FORMATTING"); ok {
+			cfg.Formatting = strings.ToLower(strings.TrimSpace(after))
+		}
+		if after, ok := cutEnvValue(line, "GIT_AI_SPINNER"); ok {
+			cfg.Spinner = strings.ToLower(strings.TrimSpace(after))
+		}
+		if after, ok := cutEnvValue(line, "GIT_AI_SPINNER_MESSAGE"); ok {
+			if msg := strings.Trim(strings.TrimSpace(after), `"'`); msg != "" {
+				cfg.SpinnerMessages = append(cfg.SpinnerMessages, msg)
+			}
+		}
+		if after, ok := cutEnvValue(line, "GIT_AI_COLOR_ACCENT"); ok {
+			cfg.ColorAccent = strings.Trim(strings.TrimSpace(after), `"'`)
+		}
+		if after, ok := cutEnvValue(line, "GIT_AI_COLOR_REASONING"); ok {
+			cfg.ColorReasoning = strings.Trim(strings.TrimSpace(after), `"'`)
+		}
+		if after, ok := cutEnvValue(line, "GIT_AI_TOOLS"); ok {
+			cfg.Tools = strings.Trim(strings.TrimSpace(after), `"'`)
+		}
+		if after, ok := cutEnvValue(line, "GIT_AI_DEEP_CONTEXT"); ok {
+			cfg.DeepContext = strings.EqualFold(strings.TrimSpace(after), "true")
+		}
+		if after, ok := cutEnvValue(line, "GIT_AI_MAX_TOOL_CALLS"); ok {
+			if v, err := strconv.Atoi(strings.TrimSpace(after)); err == nil && v > 0 {
+				cfg.MaxToolCalls = v
+			}
+		}
 		if after, ok := cutEnvValue(line, "GIT_AI_BUDGET"); ok {
 			if v, err := strconv.ParseFloat(strings.TrimSpace(after), 64); err == nil && v > 0 {
 				cfg.Budget = v
@@ -50,6 +323,153 @@ func Load(path string) Config {
 	return cfg
 }
 
+// ParseList splits a list value, either comma-separated (feat,fix) or
+// written as an array (["feat", "fix"]), into its trimmed, unquoted items.
+func ParseList(value string) []string {
+	value = strings.TrimSpace(value)
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	var items []string
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.Trim(strings.TrimSpace(item), `"'`); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Set writes key=value into the .agentrc file at path, replacing an existing
+// assignment of key in place or appending an export line when none exists.
+// The file is created if it does not exist and replaced as a whole, keeping
+// its mode.
+func Set(path, key, value string) error {
+	var (
+		mode  os.FileMode = 0o644
+		lines []string
+		found bool
+	)
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if info, statErr := os.Stat(path); statErr == nil {
+			mode = info.Mode().Perm()
+		}
+		if trimmed := strings.TrimRight(string(data), "\n"); trimmed != "" {
+			lines = strings.Split(trimmed, "\n")
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if _, ok := cutEnvValue(trimmed, key); !ok {
+			continue
+		}
+		prefix := ""
+		if strings.HasPrefix(trimmed, "export ") {
+			prefix = "export "
+		}
+		lines[i] = prefix + key + "=" + value
+		found = true
+	}
+	if !found {
+		lines = append(lines, "export "+key+"="+value)
+	}
+	return writeFile(path, []byte(strings.Join(lines, "\n")+"\n"), mode)
+}
+
+// writeFile replaces the file at path with data through a temporary file
+// in the same directory, so a crash or a concurrent reader never sees it
+// half-written. A symlinked file is replaced at its target.
+func writeFile(path string, data []byte, mode os.FileMode) error {
+	if target, err := filepath.EvalSymlinks(path); err == nil {
+		path = target
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Chmod(mode); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// BackendModelKey returns the key that sets the model of backend.
+func BackendModelKey(backend string) string {
+	return ModelKeyPrefix + strings.ToUpper(backend)
+}
+
+// BackendExtraArgsKey returns the key that passes extra CLI flags to
+// backend.
+func BackendExtraArgsKey(backend string) string {
+	return ExtraArgsKeyPrefix + strings.ToUpper(backend)
+}
+
+// cutBackendKey parses a <prefix><BACKEND>=value line, such as
+// GIT_AI_MODEL_CLAUDE=claude-opus-4-6, into the lower-case backend name and
+// the value.
+func cutBackendKey(line, prefix string) (string, string, bool) {
+	line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+	rest, ok := strings.CutPrefix(line, prefix)
+	if !ok {
+		return "", "", false
+	}
+	backend, value, ok := strings.Cut(rest, "=")
+	if !ok || backend == "" {
+		return "", "", false
+	}
+	return strings.ToLower(strings.TrimSpace(backend)), strings.TrimSpace(value), true
+}
+
+// SplitArgs splits s into arguments at whitespace, as a shell would for
+// simple cases: single or double quotes group words and are removed.
+// A value wrapped in one pair of quotes as a whole, as .agentrc lines
+// often are, is unwrapped first.
+func SplitArgs(s string) []string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] && strings.Count(s, s[:1]) == 2 {
+		s = s[1 : len(s)-1]
+	}
+	var (
+		args  []string
+		arg   strings.Builder
+		quote rune
+		inArg bool
+	)
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			arg.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args
+}
+
 func cutEnvValue(line, key string) (string, bool) {
 	if afterExport, ok := strings.CutPrefix(line, "export "); ok {
 		line = strings.TrimSpace(afterExport)
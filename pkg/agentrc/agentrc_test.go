@@ -0,0 +1,90 @@
+package agentrc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		initial *string
+		mode    os.FileMode
+		want    string
+	}{
+		{
+			name:    "replaces export line",
+			initial: ptr("export GIT_AI_BACKEND=codex\nexport GEMINI_SESSION_ID=old\n"),
+			mode:    0o644,
+			want:    "export GIT_AI_BACKEND=codex\nexport GEMINI_SESSION_ID=new\n",
+		},
+		{
+			name:    "replaces bare assignment",
+			initial: ptr("GEMINI_SESSION_ID=old\nGIT_AI_NO_CC=true\n"),
+			mode:    0o644,
+			want:    "GEMINI_SESSION_ID=new\nGIT_AI_NO_CC=true\n",
+		},
+		{
+			name:    "appends to missing file",
+			initial: nil,
+			want:    "export GEMINI_SESSION_ID=new\n",
+		},
+		{
+			name:    "appends to existing file",
+			initial: ptr("# my settings\nexport GIT_AI_BACKEND=gemini"),
+			mode:    0o644,
+			want:    "# my settings\nexport GIT_AI_BACKEND=gemini\nexport GEMINI_SESSION_ID=new\n",
+		},
+		{
+			name:    "keeps other lines and the mode",
+			initial: ptr("# comment\n\nexport GIT_AI_MODEL=x  # keep\nexport GEMINI_SESSION_ID=old\nexport GEMINI_SESSION_ID_OTHER=y\n"),
+			mode:    0o600,
+			want:    "# comment\n\nexport GIT_AI_MODEL=x  # keep\nexport GEMINI_SESSION_ID=new\nexport GEMINI_SESSION_ID_OTHER=y\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			path := filepath.Join(t.TempDir(), ".agentrc")
+			wantMode := os.FileMode(0o644)
+			if tt.initial != nil {
+				if err := os.WriteFile(path, []byte(*tt.initial), tt.mode); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Chmod(path, tt.mode); err != nil {
+					t.Fatal(err)
+				}
+				wantMode = tt.mode
+			}
+			if err := Set(path, "GEMINI_SESSION_ID", "new"); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Fatalf("file =\n%q\nwant\n%q", data, tt.want)
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != wantMode {
+				t.Fatalf("mode = %v, want %v", info.Mode().Perm(), wantMode)
+			}
+			entries, err := os.ReadDir(filepath.Dir(path))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				t.Fatalf("temporary files left behind: %v", entries)
+			}
+		})
+	}
+}
+
+func ptr(s string) *string { return &s }
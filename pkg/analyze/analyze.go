@@ -0,0 +1,249 @@
+// Package analyze inspects a unified diff for signs of a breaking change
+// before the backend sees it: removed exported Go identifiers, changed
+// public function signatures, deleted API routes and new migrations.
+package analyze
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/commitlint"
+)
+
+// Kind classifies a Finding.
+type Kind string
+
+const (
+	KindRemoved   Kind = "removed"   // an exported Go identifier was removed
+	KindSignature Kind = "signature" // an exported Go function changed its signature
+	KindRoute     Kind = "route"     // an API route registration was removed
+	KindMigration Kind = "migration" // a database migration was added
+)
+
+// Finding is one sign of a breaking change.
+type Finding struct {
+	Kind Kind   `json:"kind"`
+	Path string `json:"path"`
+	// Name is the identifier, route or migration file concerned.
+	Name string `json:"name"`
+}
+
+func (f Finding) String() string {
+	switch f.Kind {
+	case KindRemoved:
+		return fmt.Sprintf("exported %s removed (%s)", f.Name, f.Path)
+	case KindSignature:
+		return fmt.Sprintf("signature of exported %s changed (%s)", f.Name, f.Path)
+	case KindRoute:
+		return fmt.Sprintf("API route %s removed (%s)", f.Name, f.Path)
+	default:
+		return "database migration " + f.Path + " added"
+	}
+}
+
+var (
+	goFunc = regexp.MustCompile(`^func\s+(?:\(\s*(?:\w+\s+)?\*?(\w+)(?:\[[^\]]*\])?\s*\)\s*)?([A-Z]\w*)\s*((?:\[[^\]]*\])?\(.*)$`)
+	goDecl = regexp.MustCompile(`^(?:type|const|var)\s+([A-Z]\w*)\b`)
+	// routeCall matches route registrations of net/http, gin, echo, chi,
+	// express, flask and Spring, capturing the method and path pattern.
+	routeCall    = regexp.MustCompile(`(?:\b(?:HandleFunc|Handle|Handler)|\.(GET|POST|PUT|PATCH|DELETE|Get|Post|Put|Patch|Delete|get|post|put|patch|delete|route|Route|Any|All|all))\(\s*["'` + "`" + `]((?:[A-Z]+ )?/[^"'` + "`" + `]*)["'` + "`" + `]`)
+	routeMapping = regexp.MustCompile(`@(Get|Post|Put|Patch|Delete|Request)Mapping\(\s*(?:(?:value|path)\s*=\s*)?"(/[^"]*)"`)
+	httpMethods  = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+	migrationDir = regexp.MustCompile(`(?i)(?:^|/)(?:migrations?|migrate|db/changelog)/`)
+)
+
+// fileLines holds the removed and added lines of one file.
+type fileLines struct {
+	path           string
+	status         string
+	removed, added []string
+}
+
+// Breaking returns the signs of a breaking change in diff, a unified diff as
+// produced by git diff (context lines are not needed).
+func Breaking(diff string) []Finding {
+	files := parseDiff(diff)
+	var (
+		findings []Finding
+		added    = map[string]string{} // package directory and Go identifier → signature
+		routes   = map[string]bool{}
+	)
+	for _, f := range files {
+		if isGoAPI(f.path) {
+			for _, line := range f.added {
+				if name, sig, ok := goIdentifier(line); ok {
+					added[path.Dir(f.path)+" "+name] = sig
+				}
+			}
+		}
+		for _, line := range f.added {
+			for _, r := range routesIn(line) {
+				routes[r] = true
+			}
+		}
+	}
+	for _, f := range files {
+		if isGoAPI(f.path) {
+			for _, line := range f.removed {
+				name, sig, ok := goIdentifier(line)
+				if !ok {
+					continue
+				}
+				switch newSig, kept := added[path.Dir(f.path)+" "+name]; {
+				case !kept:
+					findings = append(findings, Finding{Kind: KindRemoved, Path: f.path, Name: name})
+				case newSig != sig:
+					findings = append(findings, Finding{Kind: KindSignature, Path: f.path, Name: name})
+				}
+			}
+		}
+		if !isTest(f.path) {
+			for _, line := range f.removed {
+				for _, r := range routesIn(line) {
+					if !routes[r] {
+						routes[r] = true // report each route once
+						findings = append(findings, Finding{Kind: KindRoute, Path: f.path, Name: r})
+					}
+				}
+			}
+		}
+		if f.status == "added" && migrationDir.MatchString(f.path) {
+			findings = append(findings, Finding{Kind: KindMigration, Path: f.path, Name: path.Base(f.path)})
+		}
+	}
+	return findings
+}
+
+// Note returns the prompt text telling the model about findings, or "" when
+// there are none.
+func Note(findings []Finding) string {
+	if len(findings) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("Static analysis flagged these likely breaking changes:\n")
+	for _, f := range findings {
+		b.WriteString("- " + f.String() + "\n")
+	}
+	b.WriteString(`Unless they are clearly internal or backwards compatible, mark the commit as breaking: add "!" after the type/scope and a "BREAKING CHANGE:" footer describing what breaks and how to migrate.`)
+	return b.String()
+}
+
+// Marked reports whether msg declares a breaking change, with "!" in the
+// header or a BREAKING CHANGE footer.
+func Marked(msg string) bool {
+	parsed := commitlint.Parse(msg)
+	if parsed.Breaking {
+		return true
+	}
+	return slices.ContainsFunc(parsed.Footer, func(line string) bool {
+		return strings.HasPrefix(line, "BREAKING CHANGE") || strings.HasPrefix(line, "BREAKING-CHANGE")
+	})
+}
+
+// parseDiff collects the removed and added lines of every file in diff.
+func parseDiff(diff string) []fileLines {
+	var (
+		files  []fileLines
+		inHunk bool
+	)
+	for line := range strings.SplitSeq(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			files = append(files, fileLines{path: postImagePath(line), status: "modified"})
+			inHunk = false
+			continue
+		case len(files) == 0:
+			continue
+		}
+		f := &files[len(files)-1]
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+		case !inHunk && strings.HasPrefix(line, "new file mode"):
+			f.status = "added"
+		case !inHunk && strings.HasPrefix(line, "deleted file mode"):
+			f.status = "deleted"
+		case inHunk && strings.HasPrefix(line, "-"):
+			f.removed = append(f.removed, line[1:])
+		case inHunk && strings.HasPrefix(line, "+"):
+			f.added = append(f.added, line[1:])
+		}
+	}
+	return files
+}
+
+// postImagePath extracts the path from a "diff --git a/x b/x" header.
+func postImagePath(header string) string {
+	header = strings.TrimSpace(strings.TrimPrefix(header, "diff --git "))
+	if i := strings.LastIndex(header, " b/"); i != -1 {
+		return header[i+3:]
+	}
+	return strings.TrimPrefix(header, "a/")
+}
+
+// isGoAPI reports whether path is a Go file whose exported identifiers other
+// packages may use: not a test, not internal and not a command.
+func isGoAPI(p string) bool {
+	if !strings.HasSuffix(p, ".go") || isTest(p) {
+		return false
+	}
+	dirs := strings.Split(path.Dir(p), "/")
+	return !slices.Contains(dirs, "internal") && !slices.Contains(dirs, "testdata") && dirs[0] != "cmd"
+}
+
+func isTest(p string) bool {
+	base := path.Base(p)
+	return strings.HasSuffix(base, "_test.go") || strings.Contains(base, ".test.") || strings.Contains(base, ".spec.") ||
+		slices.Contains(strings.Split(path.Dir(p), "/"), "testdata")
+}
+
+// goIdentifier returns the exported top-level Go identifier declared on
+// line, qualified by its receiver type for methods, and its normalized
+// signature (empty for types, constants and variables).
+func goIdentifier(line string) (string, string, bool) {
+	if m := goFunc.FindStringSubmatch(line); m != nil {
+		name := m[2]
+		if m[1] != "" {
+			if !isExported(m[1]) {
+				return "", "", false
+			}
+			name = m[1] + "." + name
+		}
+		sig := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(m[3]), "{"))
+		return name, strings.Join(strings.Fields(sig), " "), true
+	}
+	if m := goDecl.FindStringSubmatch(line); m != nil {
+		return m[1], "", true
+	}
+	return "", "", false
+}
+
+func isExported(name string) bool {
+	return name != "" && name[0] >= 'A' && name[0] <= 'Z'
+}
+
+// routesIn returns the routes registered on line as "METHOD /path" or
+// "/path".
+func routesIn(line string) []string {
+	var routes []string
+	for _, m := range routeCall.FindAllStringSubmatch(line, -1) {
+		if method := strings.ToUpper(m[1]); slices.Contains(httpMethods, method) {
+			routes = append(routes, method+" "+m[2])
+			continue
+		}
+		routes = append(routes, m[2])
+	}
+	for _, m := range routeMapping.FindAllStringSubmatch(line, -1) {
+		method := strings.ToUpper(m[1])
+		if method == "REQUEST" {
+			routes = append(routes, m[2])
+			continue
+		}
+		routes = append(routes, method+" "+m[2])
+	}
+	return routes
+}
@@ -0,0 +1,102 @@
+package analyze
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+const sampleDiff = `diff --git a/pkg/store/store.go b/pkg/store/store.go
+index 1111111..2222222 100644
+--- a/pkg/store/store.go
++++ b/pkg/store/store.go
+@@ -10 +10 @@ type Store struct {
+-func (s *Store) Get(key string) (string, error) {
++func (s *Store) Get(ctx context.Context, key string) (string, error) {
+@@ -20,3 +20 @@ func (s *Store) Get(key string) (string, error) {
+-func (s *Store) Put(key, value string) error {
++func (s *Store) Put(key, value string) error  {
+-func Legacy() {}
+-func helper() {}
+diff --git a/pkg/store/old.go b/pkg/store/old.go
+deleted file mode 100644
+index 3333333..0000000
+--- a/pkg/store/old.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-type Moved struct{}
+-const Limit = 10
+diff --git a/pkg/store/moved.go b/pkg/store/moved.go
+new file mode 100644
+index 0000000..4444444
+--- /dev/null
++++ b/pkg/store/moved.go
+@@ -0,0 +1 @@
++type Moved struct{}
+diff --git a/internal/x/x.go b/internal/x/x.go
+index 5555555..6666666 100644
+--- a/internal/x/x.go
++++ b/internal/x/x.go
+@@ -1 +0,0 @@
+-func Hidden() {}
+diff --git a/server/routes.go b/server/routes.go
+index 7777777..8888888 100644
+--- a/server/routes.go
++++ b/server/routes.go
+@@ -5,2 +5 @@
+-	r.GET("/v1/users", listUsers)
+-	mux.HandleFunc("/healthz", health)
++	mux.HandleFunc("/healthz", healthz)
+diff --git a/db/migrations/0042_drop_users.sql b/db/migrations/0042_drop_users.sql
+new file mode 100644
+index 0000000..9999999
+--- /dev/null
++++ b/db/migrations/0042_drop_users.sql
+@@ -0,0 +1 @@
++DROP TABLE users;
+`
+
+func TestBreaking(t *testing.T) {
+	t.Parallel()
+
+	want := []Finding{
+		{Kind: KindSignature, Path: "pkg/store/store.go", Name: "Store.Get"},
+		{Kind: KindRemoved, Path: "pkg/store/store.go", Name: "Legacy"},
+		{Kind: KindRemoved, Path: "pkg/store/old.go", Name: "Limit"},
+		{Kind: KindRoute, Path: "server/routes.go", Name: "GET /v1/users"},
+		{Kind: KindMigration, Path: "db/migrations/0042_drop_users.sql", Name: "0042_drop_users.sql"},
+	}
+	if got := Breaking(sampleDiff); !slices.Equal(got, want) {
+		t.Fatalf("Breaking() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestMarked(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		msg  string
+		want bool
+	}{
+		{"feat(api)!: drop v1 users", true},
+		{"feat(api): drop v1 users\n\nBREAKING CHANGE: /v1/users is gone", true},
+		{"feat(api): drop v1 users\n\nThe route is gone.", false},
+	}
+	for _, tt := range tests {
+		if got := Marked(tt.msg); got != tt.want {
+			t.Errorf("Marked(%q) = %v, want %v", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func TestNote(t *testing.T) {
+	t.Parallel()
+
+	if Note(nil) != "" {
+		t.Fatal("Note(nil) should be empty")
+	}
+	note := Note([]Finding{{Kind: KindRoute, Path: "server/routes.go", Name: "GET /v1/users"}})
+	if !strings.Contains(note, "- API route GET /v1/users removed (server/routes.go)\n") {
+		t.Fatalf("Note() missing finding: %q", note)
+	}
+}
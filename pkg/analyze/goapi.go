@@ -0,0 +1,183 @@
+package analyze
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// Symbol changes.
+const (
+	SymbolAdded    = "added"
+	SymbolRemoved  = "removed"
+	SymbolModified = "modified"
+)
+
+// maxSymbolLines caps the symbols SymbolNote lists.
+const maxSymbolLines = 40
+
+// SymbolChange is an exported top-level Go declaration that differs between
+// two versions of a file.
+type SymbolChange struct {
+	Path string `json:"path"`
+	// Kind is func, method, type, const or var.
+	Kind string `json:"kind"`
+	// Name is the identifier, as Type.Method for methods.
+	Name   string `json:"name"`
+	Change string `json:"change"`
+	// Signature reports, for modified functions and methods, whether the
+	// signature changed rather than only the body.
+	Signature bool `json:"signature,omitempty"`
+}
+
+func (c SymbolChange) String() string {
+	s := fmt.Sprintf("%s %s %s", c.Change, c.Kind, c.Name)
+	switch {
+	case c.Change != SymbolModified:
+	case c.Signature:
+		s += " (signature)"
+	case c.Kind == "func" || c.Kind == "method":
+		s += " (body only)"
+	}
+	return s
+}
+
+// goSymbol is one exported declaration: its kind, its signature (the
+// declaration without a function body) and its full source. Files are
+// parsed without comments, so doc changes do not count.
+type goSymbol struct {
+	kind, signature, source string
+}
+
+// GoSymbols compares the exported top-level declarations of a Go file
+// before (oldSrc) and after (newSrc) a change. Either may be nil for added
+// and deleted files. Changes are sorted by name.
+func GoSymbols(path string, oldSrc, newSrc []byte) ([]SymbolChange, error) {
+	before, err := goSymbols(path, oldSrc)
+	if err != nil {
+		return nil, err
+	}
+	after, err := goSymbols(path, newSrc)
+	if err != nil {
+		return nil, err
+	}
+	var changes []SymbolChange
+	for name, a := range after {
+		switch b, ok := before[name]; {
+		case !ok:
+			changes = append(changes, SymbolChange{Path: path, Kind: a.kind, Name: name, Change: SymbolAdded})
+		case a.source != b.source:
+			changes = append(changes, SymbolChange{Path: path, Kind: a.kind, Name: name, Change: SymbolModified, Signature: a.signature != b.signature})
+		}
+	}
+	for name, b := range before {
+		if _, ok := after[name]; !ok {
+			changes = append(changes, SymbolChange{Path: path, Kind: b.kind, Name: name, Change: SymbolRemoved})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes, nil
+}
+
+// SymbolNote returns the prompt text listing changes by file, or "" when
+// there are none.
+func SymbolNote(changes []SymbolChange) string {
+	if len(changes) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("Exported Go declarations changed (from parsing the old and new files; refer to them by these names):\n")
+	for i, c := range changes {
+		if i == maxSymbolLines {
+			fmt.Fprintf(&b, "- ... and %d more\n", len(changes)-i)
+			break
+		}
+		fmt.Fprintf(&b, "- %s: %s\n", c.Path, c)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// goSymbols parses src and returns its exported top-level declarations by
+// name. Nil src has none.
+func goSymbols(path string, src []byte) (map[string]goSymbol, error) {
+	symbols := map[string]goSymbol{}
+	if src == nil {
+		return symbols, nil
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	render := func(node any) string {
+		var buf bytes.Buffer
+		_ = printer.Fprint(&buf, fset, node)
+		return buf.String()
+	}
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			name, kind := d.Name.Name, "func"
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				recv := receiverType(d.Recv.List[0].Type)
+				if !ast.IsExported(recv) {
+					continue
+				}
+				name, kind = recv+"."+name, "method"
+			}
+			if !d.Name.IsExported() {
+				continue
+			}
+			full := render(d)
+			body := d.Body
+			d.Body = nil
+			symbols[name] = goSymbol{kind: kind, signature: render(d), source: full}
+			d.Body = body
+		case *ast.GenDecl:
+			kind := d.Tok.String()
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if s.Name.IsExported() {
+						src := render(s)
+						symbols[s.Name.Name] = goSymbol{kind: kind, signature: src, source: src}
+					}
+				case *ast.ValueSpec:
+					src := render(s)
+					for _, n := range s.Names {
+						if n.IsExported() {
+							symbols[n.Name] = goSymbol{kind: kind, signature: src, source: src}
+						}
+					}
+				}
+			}
+		}
+	}
+	return symbols, nil
+}
+
+// receiverType returns the type name of a method receiver, without pointer
+// and type parameters.
+func receiverType(expr ast.Expr) string {
+	for {
+		switch t := expr.(type) {
+		case *ast.StarExpr:
+			expr = t.X
+		case *ast.IndexExpr:
+			expr = t.X
+		case *ast.IndexListExpr:
+			expr = t.X
+		case *ast.ParenExpr:
+			expr = t.X
+		case *ast.Ident:
+			return t.Name
+		default:
+			return ""
+		}
+	}
+}
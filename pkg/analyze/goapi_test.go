@@ -0,0 +1,74 @@
+package analyze
+
+import (
+	"slices"
+	"testing"
+)
+
+const goBefore = `package store
+
+type Store struct{ m map[string]string }
+
+const Limit = 10
+
+func (s *Store) Get(key string) string { return s.m[key] }
+
+func (s *Store) Put(key, value string) { s.m[key] = value }
+
+func Legacy() {}
+
+func helper() {}
+`
+
+const goAfter = `package store
+
+import "context"
+
+type Store struct{ m map[string]string }
+
+const Limit = 10
+
+// Get returns the value of key.
+func (s *Store) Get(ctx context.Context, key string) string { return s.m[key] }
+
+func (s *Store) Put(key, value string) {
+	s.m[key] = value + ""
+}
+
+func New() *Store { return &Store{m: map[string]string{}} }
+
+func helper() { _ = 1 }
+`
+
+func TestGoSymbols(t *testing.T) {
+	t.Parallel()
+
+	got, err := GoSymbols("pkg/store/store.go", []byte(goBefore), []byte(goAfter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []SymbolChange{
+		{Path: "pkg/store/store.go", Kind: "func", Name: "Legacy", Change: SymbolRemoved},
+		{Path: "pkg/store/store.go", Kind: "func", Name: "New", Change: SymbolAdded},
+		{Path: "pkg/store/store.go", Kind: "method", Name: "Store.Get", Change: SymbolModified, Signature: true},
+		{Path: "pkg/store/store.go", Kind: "method", Name: "Store.Put", Change: SymbolModified},
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("GoSymbols() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestGoSymbolsAddedFile(t *testing.T) {
+	t.Parallel()
+
+	got, err := GoSymbols("pkg/store/store.go", nil, []byte(goBefore))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 5 || got[0].Change != SymbolAdded {
+		t.Fatalf("GoSymbols() of an added file = %v", got)
+	}
+	if _, err = GoSymbols("broken.go", nil, []byte("package x\nfunc (")); err == nil {
+		t.Fatal("expected a parse error")
+	}
+}
@@ -0,0 +1,114 @@
+// Package attempt persists the last generated message per worktree
+// (.git/git-ai/last-attempt.json) so a regeneration can tell the backend
+// what was rejected and why instead of starting cold.
+package attempt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+)
+
+const fileName = "last-attempt.json"
+
+// Rejection is a message the user turned down.
+type Rejection struct {
+	Message string `json:"message"`
+	// Reason is the user's objection ("too vague", "wrong type"); it may be
+	// empty.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Attempt is the last generation for a staged state.
+type Attempt struct {
+	Time time.Time `json:"time"`
+	// Tree identifies the staged content the message was generated for
+	// (git write-tree of the index).
+	Tree    string `json:"tree"`
+	Message string `json:"message"`
+	// Usage is the backend's token/cost report for the message, one
+	// "key=value ..." line per trailer line.
+	Usage string `json:"usage,omitempty"`
+	// Rejected are the earlier messages for the same staged state, oldest
+	// first.
+	Rejected []Rejection `json:"rejected,omitempty"`
+}
+
+// Path returns the attempt file of the current worktree.
+func Path() (string, error) {
+	dir, err := git.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "git-ai", fileName), nil
+}
+
+// Load returns the last attempt, or nil (no error) when none was saved.
+func Load() (*Attempt, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var a Attempt
+	if err = json.Unmarshal(data, &a); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &a, nil
+}
+
+// Save replaces the last attempt with a, stamping Time when unset.
+func Save(a Attempt) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if a.Time.IsZero() {
+		a.Time = time.Now()
+	}
+	data, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Reject returns the rejections to carry into the next attempt: the earlier
+// ones plus the current message with reason.
+func (a Attempt) Reject(reason string) []Rejection {
+	rejected := make([]Rejection, 0, len(a.Rejected)+1)
+	rejected = append(rejected, a.Rejected...)
+	return append(rejected, Rejection{Message: a.Message, Reason: strings.TrimSpace(reason)})
+}
+
+// Note renders rejected as extra prompt context asking for a better
+// message. It returns an empty string when nothing was rejected.
+func Note(rejected []Rejection) string {
+	if len(rejected) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("Previous attempts for this change were rejected. Write a new message that addresses the objections instead of repeating them.\n")
+	for i, r := range rejected {
+		fmt.Fprintf(&b, "\nRejected message %d:\n%s\n", i+1, strings.TrimSpace(r.Message))
+		if r.Reason != "" {
+			fmt.Fprintf(&b, "Objection: %s\n", r.Reason)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,35 @@
+package attempt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRejectAndNote(t *testing.T) {
+	t.Parallel()
+
+	a := Attempt{
+		Message:  "fix: update code",
+		Rejected: []Rejection{{Message: "chore: changes"}},
+	}
+	rejected := a.Reject("  too vague ")
+	if len(rejected) != 2 || rejected[1].Reason != "too vague" {
+		t.Fatalf("Reject() = %+v", rejected)
+	}
+
+	note := Note(rejected)
+	for _, want := range []string{
+		"Rejected message 1:\nchore: changes\n",
+		"Rejected message 2:\nfix: update code\nObjection: too vague\n",
+	} {
+		if !strings.Contains(note, want) {
+			t.Errorf("Note() missing %q in:\n%s", want, note)
+		}
+	}
+	if strings.Count(note, "Objection:") != 1 {
+		t.Errorf("Note() printed an empty objection:\n%s", note)
+	}
+	if Note(nil) != "" {
+		t.Error("Note(nil) should be empty")
+	}
+}
@@ -0,0 +1,221 @@
+// Package ccparse parses commit messages according to the Conventional
+// Commits 1.0.0 specification: the type, optional scope and "!" of the
+// header, the free-form body, and the footers with their multi-line values,
+// including BREAKING CHANGE and its BREAKING-CHANGE synonym.
+package ccparse
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// Footer separators.
+const (
+	SeparatorColon = ": "
+	SeparatorHash  = " #"
+)
+
+// Breaking change footer tokens. Both are upper case only; other tokens
+// are matched without regard to case.
+const (
+	TokenBreaking      = "BREAKING CHANGE"
+	TokenBreakingAlias = "BREAKING-CHANGE"
+)
+
+var (
+	ErrEmpty         = errors.New("commit message is empty")
+	ErrNoType        = errors.New("header does not start with a type followed by a colon and a space")
+	ErrEmptyScope    = errors.New("header has an empty scope")
+	ErrNoDescription = errors.New("header has no description after the colon")
+)
+
+var (
+	// headerPattern is type, optional (scope), optional "!", then ":" and
+	// the description.
+	headerPattern = regexp.MustCompile(`^([A-Za-z][\w-]*)(?:\(([^()]*)\))?(!)?:(.*)$`)
+	// footerPattern is a token (words joined by "-", or BREAKING CHANGE)
+	// and a separator, then the start of the value.
+	footerPattern = regexp.MustCompile(`^(BREAKING CHANGE|[\w-]+)(: | #)(.*)$`)
+)
+
+// Footer is one footer of a commit message.
+type Footer struct {
+	Token string
+	// Separator is SeparatorColon or SeparatorHash.
+	Separator string
+	// Value runs until the next footer; it may span several lines.
+	Value string
+}
+
+func (f Footer) String() string {
+	return f.Token + f.Separator + f.Value
+}
+
+// IsBreaking reports whether f is a BREAKING CHANGE footer.
+func (f Footer) IsBreaking() bool {
+	return f.Token == TokenBreaking || f.Token == TokenBreakingAlias
+}
+
+// Commit is a parsed Conventional Commits message.
+type Commit struct {
+	Type  string
+	Scope string
+	// Bang reports a "!" before the colon of the header.
+	Bang        bool
+	Description string
+	// Body is the text between the header and the footers, without the
+	// surrounding blank lines.
+	Body    string
+	Footers []Footer
+}
+
+// Parse parses msg as a Conventional Commits message. It fails when the
+// header does not follow the specification; the body and footers are
+// free-form and always parse. Carriage returns and trailing blank lines
+// are ignored.
+func Parse(msg string) (Commit, error) {
+	msg = strings.TrimRight(strings.ReplaceAll(msg, "\r\n", "\n"), "\n\t ")
+	if strings.TrimSpace(msg) == "" {
+		return Commit{}, ErrEmpty
+	}
+	header, rest, _ := strings.Cut(msg, "\n")
+	c, err := parseHeader(header)
+	if err != nil {
+		return Commit{}, err
+	}
+	lines := strings.Split(rest, "\n")
+	if rest == "" {
+		lines = nil
+	}
+	start := footerStart(lines)
+	c.Body = strings.Trim(strings.Join(lines[:start], "\n"), "\n")
+	c.Footers = parseFooters(lines[start:])
+	return c, nil
+}
+
+// parseHeader parses the first line of a message.
+func parseHeader(header string) (Commit, error) {
+	m := headerPattern.FindStringSubmatch(strings.TrimSpace(header))
+	switch {
+	case m == nil:
+		return Commit{}, ErrNoType
+	case strings.Contains(m[0], "()"):
+		return Commit{}, ErrEmptyScope
+	case strings.TrimSpace(m[4]) == "":
+		return Commit{}, ErrNoDescription
+	case !strings.HasPrefix(m[4], " "):
+		return Commit{}, ErrNoType
+	}
+	return Commit{Type: m[1], Scope: strings.TrimSpace(m[2]), Bang: m[3] == "!", Description: strings.TrimSpace(m[4])}, nil
+}
+
+// footerStart returns the index of the first footer line: the start of the
+// earliest paragraph from which every paragraph begins with a footer
+// token, or len(lines) when the message has no footers.
+func footerStart(lines []string) int {
+	start := len(lines)
+	for i := len(lines) - 1; i >= 0; i-- {
+		paragraphStart := i == 0 || strings.TrimSpace(lines[i-1]) == ""
+		if !paragraphStart || strings.TrimSpace(lines[i]) == "" {
+			continue
+		}
+		if !footerPattern.MatchString(lines[i]) {
+			break
+		}
+		start = i
+	}
+	return start
+}
+
+// parseFooters splits the footer lines into footers. A line that does not
+// start with a token continues the value of the footer before it.
+func parseFooters(lines []string) []Footer {
+	var footers []Footer
+	for _, line := range lines {
+		if m := footerPattern.FindStringSubmatch(line); m != nil {
+			footers = append(footers, Footer{Token: m[1], Separator: m[2], Value: m[3]})
+			continue
+		}
+		if len(footers) > 0 {
+			footers[len(footers)-1].Value += "\n" + line
+		}
+	}
+	for i := range footers {
+		footers[i].Value = strings.TrimRight(footers[i].Value, "\n ")
+	}
+	return footers
+}
+
+// Header returns the first line: type, scope, "!" and description.
+func (c Commit) Header() string {
+	var b strings.Builder
+	b.WriteString(c.Type)
+	if c.Scope != "" {
+		b.WriteString("(" + c.Scope + ")")
+	}
+	if c.Bang {
+		b.WriteByte('!')
+	}
+	b.WriteString(": " + c.Description)
+	return b.String()
+}
+
+// IsBreaking reports whether the commit introduces a breaking change, with
+// "!" in the header or a BREAKING CHANGE footer.
+func (c Commit) IsBreaking() bool {
+	if c.Bang {
+		return true
+	}
+	for _, f := range c.Footers {
+		if f.IsBreaking() {
+			return true
+		}
+	}
+	return false
+}
+
+// BreakingChange returns the description of the breaking change: the
+// value of the first BREAKING CHANGE footer, or the header description
+// when only "!" marks it. It is empty for non-breaking commits.
+func (c Commit) BreakingChange() string {
+	for _, f := range c.Footers {
+		if f.IsBreaking() {
+			return f.Value
+		}
+	}
+	if c.Bang {
+		return c.Description
+	}
+	return ""
+}
+
+// Footer returns the value of the first footer with token, compared
+// without regard to case except for BREAKING CHANGE.
+func (c Commit) Footer(token string) (string, bool) {
+	for _, f := range c.Footers {
+		if f.Token == token || (!f.IsBreaking() && strings.EqualFold(f.Token, token)) {
+			return f.Value, true
+		}
+	}
+	return "", false
+}
+
+// String renders the commit as a message: the header, then the body and
+// the footers, each separated by a blank line.
+func (c Commit) String() string {
+	var b strings.Builder
+	b.WriteString(c.Header())
+	if c.Body != "" {
+		b.WriteString("\n\n" + c.Body)
+	}
+	for i, f := range c.Footers {
+		if i == 0 {
+			b.WriteString("\n\n")
+		} else {
+			b.WriteByte('\n')
+		}
+		b.WriteString(f.String())
+	}
+	return b.String()
+}
@@ -0,0 +1,135 @@
+package ccparse
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		msg      string
+		want     Commit
+		breaking string
+	}{
+		{
+			name: "header only",
+			msg:  "docs: correct spelling of CHANGELOG",
+			want: Commit{Type: "docs", Description: "correct spelling of CHANGELOG"},
+		},
+		{
+			name:     "scope and bang",
+			msg:      "feat(api)!: send an email to the customer when a product is shipped",
+			want:     Commit{Type: "feat", Scope: "api", Bang: true, Description: "send an email to the customer when a product is shipped"},
+			breaking: "send an email to the customer when a product is shipped",
+		},
+		{
+			name: "breaking footer",
+			msg:  "feat: allow provided config object to extend other configs\n\nBREAKING CHANGE: `extends` key in config file is now used for extending other config files",
+			want: Commit{Type: "feat", Description: "allow provided config object to extend other configs", Footers: []Footer{
+				{Token: "BREAKING CHANGE", Separator: ": ", Value: "`extends` key in config file is now used for extending other config files"},
+			}},
+			breaking: "`extends` key in config file is now used for extending other config files",
+		},
+		{
+			name: "breaking alias",
+			msg:  "refactor: drop v1\n\nBREAKING-CHANGE: v1 is gone",
+			want: Commit{Type: "refactor", Description: "drop v1", Footers: []Footer{
+				{Token: "BREAKING-CHANGE", Separator: ": ", Value: "v1 is gone"},
+			}},
+			breaking: "v1 is gone",
+		},
+		{
+			name: "body and multi-line footers",
+			msg: "fix: prevent racing of requests\n\nIntroduce a request id.\n\nRemove timeouts.\n\n" +
+				"Reviewed-by: Z\nRefs #123\nBREAKING CHANGE: callers must\n  pass an id\n",
+			want: Commit{Type: "fix", Description: "prevent racing of requests", Body: "Introduce a request id.\n\nRemove timeouts.", Footers: []Footer{
+				{Token: "Reviewed-by", Separator: ": ", Value: "Z"},
+				{Token: "Refs", Separator: " #", Value: "123"},
+				{Token: "BREAKING CHANGE", Separator: ": ", Value: "callers must\n  pass an id"},
+			}},
+			breaking: "callers must\n  pass an id",
+		},
+		{
+			name: "lower-case breaking change is not a footer",
+			msg:  "fix: x\n\nbreaking change: no",
+			want: Commit{Type: "fix", Description: "x", Body: "breaking change: no"},
+		},
+		{
+			name: "footer-like line inside the body",
+			msg:  "fix: x\n\nNote: this is body text\nand continues.\n\nMore body.",
+			want: Commit{Type: "fix", Description: "x", Body: "Note: this is body text\nand continues.\n\nMore body."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Parse(tt.msg)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got.Type != tt.want.Type || got.Scope != tt.want.Scope || got.Bang != tt.want.Bang ||
+				got.Description != tt.want.Description || got.Body != tt.want.Body || !slices.Equal(got.Footers, tt.want.Footers) {
+				t.Fatalf("Parse() = %+v, want %+v", got, tt.want)
+			}
+			if got.IsBreaking() != (tt.breaking != "") || got.BreakingChange() != tt.breaking {
+				t.Fatalf("BreakingChange() = %q, want %q", got.BreakingChange(), tt.breaking)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	for msg, want := range map[string]error{
+		"":                ErrEmpty,
+		"\n\n":            ErrEmpty,
+		"update readme":   ErrNoType,
+		"feat:no space":   ErrNoType,
+		"feat(): x":       ErrEmptyScope,
+		"feat(api): ":     ErrNoDescription,
+		"(api): add docs": ErrNoType,
+	} {
+		if _, err := Parse(msg); !errors.Is(err, want) {
+			t.Errorf("Parse(%q) error = %v, want %v", msg, err, want)
+		}
+	}
+}
+
+func TestStringRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, msg := range []string{
+		"chore: bump deps",
+		"feat(ui)!: new layout\n\nThe old layout is gone.",
+		"fix: x\n\nBody.\n\nRefs #1\nBREAKING CHANGE: a\n  b",
+	} {
+		c, err := Parse(msg)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", msg, err)
+		}
+		if got := c.String(); got != msg {
+			t.Errorf("String() = %q, want %q", got, msg)
+		}
+	}
+}
+
+func TestFooter(t *testing.T) {
+	t.Parallel()
+
+	c, err := Parse("fix: x\n\nreviewed-by: Z\nBREAKING-CHANGE: y")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := c.Footer("Reviewed-By"); !ok || v != "Z" {
+		t.Errorf("Footer(Reviewed-By) = %q, %v", v, ok)
+	}
+	if _, ok := c.Footer("breaking-change"); ok {
+		t.Error("BREAKING-CHANGE matched in lower case")
+	}
+}
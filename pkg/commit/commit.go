@@ -70,6 +70,7 @@ const BodyLineWidth = 72
 // StripCodeFence removes markdown code fences (```...```) that LLMs
 // sometimes wrap around their output.
 func StripCodeFence(s string) string {
+	s = strings.TrimSpace(s)
 	if !strings.HasPrefix(s, "```") {
 		return s
 	}
@@ -85,84 +86,34 @@ func StripCodeFence(s string) string {
 	return strings.TrimSpace(body)
 }
 
-func WrapMessage(msg string, width int) string {
-	paragraphs := strings.Split(msg, "\n\n")
-	out := make([]string, 0, len(paragraphs))
-	for _, p := range paragraphs {
-		p = strings.TrimSpace(p)
-		if p == "" {
-			out = append(out, "")
+// SplitComments separates the '#'-prefixed lines of msg (the usage trailer
+// and other notes) from the message text, which is trimmed.
+func SplitComments(msg string) (string, []string) {
+	var (
+		lines    = strings.Split(msg, "\n")
+		text     = make([]string, 0, len(lines))
+		comments []string
+	)
+	for _, line := range lines {
+		if strings.HasPrefix(line, "#") {
+			comments = append(comments, line)
 			continue
 		}
-		run := strings.ReplaceAll(p, "\n", " ")
-		var (
-			line strings.Builder
-			pos  int
-		)
-		for pos < len(run) {
-			for pos < len(run) && run[pos] == ' ' {
-				pos++
-			}
-			if pos >= len(run) {
-				break
-			}
-			start := pos
-			for pos < len(run) && run[pos] != ' ' {
-				pos++
-			}
-			word := run[start:pos]
-			newLen := line.Len()
-			if newLen > 0 {
-				newLen++
-			}
-			newLen += len(word)
-			if newLen > width && line.Len() > 0 {
-				lineStr := line.String()
-				lastSent := -1
-				for i := len(lineStr) - 1; i >= 0 && i >= len(lineStr)-width; i-- {
-					if i > 0 && (lineStr[i] == '.' || lineStr[i] == '?' || lineStr[i] == '!') && lineStr[i-1] != '.' {
-						if i+1 < len(lineStr) && lineStr[i+1] == ' ' {
-							lastSent = i + 2
-							break
-						}
-						if i+1 >= len(lineStr) {
-							lastSent = i + 1
-							break
-						}
-					}
-				}
-				var breakAt int
-				if lastSent > 0 && lastSent <= len(lineStr) {
-					breakAt = lastSent
-				} else {
-					lastSpace := strings.LastIndex(lineStr, " ")
-					if lastSpace > 0 {
-						breakAt = lastSpace + 1
-					} else {
-						breakAt = len(lineStr)
-					}
-				}
-				out = append(out, strings.TrimSpace(lineStr[:breakAt]))
-				line.Reset()
-				line.WriteString(strings.TrimLeft(lineStr[breakAt:], " "))
-			}
-			if line.Len() > 0 {
-				line.WriteByte(' ')
-			}
-			line.WriteString(word)
-		}
-		if line.Len() > 0 {
-			out = append(out, line.String())
-		}
-	}
-	result := strings.Join(out, "\n")
-	before, after, ok := strings.Cut(result, "\n")
-	if !ok {
-		return result
+		text = append(text, line)
 	}
-	rest := strings.TrimLeft(after, "\n")
-	if strings.TrimSpace(rest) == "" {
-		return before
+	return strings.TrimSpace(strings.Join(text, "\n")), comments
+}
+
+// StripComments removes '#'-prefixed lines (the usage trailer and other
+// notes git would drop on commit) and trims the result.
+func StripComments(msg string) string {
+	lines := strings.Split(msg, "\n")
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		out = append(out, line)
 	}
-	return before + "\n\n" + rest
+	return strings.TrimSpace(strings.Join(out, "\n"))
 }
@@ -0,0 +1,78 @@
+package commit
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// footerVar matches a footer variable such as {date} or {env:NAME}.
+	footerVar = regexp.MustCompile(`\{([a-z_]+)(?::([^{}]*))?\}`)
+	// footerToken matches the "Token: " start of a footer line.
+	footerToken = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9-]*): `)
+)
+
+// Whether a footer variable takes an argument after a colon.
+const (
+	argNone     = iota
+	argOptional // {date} or {date:02.01.2006}
+	argRequired // {env:NAME}
+)
+
+// footerVars are the variables footer templates may use.
+var footerVars = map[string]int{
+	"gen":    argNone,
+	"date":   argOptional,
+	"branch": argNone,
+	"ticket": argNone,
+	"mr_url": argNone,
+	"env":    argRequired,
+}
+
+// FooterToken returns the token of a footer template or line ("Change-Id"
+// for "Change-Id: {gen}"), or "" when it is not of the form "Token: value".
+func FooterToken(footer string) string {
+	m := footerToken.FindStringSubmatch(strings.TrimSpace(footer))
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// ValidateFooter checks that tmpl is a "Token: value" footer whose
+// variables are all known.
+func ValidateFooter(tmpl string) error {
+	if FooterToken(tmpl) == "" {
+		return errors.New(`footer must look like "Token: value"`)
+	}
+	for _, m := range footerVar.FindAllStringSubmatch(tmpl, -1) {
+		arg, ok := footerVars[m[1]]
+		switch {
+		case !ok:
+			return fmt.Errorf("unknown footer variable {%s}", m[1])
+		case arg == argNone && m[2] != "":
+			return fmt.Errorf("footer variable {%s} takes no argument", m[1])
+		case arg == argRequired && m[2] == "":
+			return fmt.Errorf("footer variable {%s} needs an argument, e.g. {%s:NAME}", m[1], m[1])
+		}
+	}
+	return nil
+}
+
+// ExpandFooter substitutes the variables of tmpl with value(name, arg). It
+// reports false when a variable expands to nothing, so the footer can be
+// left out rather than added half empty.
+func ExpandFooter(tmpl string, value func(name, arg string) string) (string, bool) {
+	ok := true
+	footer := footerVar.ReplaceAllStringFunc(strings.TrimSpace(tmpl), func(v string) string {
+		m := footerVar.FindStringSubmatch(v)
+		s := strings.TrimSpace(value(m[1], m[2]))
+		if s == "" {
+			ok = false
+		}
+		return s
+	})
+	return footer, ok
+}
@@ -0,0 +1,50 @@
+package commit
+
+import "testing"
+
+func TestValidateFooter(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		tmpl string
+		ok   bool
+	}{
+		{"Change-Id: {gen}", true},
+		{"Reviewed-on: {mr_url}", true},
+		{"Date: {date}", true},
+		{"Datum: {date:02.01.2006}", true},
+		{"Build: {env:CI_PIPELINE_ID}", true},
+		{"Refs: {ticket} on {branch}", true},
+		{"no token here", false},
+		{"Refs: {nope}", false},
+		{"Build: {env}", false},
+		{"Change-Id: {gen:x}", false},
+	} {
+		if err := ValidateFooter(tc.tmpl); (err == nil) != tc.ok {
+			t.Errorf("ValidateFooter(%q) = %v, want ok=%v", tc.tmpl, err, tc.ok)
+		}
+	}
+}
+
+func TestExpandFooter(t *testing.T) {
+	t.Parallel()
+	value := func(name, arg string) string {
+		switch name {
+		case "env":
+			return "env-" + arg
+		case "ticket":
+			return "ABC-12"
+		}
+		return ""
+	}
+
+	got, ok := ExpandFooter("Refs: {ticket} ({env:JOB})", value)
+	if !ok || got != "Refs: ABC-12 (env-JOB)" {
+		t.Fatalf("ExpandFooter() = %q, %v", got, ok)
+	}
+	if _, ok = ExpandFooter("Reviewed-on: {mr_url}", value); ok {
+		t.Fatal("ExpandFooter() with an empty variable reported ok")
+	}
+	if token := FooterToken("Change-Id: {gen}"); token != "Change-Id" {
+		t.Fatalf("FooterToken() = %q", token)
+	}
+}
@@ -1,39 +1,136 @@
 package commit
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
 
 // PromptOptions contains the pieces used to build the commit prompt.
 type PromptOptions struct {
 	SkillText string
 	Diff      string
+	// Changes is the per-file change summary (one "status: path" per line)
+	// shown ahead of the diff.
+	Changes string
+	// Summaries, when set and Diff is empty, stands in for the diff: one
+	// "path: summary" line per file from a first summarization pass.
+	Summaries string
 	ExtraNote string
 	NoCC      bool
+	// Risk asks for Risk/Affects/Migration footers classifying the change.
+	Risk bool
+	// MaxSubject is the maximum subject length in characters (0: no limit).
+	MaxSubject int
+	// SubjectOnly asks for a single subject line without body or footers.
+	SubjectOnly bool
+	// Scope is the scope mapped from the changed paths; Scopes lists every
+	// allowed scope. Both come from the repository's scope map.
+	Scope  string
+	Scopes []string
+	// ScopePolicy is ScopeOptional (or empty), ScopeRequired or
+	// ScopeForbidden; ScopeHints suggests scopes for a required scope when
+	// there is no scope map.
+	ScopePolicy string
+	ScopeHints  []string
+	// Types lists the allowed commit types; empty allows any type.
+	Types []string
+	// Structured asks for the message as JSON (see StructuredSchema).
+	Structured bool
+	// Template, when set, asks for the values of its slots as JSON instead
+	// of the message; it takes precedence over Structured.
+	Template *Template
 }
 
-// BuildSystemPrompt returns the stable system-prompt text (instructions +
-// skill rules). It is suitable for passing as --system-prompt so that Claude
-// can cache it across invocations where only the diff changes.
-func BuildSystemPrompt(opts PromptOptions) string {
-	var b strings.Builder
+// RiskInstructions asks the model to classify the change in footers that
+// deploy tooling can gate on.
+const RiskInstructions = `Classify the change and end the message with these footers, each on its own line after a blank line:
+Risk: low|medium|high (how likely the change is to break production)
+Affects: comma-separated list of affected areas (e.g. api, db, ui, build)
+Migration: required|none (whether deploying needs a data or config migration)
+`
+
+// writeHeader writes the task description shared by every prompt shape.
+func writeHeader(b *strings.Builder, opts PromptOptions) {
 	if opts.NoCC {
 		b.WriteString("Generate a commit message from the staged git diff.\n")
 	} else {
 		b.WriteString("Generate a Conventional Commit message from the staged git diff.\n")
 	}
 	b.WriteString("Use the instructions below and output only the commit message.\n")
-	b.WriteString("Limit each line in the commit body to 72 characters; wrap at sentence boundaries (e.g. after a period and space) when possible so lines do not break mid-sentence.\n\n")
+	b.WriteString("Limit each line in the commit body to 72 characters; wrap at sentence boundaries (e.g. after a period and space) when possible so lines do not break mid-sentence.\n")
+	if !opts.NoCC {
+		if len(opts.Types) > 0 {
+			fmt.Fprintf(b, "The type must be one of: %s.\n", strings.Join(opts.Types, ", "))
+		}
+		required := opts.ScopePolicy == ScopeRequired
+		switch {
+		case opts.ScopePolicy == ScopeForbidden:
+			b.WriteString("Do not use a scope: write the type directly followed by the colon.\n")
+		case opts.Scope != "":
+			fmt.Fprintf(b, "Use exactly the scope %q.\n", opts.Scope)
+		case len(opts.Scopes) > 0 && required:
+			fmt.Fprintf(b, "The change must have a scope, one of: %s.\n", strings.Join(opts.Scopes, ", "))
+		case len(opts.Scopes) > 0:
+			fmt.Fprintf(b, "If the change has a scope, it must be one of: %s.\n", strings.Join(opts.Scopes, ", "))
+		case required && len(opts.ScopeHints) > 0:
+			fmt.Fprintf(b, "The change must have a scope naming the changed area, such as one of: %s.\n", strings.Join(opts.ScopeHints, ", "))
+		case required:
+			b.WriteString("The change must have a scope naming the changed area.\n")
+		}
+	}
+	if opts.MaxSubject > 0 {
+		fmt.Fprintf(b, "Keep the subject line (the first line) at most %d characters long.\n", opts.MaxSubject)
+	}
+	if opts.SubjectOnly {
+		b.WriteString("Output only the subject line: no body and no footers.\n")
+	} else if opts.Risk {
+		b.WriteString(RiskInstructions)
+	}
+	switch {
+	case opts.Template != nil:
+		b.WriteString(opts.Template.Instructions())
+	case opts.Structured:
+		b.WriteString(StructuredInstructions)
+	}
+	b.WriteString("\n")
+}
+
+// BuildSystemPrompt returns the stable system-prompt text (instructions +
+// skill rules). It is suitable for passing as --system-prompt so that Claude
+// can cache it across invocations where only the diff changes.
+func BuildSystemPrompt(opts PromptOptions) string {
+	var b strings.Builder
+	writeHeader(&b, opts)
 	b.WriteString("Instructions:\n")
 	b.WriteString(opts.SkillText)
 	return b.String()
 }
 
+// ChangesSection is the prompt block listing the per-file change summary, so
+// the model describes renames as renames rather than a delete and an add.
+func ChangesSection(changes string) string {
+	if strings.TrimSpace(changes) == "" {
+		return ""
+	}
+	return "Changed files (describe renamed or copied files as such, not as a deletion plus an addition):\n" +
+		strings.TrimRight(changes, "\n") + "\n\n"
+}
+
+// diffSection is the prompt block carrying the staged diff, or the per-file
+// summaries standing in for it.
+func diffSection(opts PromptOptions) string {
+	if opts.Diff == "" && strings.TrimSpace(opts.Summaries) != "" {
+		return "Per-file summaries of the staged diff:\n" + strings.TrimRight(opts.Summaries, "\n") + "\n"
+	}
+	return "Staged diff:\n" + opts.Diff + "\n"
+}
+
 // BuildUserMessage returns the user-facing message text (diff + optional
 // extra note). This is the part that changes on every run.
 func BuildUserMessage(opts PromptOptions) string {
 	var b strings.Builder
-	b.WriteString("Staged diff:\n")
-	b.WriteString(opts.Diff)
-	b.WriteByte('\n')
+	b.WriteString(ChangesSection(opts.Changes))
+	b.WriteString(diffSection(opts))
 	if strings.TrimSpace(opts.ExtraNote) != "" {
 		b.WriteString("\nExtra context:\n")
 		b.WriteString(strings.TrimSpace(opts.ExtraNote))
@@ -47,19 +144,12 @@ func BuildUserMessage(opts PromptOptions) string {
 func BuildConventionalPrompt(opts PromptOptions) string {
 	var prompt strings.Builder
 
-	if opts.NoCC {
-		prompt.WriteString("Generate a commit message from the staged git diff.\n")
-	} else {
-		prompt.WriteString("Generate a Conventional Commit message from the staged git diff.\n")
-	}
-	prompt.WriteString("Use the instructions below and output only the commit message.\n")
-	prompt.WriteString("Limit each line in the commit body to 72 characters; wrap at sentence boundaries (e.g. after a period and space) when possible so lines do not break mid-sentence.\n\n")
+	writeHeader(&prompt, opts)
 	prompt.WriteString("Instructions:\n")
 	prompt.WriteString(opts.SkillText)
 	prompt.WriteString("\n\n")
-	prompt.WriteString("Staged diff:\n")
-	prompt.WriteString(opts.Diff)
-	prompt.WriteString("\n")
+	prompt.WriteString(ChangesSection(opts.Changes))
+	prompt.WriteString(diffSection(opts))
 	if strings.TrimSpace(opts.ExtraNote) != "" {
 		prompt.WriteString("\nExtra context:\n")
 		prompt.WriteString(strings.TrimSpace(opts.ExtraNote))
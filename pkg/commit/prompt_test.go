@@ -35,3 +35,85 @@ func TestBuildConventionalPromptWithoutExtraContext(t *testing.T) {
 		t.Fatalf("prompt should not include extra context section: %q", out)
 	}
 }
+
+func TestBuildSystemPromptSubjectOnly(t *testing.T) {
+	t.Parallel()
+
+	out := BuildSystemPrompt(PromptOptions{SkillText: "rules", SubjectOnly: true, Risk: true})
+	if !strings.Contains(out, "Output only the subject line") {
+		t.Fatalf("prompt missing subject-only instruction: %q", out)
+	}
+	if strings.Contains(out, RiskInstructions) {
+		t.Fatalf("subject-only prompt must not ask for footers: %q", out)
+	}
+}
+
+func TestBuildSystemPromptTypes(t *testing.T) {
+	t.Parallel()
+
+	out := BuildSystemPrompt(PromptOptions{SkillText: "rules", Types: []string{"feat", "fix", "deps"}})
+	if !strings.Contains(out, "The type must be one of: feat, fix, deps.\n") {
+		t.Fatalf("prompt missing allowed types: %q", out)
+	}
+	out = BuildSystemPrompt(PromptOptions{SkillText: "rules", Types: []string{"feat"}, NoCC: true})
+	if strings.Contains(out, "The type must be one of") {
+		t.Fatalf("standard-style prompt must not restrict types: %q", out)
+	}
+}
+
+func TestBuildSystemPromptScopePolicy(t *testing.T) {
+	t.Parallel()
+
+	out := BuildSystemPrompt(PromptOptions{SkillText: "rules", ScopePolicy: ScopeRequired, ScopeHints: []string{"git", "web"}})
+	if !strings.Contains(out, "must have a scope naming the changed area, such as one of: git, web.") {
+		t.Fatalf("prompt missing required scope hints: %q", out)
+	}
+	out = BuildSystemPrompt(PromptOptions{SkillText: "rules", ScopePolicy: ScopeForbidden, Scope: "git"})
+	if !strings.Contains(out, "Do not use a scope") || strings.Contains(out, `"git"`) {
+		t.Fatalf("prompt must forbid scopes: %q", out)
+	}
+}
+
+func TestBuildSystemPromptTemplate(t *testing.T) {
+	t.Parallel()
+
+	tmpl, err := ParseTemplate(`{type}: {subject}\n\nJira: {ticket}`)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	out := BuildSystemPrompt(PromptOptions{SkillText: "rules", Template: tmpl, Structured: true})
+	if !strings.Contains(out, "- ticket: issue or ticket key") || !strings.Contains(out, "Jira: {ticket}\n") {
+		t.Fatalf("prompt missing template slots: %q", out)
+	}
+	if strings.Contains(out, StructuredInstructions) {
+		t.Fatalf("template prompt must not ask for structured output: %q", out)
+	}
+}
+
+func TestBuildConventionalPromptChanges(t *testing.T) {
+	t.Parallel()
+
+	out := BuildConventionalPrompt(PromptOptions{
+		SkillText: "rules",
+		Diff:      "diff --git a b",
+		Changes:   "renamed: a.go -> b.go\n",
+	})
+	if !strings.Contains(out, "renamed: a.go -> b.go\n\nStaged diff:\n") {
+		t.Fatalf("prompt missing change summary before diff: %q", out)
+	}
+}
+
+func TestBuildConventionalPromptSummaries(t *testing.T) {
+	t.Parallel()
+
+	out := BuildConventionalPrompt(PromptOptions{
+		SkillText: "rules",
+		Summaries: "a.go: add retries\n",
+	})
+	if !strings.Contains(out, "Per-file summaries of the staged diff:\na.go: add retries\n") {
+		t.Fatalf("prompt missing summaries: %q", out)
+	}
+	if strings.Contains(out, "Staged diff:") {
+		t.Fatalf("summaries should replace the diff section: %q", out)
+	}
+}
@@ -0,0 +1,24 @@
+package commit
+
+import "strings"
+
+// RevertFooters adds what git revert records to a revert message when it
+// is missing: a "This reverts commit <hash>." paragraph ahead of the
+// footers and a Refs trailer with the abbreviated hash.
+func RevertFooters(msg, hash string) string {
+	text, comments := SplitComments(msg)
+	if line := "This reverts commit " + hash + "."; !strings.Contains(text, "This reverts commit "+hash) {
+		paragraphs := strings.Split(text, "\n\n")
+		switch last := strings.TrimSpace(paragraphs[len(paragraphs)-1]); {
+		case len(paragraphs) > 1 && isFooterBlock(last):
+			paragraphs = append(paragraphs[:len(paragraphs)-1], line, last)
+		default:
+			paragraphs = append(paragraphs, line)
+		}
+		text = strings.Join(paragraphs, "\n\n")
+	}
+	if len(comments) > 0 {
+		text += "\n\n" + strings.Join(comments, "\n")
+	}
+	return AddTrailer(text, "Refs: "+hash[:min(len(hash), 12)])
+}
@@ -0,0 +1,38 @@
+package commit
+
+import "testing"
+
+func TestRevertFooters(t *testing.T) {
+	t.Parallel()
+
+	const hash = "0123456789abcdef0123456789abcdef01234567"
+	tests := []struct {
+		name string
+		msg  string
+		want string
+	}{
+		{
+			name: "subject only",
+			msg:  "revert: add export",
+			want: "revert: add export\n\nThis reverts commit " + hash + ".\n\nRefs: 0123456789ab",
+		},
+		{
+			name: "footer block kept last",
+			msg:  "revert: add export\n\nIt broke imports.\n\nSigned-off-by: A <a@b.c>\n# usage",
+			want: "revert: add export\n\nIt broke imports.\n\nThis reverts commit " + hash + ".\n\nSigned-off-by: A <a@b.c>\nRefs: 0123456789ab\n\n# usage",
+		},
+		{
+			name: "already present",
+			msg:  "revert: add export\n\nThis reverts commit " + hash + ".\n\nRefs: 0123456789ab",
+			want: "revert: add export\n\nThis reverts commit " + hash + ".\n\nRefs: 0123456789ab",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := RevertFooters(tt.msg, hash); got != tt.want {
+				t.Fatalf("RevertFooters() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
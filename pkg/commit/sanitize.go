@@ -0,0 +1,157 @@
+package commit
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Boilerplate matches attribution lines models add despite the prompt
+// ("Generated with ...", AI co-author trailers and sign-offs).
+var Boilerplate = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)^\W*generated (with|by|using) .*$`),
+	regexp.MustCompile(`(?i)^(co-authored-by|signed-off-by):.*\b(claude|anthropic|openai|chatgpt|codex|gemini|copilot)\b.*$`),
+	regexp.MustCompile(`(?i)^\W*(written|created|authored) (with|by) (claude|chatgpt|codex|gemini|copilot|an? ai)\b.*$`),
+}
+
+var (
+	markdownHeader = regexp.MustCompile(`^#{1,6}\s+`)
+	messageLabel   = regexp.MustCompile(`(?i)^\**(suggested |proposed )?commit message\**(:\**\s*|\s*$)`)
+)
+
+// CompilePatterns compiles user-supplied boilerplate patterns.
+func CompilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	out := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid strip pattern %q: %w", p, err)
+		}
+		out = append(out, re)
+	}
+	return out, nil
+}
+
+// Normalize cleans raw model output into message text: a markdown code
+// fence (with any one-line preamble before it), matching quotes around the
+// whole text and markdown header markup are removed, and lines still
+// starting with '#' are indented so git does not drop them as comments.
+// Every backend passes its output through Normalize.
+func Normalize(raw string) string {
+	msg := unquote(stripFencedBlock(strings.TrimSpace(raw)))
+	lines := strings.Split(msg, "\n")
+	for i, line := range lines {
+		lines[i] = markdownHeader.ReplaceAllString(line, "")
+	}
+	return EscapeComments(strings.TrimSpace(strings.Join(lines, "\n")))
+}
+
+// stripFencedBlock returns the content of a code fence wrapping s. A short
+// preamble before the fence ("Here is the message:") is dropped with it.
+func stripFencedBlock(s string) string {
+	lines := strings.Split(s, "\n")
+	for i := 0; i < len(lines) && i <= 1; i++ {
+		if strings.HasPrefix(strings.TrimSpace(lines[i]), "```") {
+			return StripCodeFence(strings.TrimSpace(strings.Join(lines[i:], "\n")))
+		}
+	}
+	return s
+}
+
+// EscapeComments indents lines starting with '#' by one space so git keeps
+// them when it strips comments from the message. Apply it after any
+// reflowing, which can move a '#' to the start of a line.
+func EscapeComments(msg string) string {
+	return EscapeCommentChar(msg, "#")
+}
+
+// EscapeCommentChar is EscapeComments for the comment prefix char (git's
+// core.commentChar).
+func EscapeCommentChar(msg, char string) string {
+	if char == "" || !strings.Contains(msg, char) {
+		return msg
+	}
+	lines := strings.Split(msg, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, char) {
+			lines[i] = " " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Sanitize removes boilerplate from a generated message: everything
+// Normalize cleans up, a leading "Commit message:" label, and lines
+// matching Boilerplate or extra.
+func Sanitize(msg string, extra []*regexp.Regexp) string {
+	msg = Normalize(msg)
+	lines := strings.Split(msg, "\n")
+	var (
+		out     = make([]string, 0, len(lines))
+		subject bool
+	)
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if !subject && trimmed != "" {
+			// Only the line before the subject may carry a header or label.
+			trimmed = markdownHeader.ReplaceAllString(trimmed, "")
+			if loc := messageLabel.FindStringIndex(trimmed); loc != nil {
+				trimmed = unquote(strings.TrimSpace(trimmed[loc[1]:]))
+			}
+			if trimmed == "" {
+				continue
+			}
+			line = trimmed
+			subject = true
+		}
+		if matchesAny(trimmed, Boilerplate) || matchesAny(trimmed, extra) {
+			continue
+		}
+		out = append(out, line)
+	}
+	return EscapeComments(unquote(strings.TrimSpace(collapseBlankLines(out))))
+}
+
+func matchesAny(line string, patterns []*regexp.Regexp) bool {
+	for _, re := range patterns {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// unquote strips one pair of matching quotes or backticks around s.
+func unquote(s string) string {
+	if len(s) < 2 {
+		return s
+	}
+	for _, q := range []string{`"`, "'", "`"} {
+		if strings.HasPrefix(s, q) && strings.HasSuffix(s, q) && !strings.Contains(s[1:len(s)-1], q) {
+			return strings.TrimSpace(s[1 : len(s)-1])
+		}
+	}
+	return s
+}
+
+// collapseBlankLines joins lines, squeezing runs of blank lines left behind
+// by removed lines into one.
+func collapseBlankLines(lines []string) string {
+	var b strings.Builder
+	blank := false
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			blank = true
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+			if blank {
+				b.WriteByte('\n')
+			}
+		}
+		blank = false
+		b.WriteString(line)
+	}
+	return b.String()
+}
@@ -0,0 +1,118 @@
+package commit
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestSanitize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "attribution lines",
+			in:   "feat: add x\n\nBody text.\n\n🤖 Generated with [Claude Code](https://claude.com/claude-code)\n\nCo-Authored-By: Claude <noreply@anthropic.com>",
+			want: "feat: add x\n\nBody text.",
+		},
+		{
+			name: "label and quotes",
+			in:   "Commit message:\n\"fix: handle nil config\"",
+			want: "fix: handle nil config",
+		},
+		{
+			name: "inline label",
+			in:   "**Commit message:** fix(git): trim output",
+			want: "fix(git): trim output",
+		},
+		{
+			name: "markdown header subject",
+			in:   "## feat: add release command\n\nDetails.",
+			want: "feat: add release command\n\nDetails.",
+		},
+		{
+			name: "human trailers kept",
+			in:   "fix: x\n\nSigned-off-by: Jane Doe <jane@example.com>",
+			want: "fix: x\n\nSigned-off-by: Jane Doe <jane@example.com>",
+		},
+		{
+			name: "subject starting with commit kept",
+			in:   "Commit generated files",
+			want: "Commit generated files",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := Sanitize(tt.in, nil); got != tt.want {
+				t.Fatalf("Sanitize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeExtraPatterns(t *testing.T) {
+	t.Parallel()
+
+	extra := []*regexp.Regexp{regexp.MustCompile(`^Reviewed-by: bot`)}
+	got := Sanitize("chore: bump deps\n\nReviewed-by: bot@ci", extra)
+	if got != "chore: bump deps" {
+		t.Fatalf("Sanitize() = %q", got)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "fence",
+			in:   "```text\nfeat: add x\n\nBody.\n```",
+			want: "feat: add x\n\nBody.",
+		},
+		{
+			name: "fence after preamble",
+			in:   "Here is the commit message:\n```\nfix: y\n```\n",
+			want: "fix: y",
+		},
+		{
+			name: "quotes",
+			in:   "  'fix: z'  ",
+			want: "fix: z",
+		},
+		{
+			name: "markdown headers in body",
+			in:   "feat: x\n\n## Changes\n- a",
+			want: "feat: x\n\nChanges\n- a",
+		},
+		{
+			name: "comment char escaped",
+			in:   "fix: close issue\n\n#123 was caused by a race.",
+			want: "fix: close issue\n\n #123 was caused by a race.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := Normalize(tt.in); got != tt.want {
+				t.Fatalf("Normalize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEscapeCommentChar(t *testing.T) {
+	t.Parallel()
+
+	got := EscapeCommentChar("fix: x\n\n; not a comment\n# kept", ";")
+	if want := "fix: x\n\n ; not a comment\n# kept"; got != want {
+		t.Fatalf("EscapeCommentChar() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,95 @@
+package commit
+
+import (
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Scope policies (GIT_AI_SCOPE).
+const (
+	ScopeOptional  = "optional"
+	ScopeRequired  = "required"
+	ScopeForbidden = "forbidden"
+)
+
+// ScopePolicies lists the accepted scope policies.
+var ScopePolicies = []string{ScopeOptional, ScopeRequired, ScopeForbidden}
+
+var (
+	typePrefix = regexp.MustCompile(`^([A-Za-z]+)(?:\([^)]*\))?(!?): `)
+	// containerDirs hold the real components of a repository, so candidate
+	// scopes come from the directory below them.
+	containerDirs = []string{"apps", "cmd", "internal", "lib", "libs", "packages", "pkg", "services", "src"}
+)
+
+// maxCandidateScopes caps the scopes CandidateScopes suggests.
+const maxCandidateScopes = 5
+
+// SetScope returns subject with its Conventional Commits scope replaced by
+// scope, or removed when scope is empty. Subjects without a type are
+// returned unchanged.
+func SetScope(subject, scope string) string {
+	m := typePrefix.FindStringSubmatchIndex(subject)
+	if m == nil {
+		return subject
+	}
+	prefix := subject[m[2]:m[3]]
+	if scope != "" {
+		prefix += "(" + scope + ")"
+	}
+	return prefix + subject[m[4]:m[5]] + ": " + subject[m[1]:]
+}
+
+// CandidateScopes derives scopes from changed paths: the first directory
+// of each path, or the one below a container directory such as pkg or src.
+// The most frequent come first; files in the repository root add none.
+func CandidateScopes(paths []string) []string {
+	counts := CountScopes(paths, PathScope)
+	scopes := make([]string, 0, min(len(counts), maxCandidateScopes))
+	for _, c := range counts[:min(len(counts), maxCandidateScopes)] {
+		scopes = append(scopes, c.Scope)
+	}
+	return scopes
+}
+
+// PathScope derives the scope of a changed path for CandidateScopes, or
+// returns "" for files in the repository root.
+func PathScope(p string) string {
+	dirs := strings.Split(path.Dir(p), "/")
+	if dirs[0] == "." {
+		return ""
+	}
+	scope := dirs[0]
+	if slices.Contains(containerDirs, scope) && len(dirs) > 1 {
+		scope = dirs[1]
+	}
+	return strings.ToLower(strings.TrimPrefix(scope, "."))
+}
+
+// ScopeCount is a scope and the number of changed files in it.
+type ScopeCount struct {
+	Scope string
+	Files int
+}
+
+// CountScopes counts the paths per scope, as named by scopeOf ("" for
+// none), the scope with the most files first and ties in path order.
+func CountScopes(paths []string, scopeOf func(string) string) []ScopeCount {
+	var counts []ScopeCount
+	for _, p := range paths {
+		scope := scopeOf(p)
+		if scope == "" {
+			continue
+		}
+		i := slices.IndexFunc(counts, func(c ScopeCount) bool { return c.Scope == scope })
+		if i < 0 {
+			i = len(counts)
+			counts = append(counts, ScopeCount{Scope: scope})
+		}
+		counts[i].Files++
+	}
+	slices.SortStableFunc(counts, func(a, b ScopeCount) int { return b.Files - a.Files })
+	return counts
+}
@@ -0,0 +1,44 @@
+package commit
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSetScope(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		subject, scope, want string
+	}{
+		{"feat: add invoices", "billing", "feat(billing): add invoices"},
+		{"feat(api)!: drop v1", "", "feat!: drop v1"},
+		{"fix(ui): align menu", "web", "fix(web): align menu"},
+		{"Update docs", "docs", "Update docs"},
+	}
+	for _, tt := range tests {
+		if got := SetScope(tt.subject, tt.scope); got != tt.want {
+			t.Errorf("SetScope(%q, %q) = %q, want %q", tt.subject, tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestCandidateScopes(t *testing.T) {
+	t.Parallel()
+
+	got := CandidateScopes([]string{"README.md", "web/app.ts", "pkg/git/git.go", "pkg/git/log.go", "cmd/tool/main.go", ".github/ci.yml"})
+	want := []string{"git", "web", "tool", "github"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("CandidateScopes() = %v, want %v", got, want)
+	}
+}
+
+func TestCountScopes(t *testing.T) {
+	t.Parallel()
+
+	got := CountScopes([]string{"README.md", "web/app.ts", "pkg/git/git.go", "pkg/git/log.go", "web/index.html", "cmd/tool/main.go"}, PathScope)
+	want := []ScopeCount{{"web", 2}, {"git", 2}, {"tool", 1}}
+	if !slices.Equal(got, want) {
+		t.Fatalf("CountScopes() = %v, want %v", got, want)
+	}
+}
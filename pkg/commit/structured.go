@@ -0,0 +1,80 @@
+package commit
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// StructuredSchema is the JSON schema for a commit message requested as
+// structured output. Every property is required so it also satisfies
+// strict schema modes; empty strings stand for "none".
+const StructuredSchema = `{
+  "type": "object",
+  "properties": {
+    "type": {"type": "string", "description": "Conventional Commits type, e.g. feat or fix; empty if not used"},
+    "scope": {"type": "string", "description": "scope without parentheses; empty if none"},
+    "breaking": {"type": "boolean"},
+    "subject": {"type": "string", "description": "description after the type/scope prefix"},
+    "body": {"type": "string", "description": "message body; empty if none"},
+    "footers": {"type": "array", "items": {"type": "string"}, "description": "trailers such as \"Refs: #12\""}
+  },
+  "required": ["type", "scope", "breaking", "subject", "body", "footers"],
+  "additionalProperties": false
+}`
+
+// StructuredInstructions tells the model how to fill StructuredSchema.
+const StructuredInstructions = "Return the message as a JSON object with the fields type, scope, breaking, subject (without the type/scope prefix), body and footers; use empty strings for parts the message does not have.\n"
+
+// Structured is a commit message returned as structured output.
+type Structured struct {
+	Type     string   `json:"type"`
+	Scope    string   `json:"scope"`
+	Breaking bool     `json:"breaking"`
+	Subject  string   `json:"subject"`
+	Body     string   `json:"body"`
+	Footers  []string `json:"footers"`
+}
+
+// ParseStructured decodes a structured commit message, tolerating a
+// surrounding code fence.
+func ParseStructured(text string) (Structured, error) {
+	var s Structured
+	if err := json.Unmarshal([]byte(StripCodeFence(strings.TrimSpace(text))), &s); err != nil {
+		return Structured{}, err
+	}
+	if strings.TrimSpace(s.Subject) == "" {
+		return Structured{}, errors.New("structured commit message has no subject")
+	}
+	return s, nil
+}
+
+// Message assembles the commit message text: the header, then the body and
+// the footers, each separated by a blank line.
+func (s Structured) Message() string {
+	var b strings.Builder
+	if typ := strings.TrimSpace(s.Type); typ != "" {
+		b.WriteString(typ)
+		if scope := strings.Trim(strings.TrimSpace(s.Scope), "()"); scope != "" {
+			b.WriteString("(" + scope + ")")
+		}
+		if s.Breaking {
+			b.WriteByte('!')
+		}
+		b.WriteString(": ")
+	}
+	b.WriteString(strings.TrimSpace(s.Subject))
+	if body := strings.TrimSpace(s.Body); body != "" {
+		b.WriteString("\n\n" + body)
+	}
+	footers := make([]string, 0, len(s.Footers))
+	for _, f := range s.Footers {
+		if f = strings.TrimSpace(f); f != "" {
+			footers = append(footers, f)
+		}
+	}
+	if len(footers) > 0 {
+		b.WriteString("\n\n" + strings.Join(footers, "\n"))
+	}
+	return b.String()
+}
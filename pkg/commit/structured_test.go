@@ -0,0 +1,69 @@
+package commit
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestStructuredMessage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   Structured
+		want string
+	}{
+		{
+			name: "full",
+			in: Structured{
+				Type: "feat", Scope: "api", Breaking: true, Subject: "drop v1 routes",
+				Body: "Clients must use /v2.", Footers: []string{"Refs: #12", " "},
+			},
+			want: "feat(api)!: drop v1 routes\n\nClients must use /v2.\n\nRefs: #12",
+		},
+		{
+			name: "no scope or body",
+			in:   Structured{Type: "fix", Subject: "handle empty diff"},
+			want: "fix: handle empty diff",
+		},
+		{
+			name: "no type",
+			in:   Structured{Subject: "Update docs", Body: "More examples."},
+			want: "Update docs\n\nMore examples.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := tt.in.Message(); got != tt.want {
+				t.Fatalf("Message() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStructured(t *testing.T) {
+	t.Parallel()
+
+	s, err := ParseStructured("```json\n{\"type\":\"fix\",\"scope\":\"\",\"breaking\":false,\"subject\":\"x\",\"body\":\"\",\"footers\":[]}\n```")
+	if err != nil {
+		t.Fatalf("ParseStructured: %v", err)
+	}
+	if s.Type != "fix" || s.Subject != "x" {
+		t.Fatalf("ParseStructured = %+v", s)
+	}
+	if _, err := ParseStructured(`{"type":"fix","subject":""}`); err == nil {
+		t.Fatal("expected an error for a missing subject")
+	}
+	if _, err := ParseStructured("fix: plain text"); err == nil {
+		t.Fatal("expected an error for plain text")
+	}
+}
+
+func TestStructuredSchemaIsJSON(t *testing.T) {
+	t.Parallel()
+
+	if !json.Valid([]byte(StructuredSchema)) {
+		t.Fatal("StructuredSchema is not valid JSON")
+	}
+}
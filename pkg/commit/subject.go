@@ -0,0 +1,101 @@
+package commit
+
+import (
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// DefaultMaxSubject is the default maximum subject length in columns.
+const DefaultMaxSubject = 72
+
+var (
+	// ccPrefix matches a Conventional Commits "type(scope)!: " prefix.
+	ccPrefix      = regexp.MustCompile(`^[A-Za-z]+(\([^)]*\))?!?: `)
+	parenthetical = regexp.MustCompile(`\s*\([^)]*\)`)
+	// danglingWords are not worth ending a truncated subject on.
+	danglingWords = []string{"a", "an", "and", "as", "for", "from", "in", "of", "on", "or", "the", "to", "with"}
+)
+
+// Subject returns the first line of msg.
+func Subject(msg string) string {
+	subject, _, _ := strings.Cut(strings.TrimSpace(msg), "\n")
+	return strings.TrimSpace(subject)
+}
+
+// ReplaceSubject returns msg with its first line replaced by subject.
+func ReplaceSubject(msg, subject string) string {
+	_, rest, ok := strings.Cut(strings.TrimSpace(msg), "\n")
+	if !ok {
+		return subject
+	}
+	return subject + "\n" + rest
+}
+
+// FormattingSubject returns the subject of a commit that only reformats
+// paths: "style: reformat <target>", or "Reformat <target>" without
+// Conventional Commits, where the target is the single path, the shared
+// directory of the paths or "code".
+func FormattingSubject(paths []string, noCC bool) string {
+	target := "code"
+	switch {
+	case len(paths) == 1:
+		target = paths[0]
+	case len(paths) > 1:
+		dir := path.Dir(paths[0])
+		if dir != "." && !slices.ContainsFunc(paths, func(p string) bool { return path.Dir(p) != dir }) {
+			target = dir
+		}
+	}
+	if noCC {
+		return "Reformat " + target
+	}
+	return "style: reformat " + target
+}
+
+// SubjectWidth returns the display width of subject.
+func SubjectWidth(subject string) int {
+	return runewidth.StringWidth(subject)
+}
+
+// TruncateSubject shortens subject to at most limit columns. A Conventional
+// Commits type/scope prefix is kept intact unless it leaves no room for the
+// description, in which case the scope is dropped; the description loses
+// its trailing period and parentheticals first, then whole trailing words,
+// and never ends on a dangling conjunction or preposition.
+func TruncateSubject(subject string, limit int) string {
+	if limit <= 0 || SubjectWidth(subject) <= limit {
+		return subject
+	}
+	var prefix, scope string
+	if m := ccPrefix.FindStringSubmatch(subject); m != nil {
+		prefix, scope = m[0], m[1]
+	}
+	desc := strings.TrimSuffix(strings.TrimSpace(subject[len(prefix):]), ".")
+	room := limit - SubjectWidth(prefix)
+	if room <= 0 && scope != "" {
+		prefix = strings.Replace(prefix, scope, "", 1)
+		room = limit - SubjectWidth(prefix)
+	}
+	if room <= 0 {
+		return runewidth.Truncate(subject, limit, "")
+	}
+	if SubjectWidth(desc) > room {
+		desc = strings.TrimSpace(parenthetical.ReplaceAllString(desc, ""))
+	}
+	words := strings.Fields(desc)
+	for len(words) > 1 && SubjectWidth(strings.Join(words, " ")) > room {
+		words = words[:len(words)-1]
+		for len(words) > 1 && slices.Contains(danglingWords, strings.ToLower(words[len(words)-1])) {
+			words = words[:len(words)-1]
+		}
+	}
+	desc = strings.TrimRight(strings.Join(words, " "), ",;:-")
+	if SubjectWidth(desc) > room {
+		desc = runewidth.Truncate(desc, room, "")
+	}
+	return prefix + desc
+}
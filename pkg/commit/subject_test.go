@@ -0,0 +1,54 @@
+package commit
+
+import "testing"
+
+func TestTruncateSubject(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		subject string
+		limit   int
+		want    string
+	}{
+		{"short enough", "fix: typo", 50, "fix: typo"},
+		{"keeps prefix", "feat(api)!: add pagination support to the list endpoints and search", 40, "feat(api)!: add pagination support"},
+		{"drops parenthetical", "fix(git): handle renames (including copies)", 30, "fix(git): handle renames"},
+		{"no dangling words", "refactor: move parser into its own package for reuse", 40, "refactor: move parser into its own"},
+		{"drops long scope", "feat(a-really-long-scope-name-that-is-long): do it", 30, "feat: do it"},
+		{"drops long scope keeps bang", "fix(a-really-long-scope-name-that-is-long)!: do it", 30, "fix!: do it"},
+		{"plain subject", "Update the documentation for the new release process", 30, "Update the documentation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := TruncateSubject(tt.subject, tt.limit)
+			if got != tt.want {
+				t.Fatalf("TruncateSubject(%q, %d) = %q, want %q", tt.subject, tt.limit, got, tt.want)
+			}
+			if SubjectWidth(got) > tt.limit {
+				t.Fatalf("result %q exceeds %d columns", got, tt.limit)
+			}
+		})
+	}
+}
+
+func TestFormattingSubject(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		paths []string
+		noCC  bool
+		want  string
+	}{
+		{[]string{"pkg/git/git.go"}, false, "style: reformat pkg/git/git.go"},
+		{[]string{"pkg/git/git.go", "pkg/git/rank.go"}, false, "style: reformat pkg/git"},
+		{[]string{"main.go", "pkg/git/git.go"}, false, "style: reformat code"},
+		{[]string{"a.go", "b.go"}, true, "Reformat code"},
+	}
+	for _, tt := range tests {
+		if got := FormattingSubject(tt.paths, tt.noCC); got != tt.want {
+			t.Errorf("FormattingSubject(%v, %v) = %q, want %q", tt.paths, tt.noCC, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package commit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	// templateSlot matches a named slot such as {subject}.
+	templateSlot = regexp.MustCompile(`\{([a-z][a-z0-9_]*)\}`)
+	// blankRuns matches the runs of blank lines left by dropped lines.
+	blankRuns = regexp.MustCompile(`\n{3,}`)
+	// slotHints describe the well-known slots to the model; other slots
+	// are explained by their name alone.
+	slotHints = map[string]string{
+		"type":     "Conventional Commits type, e.g. feat or fix",
+		"scope":    "scope without parentheses; empty if none",
+		"subject":  "short imperative description of the change",
+		"body":     "what changed and why, wrapped at 72 characters; empty if the subject says it all",
+		"ticket":   "issue or ticket key the change refers to, e.g. from the branch name or diff; empty if unknown",
+		"breaking": "what breaks and how to migrate; empty if nothing breaks",
+	}
+)
+
+// Template is a commit message layout with named slots the model fills,
+// such as "{type}({scope}): {subject}\n\n{body}\n\nJira: {ticket}".
+type Template struct {
+	text  string
+	slots []string
+}
+
+// ParseTemplate parses text as a message template. A literal "\n" stands
+// for a newline, so templates fit on one .agentrc line.
+func ParseTemplate(text string) (*Template, error) {
+	text = strings.TrimSpace(strings.ReplaceAll(text, `\n`, "\n"))
+	var slots []string
+	for _, m := range templateSlot.FindAllStringSubmatch(text, -1) {
+		if !slices.Contains(slots, m[1]) {
+			slots = append(slots, m[1])
+		}
+	}
+	if len(slots) == 0 {
+		return nil, errors.New("template has no {slot} placeholders")
+	}
+	return &Template{text: text, slots: slots}, nil
+}
+
+// Slots returns the slot names in order of first use.
+func (t *Template) Slots() []string {
+	return t.slots
+}
+
+// Instructions tells the model to answer with the slot values as JSON.
+func (t *Template) Instructions() string {
+	var b strings.Builder
+	b.WriteString("The message follows a fixed template; the tool fills it in. Instead of the message, return only a JSON object with one string per slot:\n")
+	for _, slot := range t.slots {
+		if hint, ok := slotHints[slot]; ok {
+			fmt.Fprintf(&b, "- %s: %s\n", slot, hint)
+			continue
+		}
+		fmt.Fprintf(&b, "- %s\n", slot)
+	}
+	b.WriteString("Use an empty string for a slot that does not apply. The template is:\n")
+	b.WriteString(t.text + "\n")
+	return b.String()
+}
+
+// Fill substitutes the slot values in reply, a JSON object as requested by
+// Instructions, into the template. Slots that share a line with other text
+// are joined into one line, "()" left around an empty slot is dropped, and
+// so are lines whose slots are all empty. It fails when reply is not such
+// an object, misses a slot, or leaves the subject empty.
+func (t *Template) Fill(reply string) (string, error) {
+	var raw map[string]any
+	if err := json.Unmarshal([]byte(StripCodeFence(strings.TrimSpace(reply))), &raw); err != nil {
+		return "", fmt.Errorf("template reply is not a JSON object: %w", err)
+	}
+	values := make(map[string]string, len(t.slots))
+	for _, slot := range t.slots {
+		v, ok := raw[slot]
+		if !ok {
+			return "", fmt.Errorf("template reply is missing slot %q", slot)
+		}
+		switch v := v.(type) {
+		case nil:
+		case string:
+			values[slot] = strings.TrimSpace(v)
+		default:
+			values[slot] = strings.TrimSpace(fmt.Sprint(v))
+		}
+	}
+	if slices.Contains(t.slots, "subject") && values["subject"] == "" {
+		return "", errors.New("template slot subject is empty")
+	}
+
+	lines := strings.Split(t.text, "\n")
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		matches := templateSlot.FindAllStringSubmatchIndex(line, -1)
+		if len(matches) == 0 {
+			out = append(out, line)
+			continue
+		}
+		alone := len(matches) == 1 && strings.TrimSpace(line) == line[matches[0][0]:matches[0][1]]
+		var (
+			b     strings.Builder
+			last  int
+			empty = true
+		)
+		for _, m := range matches {
+			start, end := m[0], m[1]
+			value := values[line[m[2]:m[3]]]
+			if value != "" {
+				empty = false
+			}
+			if !alone {
+				value = strings.Join(strings.Fields(value), " ")
+			}
+			if value == "" && start > last && line[start-1] == '(' && end < len(line) && line[end] == ')' {
+				start--
+				end++
+			}
+			b.WriteString(line[last:start])
+			b.WriteString(value)
+			last = end
+		}
+		if empty {
+			continue
+		}
+		b.WriteString(line[last:])
+		out = append(out, strings.TrimRight(b.String(), " "))
+	}
+	msg := strings.TrimSpace(blankRuns.ReplaceAllString(strings.Join(out, "\n"), "\n\n"))
+	if Subject(msg) == "" {
+		return "", errors.New("template produced an empty subject")
+	}
+	return msg, nil
+}
@@ -0,0 +1,76 @@
+package commit
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+const jiraTemplate = `{type}({scope}): {subject}\n\n{body}\n\nJira: {ticket}`
+
+func TestTemplateFill(t *testing.T) {
+	t.Parallel()
+
+	tmpl, err := ParseTemplate(jiraTemplate)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	if got, want := tmpl.Slots(), []string{"type", "scope", "subject", "body", "ticket"}; !slices.Equal(got, want) {
+		t.Fatalf("Slots() = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		name  string
+		reply string
+		want  string
+	}{
+		{
+			name:  "all slots",
+			reply: `{"type":"feat","scope":"api","subject":"add export","body":"Export as CSV.\nAnd JSON.","ticket":"PAY-12"}`,
+			want:  "feat(api): add export\n\nExport as CSV.\nAnd JSON.\n\nJira: PAY-12",
+		},
+		{
+			name:  "empty slots dropped",
+			reply: "```json\n" + `{"type":"fix","scope":"","subject":"handle nil","body":"","ticket":""}` + "\n```",
+			want:  "fix: handle nil",
+		},
+		{
+			name:  "inline slot joined",
+			reply: `{"type":"fix","scope":"ui","subject":"trim\nspaces","body":"","ticket":null}`,
+			want:  "fix(ui): trim spaces",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := tmpl.Fill(tt.reply)
+			if err != nil {
+				t.Fatalf("Fill() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("Fill() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateFillErrors(t *testing.T) {
+	t.Parallel()
+
+	tmpl, err := ParseTemplate(jiraTemplate)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	for reply, want := range map[string]string{
+		"feat: plain text":                                               "not a JSON object",
+		`{"type":"feat","scope":"","subject":"x"}`:                       `missing slot "body"`,
+		`{"type":"feat","scope":"","subject":" ","body":"","ticket":""}`: "subject is empty",
+	} {
+		if _, err := tmpl.Fill(reply); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Fill(%q) error = %v, want %q", reply, err, want)
+		}
+	}
+	if _, err := ParseTemplate("no slots here"); err == nil {
+		t.Error("ParseTemplate() without slots succeeded")
+	}
+}
@@ -0,0 +1,187 @@
+package commit
+
+import (
+	"slices"
+	"strings"
+)
+
+// AddTrailer appends trailer ("Key: value") to the footer block of msg,
+// starting one when the last paragraph is not a footer block. A trailer
+// already present is not repeated. Comment lines (the usage trailer) stay
+// at the end.
+func AddTrailer(msg, trailer string) string {
+	text, comments := SplitComments(msg)
+	trailer = strings.TrimSpace(trailer)
+	for line := range strings.SplitSeq(text, "\n") {
+		if strings.TrimSpace(line) == trailer {
+			return msg
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(text)
+	paragraphs := strings.Split(text, "\n\n")
+	if last := strings.TrimSpace(paragraphs[len(paragraphs)-1]); len(paragraphs) > 1 && isFooterBlock(last) {
+		b.WriteString("\n")
+	} else {
+		b.WriteString("\n\n")
+	}
+	b.WriteString(trailer)
+	if len(comments) > 0 {
+		b.WriteString("\n\n")
+		b.WriteString(strings.Join(comments, "\n"))
+	}
+	return b.String()
+}
+
+// RemoveTrailers drops the trailers with token (case-insensitive) from the
+// footer block of msg, and the block itself when nothing else is left in
+// it. Comment lines stay at the end.
+func RemoveTrailers(msg, token string) string {
+	text, comments := SplitComments(msg)
+	paragraphs := strings.Split(text, "\n\n")
+	last := len(paragraphs) - 1
+	if last == 0 || !isFooterBlock(strings.TrimSpace(paragraphs[last])) {
+		return msg
+	}
+	kept := make([]string, 0, strings.Count(paragraphs[last], "\n")+1)
+	for line := range strings.SplitSeq(paragraphs[last], "\n") {
+		key, _, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(key), token) {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	paragraphs[last] = strings.Join(kept, "\n")
+	if strings.TrimSpace(paragraphs[last]) == "" {
+		paragraphs = paragraphs[:last]
+	}
+	text = strings.Join(paragraphs, "\n\n")
+	if len(comments) == 0 {
+		return text
+	}
+	return text + "\n\n" + strings.Join(comments, "\n")
+}
+
+// FooterOrderRest stands for every footer token a footer order does not
+// name.
+const FooterOrderRest = "*"
+
+// listTokens are the footer tokens whose values are comma-separated
+// references; repeated footers with these tokens are merged into one.
+var listTokens = []string{"refs"}
+
+// footer is one footer of a message; value may span several lines.
+type footer struct {
+	token, sep, value string
+}
+
+func (f footer) String() string {
+	return f.token + f.sep + f.value
+}
+
+// footerKey returns the token compared without case, with BREAKING-CHANGE folded
+// into BREAKING CHANGE.
+func footerKey(token string) string {
+	if token == "BREAKING-CHANGE" {
+		token = "BREAKING CHANGE"
+	}
+	return strings.ToLower(token)
+}
+
+// splitFooters returns the paragraphs of text before its footers and the
+// footers: the trailing paragraphs that each start with a footer line. A
+// line that is not a footer continues the value of the footer before it.
+func splitFooters(text string) ([]string, []footer) {
+	paragraphs := strings.Split(strings.TrimSpace(text), "\n\n")
+	start := len(paragraphs)
+	for start > 1 && footerLine.MatchString(strings.TrimSpace(paragraphs[start-1])) {
+		start--
+	}
+	var footers []footer
+	for _, p := range paragraphs[start:] {
+		for line := range strings.SplitSeq(strings.TrimSpace(p), "\n") {
+			if m := footerLine.FindStringSubmatch(line); m != nil {
+				footers = append(footers, footer{token: m[1], sep: m[2], value: strings.TrimSpace(line[len(m[0]):])})
+				continue
+			}
+			footers[len(footers)-1].value += "\n" + line
+		}
+	}
+	return paragraphs[:start], footers
+}
+
+// NormalizeFooters rewrites the footers of msg as one block: repeated
+// footers (same token without regard to case, same value) are dropped,
+// Refs footers are merged into one, and the footers are ordered by their
+// token's position in order, where FooterOrderRest places the unnamed
+// ones (at the end when order lacks it). Footers keep their relative order
+// otherwise. Comment lines stay at the end.
+func NormalizeFooters(msg string, order []string) string {
+	text, comments := SplitComments(msg)
+	paragraphs, footers := splitFooters(text)
+	if len(footers) == 0 {
+		return msg
+	}
+
+	var (
+		kept = make([]footer, 0, len(footers))
+		seen = make(map[string]bool, len(footers))
+		list = map[string]int{}
+	)
+	for _, f := range footers {
+		key := footerKey(f.token)
+		if slices.Contains(listTokens, key) && f.sep == ": " {
+			i, ok := list[key]
+			if !ok {
+				list[key] = len(kept)
+				f.value = strings.Join(mergeRefs(nil, f.value), ", ")
+				kept = append(kept, f)
+				continue
+			}
+			kept[i].value = strings.Join(mergeRefs(strings.Split(kept[i].value, ", "), f.value), ", ")
+			continue
+		}
+		if id := key + f.sep + strings.Join(strings.Fields(f.value), " "); !seen[id] {
+			seen[id] = true
+			kept = append(kept, f)
+		}
+	}
+
+	rank := func(token string) int {
+		rest := len(order)
+		for i, o := range order {
+			switch {
+			case footerKey(o) == footerKey(token):
+				return i
+			case o == FooterOrderRest:
+				rest = i
+			}
+		}
+		return rest
+	}
+	slices.SortStableFunc(kept, func(a, b footer) int {
+		return rank(a.token) - rank(b.token)
+	})
+
+	lines := make([]string, 0, len(kept))
+	for _, f := range kept {
+		lines = append(lines, f.String())
+	}
+	text = strings.Join(append(paragraphs, strings.Join(lines, "\n")), "\n\n")
+	if len(comments) == 0 {
+		return text
+	}
+	return text + "\n\n" + strings.Join(comments, "\n")
+}
+
+// mergeRefs appends the comma-separated references of value to refs,
+// skipping ones already there.
+func mergeRefs(refs []string, value string) []string {
+	for ref := range strings.SplitSeq(value, ",") {
+		if ref = strings.TrimSpace(ref); ref != "" && !slices.Contains(refs, ref) {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
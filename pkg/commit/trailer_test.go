@@ -0,0 +1,124 @@
+package commit
+
+import "testing"
+
+func TestAddTrailer(t *testing.T) {
+	t.Parallel()
+
+	const signoff = "Signed-off-by: Jane Doe <jane@example.com>"
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "subject only",
+			in:   "fix: x",
+			want: "fix: x\n\n" + signoff,
+		},
+		{
+			name: "body without footers",
+			in:   "fix: x\n\nBody text.",
+			want: "fix: x\n\nBody text.\n\n" + signoff,
+		},
+		{
+			name: "existing footer block",
+			in:   "fix: x\n\nBody text.\n\nRefs: #12",
+			want: "fix: x\n\nBody text.\n\nRefs: #12\n" + signoff,
+		},
+		{
+			name: "already present",
+			in:   "fix: x\n\n" + signoff,
+			want: "fix: x\n\n" + signoff,
+		},
+		{
+			name: "usage comments stay last",
+			in:   "fix: x\n\n# cost=$0.01",
+			want: "fix: x\n\n" + signoff + "\n\n# cost=$0.01",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := AddTrailer(tt.in, signoff); got != tt.want {
+				t.Fatalf("AddTrailer() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRemoveTrailers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "one of several",
+			in:   "fix: x\n\nBody.\n\nRefs: #12\nChange-Id: I123",
+			want: "fix: x\n\nBody.\n\nRefs: #12",
+		},
+		{
+			name: "whole block",
+			in:   "fix: x\n\nchange-id: I123\n\n# cost=$0.01",
+			want: "fix: x\n\n# cost=$0.01",
+		},
+		{
+			name: "not in the footer block",
+			in:   "fix: x\n\nChange-Id: I123 is mentioned in prose.\nMore prose.",
+			want: "fix: x\n\nChange-Id: I123 is mentioned in prose.\nMore prose.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := RemoveTrailers(tt.in, "Change-Id"); got != tt.want {
+				t.Fatalf("RemoveTrailers() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeFooters(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		in    string
+		order []string
+		want  string
+	}{
+		{
+			name: "no footers",
+			in:   "fix: x\n\nBody text.",
+			want: "fix: x\n\nBody text.",
+		},
+		{
+			name: "duplicates dropped",
+			in:   "feat: x\n\nBREAKING CHANGE: drops v1\nRefs: #1\n\nBREAKING CHANGE: drops  v1\nrefs: #2, #1",
+			want: "feat: x\n\nBREAKING CHANGE: drops v1\nRefs: #1, #2",
+		},
+		{
+			name:  "breaking change last",
+			in:    "feat: x\n\nBody.\n\nBREAKING-CHANGE: drops v1\n  and v2\nSigned-off-by: A <a@x>\nRefs: #1",
+			order: []string{FooterOrderRest, "Signed-off-by", "BREAKING CHANGE"},
+			want:  "feat: x\n\nBody.\n\nRefs: #1\nSigned-off-by: A <a@x>\nBREAKING-CHANGE: drops v1\n  and v2",
+		},
+		{
+			name:  "unnamed tokens go last without rest",
+			in:    "fix: x\n\nRefs: #1\nBREAKING CHANGE: y\n\n# cost=$0.01",
+			order: []string{"BREAKING CHANGE"},
+			want:  "fix: x\n\nBREAKING CHANGE: y\nRefs: #1\n\n# cost=$0.01",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := NormalizeFooters(tt.in, tt.order); got != tt.want {
+				t.Fatalf("NormalizeFooters() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
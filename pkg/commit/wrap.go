@@ -0,0 +1,241 @@
+package commit
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+var (
+	// footerLine matches a git trailer / Conventional Commits footer line.
+	footerLine = regexp.MustCompile(`^(BREAKING CHANGE|BREAKING-CHANGE|[A-Za-z][\w-]*)(: | #)`)
+	// listItem matches a bullet or numbered list item.
+	listItem = regexp.MustCompile(`^\s*([-*+]|\d+[.)])\s+`)
+)
+
+// isFooterBlock reports whether every line of paragraph p is a footer, in
+// which case its line structure must be kept when p ends the message.
+func isFooterBlock(p string) bool {
+	for line := range strings.SplitSeq(p, "\n") {
+		if !footerLine.MatchString(strings.TrimSpace(line)) {
+			return false
+		}
+	}
+	return true
+}
+
+// isIndentedCode reports whether paragraph p is an indented code block.
+func isIndentedCode(p string) bool {
+	return strings.HasPrefix(p, "    ") || strings.HasPrefix(p, "\t")
+}
+
+// WrapMessage wraps the body of msg to width display columns. The subject
+// line, the footers (the last paragraph, when every line is one) and
+// fenced or indented code are kept as they are. Prose is
+// reflowed, preferring to break after a sentence; list items keep their own
+// lines and wrap with a hanging indent.
+func WrapMessage(msg string, width int) string {
+	msg = strings.TrimSpace(msg)
+	subject, body, _ := strings.Cut(msg, "\n")
+	subject = strings.TrimSpace(subject)
+	blocks := splitBlocks(body)
+	if len(blocks) == 0 {
+		return subject
+	}
+	out := make([]string, 0, len(blocks)+1)
+	out = append(out, subject)
+	for i, b := range blocks {
+		switch {
+		case strings.HasPrefix(strings.TrimSpace(b), "```"), isIndentedCode(b):
+			out = append(out, b)
+		case i == len(blocks)-1 && isFooterBlock(b):
+			out = append(out, b)
+		default:
+			out = append(out, wrapBlock(b, width))
+		}
+	}
+	return strings.Join(out, "\n\n")
+}
+
+// splitBlocks splits body into blank-line separated blocks, keeping fenced
+// code blocks (which may contain blank lines) whole.
+func splitBlocks(body string) []string {
+	var (
+		blocks  []string
+		current []string
+		inFence bool
+	)
+	flush := func() {
+		if len(current) > 0 {
+			blocks = append(blocks, strings.Join(current, "\n"))
+			current = current[:0]
+		}
+	}
+	for line := range strings.SplitSeq(body, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			if !inFence {
+				flush()
+			}
+			current = append(current, line)
+			if inFence {
+				flush()
+			}
+			inFence = !inFence
+			continue
+		}
+		if !inFence && strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		current = append(current, line)
+	}
+	flush()
+	return blocks
+}
+
+// wrapBlock wraps a block that may mix prose lines and list items (e.g.
+// "Changes:" followed by bullets). Each list item starts on its own line;
+// indented lines after an item continue it.
+func wrapBlock(b string, width int) string {
+	var (
+		out    []string
+		prose  []string
+		marker string
+		item   []string
+	)
+	flush := func() {
+		if len(prose) > 0 {
+			out = append(out, wrapParagraph(strings.Join(prose, " "), width))
+			prose = nil
+		}
+		if marker != "" {
+			out = append(out, wrapItem(marker, strings.Join(item, " "), width))
+			marker, item = "", nil
+		}
+	}
+	for line := range strings.SplitSeq(b, "\n") {
+		if loc := listItem.FindStringIndex(line); loc != nil {
+			flush()
+			marker, item = line[:loc[1]], []string{line[loc[1]:]}
+			continue
+		}
+		if marker != "" && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			item = append(item, strings.TrimSpace(line))
+			continue
+		}
+		if marker != "" {
+			flush()
+		}
+		prose = append(prose, line)
+	}
+	flush()
+	return strings.Join(out, "\n")
+}
+
+// wrapItem wraps a list item's text after marker (e.g. "  - " or "1. ") and
+// indents continuation lines to align with the text.
+func wrapItem(marker, text string, width int) string {
+	indent := strings.Repeat(" ", runewidth.StringWidth(marker))
+	lines := strings.Split(wrapParagraph(text, max(width-len(indent), 1)), "\n")
+	for i := range lines {
+		if i == 0 {
+			lines[i] = marker + lines[i]
+		} else {
+			lines[i] = indent + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// wrapParagraph reflows p to width display columns. Words wider than width
+// are broken between wide (CJK) characters, which need no space to break
+// at, and otherwise kept whole on their own line.
+func wrapParagraph(p string, width int) string {
+	words := strings.Fields(p)
+	var (
+		lines   []string
+		line    []string
+		lineLen int
+	)
+	for _, word := range words {
+		w := runewidth.StringWidth(word)
+		for w > width {
+			room := width
+			if len(line) > 0 {
+				room -= lineLen + 1
+			}
+			head, rest, ok := breakWide(word, room)
+			if !ok && len(line) > 0 {
+				lines = append(lines, strings.Join(line, " "))
+				line, lineLen = nil, 0
+				continue
+			}
+			if !ok {
+				break
+			}
+			lines = append(lines, strings.Join(append(line, head), " "))
+			line, lineLen = nil, 0
+			word, w = rest, runewidth.StringWidth(rest)
+		}
+		for len(line) > 0 && lineLen+1+w > width {
+			// Break after the last sentence end on the line when there is
+			// one, so sentences tend to start on a new line.
+			cut := len(line)
+			for i := len(line) - 1; i > 0; i-- {
+				if endsSentence(line[i-1]) {
+					cut = i
+					break
+				}
+			}
+			lines = append(lines, strings.Join(line[:cut], " "))
+			line = append([]string(nil), line[cut:]...)
+			lineLen = runewidth.StringWidth(strings.Join(line, " "))
+		}
+		if len(line) > 0 {
+			lineLen++
+		}
+		line = append(line, word)
+		lineLen += w
+	}
+	if len(line) > 0 {
+		lines = append(lines, strings.Join(line, " "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// noLineStart are the closing marks a line should not start with.
+const noLineStart = "、。，．・：；！？）」』】〉》"
+
+// breakWide splits word at the last break point that keeps head within
+// room columns: a point next to a wide rune that is not followed by a
+// closing mark. ok is false when there is none.
+func breakWide(word string, room int) (head, rest string, ok bool) {
+	var (
+		cols int
+		prev rune
+		cut  int
+	)
+	for i, r := range word {
+		if i > 0 && (runewidth.RuneWidth(prev) == 2 || runewidth.RuneWidth(r) == 2) && !strings.ContainsRune(noLineStart, r) {
+			cut = i
+		}
+		cols += runewidth.RuneWidth(r)
+		if cols > room {
+			break
+		}
+		prev = r
+	}
+	if cut == 0 {
+		return "", word, false
+	}
+	return word[:cut], word[cut:], true
+}
+
+func endsSentence(word string) bool {
+	if strings.HasSuffix(word, "..") {
+		return false
+	}
+	return strings.HasSuffix(word, ".") || strings.HasSuffix(word, "?") || strings.HasSuffix(word, "!") || strings.HasSuffix(word, "。")
+}
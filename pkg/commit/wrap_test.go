@@ -0,0 +1,93 @@
+package commit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattn/go-runewidth"
+)
+
+func TestWrapMessage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "subject never wrapped",
+			in:   "feat: " + strings.Repeat("long ", 20),
+			want: "feat: " + strings.TrimSpace(strings.Repeat("long ", 20)),
+		},
+		{
+			name: "paragraphs kept apart",
+			in:   "fix: x\n\nFirst paragraph.\n\nSecond paragraph.",
+			want: "fix: x\n\nFirst paragraph.\n\nSecond paragraph.",
+		},
+		{
+			name: "prose reflowed",
+			in:   "fix: x\n\nalpha beta\ngamma delta epsilon",
+			want: "fix: x\n\nalpha beta gamma\ndelta epsilon",
+		},
+		{
+			name: "footers verbatim",
+			in:   "feat: y\n\nRefs: #12\nBREAKING CHANGE: gone",
+			want: "feat: y\n\nRefs: #12\nBREAKING CHANGE: gone",
+		},
+		{
+			name: "footer-shaped body paragraph wrapped",
+			in:   "feat: y\n\nNote: this rewrites the whole file\n\nRefs: #12",
+			want: "feat: y\n\nNote: this rewrites\nthe whole file\n\nRefs: #12",
+		},
+		{
+			name: "list items wrap with hanging indent",
+			in:   "feat: y\n\n- one two three four five six\n- b",
+			want: "feat: y\n\n- one two three four\n  five six\n- b",
+		},
+		{
+			name: "prose followed by numbered list",
+			in:   "feat: y\n\nChanges:\n1. first item\n   continues here\n2) second\n  - nested item",
+			want: "feat: y\n\nChanges:\n1. first item\n   continues here\n2) second\n  - nested item",
+		},
+		{
+			name: "code fence with blank lines verbatim",
+			in:   "docs: z\n\n```\na    b\n\nc d e f g h i j k l m n\n```",
+			want: "docs: z\n\n```\na    b\n\nc d e f g h i j k l m n\n```",
+		},
+		{
+			name: "CJK without spaces",
+			in:   "feat: y\n\n提交信息的正文需要按照显示宽度换行，而不是字节数。",
+			want: "feat: y\n\n提交信息的正文需要按\n照显示宽度换行，而不\n是字节数。",
+		},
+		{
+			name: "no line starts with a closing mark",
+			in:   "feat: y\n\nab 提交信息的正文需，要按照",
+			want: "feat: y\n\nab 提交信息的正文\n需，要按照",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := WrapMessage(tt.in, 20); got != tt.want {
+				t.Fatalf("WrapMessage() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrapMessageDisplayWidth(t *testing.T) {
+	t.Parallel()
+
+	body := strings.Repeat("变更 ", 30) + strings.Repeat("🚀 ", 30)
+	got := WrapMessage("feat: 国际化\n\n"+body, BodyLineWidth)
+	_, wrapped, _ := strings.Cut(got, "\n\n")
+	for line := range strings.SplitSeq(wrapped, "\n") {
+		if w := runewidth.StringWidth(line); w > BodyLineWidth {
+			t.Fatalf("line %q is %d columns wide", line, w)
+		}
+	}
+	if strings.Join(strings.Fields(wrapped), " ") != strings.TrimSpace(body) {
+		t.Fatalf("wrapping changed the text: %q", wrapped)
+	}
+}
@@ -0,0 +1,209 @@
+// Package commitlint validates commit messages against a set of named rules
+// modelled on commitlint (https://commitlint.js.org). Each rule has a
+// configurable severity and reports machine-readable problems, so other Go
+// tools can apply exactly the same checks the CLI runs on generated messages.
+package commitlint
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Severity is the level a rule reports at. The numeric values match
+// commitlint's rule levels (0 = disabled, 1 = warning, 2 = error).
+type Severity int
+
+const (
+	SeverityOff Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return "off"
+	}
+}
+
+// MarshalText encodes the severity by name for JSON/YAML output.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText accepts either a name ("off", "warning", "error") or a
+// commitlint numeric level ("0", "1", "2").
+func (s *Severity) UnmarshalText(text []byte) error {
+	switch strings.ToLower(strings.TrimSpace(string(text))) {
+	case "off", "0":
+		*s = SeverityOff
+	case "warning", "warn", "1":
+		*s = SeverityWarning
+	case "error", "2":
+		*s = SeverityError
+	default:
+		return fmt.Errorf("unknown severity %q", string(text))
+	}
+	return nil
+}
+
+// RuleConfig configures a single rule.
+type RuleConfig struct {
+	Severity Severity `json:"severity"`
+	// Limit is the length limit used by the *-max-length rules.
+	Limit int `json:"limit,omitempty"`
+	// Values is the allowed set used by the *-enum rules.
+	Values []string `json:"values,omitempty"`
+	// Condition is ConditionAlways or ConditionNever for rules that take
+	// one (scope-empty); empty means ConditionNever.
+	Condition string `json:"condition,omitempty"`
+}
+
+// Rule conditions, as in commitlint's [level, condition, value] tuples.
+const (
+	ConditionAlways = "always"
+	ConditionNever  = "never"
+)
+
+// Config maps rule names to their configuration. Rules missing from the map
+// are disabled.
+type Config map[string]RuleConfig
+
+// Problem is a single rule violation.
+type Problem struct {
+	Rule     string   `json:"name"`
+	Severity Severity `json:"level"`
+	Message  string   `json:"message"`
+}
+
+// Result is the outcome of linting one message. The shape follows
+// commitlint's JSON formatter output.
+type Result struct {
+	Valid    bool      `json:"valid"`
+	Input    string    `json:"input"`
+	Errors   []Problem `json:"errors"`
+	Warnings []Problem `json:"warnings"`
+}
+
+// Problems returns errors followed by warnings.
+func (r Result) Problems() []Problem {
+	out := make([]Problem, 0, len(r.Errors)+len(r.Warnings))
+	out = append(out, r.Errors...)
+	return append(out, r.Warnings...)
+}
+
+// Rule is a named check. Check returns a description of the violation, or
+// an empty string when the message satisfies the rule.
+type Rule struct {
+	Name  string
+	Check func(msg Message, cfg RuleConfig) string
+}
+
+// DefaultTypes are the commit types allowed by the type-enum rule in
+// DefaultConfig (the @commitlint/config-conventional set).
+var DefaultTypes = []string{
+	"build", "chore", "ci", "docs", "feat", "fix",
+	"perf", "refactor", "revert", "style", "test",
+}
+
+// DefaultConfig returns the rule configuration the CLI applies to generated
+// Conventional Commit messages.
+func DefaultConfig() Config {
+	return Config{
+		"header-trim":            {Severity: SeverityError},
+		"header-format":          {Severity: SeverityError},
+		"header-max-length":      {Severity: SeverityError, Limit: 72},
+		"type-empty":             {Severity: SeverityError},
+		"type-case":              {Severity: SeverityError},
+		"type-enum":              {Severity: SeverityError, Values: slices.Clone(DefaultTypes)},
+		"scope-case":             {Severity: SeverityError},
+		"subject-empty":          {Severity: SeverityError},
+		"subject-full-stop":      {Severity: SeverityError},
+		"body-leading-blank":     {Severity: SeverityWarning},
+		"body-max-line-length":   {Severity: SeverityError, Limit: 72},
+		"footer-leading-blank":   {Severity: SeverityWarning},
+		"footer-max-line-length": {Severity: SeverityError, Limit: 100},
+	}
+}
+
+// ErrUnknownRule is returned by Config.Validate for rule names that are not
+// registered.
+var ErrUnknownRule = errors.New("unknown rule")
+
+// Validate reports rule names in cfg that no registered rule implements.
+func (c Config) Validate() error {
+	for name := range c {
+		if _, ok := lookup(name); !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownRule, name)
+		}
+	}
+	return nil
+}
+
+// Rules returns the names of all registered rules in evaluation order.
+func Rules() []string {
+	names := make([]string, 0, len(rules))
+	for _, r := range rules {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+// ignoredHeaders are header prefixes git generates itself; like
+// commitlint's default ignores, such messages always pass.
+var ignoredHeaders = []string{"Merge ", "Revert \"", "fixup! ", "squash! ", "amend! ", "Automatic merge"}
+
+// Ignored reports whether msg is a git-generated message that Lint skips.
+func Ignored(msg string) bool {
+	header := Parse(msg).Header
+	for _, prefix := range ignoredHeaders {
+		if strings.HasPrefix(header, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Lint checks msg against every enabled rule in cfg. Lines starting with
+// '#' are ignored, matching git's default commit.cleanup behaviour.
+func Lint(msg string, cfg Config) Result {
+	parsed := Parse(msg)
+	res := Result{Input: msg, Errors: []Problem{}, Warnings: []Problem{}}
+	if Ignored(msg) {
+		res.Valid = true
+		return res
+	}
+	for _, r := range rules {
+		rc, ok := cfg[r.Name]
+		if !ok || rc.Severity == SeverityOff {
+			continue
+		}
+		text := r.Check(parsed, rc)
+		if text == "" {
+			continue
+		}
+		p := Problem{Rule: r.Name, Severity: rc.Severity, Message: text}
+		if rc.Severity == SeverityError {
+			res.Errors = append(res.Errors, p)
+		} else {
+			res.Warnings = append(res.Warnings, p)
+		}
+	}
+	res.Valid = len(res.Errors) == 0
+	return res
+}
+
+func lookup(name string) (Rule, bool) {
+	for _, r := range rules {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
@@ -0,0 +1,130 @@
+package commitlint
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLintValidMessage(t *testing.T) {
+	t.Parallel()
+
+	res := Lint("feat(parser): add array support\n\nParse arrays in the config.\n\nRefs: #12\n# cost=$0.01", DefaultConfig())
+	if !res.Valid || len(res.Warnings) != 0 {
+		t.Fatalf("expected valid message, got %+v", res)
+	}
+}
+
+func TestLintReportsNamedProblems(t *testing.T) {
+	t.Parallel()
+
+	res := Lint("Feature(API): Added things.\nno blank line", DefaultConfig())
+	if res.Valid {
+		t.Fatalf("expected invalid result: %+v", res)
+	}
+	var names []string
+	for _, p := range res.Problems() {
+		names = append(names, p.Rule)
+	}
+	for _, want := range []string{"type-case", "type-enum", "scope-case", "subject-full-stop", "body-leading-blank"} {
+		if !slices.Contains(names, want) {
+			t.Errorf("missing %s in %v", want, names)
+		}
+	}
+}
+
+func TestLintSeverityOff(t *testing.T) {
+	t.Parallel()
+
+	cfg := DefaultConfig()
+	cfg["type-enum"] = RuleConfig{Severity: SeverityOff}
+	if res := Lint("deps: bump go", cfg); !res.Valid {
+		t.Fatalf("type-enum disabled but got %+v", res.Errors)
+	}
+}
+
+func TestParseFooterAndBreaking(t *testing.T) {
+	t.Parallel()
+
+	m := Parse("fix!: drop v1\n\nbody text\n\nBREAKING CHANGE: v1 removed\nRefs: #1")
+	if m.Type != "fix" || !m.Breaking || m.Subject != "drop v1" {
+		t.Fatalf("unexpected header parse: %+v", m)
+	}
+	if len(m.Footer) != 2 || !m.FooterLeadingBlank {
+		t.Fatalf("unexpected footer parse: %+v", m)
+	}
+}
+
+func TestResultJSON(t *testing.T) {
+	t.Parallel()
+
+	data, err := json.Marshal(Lint("feat: ok", DefaultConfig()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"valid":true`) || !strings.Contains(string(data), `"errors":[]`) {
+		t.Fatalf("unexpected JSON: %s", data)
+	}
+}
+
+func TestLintScopeEnum(t *testing.T) {
+	t.Parallel()
+
+	cfg := DefaultConfig()
+	cfg["scope-enum"] = RuleConfig{Severity: SeverityError, Values: []string{"auth", "ui"}}
+	if res := Lint("feat(auth,ui): share session banner", cfg); !res.Valid {
+		t.Fatalf("comma scope of allowed values rejected: %+v", res.Errors)
+	}
+	if res := Lint("feat(billing): add invoices", cfg); res.Valid {
+		t.Fatal("unknown scope accepted")
+	}
+}
+
+func TestLintScopeEmpty(t *testing.T) {
+	t.Parallel()
+
+	cfg := DefaultConfig()
+	cfg["scope-empty"] = RuleConfig{Severity: SeverityError}
+	if res := Lint("feat: add invoices", cfg); res.Valid {
+		t.Fatal("missing scope accepted")
+	}
+	if res := Lint("feat(billing): add invoices", cfg); !res.Valid {
+		t.Fatalf("scoped message rejected: %+v", res.Errors)
+	}
+	cfg["scope-empty"] = RuleConfig{Severity: SeverityError, Condition: ConditionAlways}
+	if res := Lint("feat(billing): add invoices", cfg); res.Valid {
+		t.Fatal("forbidden scope accepted")
+	}
+	if res := Lint("feat: add invoices", cfg); !res.Valid {
+		t.Fatalf("unscoped message rejected: %+v", res.Errors)
+	}
+}
+
+func TestLintHeaderFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		msg  string
+		want bool
+	}{
+		{"feat:nospace", true},
+		{"feat(): x", true},
+		{"feat( )!: x", true},
+		{"feat(api):x", true},
+		{"feat: x", false},
+		{"feat(api)!: x", false},
+		{"feat:", false},
+		{"Update the docs", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			t.Parallel()
+			res := Lint(tt.msg, DefaultConfig())
+			got := slices.ContainsFunc(res.Problems(), func(p Problem) bool { return p.Rule == "header-format" })
+			if got != tt.want {
+				t.Fatalf("header-format reported = %v, want %v (%+v)", got, tt.want, res.Problems())
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package commitlint
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Message is the structural view of a commit message the rules operate on.
+type Message struct {
+	Header   string
+	Type     string
+	Scope    string
+	Breaking bool
+	Subject  string
+	// Body is every line between the header and the footer block, including
+	// the separating blank line (if any) as its first element.
+	Body []string
+	// Footer holds the trailing footer lines (token: value / token #value).
+	Footer []string
+	// FooterLeadingBlank reports whether a blank line precedes the footer.
+	FooterLeadingBlank bool
+}
+
+var (
+	// headerPattern is lenient so that malformed headers still yield a type;
+	// header-format reports the empty scope or missing space it accepts.
+	headerPattern = regexp.MustCompile(`^(\w*)(\(([^()]*)\))?(!)?:( ?)(.*)$`)
+	footerPattern = regexp.MustCompile(`^(BREAKING CHANGE|BREAKING-CHANGE|[\w-]+)(: | #)`)
+)
+
+// Parse splits msg into header fields, body and footer. Comment lines
+// starting with '#' are dropped first. Headers that do not follow the
+// Conventional Commits shape leave Type and Scope empty and put the whole
+// header into Subject.
+func Parse(msg string) Message {
+	lines := make([]string, 0, strings.Count(msg, "\n")+1)
+	for line := range strings.SplitSeq(msg, "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, strings.TrimRight(line, "\r"))
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return Message{}
+	}
+
+	m := Message{Header: lines[0]}
+	if sub := headerPattern.FindStringSubmatch(strings.TrimSpace(m.Header)); sub != nil {
+		m.Type = sub[1]
+		m.Scope = sub[3]
+		m.Breaking = sub[4] == "!"
+		m.Subject = sub[6]
+	} else {
+		m.Subject = strings.TrimSpace(m.Header)
+	}
+
+	rest := lines[1:]
+	footerStart := len(rest)
+	for i := len(rest) - 1; i >= 0; i-- {
+		if strings.TrimSpace(rest[i]) == "" {
+			break
+		}
+		if footerPattern.MatchString(rest[i]) {
+			footerStart = i
+		}
+	}
+	// A footer block must start at a token line directly after a blank line
+	// (or the header); otherwise the trailing lines are body text.
+	if footerStart < len(rest) && footerStart > 0 && strings.TrimSpace(rest[footerStart-1]) != "" {
+		footerStart = len(rest)
+	}
+	m.Body = rest[:footerStart]
+	m.Footer = rest[footerStart:]
+	if len(m.Footer) > 0 {
+		m.FooterLeadingBlank = footerStart > 0 && strings.TrimSpace(rest[footerStart-1]) == ""
+		for _, f := range m.Footer {
+			if strings.HasPrefix(f, "BREAKING CHANGE") || strings.HasPrefix(f, "BREAKING-CHANGE") {
+				m.Breaking = true
+			}
+		}
+	}
+	return m
+}
+
+// hasBody reports whether the message has any non-blank body text.
+func (m Message) hasBody() bool {
+	for _, line := range m.Body {
+		if strings.TrimSpace(line) != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package commitlint
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// rules is the registry of built-in checks, in evaluation order. Names match
+// the corresponding commitlint rules.
+var rules = []Rule{
+	{Name: "header-trim", Check: checkHeaderTrim},
+	{Name: "header-format", Check: checkHeaderFormat},
+	{Name: "header-max-length", Check: checkHeaderMaxLength},
+	{Name: "type-empty", Check: checkTypeEmpty},
+	{Name: "type-case", Check: checkTypeCase},
+	{Name: "type-enum", Check: checkTypeEnum},
+	{Name: "scope-case", Check: checkScopeCase},
+	{Name: "scope-enum", Check: checkScopeEnum},
+	{Name: "scope-empty", Check: checkScopeEmpty},
+	{Name: "subject-empty", Check: checkSubjectEmpty},
+	{Name: "subject-full-stop", Check: checkSubjectFullStop},
+	{Name: "body-leading-blank", Check: checkBodyLeadingBlank},
+	{Name: "body-max-line-length", Check: checkBodyMaxLineLength},
+	{Name: "footer-leading-blank", Check: checkFooterLeadingBlank},
+	{Name: "footer-max-line-length", Check: checkFooterMaxLineLength},
+}
+
+func checkHeaderTrim(m Message, _ RuleConfig) string {
+	if m.Header != strings.TrimSpace(m.Header) {
+		return "header must not be surrounded by whitespace"
+	}
+	return ""
+}
+
+// checkHeaderFormat reports Conventional Commits headers that only parse
+// leniently: an empty scope or no space after the colon. Headers without a
+// type are left to type-empty.
+func checkHeaderFormat(m Message, _ RuleConfig) string {
+	sub := headerPattern.FindStringSubmatch(strings.TrimSpace(m.Header))
+	switch {
+	case sub == nil || sub[1] == "":
+		return ""
+	case sub[2] != "" && strings.TrimSpace(sub[3]) == "":
+		return "header must not have an empty scope; drop the parentheses"
+	case sub[5] == "" && sub[6] != "":
+		return "header must have a space after the colon"
+	}
+	return ""
+}
+
+func checkHeaderMaxLength(m Message, rc RuleConfig) string {
+	if rc.Limit > 0 && utf8.RuneCountInString(m.Header) > rc.Limit {
+		return fmt.Sprintf("header must not be longer than %d characters, current length is %d", rc.Limit, utf8.RuneCountInString(m.Header))
+	}
+	return ""
+}
+
+func checkTypeEmpty(m Message, _ RuleConfig) string {
+	if m.Type == "" {
+		return "type may not be empty"
+	}
+	return ""
+}
+
+func checkTypeCase(m Message, _ RuleConfig) string {
+	if m.Type != strings.ToLower(m.Type) {
+		return "type must be lower-case"
+	}
+	return ""
+}
+
+func checkTypeEnum(m Message, rc RuleConfig) string {
+	if m.Type == "" || len(rc.Values) == 0 || slices.Contains(rc.Values, m.Type) {
+		return ""
+	}
+	return "type must be one of [" + strings.Join(rc.Values, ", ") + "]"
+}
+
+func checkScopeCase(m Message, _ RuleConfig) string {
+	if m.Scope != strings.ToLower(m.Scope) {
+		return "scope must be lower-case"
+	}
+	return ""
+}
+
+// checkScopeEnum accepts comma-separated multi-scopes when every part is
+// allowed.
+func checkScopeEnum(m Message, rc RuleConfig) string {
+	if m.Scope == "" || len(rc.Values) == 0 {
+		return ""
+	}
+	for part := range strings.SplitSeq(m.Scope, ",") {
+		if !slices.Contains(rc.Values, strings.TrimSpace(part)) {
+			return "scope must be one of [" + strings.Join(rc.Values, ", ") + "]"
+		}
+	}
+	return ""
+}
+
+// checkScopeEmpty requires a scope, or with Condition "always" forbids one.
+// Messages without a type are left to type-empty.
+func checkScopeEmpty(m Message, rc RuleConfig) string {
+	switch {
+	case rc.Condition == ConditionAlways && m.Scope != "":
+		return "scope must be empty"
+	case rc.Condition != ConditionAlways && m.Type != "" && strings.TrimSpace(m.Scope) == "":
+		return "scope may not be empty"
+	}
+	return ""
+}
+
+func checkSubjectEmpty(m Message, _ RuleConfig) string {
+	if strings.TrimSpace(m.Subject) == "" {
+		return "subject may not be empty"
+	}
+	return ""
+}
+
+func checkSubjectFullStop(m Message, _ RuleConfig) string {
+	if strings.HasSuffix(strings.TrimSpace(m.Subject), ".") {
+		return "subject may not end with full stop"
+	}
+	return ""
+}
+
+func checkBodyLeadingBlank(m Message, _ RuleConfig) string {
+	if len(m.Body) > 0 && m.hasBody() && strings.TrimSpace(m.Body[0]) != "" {
+		return "body must have leading blank line"
+	}
+	return ""
+}
+
+func checkBodyMaxLineLength(m Message, rc RuleConfig) string {
+	return checkLineLengths("body", m.Body, rc.Limit)
+}
+
+func checkFooterLeadingBlank(m Message, _ RuleConfig) string {
+	if len(m.Footer) > 0 && !m.FooterLeadingBlank {
+		return "footer must have leading blank line"
+	}
+	return ""
+}
+
+func checkFooterMaxLineLength(m Message, rc RuleConfig) string {
+	return checkLineLengths("footer", m.Footer, rc.Limit)
+}
+
+func checkLineLengths(section string, lines []string, limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+	for _, line := range lines {
+		if n := utf8.RuneCountInString(line); n > limit {
+			return fmt.Sprintf("%s's lines must not be longer than %d characters", section, limit)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,275 @@
+// Package feedback stores the edits people make to generated messages
+// before committing them (.git/git-ai/feedback.jsonl) and turns recent
+// edits into prompt guidance ("the user usually shortens subjects").
+package feedback
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/commitlint"
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/ndjson"
+)
+
+const fileName = "feedback.jsonl"
+
+// Edit is one generated message and the message that was committed.
+type Edit struct {
+	Time      time.Time `json:"time"`
+	Generated string    `json:"generated"`
+	Committed string    `json:"committed"`
+	// Diff is a line diff from Generated to Committed.
+	Diff string `json:"diff"`
+}
+
+// NewEdit returns the Edit for a generated and a committed message, or
+// false when they are the same.
+func NewEdit(generated, committed string) (Edit, bool) {
+	generated, committed = strings.TrimSpace(generated), strings.TrimSpace(committed)
+	if generated == committed {
+		return Edit{}, false
+	}
+	return Edit{Generated: generated, Committed: committed, Diff: LineDiff(generated, committed)}, true
+}
+
+// Path returns the feedback file shared by all worktrees of the repository.
+func Path() (string, error) {
+	dir, err := git.CommonDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "git-ai", fileName), nil
+}
+
+// Append adds e to the feedback file, stamping Time when unset.
+func Append(e Edit) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Recent returns up to n of the latest edits, oldest first. A missing file
+// yields no edits and no error; malformed lines are skipped.
+func Recent(n int) ([]Edit, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	edits, err := ndjson.Decode[Edit](f)
+	if err != nil {
+		return nil, err
+	}
+	if len(edits) > n {
+		edits = edits[len(edits)-n:]
+	}
+	return edits, nil
+}
+
+// LineDiff renders a minimal line diff of a to b ("-" removed, "+" added,
+// " " kept).
+func LineDiff(a, b string) string {
+	var out strings.Builder
+	diffTokens(strings.Split(a, "\n"), strings.Split(b, "\n"), func(op byte, line string) {
+		out.WriteString(string(op) + line + "\n")
+	})
+	return out.String()
+}
+
+// WordChange is a run of text in a word diff: Op is '-' for removed, '+'
+// for added and ' ' for kept text.
+type WordChange struct {
+	Op   byte
+	Text string
+}
+
+var wordPattern = regexp.MustCompile(`\s+|\S+`)
+
+// WordDiff returns a minimal diff of a to b by words, keeping whitespace
+// and line breaks in the text of the changes. Adjacent changes of the same
+// kind are merged.
+func WordDiff(a, b string) []WordChange {
+	var changes []WordChange
+	diffTokens(wordPattern.FindAllString(a, -1), wordPattern.FindAllString(b, -1), func(op byte, word string) {
+		if n := len(changes); n > 0 && changes[n-1].Op == op {
+			changes[n-1].Text += word
+			return
+		}
+		changes = append(changes, WordChange{Op: op, Text: word})
+	})
+	return changes
+}
+
+// diffTokens calls emit for each token of a longest-common-subsequence
+// diff of x to y, removals before additions.
+func diffTokens(x, y []string, emit func(op byte, token string)) {
+	// lcs[i][j] is the LCS length of x[i:] and y[j:].
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var i, j int
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			emit(' ', x[i])
+			i, j = i+1, j+1
+		case i < len(x) && (j == len(y) || lcs[i+1][j] >= lcs[i][j+1]):
+			emit('-', x[i])
+			i++
+		default:
+			emit('+', y[j])
+			j++
+		}
+	}
+}
+
+// minPattern is how many edits must agree before a pattern is reported.
+const minPattern = 2
+
+// Patterns describes the recurring edits in edits, most frequent first.
+func Patterns(edits []Edit) []string {
+	var (
+		shortened, shortGen, shortCommit int
+		bodyDropped, bodyAdded           int
+		scopes                           = map[string]int{}
+		types                            = map[string]int{}
+	)
+	for _, e := range edits {
+		gen, com := commitlint.Parse(e.Generated), commitlint.Parse(e.Committed)
+		if len(com.Header) < len(gen.Header)-5 {
+			shortened++
+			shortGen += len(gen.Header)
+			shortCommit += len(com.Header)
+		}
+		switch genBody, comBody := hasText(gen.Body), hasText(com.Body); {
+		case genBody && !comBody:
+			bodyDropped++
+		case !genBody && comBody:
+			bodyAdded++
+		}
+		if gen.Scope != com.Scope && com.Type != "" {
+			scopes[describeChange(gen.Scope, com.Scope)]++
+		}
+		if gen.Type != com.Type && gen.Type != "" && com.Type != "" {
+			types[gen.Type+" to "+com.Type]++
+		}
+	}
+
+	type pattern struct {
+		text  string
+		count int
+	}
+	var found []pattern
+	if shortened >= minPattern {
+		found = append(found, pattern{fmt.Sprintf("shortens the subject line (from about %d to %d characters)", shortGen/shortened, shortCommit/shortened), shortened})
+	}
+	if bodyDropped >= minPattern {
+		found = append(found, pattern{"removes the body and keeps only the subject line", bodyDropped})
+	}
+	if bodyAdded >= minPattern {
+		found = append(found, pattern{"adds a body explaining the change", bodyAdded})
+	}
+	for change, n := range scopes {
+		if n >= minPattern {
+			found = append(found, pattern{change, n})
+		}
+	}
+	for change, n := range types {
+		if n >= minPattern {
+			found = append(found, pattern{"changes the type from " + change, n})
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].count != found[j].count {
+			return found[i].count > found[j].count
+		}
+		return found[i].text < found[j].text
+	})
+	out := make([]string, 0, len(found))
+	for _, p := range found {
+		out = append(out, fmt.Sprintf("%s (%d of the last %d edits)", p.text, p.count, len(edits)))
+	}
+	return out
+}
+
+func describeChange(from, to string) string {
+	switch {
+	case from == "":
+		return fmt.Sprintf("adds the scope %q", to)
+	case to == "":
+		return fmt.Sprintf("removes the scope %q", from)
+	default:
+		return fmt.Sprintf("prefers the scope %q over %q", to, from)
+	}
+}
+
+func hasText(lines []string) bool {
+	for _, l := range lines {
+		if strings.TrimSpace(l) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Note renders the patterns in edits as extra prompt context, or an empty
+// string when no pattern recurs.
+func Note(edits []Edit) string {
+	patterns := Patterns(edits)
+	if len(patterns) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("When editing generated messages before committing, this user usually:\n")
+	for _, p := range patterns {
+		b.WriteString("- " + p + "\n")
+	}
+	b.WriteString("Write the message the way they would commit it.\n")
+	return b.String()
+}
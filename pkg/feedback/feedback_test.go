@@ -0,0 +1,65 @@
+package feedback
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLineDiff(t *testing.T) {
+	t.Parallel()
+
+	got := LineDiff("feat: a\n\nbody\nmore", "feat: b\n\nbody")
+	want := "-feat: a\n+feat: b\n \n body\n-more\n"
+	if got != want {
+		t.Errorf("LineDiff() = %q, want %q", got, want)
+	}
+}
+
+func TestWordDiff(t *testing.T) {
+	t.Parallel()
+
+	got := WordDiff("feat(api): add export\n\nAdds CSV.", "feat(api): add CSV export\n\nAdds CSV.")
+	want := []WordChange{
+		{Op: ' ', Text: "feat(api): add "},
+		{Op: '+', Text: "CSV "},
+		{Op: ' ', Text: "export\n\nAdds CSV."},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("WordDiff() = %q, want %q", got, want)
+	}
+	if got := WordDiff("fix: a", "fix: a"); len(got) != 1 || got[0].Op != ' ' {
+		t.Errorf("WordDiff() of equal messages = %q", got)
+	}
+}
+
+func TestNewEditSkipsUnchanged(t *testing.T) {
+	t.Parallel()
+
+	if _, ok := NewEdit("fix: x\n", " fix: x"); ok {
+		t.Error("NewEdit() reported an edit for identical messages")
+	}
+}
+
+func TestNote(t *testing.T) {
+	t.Parallel()
+
+	edits := []Edit{
+		{Generated: "feat(core): add a much longer subject line than needed\n\nBody text.", Committed: "feat(api): add subject"},
+		{Generated: "fix(core): correct the handling of some long edge case\n\nBody.", Committed: "fix(api): correct edge case"},
+		{Generated: "docs: update", Committed: "docs: update readme"},
+	}
+	note := Note(edits)
+	for _, want := range []string{
+		"shortens the subject line",
+		"removes the body",
+		`prefers the scope "api" over "core" (2 of the last 3 edits)`,
+	} {
+		if !strings.Contains(note, want) {
+			t.Errorf("Note() missing %q in:\n%s", want, note)
+		}
+	}
+	if Note(edits[2:]) != "" {
+		t.Error("Note() reported a pattern from a single edit")
+	}
+}
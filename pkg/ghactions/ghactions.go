@@ -0,0 +1,74 @@
+// Package ghactions writes GitHub Actions workflow commands and job
+// summaries, so that problems show up as annotations on the workflow run
+// and generated messages in the summary of the job.
+package ghactions
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// Env is set to "true" by the GitHub Actions runner.
+	Env = "GITHUB_ACTIONS"
+	// SummaryEnv names the Markdown file of the job summary of the step.
+	SummaryEnv = "GITHUB_STEP_SUMMARY"
+)
+
+// Annotation levels.
+const (
+	Notice  = "notice"
+	Warning = "warning"
+	Error   = "error"
+)
+
+var (
+	dataEscaper     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	propertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+// Enabled reports whether the process runs in a GitHub Actions workflow.
+func Enabled() bool {
+	return os.Getenv(Env) == "true"
+}
+
+// Annotate writes the workflow command of an annotation at level with an
+// optional title to w. The runner reads commands from stdout and stderr;
+// stderr keeps them out of a message captured from stdout.
+func Annotate(w io.Writer, level, title, message string) {
+	props := ""
+	if title != "" {
+		props = " title=" + propertyEscaper.Replace(title)
+	}
+	fmt.Fprintf(w, "::%s%s::%s\n", level, props, dataEscaper.Replace(message))
+}
+
+// AppendSummary appends Markdown to the job summary. It does nothing
+// outside a workflow step.
+func AppendSummary(markdown string) error {
+	path := os.Getenv(SummaryEnv)
+	if path == "" {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open the job summary: %w", err)
+	}
+	if _, err = io.WriteString(f, strings.TrimRight(markdown, "\n")+"\n\n"); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write the job summary: %w", err)
+	}
+	return f.Close()
+}
+
+// CodeBlock returns text as a fenced Markdown code block, with a fence
+// longer than any backtick run in text.
+func CodeBlock(lang, text string) string {
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	return fence + lang + "\n" + strings.TrimRight(text, "\n") + "\n" + fence + "\n"
+}
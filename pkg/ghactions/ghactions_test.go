@@ -0,0 +1,56 @@
+package ghactions
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAnnotate(t *testing.T) {
+	tests := []struct {
+		level, title, message string
+		want                  string
+	}{
+		{Error, "commitlint type-empty", "type may not be empty", "::error title=commitlint type-empty::type may not be empty\n"},
+		{Warning, "", "100% of\nthe body", "::warning::100%25 of%0Athe body\n"},
+		{Notice, "scope: api, web", "feat(api): add retries", "::notice title=scope%3A api%2C web::feat(api): add retries\n"},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		Annotate(&b, tt.level, tt.title, tt.message)
+		if b.String() != tt.want {
+			t.Errorf("Annotate() = %q, want %q", b.String(), tt.want)
+		}
+	}
+}
+
+func TestAppendSummary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "summary.md")
+	t.Setenv(SummaryEnv, path)
+	for _, md := range []string{"### first\n", "### second"} {
+		if err := AppendSummary(md); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "### first\n\n### second\n\n"; string(got) != want {
+		t.Errorf("summary = %q, want %q", got, want)
+	}
+
+	t.Setenv(SummaryEnv, "")
+	if err := AppendSummary("ignored"); err != nil {
+		t.Errorf("AppendSummary() outside a step = %v, want nil", err)
+	}
+}
+
+func TestCodeBlock(t *testing.T) {
+	got := CodeBlock("text", "docs: show ```go``` fences\n")
+	want := "````text\ndocs: show ```go``` fences\n````\n"
+	if got != want {
+		t.Errorf("CodeBlock() = %q, want %q", got, want)
+	}
+}
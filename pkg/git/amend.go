@@ -0,0 +1,44 @@
+package git
+
+import "errors"
+
+// emptyTree is the object name of the empty tree, the base of a root
+// commit.
+const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// diffBase is the revision the staged diff is taken against instead of
+// HEAD; empty unless SetAmend was called.
+var diffBase string
+
+// SetAmend makes the staged diff functions compare the index with the
+// parent of HEAD, so that they describe the commit git commit --amend
+// would create. It fails when there is no commit to amend.
+func SetAmend() error {
+	if _, err := ResolveCommit("HEAD"); err != nil {
+		return errors.New("there is no commit to amend")
+	}
+	diffBase = emptyTree
+	if parent, err := ResolveCommit("HEAD^"); err == nil {
+		diffBase = parent
+	}
+	return nil
+}
+
+// BaseRev returns the revision the staged changes are compared with: HEAD,
+// or its parent after SetAmend.
+func BaseRev() string {
+	if diffBase == "" {
+		return "HEAD"
+	}
+	return diffBase
+}
+
+// diffStagedArgs returns the arguments of git diff --staged followed by
+// args, against the amend base after SetAmend.
+func diffStagedArgs(args ...string) []string {
+	base := []string{"diff", "--staged"}
+	if diffBase != "" {
+		base = append(base, diffBase)
+	}
+	return append(base, args...)
+}
@@ -0,0 +1,219 @@
+package git
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// FileChange is one entry of the per-file change summary.
+type FileChange struct {
+	// Status is added, modified, deleted, renamed, copied or typechange.
+	Status string
+	Path   string
+	// OldPath is the source path of a rename or copy.
+	OldPath string
+}
+
+// String renders the change as "renamed: old -> new" or "added: path".
+func (c FileChange) String() string {
+	if c.OldPath != "" {
+		return c.Status + ": " + c.OldPath + " -> " + c.Path
+	}
+	return c.Status + ": " + c.Path
+}
+
+var statusNames = map[byte]string{
+	'A': "added",
+	'M': "modified",
+	'D': "deleted",
+	'R': "renamed",
+	'C': "copied",
+	'T': "typechange",
+}
+
+// StagedChanges returns the staged files with rename and copy detection
+// enabled.
+func StagedChanges() ([]FileChange, error) {
+	if err := checkGitDir(); err != nil {
+		return nil, err
+	}
+	cmd := gitCmd(diffStagedArgs("--name-status", "-z", "-M", "-C")...)
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list staged changes: %w", err)
+	}
+	fields := strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00")
+	changes := make([]FileChange, 0, len(fields)/2)
+	for i := 0; i+1 < len(fields); i += 2 {
+		status := fields[i]
+		if status == "" {
+			continue
+		}
+		name, ok := statusNames[status[0]]
+		if !ok {
+			name = "modified"
+		}
+		change := FileChange{Status: name, Path: fields[i+1]}
+		if (status[0] == 'R' || status[0] == 'C') && i+2 < len(fields) {
+			change.OldPath, change.Path = fields[i+1], fields[i+2]
+			i++
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// FormattingOnly reports whether the staged changes only touch whitespace
+// and blank lines: git diff --staged -w --ignore-blank-lines shows nothing
+// although changes are staged. Renames, mode changes and binary files
+// count as real changes, and so do changes to files where whitespace
+// matters (see WhitespaceSensitive) and whitespace changes inside string
+// literals.
+func FormattingOnly(changes []FileChange) (bool, error) {
+	if len(changes) == 0 {
+		return false, nil
+	}
+	for _, c := range changes {
+		if WhitespaceSensitive(c.Path) || (c.OldPath != "" && WhitespaceSensitive(c.OldPath)) {
+			return false, nil
+		}
+	}
+	cmd := gitCmd(diffStagedArgs("-M", "-C", "-w", "--ignore-blank-lines", "-U0", "--no-color")...)
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to read staged diff (git diff --staged -w): %w", err)
+	}
+	if strings.TrimSpace(string(out)) != "" {
+		return false, nil
+	}
+	cmd = gitCmd(diffStagedArgs("-M", "-C", "-U0", "--no-color")...)
+	cmd.Stderr = io.Discard
+	if out, err = cmd.Output(); err != nil {
+		return false, fmt.Errorf("failed to read staged diff: %w", err)
+	}
+	return !literalWhitespaceChanged(string(out)), nil
+}
+
+// whitespaceNames and whitespaceExts are the files where indentation or
+// blank lines carry meaning: Python blocks, YAML nesting, Makefile tabs and
+// Markdown line breaks and code blocks.
+var (
+	whitespaceNames = []string{"Makefile", "makefile", "GNUmakefile"}
+	whitespaceExts  = []string{".py", ".pyi", ".yaml", ".yml", ".mk", ".md", ".markdown"}
+)
+
+// WhitespaceSensitive reports whether a whitespace-only change to p can
+// change what the file means.
+func WhitespaceSensitive(p string) bool {
+	base := path.Base(p)
+	return slices.Contains(whitespaceNames, base) || slices.Contains(whitespaceExts, strings.ToLower(path.Ext(base)))
+}
+
+// literalWhitespaceChanged reports whether a -U0 diff changes whitespace
+// inside a quoted string: a hunk whose removed and added lines differ once
+// the whitespace outside quotes is dropped. Quotes are matched per hunk,
+// so a string that starts before the hunk can be misread; the check errs
+// towards reporting a change.
+func literalWhitespaceChanged(diff string) bool {
+	var removed, added strings.Builder
+	changed := func() bool {
+		differ := stripUnquotedSpace(removed.String()) != stripUnquotedSpace(added.String())
+		removed.Reset()
+		added.Reset()
+		return differ
+	}
+	inHunk := false
+	for line := range strings.SplitSeq(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@"), strings.HasPrefix(line, "diff --git "):
+			if changed() {
+				return true
+			}
+			inHunk = strings.HasPrefix(line, "@@")
+		case !inHunk:
+		case strings.HasPrefix(line, "-"):
+			removed.WriteString(line[1:] + "\n")
+		case strings.HasPrefix(line, "+"):
+			added.WriteString(line[1:] + "\n")
+		}
+	}
+	return changed()
+}
+
+// stripUnquotedSpace drops the whitespace of s that is outside ", ' and `
+// quotes, honouring backslash escapes.
+func stripUnquotedSpace(s string) string {
+	var (
+		b       strings.Builder
+		quote   rune
+		escaped bool
+	)
+	for _, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0 && r == '\\' && quote != '`':
+			escaped = true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\'' || r == '`'):
+			quote = r
+		case quote == 0 && unicode.IsSpace(r):
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// ParseChanges derives the per-file change summary from a unified diff, for
+// diffs that did not come from the local repository.
+func ParseChanges(diff string) []FileChange {
+	_, files := splitFileDiffs(diff)
+	changes := make([]FileChange, 0, len(files))
+	for _, f := range files {
+		change := FileChange{Status: changeType(f.text), Path: f.path}
+		header, _, _ := strings.Cut(f.text, "\n@@")
+		for line := range strings.SplitSeq(header, "\n") {
+			if from, ok := strings.CutPrefix(line, "rename from "); ok {
+				change.OldPath = from
+			} else if from, ok := strings.CutPrefix(line, "copy from "); ok {
+				change.OldPath = from
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// ChangedPaths returns every path touched by changes, including the source
+// paths of renames and copies.
+func ChangedPaths(changes []FileChange) []string {
+	paths := make([]string, 0, len(changes))
+	for _, c := range changes {
+		if c.OldPath != "" {
+			paths = append(paths, c.OldPath)
+		}
+		paths = append(paths, c.Path)
+	}
+	return paths
+}
+
+// FormatChanges renders changes one per line for the prompt header, the
+// most important first and each annotated with its class (see Classify).
+func FormatChanges(changes []FileChange) string {
+	ranked := slices.Clone(changes)
+	slices.SortStableFunc(ranked, func(a, b FileChange) int { return classRank(a.Path) - classRank(b.Path) })
+	var b strings.Builder
+	for _, c := range ranked {
+		b.WriteString(c.String())
+		b.WriteString(" (" + Classify(c.Path) + ")\n")
+	}
+	return b.String()
+}
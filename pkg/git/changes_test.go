@@ -0,0 +1,58 @@
+package git
+
+import "testing"
+
+func TestWhitespaceSensitive(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"main.go", false},
+		{"web/app.ts", false},
+		{"tools/gen.py", true},
+		{".github/workflows/ci.yml", true},
+		{"deploy/values.YAML", true},
+		{"Makefile", true},
+		{"build/rules.mk", true},
+		{"README.md", true},
+		{"docs/makefile.txt", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+			if got := WhitespaceSensitive(tt.path); got != tt.want {
+				t.Fatalf("WhitespaceSensitive(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLiteralWhitespaceChanged(t *testing.T) {
+	t.Parallel()
+
+	const header = "diff --git a/x.go b/x.go\nindex 1..2 100644\n--- a/x.go\n+++ b/x.go\n"
+	tests := []struct {
+		name string
+		diff string
+		want bool
+	}{
+		{"reindent", header + "@@ -3 +3 @@\n-  x := f(a,b)\n+\tx := f(a, b)\n", false},
+		{"line joined", header + "@@ -3,2 +3 @@\n-x := f(a,\n-\tb)\n+x := f(a, b)\n", false},
+		{"blank line", header + "@@ -4,0 +5 @@\n+\n", false},
+		{"space in string", header + "@@ -3 +3 @@\n-s := \"a b\"\n+s := \"a  b\"\n", true},
+		{"space in raw string", header + "@@ -3 +3 @@\n-s := `a\tb`\n+s := `a b`\n", true},
+		{"escaped quote", header + "@@ -3 +3 @@\n-s := \"\\\" a\"  +  x\n+s := \"\\\" a\" + x\n", false},
+		{"removed dashes", header + "@@ -3 +3 @@\n---  note\n+-- note\n", false},
+		{"second hunk", header + "@@ -1 +1 @@\n- a\n+a\n@@ -9 +9 @@\n-'x y'\n+'x  y'\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := literalWhitespaceChanged(tt.diff); got != tt.want {
+				t.Fatalf("literalWhitespaceChanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
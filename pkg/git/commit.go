@@ -0,0 +1,205 @@
+package git
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// zeroOID is the object name update-index uses to remove an entry.
+const zeroOID = "0000000000000000000000000000000000000000"
+
+// DiffStagedPaths returns the staged diff limited to paths, with the same
+// rename detection, context and condensing as DiffStaged.
+func DiffStagedPaths(paths []string) (string, error) {
+	if err := checkGitDir(); err != nil {
+		return "", err
+	}
+	out, err := stagedDiff(paths...)
+	if err != nil {
+		return "", fmt.Errorf("failed to read staged diff: %w", err)
+	}
+	return condenseStaged(out), nil
+}
+
+// CommitPaths commits the staged state of paths only and leaves every other
+// staged change in the index. The commit is made with git commit against a
+// temporary index built from HEAD plus the staged entries of paths, so hooks
+// and signing (commit.gpgsign) apply as usual. args are extra git commit
+// options such as --gpg-sign.
+func CommitPaths(message string, paths []string, args ...string) error {
+	gitDir, err := Dir()
+	if err != nil {
+		return err
+	}
+	tmpDir, err := os.MkdirTemp(gitDir, "git-ai-index-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	indexEnv := "GIT_INDEX_FILE=" + filepath.Join(tmpDir, "index")
+
+	run := func(stdin string, args ...string) ([]byte, error) {
+		cmd, err := rootCmd(args...)
+		if err != nil {
+			return nil, err
+		}
+		cmd.Env = append(cmd.Env, indexEnv)
+		cmd.Stdin = strings.NewReader(stdin)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			detail := stderr.String()
+			if strings.TrimSpace(detail) == "" {
+				detail = string(out)
+			}
+			return nil, fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(detail))
+		}
+		return out, nil
+	}
+
+	base := []string{"read-tree", "HEAD"}
+	if err = gitCmd("rev-parse", "--verify", "-q", "HEAD").Run(); err != nil {
+		base = []string{"read-tree", "--empty"}
+	}
+	if _, err = run("", base...); err != nil {
+		return err
+	}
+
+	// Staged entries come from the real index; paths missing from it were
+	// deleted and are removed from the temporary one.
+	ls, err := rootCmd(append([]string{"--literal-pathspecs", "ls-files", "-s", "-z", "--"}, paths...)...)
+	if err != nil {
+		return err
+	}
+	staged, err := ls.Output()
+	if err != nil {
+		return fmt.Errorf("failed to read staged entries: %w", err)
+	}
+	var (
+		info    strings.Builder
+		present = map[string]bool{}
+	)
+	for entry := range strings.SplitSeq(strings.TrimSuffix(string(staged), "\x00"), "\x00") {
+		if _, p, ok := strings.Cut(entry, "\t"); ok {
+			present[p] = true
+			info.WriteString(entry)
+			info.WriteByte(0)
+		}
+	}
+	for _, p := range paths {
+		if !present[p] {
+			fmt.Fprintf(&info, "0 %s\t%s\x00", zeroOID, p)
+		}
+	}
+	if _, err = run(info.String(), "update-index", "-z", "--index-info"); err != nil {
+		return err
+	}
+	_, err = run(message, append([]string{"commit", "-q", "-F", "-"}, args...)...)
+	return err
+}
+
+// SignoffTrailer returns the Signed-off-by trailer git commit --signoff
+// would add, built from the committer identity (user.name, user.email).
+func SignoffTrailer() (string, error) {
+	cmd := gitCmd("var", "GIT_COMMITTER_IDENT")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine the committer identity: %s", strings.TrimSpace(stderr.String()))
+	}
+	ident := strings.TrimSpace(string(out))
+	// The identity ends with the timestamp and zone: "Name <email> 1700000000 +0100".
+	if end := strings.LastIndex(ident, ">"); end != -1 {
+		ident = ident[:end+1]
+	}
+	return "Signed-off-by: " + ident, nil
+}
+
+// CommitEdit runs git commit with message as the initial commit message
+// and opens the editor on it, as git commit -F <file> --edit would. The
+// editor and git's output use the terminal; comment lines are stripped by
+// git as usual.
+func CommitEdit(message string, args ...string) error {
+	gitDir, err := Dir()
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(gitDir, "git-ai-msg-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.WriteString(message); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	cmd := exec.Command("git", append([]string{"commit", "-F", f.Name(), "--edit"}, args...)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// ChangeID returns a Gerrit Change-Id ("I" and 40 hex digits) for message,
+// hashed the way Gerrit's commit-msg hook does it from the staged tree, the
+// parent commit (see BaseRev), the author and committer identities and the
+// message.
+func ChangeID(message string) (string, error) {
+	tree, err := IndexTree()
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "tree %s\n", tree)
+	if parent, err := ResolveCommit(BaseRev()); err == nil {
+		fmt.Fprintf(&b, "parent %s\n", parent)
+	}
+	for _, ident := range [][2]string{{"author", "GIT_AUTHOR_IDENT"}, {"committer", "GIT_COMMITTER_IDENT"}} {
+		out, err := gitCmd("var", ident[1]).Output()
+		if err != nil {
+			return "", fmt.Errorf("cannot determine the %s identity: %w", ident[0], err)
+		}
+		fmt.Fprintf(&b, "%s %s\n", ident[0], strings.TrimSpace(string(out)))
+	}
+	b.WriteString("\n" + message)
+	sum := sha1.Sum(fmt.Appendf(nil, "blob %d\x00%s", b.Len(), b.String()))
+	return "I" + hex.EncodeToString(sum[:]), nil
+}
+
+// CurrentBranch returns the short name of the checked-out branch, or ""
+// on a detached HEAD.
+func CurrentBranch() string {
+	cmd := gitCmd("symbolic-ref", "--quiet", "--short", "HEAD")
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// AddTrailerToFile adds trailer ("Key: value") to the message file at path
+// with git interpret-trailers, which copes with comment lines and the
+// scissors line of git commit -v. Nothing is added when the message has a
+// trailer with the same key.
+func AddTrailerToFile(path, trailer string) error {
+	cmd := gitCmd("interpret-trailers", "--in-place", "--if-exists", "doNothing", "--trailer", trailer, path)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git interpret-trailers: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
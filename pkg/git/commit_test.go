@@ -0,0 +1,151 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// newRepo creates a repository in a temporary directory, makes it the
+// working directory and isolates git from the user's configuration.
+func newRepo(t *testing.T) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	for k, v := range map[string]string{
+		"GIT_CONFIG_GLOBAL":   os.DevNull,
+		"GIT_CONFIG_NOSYSTEM": "1",
+		"GIT_AUTHOR_NAME":     "Test",
+		"GIT_AUTHOR_EMAIL":    "test@example.com",
+		"GIT_COMMITTER_NAME":  "Test",
+		"GIT_COMMITTER_EMAIL": "test@example.com",
+	} {
+		t.Setenv(k, v)
+	}
+	runGit(t, "init", "-q")
+}
+
+func runGit(t *testing.T, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return string(out)
+}
+
+func writeFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// commitBase commits a/1 and b/1.
+func commitBase(t *testing.T) {
+	t.Helper()
+	writeFiles(t, map[string]string{"a/1": "a1\n", "b/1": "b1\n"})
+	runGit(t, "add", "-A")
+	runGit(t, "commit", "-q", "-m", "base")
+}
+
+func TestCommitPaths(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T)
+		paths []string
+		// tree is the content of every file of the new commit.
+		tree map[string]string
+		// staged is what is left staged on top of the new commit.
+		staged string
+	}{
+		{
+			name: "one directory of two",
+			setup: func(t *testing.T) {
+				commitBase(t)
+				writeFiles(t, map[string]string{"a/1": "a2\n", "b/1": "b2\n"})
+				runGit(t, "add", "-A")
+			},
+			paths:  []string{"a/1"},
+			tree:   map[string]string{"a/1": "a2\n", "b/1": "b1\n"},
+			staged: "M\tb/1\n",
+		},
+		{
+			name: "staged deletion",
+			setup: func(t *testing.T) {
+				commitBase(t)
+				runGit(t, "rm", "-q", "a/1")
+				writeFiles(t, map[string]string{"b/1": "b2\n"})
+				runGit(t, "add", "b/1")
+			},
+			paths:  []string{"a/1"},
+			tree:   map[string]string{"b/1": "b1\n"},
+			staged: "M\tb/1\n",
+		},
+		{
+			name: "rename across directories",
+			setup: func(t *testing.T) {
+				commitBase(t)
+				if err := os.Mkdir("c", 0o755); err != nil {
+					t.Fatal(err)
+				}
+				runGit(t, "mv", "a/1", "c/1")
+				writeFiles(t, map[string]string{"d/1": "d1\n"})
+				runGit(t, "add", "d/1")
+			},
+			paths:  []string{"a/1", "c/1"},
+			tree:   map[string]string{"b/1": "b1\n", "c/1": "a1\n"},
+			staged: "A\td/1\n",
+		},
+		{
+			name: "no HEAD",
+			setup: func(t *testing.T) {
+				writeFiles(t, map[string]string{"a/1": "a1\n", "b/1": "b1\n"})
+				runGit(t, "add", "-A")
+			},
+			paths:  []string{"a/1"},
+			tree:   map[string]string{"a/1": "a1\n"},
+			staged: "A\tb/1\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newRepo(t)
+			tt.setup(t)
+			index := runGit(t, "ls-files", "-s")
+
+			if err := CommitPaths("feat: commit part\n", tt.paths); err != nil {
+				t.Fatalf("CommitPaths() error = %v", err)
+			}
+
+			if subject := strings.TrimSpace(runGit(t, "log", "-1", "--format=%s")); subject != "feat: commit part" {
+				t.Errorf("subject = %q", subject)
+			}
+			names := strings.Fields(runGit(t, "ls-tree", "-r", "--name-only", "HEAD"))
+			want := make([]string, 0, len(tt.tree))
+			for name, content := range tt.tree {
+				want = append(want, name)
+				if got := runGit(t, "show", "HEAD:"+name); got != content {
+					t.Errorf("HEAD:%s = %q, want %q", name, got, content)
+				}
+			}
+			slices.Sort(want)
+			if !slices.Equal(names, want) {
+				t.Errorf("tree = %q, want %q", names, want)
+			}
+			if got := runGit(t, "ls-files", "-s"); got != index {
+				t.Errorf("index changed:\n%s\nwant\n%s", got, index)
+			}
+			if got := runGit(t, "diff", "--cached", "--name-status", "-M"); got != tt.staged {
+				t.Errorf("left staged = %q, want %q", got, tt.staged)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxFileDiffBytes caps the diff of a single file; larger file diffs are
+	// replaced by a synopsis.
+	maxFileDiffBytes = 64 * 1024
+	// maxLineBytes flags minified or otherwise machine-written content.
+	maxLineBytes = 1000
+)
+
+// minifiedSuffixes are file name suffixes of generated bundles.
+var minifiedSuffixes = []string{".min.js", ".min.css", ".min.map", ".js.map", ".css.map"}
+
+// fileDiff is the part of a unified diff that belongs to one file.
+type fileDiff struct {
+	path string
+	text string
+}
+
+// splitFileDiffs splits diff at "diff --git" headers. Text before the first
+// header is returned as preamble.
+func splitFileDiffs(diff string) (string, []fileDiff) {
+	var (
+		preamble strings.Builder
+		files    []fileDiff
+		current  *strings.Builder
+	)
+	flush := func() {
+		if current != nil {
+			files[len(files)-1].text = current.String()
+		}
+	}
+	for line := range strings.SplitAfterSeq(diff, "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			flush()
+			files = append(files, fileDiff{path: diffFilePath(line)})
+			current = &strings.Builder{}
+		}
+		if current == nil {
+			preamble.WriteString(line)
+			continue
+		}
+		current.WriteString(line)
+	}
+	flush()
+	return preamble.String(), files
+}
+
+// changeType returns added, deleted, renamed, copied or modified for a file
+// diff.
+func changeType(text string) string {
+	header, _, _ := strings.Cut(text, "\n@@")
+	switch {
+	case strings.Contains(header, "\nnew file mode"):
+		return "added"
+	case strings.Contains(header, "\ndeleted file mode"):
+		return "deleted"
+	case strings.Contains(header, "\nrename from "):
+		return "renamed"
+	case strings.Contains(header, "\ncopy from "):
+		return "copied"
+	default:
+		return "modified"
+	}
+}
+
+func isBinaryDiff(text string) bool {
+	return strings.Contains(text, "\nBinary files ") || strings.Contains(text, "\nGIT binary patch")
+}
+
+func hasLongLine(text string) bool {
+	for line := range strings.SplitSeq(text, "\n") {
+		if len(line) > maxLineBytes {
+			return true
+		}
+	}
+	return false
+}
+
+func isMinifiedName(p string) bool {
+	base := path.Base(p)
+	for _, suffix := range minifiedSuffixes {
+		if strings.HasSuffix(base, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// condenseDiff orders the file diffs by importance (see Classify) and
+// replaces the diffs of binary, generated and oversized files with a
+// one-line synopsis (kind, change type, path and size) so they do not crowd
+// out the rest of the diff. generated reports paths marked
+// linguist-generated and size returns the blob size in bytes of a path; both
+// may be nil when the diff does not come from the local repository.
+func condenseDiff(diff string, generated map[string]bool, size func(path, change string) int64) string {
+	preamble, files := splitFileDiffs(diff)
+	if len(files) == 0 {
+		return diff
+	}
+	rankFiles(files)
+	var b strings.Builder
+	b.WriteString(preamble)
+	for _, f := range files {
+		var kind string
+		switch {
+		case isBinaryDiff(f.text):
+			kind = "binary file"
+		case generated[f.path], isMinifiedName(f.path), hasLongLine(f.text):
+			kind = "generated file"
+		case len(f.text) > maxFileDiffBytes:
+			kind = "large file"
+		default:
+			b.WriteString(f.text)
+			continue
+		}
+		header, _, _ := strings.Cut(f.text, "\n")
+		change := changeType(f.text)
+		fmt.Fprintf(&b, "%s\n[%s %s: %s", header, kind, change, f.path)
+		if size != nil {
+			if n := size(f.path, change); n >= 0 {
+				fmt.Fprintf(&b, ", %s", formatBytes(n))
+			}
+		} else if kind == "large file" {
+			fmt.Fprintf(&b, ", %s of diff", formatBytes(int64(len(f.text))))
+		}
+		b.WriteString("; content omitted]\n")
+	}
+	return b.String()
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return strconv.FormatInt(n, 10) + " B"
+	}
+}
+
+// condenseStaged condenses a staged diff using the repository's
+// linguist-generated attributes and blob sizes.
+func condenseStaged(diff string) string {
+	_, files := splitFileDiffs(diff)
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		paths = append(paths, f.path)
+	}
+	return condenseDiff(diff, generatedPaths(paths), stagedBlobSize)
+}
+
+// generatedPaths returns the subset of paths whose linguist-generated
+// attribute is set.
+func generatedPaths(paths []string) map[string]bool {
+	generated := map[string]bool{}
+	if len(paths) == 0 {
+		return generated
+	}
+	cmd, err := rootCmd(append([]string{"check-attr", "-z", "linguist-generated", "--"}, paths...)...)
+	if err != nil {
+		return generated
+	}
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	if err != nil {
+		return generated
+	}
+	// Output is a sequence of NUL-terminated path, attribute, value triples.
+	fields := bytes.Split(out, []byte{0})
+	for i := 0; i+2 < len(fields); i += 3 {
+		switch string(fields[i+2]) {
+		case "set", "true":
+			generated[string(fields[i])] = true
+		}
+	}
+	return generated
+}
+
+// stagedBlobSize returns the size of the staged blob at p (or the base
+// blob for deletions, see BaseRev), or -1 when it cannot be determined.
+func stagedBlobSize(p, change string) int64 {
+	object := ":" + p
+	if change == "deleted" {
+		object = BaseRev() + ":" + p
+	}
+	cmd := gitCmd("cat-file", "-s", object)
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	if err != nil {
+		return -1
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
@@ -0,0 +1,54 @@
+package git
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCondenseDiff(t *testing.T) {
+	diff := "diff --git a/main.go b/main.go\n" +
+		"--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-a\n+b\n" +
+		"diff --git a/logo.png b/logo.png\nnew file mode 100644\n" +
+		"Binary files /dev/null and b/logo.png differ\n" +
+		"diff --git a/web/app.min.js b/web/app.min.js\n" +
+		"--- a/web/app.min.js\n+++ b/web/app.min.js\n@@ -1 +1 @@\n-x\n+y\n" +
+		"diff --git a/api/types.pb.go b/api/types.pb.go\n" +
+		"--- a/api/types.pb.go\n+++ b/api/types.pb.go\n@@ -1 +1 @@\n-x\n+y\n"
+
+	got := condenseDiff(diff, map[string]bool{"api/types.pb.go": true}, nil)
+
+	for _, want := range []string{
+		"+b\n",
+		"diff --git a/logo.png b/logo.png\n[binary file added: logo.png; content omitted]\n",
+		"[generated file modified: web/app.min.js; content omitted]\n",
+		"[generated file modified: api/types.pb.go; content omitted]\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("condenseDiff() missing %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "+y") {
+		t.Errorf("condenseDiff() kept generated content:\n%s", got)
+	}
+}
+
+func TestCondenseDiffLargeFile(t *testing.T) {
+	diff := "diff --git a/data.txt b/data.txt\n@@ -0,0 +1 @@\n" +
+		strings.Repeat("+line of text\n", maxFileDiffBytes/10)
+
+	got := condenseDiff(diff, nil, nil)
+	if !strings.Contains(got, "[large file modified: data.txt, ") {
+		t.Errorf("condenseDiff() = %q", got[:min(len(got), 200)])
+	}
+}
+
+func TestParseChanges(t *testing.T) {
+	diff := "diff --git a/old.go b/new.go\nsimilarity index 100%\nrename from old.go\nrename to new.go\n" +
+		"diff --git a/gone.go b/gone.go\ndeleted file mode 100644\n--- a/gone.go\n+++ /dev/null\n@@ -1 +0,0 @@\n-x\n"
+
+	got := FormatChanges(ParseChanges(diff))
+	want := "renamed: old.go -> new.go (source)\ndeleted: gone.go (source)\n"
+	if got != want {
+		t.Errorf("FormatChanges(ParseChanges()) = %q, want %q", got, want)
+	}
+}
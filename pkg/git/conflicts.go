@@ -0,0 +1,132 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNoOperation is returned when no merge, rebase, cherry-pick or revert
+// is in progress.
+var ErrNoOperation = errors.New("no merge, rebase, cherry-pick or revert in progress")
+
+// Operation is an in-progress operation that stopped on conflicts.
+type Operation struct {
+	// Kind is "merge", "rebase", "cherry-pick" or "revert".
+	Kind string
+	// Theirs is the ref of the side being applied onto HEAD (MERGE_HEAD,
+	// REBASE_HEAD, ...).
+	Theirs string
+	// MessageFile is the file git takes the commit message from when the
+	// operation is committed or continued.
+	MessageFile string
+	// MergeMsg is the file git lists the conflicted paths in.
+	MergeMsg string
+}
+
+// InProgress returns the operation in progress in the current worktree.
+func InProgress() (Operation, error) {
+	dir, err := Dir()
+	if err != nil {
+		return Operation{}, err
+	}
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(dir, name))
+		return err == nil
+	}
+	op := Operation{MessageFile: filepath.Join(dir, "MERGE_MSG"), MergeMsg: filepath.Join(dir, "MERGE_MSG")}
+	switch {
+	case exists("REBASE_HEAD") && exists("rebase-merge"):
+		op.Kind, op.Theirs = "rebase", "REBASE_HEAD"
+		op.MessageFile = filepath.Join(dir, "rebase-merge", "message")
+	case exists("REBASE_HEAD"):
+		op.Kind, op.Theirs = "rebase", "REBASE_HEAD"
+	case exists("MERGE_HEAD"):
+		op.Kind, op.Theirs = "merge", "MERGE_HEAD"
+	case exists("CHERRY_PICK_HEAD"):
+		op.Kind, op.Theirs = "cherry-pick", "CHERRY_PICK_HEAD"
+	case exists("REVERT_HEAD"):
+		op.Kind, op.Theirs = "revert", "REVERT_HEAD"
+	default:
+		return Operation{}, ErrNoOperation
+	}
+	return op, nil
+}
+
+// CommentChar returns core.commentChar, treating "auto" (and unset) as "#".
+func CommentChar() string {
+	if c := Config("core.commentChar", "#"); c != "auto" {
+		return c
+	}
+	return "#"
+}
+
+// ParseConflicts returns the paths listed in the commented "Conflicts:"
+// section git writes to MERGE_MSG.
+func ParseConflicts(msg, commentChar string) []string {
+	var (
+		paths   []string
+		inBlock bool
+	)
+	for line := range strings.SplitSeq(msg, "\n") {
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case strings.TrimSpace(strings.TrimPrefix(line, commentChar)) == "Conflicts:" && strings.HasPrefix(line, commentChar):
+			inBlock = true
+		case !inBlock:
+		case strings.HasPrefix(line, commentChar+"\t"):
+			paths = append(paths, strings.TrimSpace(strings.TrimPrefix(line, commentChar+"\t")))
+		case strings.TrimSpace(line) == commentChar:
+			// The blank comment line after the header.
+		default:
+			inBlock = false
+		}
+	}
+	return paths
+}
+
+// ConflictedPaths returns the paths op stopped on, from MERGE_MSG.
+func (op Operation) ConflictedPaths() ([]string, error) {
+	data, err := os.ReadFile(op.MergeMsg)
+	if err != nil {
+		return nil, err
+	}
+	return ParseConflicts(string(data), CommentChar()), nil
+}
+
+// UnmergedPaths returns the paths that still have unresolved conflicts.
+func UnmergedPaths() ([]string, error) {
+	cmd, err := rootCmd("diff", "--name-only", "-z", "--diff-filter=U")
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unmerged paths: %w", err)
+	}
+	var paths []string
+	for p := range strings.SplitSeq(string(out), "\x00") {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths, nil
+}
+
+// ResolutionDiff returns the diff from rev to the staged resolution of path.
+func ResolutionDiff(rev, path string) (string, error) {
+	cmd, err := rootCmd("diff", "--cached", "--no-color", "--no-ext-diff", rev, "--", path)
+	if err != nil {
+		return "", err
+	}
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to diff %s against %s: %w", path, rev, err)
+	}
+	return string(out), nil
+}
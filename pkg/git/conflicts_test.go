@@ -0,0 +1,21 @@
+package git
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseConflicts(t *testing.T) {
+	t.Parallel()
+
+	msg := "Merge branch 'other'\n\n# Conflicts:\n#\tcmd/main.go\n#\tREADME.md\n#\n# It looks like you may be committing a merge.\n"
+	if got, want := ParseConflicts(msg, "#"), []string{"cmd/main.go", "README.md"}; !slices.Equal(got, want) {
+		t.Fatalf("ParseConflicts() = %q, want %q", got, want)
+	}
+	if got := ParseConflicts("Merge branch 'other'\n", "#"); got != nil {
+		t.Fatalf("ParseConflicts() = %q, want none", got)
+	}
+	if got, want := ParseConflicts("x\n\n; Conflicts:\n;\ta.go\n", ";"), []string{"a.go"}; !slices.Equal(got, want) {
+		t.Fatalf("ParseConflicts(;) = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,92 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Diff context strategies for the staged diffs sent to backends.
+const (
+	// ContextHunk keeps git's three lines around each change; the hunk
+	// headers still name the enclosing function.
+	ContextHunk = "hunk"
+	// ContextFunction shows whole enclosing functions (git diff -W).
+	ContextFunction = "function"
+	// ContextAuto shows whole functions for the files where that keeps the
+	// diff small, and hunks elsewhere.
+	ContextAuto = "auto"
+)
+
+// ContextModes lists the valid context strategies.
+var ContextModes = []string{ContextHunk, ContextAuto, ContextFunction}
+
+const (
+	// functionGrowth and maxFunctionExtra bound the function-context diff
+	// of a file in auto mode, relative to its hunk diff.
+	functionGrowth   = 3
+	maxFunctionExtra = 8 * 1024
+)
+
+var contextMode = ContextHunk
+
+// SetContext selects the context strategy (ContextHunk, ContextFunction or
+// ContextAuto) of DiffStaged, DiffStagedChunks and DiffStagedPaths.
+func SetContext(mode string) { contextMode = mode }
+
+// stagedDiff runs git diff --staged with rename and copy detection over the
+// literal pathspecs from the repository root, with the context of the
+// selected strategy.
+func stagedDiff(pathspecs ...string) (string, error) {
+	run := func(extra ...string) (string, error) {
+		args := append(append([]string{"--literal-pathspecs"}, diffStagedArgs("-M", "-C")...), extra...)
+		cmd, err := rootCmd(append(append(args, "--"), pathspecs...)...)
+		if err != nil {
+			return "", err
+		}
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		return string(out), nil
+	}
+	switch contextMode {
+	case ContextFunction:
+		return run("--function-context")
+	case ContextAuto:
+		hunks, err := run()
+		if err != nil {
+			return "", err
+		}
+		functions, err := run("--function-context")
+		if err != nil {
+			return hunks, nil
+		}
+		return mergeContext(hunks, functions), nil
+	}
+	return run()
+}
+
+// mergeContext takes, file by file, the function-context diff where it
+// stays within functionGrowth times and maxFunctionExtra bytes of the hunk
+// diff, and the hunk diff otherwise.
+func mergeContext(hunks, functions string) string {
+	_, wide := splitFileDiffs(functions)
+	byPath := make(map[string]string, len(wide))
+	for _, f := range wide {
+		byPath[f.path] = f.text
+	}
+	preamble, files := splitFileDiffs(hunks)
+	var b strings.Builder
+	b.WriteString(preamble)
+	for _, f := range files {
+		if w, ok := byPath[f.path]; ok && len(w) <= functionGrowth*len(f.text) && len(w)-len(f.text) <= maxFunctionExtra {
+			b.WriteString(w)
+			continue
+		}
+		b.WriteString(f.text)
+	}
+	return b.String()
+}
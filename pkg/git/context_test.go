@@ -0,0 +1,20 @@
+package git
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMergeContext(t *testing.T) {
+	file := func(p string, lines int) string {
+		return "diff --git a/" + p + " b/" + p + "\n@@ -1 +1 @@ func f()\n" + strings.Repeat(" context\n", lines) + "-a\n+b\n"
+	}
+	hunks := file("small.go", 3) + file("large.go", 3)
+	functions := file("small.go", 10) + file("large.go", 2000)
+
+	got := mergeContext(hunks, functions)
+
+	if want := file("small.go", 10) + file("large.go", 3); got != want {
+		t.Fatalf("mergeContext() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,212 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FixupTarget is a recent commit the staged changes may amend, scored by
+// how much of the code they touch it last changed.
+type FixupTarget struct {
+	Hash    string
+	Subject string
+	// Lines counts the pre-image lines of the staged hunks (or their
+	// neighbours, for pure additions) that git blame attributes to the
+	// commit.
+	Lines int
+	// Files counts the staged files the commit also changed.
+	Files int
+}
+
+// lineRange is a 1-based, inclusive range of lines in the HEAD version of
+// a file.
+type lineRange struct {
+	start, end int
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+\d+(?:,\d+)? @@`)
+
+// FixupTargets returns the last limit commits of HEAD that are not merges
+// and not yet in the upstream branch, best fixup target first: most blamed
+// lines, then most shared files, then the most recent. Commits the staged
+// changes do not touch at all are left out.
+func FixupTargets(limit int) ([]FixupTarget, error) {
+	if err := checkGitDir(); err != nil {
+		return nil, err
+	}
+	diff, err := DiffStagedLines()
+	if err != nil {
+		return nil, err
+	}
+	hunks := stagedHunks(diff)
+	if len(hunks) == 0 {
+		return nil, nil
+	}
+	targets, files, err := recentCommits(limit)
+	if err != nil || len(targets) == 0 {
+		return nil, err
+	}
+
+	index := make(map[string]int, len(targets))
+	for i, t := range targets {
+		index[t.Hash] = i
+	}
+	for path, ranges := range hunks {
+		for i, t := range targets {
+			if slices.Contains(files[t.Hash], path) {
+				targets[i].Files++
+			}
+		}
+		for _, r := range ranges {
+			for hash, n := range blameLines(path, r) {
+				if i, ok := index[hash]; ok {
+					targets[i].Lines += n
+				}
+			}
+		}
+	}
+	ranked := make([]FixupTarget, 0, len(targets))
+	for _, t := range targets {
+		if t.Lines > 0 || t.Files > 0 {
+			ranked = append(ranked, t)
+		}
+	}
+	slices.SortStableFunc(ranked, func(a, b FixupTarget) int {
+		if a.Lines != b.Lines {
+			return b.Lines - a.Lines
+		}
+		return b.Files - a.Files
+	})
+	return ranked, nil
+}
+
+// CommitFixup commits the staged changes as a fixup! (or, with squash, a
+// squash! commit with message as its body) of hash for git rebase
+// --autosquash. Hooks run and git's output goes to the terminal.
+func CommitFixup(hash string, squash bool, message string) error {
+	args := []string{"commit", "--fixup=" + hash}
+	if squash {
+		args = []string{"commit", "--squash=" + hash}
+		if strings.TrimSpace(message) != "" {
+			args = append(args, "-m", message)
+		}
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// stagedHunks returns the HEAD line ranges each staged hunk of a -U0 diff
+// replaces, by file. A pure addition after line n covers lines n and n+1,
+// whose authors most likely wrote the surrounding code. Added files have
+// no ranges.
+func stagedHunks(diff string) map[string][]lineRange {
+	hunks := map[string][]lineRange{}
+	var path string
+	for line := range strings.SplitSeq(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "--- a/"):
+			path = strings.TrimPrefix(line, "--- a/")
+		case strings.HasPrefix(line, "--- "):
+			path = ""
+		case path != "" && strings.HasPrefix(line, "@@"):
+			m := hunkHeader.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			start, _ := strconv.Atoi(m[1])
+			count := 1
+			if m[2] != "" {
+				count, _ = strconv.Atoi(m[2])
+			}
+			r := lineRange{start: start, end: start + count - 1}
+			if count == 0 {
+				r = lineRange{start: max(start, 1), end: start + 1}
+			}
+			hunks[path] = append(hunks[path], r)
+		}
+	}
+	return hunks
+}
+
+// recentCommits returns the candidate commits, newest first, and the paths
+// each changed.
+func recentCommits(limit int) ([]FixupTarget, map[string][]string, error) {
+	args := []string{"log", "--no-merges", "--no-renames", "--format=%x00%H%x00%s", "--name-only", "-n", strconv.Itoa(limit), "HEAD"}
+	if upstream, err := ResolveCommit("@{upstream}"); err == nil {
+		args = append(args, "^"+upstream)
+	}
+	cmd, err := rootCmd(append(args, "--")...)
+	if err != nil {
+		return nil, nil, err
+	}
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read recent commits: %w", err)
+	}
+	targets, files := parseCommitFiles(string(out))
+	return targets, files, nil
+}
+
+// parseCommitFiles parses git log --format=%x00%H%x00%s --name-only output.
+func parseCommitFiles(out string) ([]FixupTarget, map[string][]string) {
+	var (
+		records = strings.Split(out, "\x00")
+		targets = make([]FixupTarget, 0, len(records)/2)
+		files   = map[string][]string{}
+	)
+	for i := 1; i+1 < len(records); i += 2 {
+		subject, names, _ := strings.Cut(records[i+1], "\n")
+		hash := records[i]
+		targets = append(targets, FixupTarget{Hash: hash, Subject: subject})
+		for name := range strings.SplitSeq(names, "\n") {
+			if name = strings.TrimSpace(name); name != "" {
+				files[hash] = append(files[hash], name)
+			}
+		}
+	}
+	return targets, files
+}
+
+// blameLines returns how many lines of r in the HEAD version of path each
+// commit last changed. Lines past the end of the file are ignored.
+func blameLines(path string, r lineRange) map[string]int {
+	cmd, err := rootCmd("blame", "--porcelain", "-L", fmt.Sprintf("%d,%d", r.start, r.end), "HEAD", "--", path)
+	if err != nil {
+		return nil
+	}
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	if err != nil && r.end > r.start {
+		// The range may end past the last line (an addition at the end
+		// of the file); blame the first line alone.
+		return blameLines(path, lineRange{start: r.start, end: r.start})
+	}
+	return parseBlame(out)
+}
+
+// parseBlame counts the lines per commit in git blame --porcelain output.
+func parseBlame(out []byte) map[string]int {
+	counts := map[string]int{}
+	for line := range bytes.SplitSeq(out, []byte("\n")) {
+		fields := strings.Fields(string(line))
+		if len(fields) < 3 || len(fields[0]) != 40 || strings.HasPrefix(string(line), "\t") {
+			continue
+		}
+		if _, err := strconv.Atoi(fields[1]); err != nil {
+			continue
+		}
+		counts[fields[0]]++
+	}
+	return counts
+}
@@ -0,0 +1,51 @@
+package git
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestStagedHunks(t *testing.T) {
+	diff := "diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n" +
+		"@@ -3,2 +3,2 @@ func main() {\n-a\n-b\n+c\n+d\n" +
+		"@@ -10 +10 @@\n-x\n+y\n" +
+		"@@ -20,0 +21,3 @@\n+new\n" +
+		"diff --git a/new.go b/new.go\nnew file mode 100644\n--- /dev/null\n+++ b/new.go\n@@ -0,0 +1 @@\n+package x\n"
+
+	got := stagedHunks(diff)
+
+	want := map[string][]lineRange{"main.go": {{3, 4}, {10, 10}, {20, 21}}}
+	if !maps.EqualFunc(got, want, slices.Equal) {
+		t.Fatalf("stagedHunks() = %v, want %v", got, want)
+	}
+}
+
+func TestParseCommitFiles(t *testing.T) {
+	out := "\x00aaa\x00feat: add x\n\npkg/x.go\npkg/x_test.go\n\x00bbb\x00docs: readme\n\nREADME.md\n"
+
+	targets, files := parseCommitFiles(out)
+
+	if len(targets) != 2 || targets[0] != (FixupTarget{Hash: "aaa", Subject: "feat: add x"}) || targets[1].Subject != "docs: readme" {
+		t.Fatalf("parseCommitFiles() targets = %+v", targets)
+	}
+	if !slices.Equal(files["aaa"], []string{"pkg/x.go", "pkg/x_test.go"}) || !slices.Equal(files["bbb"], []string{"README.md"}) {
+		t.Fatalf("parseCommitFiles() files = %v", files)
+	}
+}
+
+func TestParseBlame(t *testing.T) {
+	const (
+		a = "1111111111111111111111111111111111111111"
+		b = "2222222222222222222222222222222222222222"
+	)
+	out := a + " 3 3 2\nauthor A\nsummary feat: x\nfilename main.go\n\tline three\n" +
+		a + " 4 4\n\tline four\n" +
+		b + " 9 5 1\nauthor B\nprevious " + a + " main.go\nfilename main.go\n\t" + a + " 1 1\n"
+
+	got := parseBlame([]byte(out))
+
+	if want := map[string]int{a: 2, b: 1}; !maps.Equal(got, want) {
+		t.Fatalf("parseBlame() = %v, want %v", got, want)
+	}
+}
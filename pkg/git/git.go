@@ -1,13 +1,17 @@
 package git
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
 	"path"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 var ErrNotGitDir = errors.New("not a git directory")
@@ -18,7 +22,7 @@ const maxDiffBytes = 512 * 1024
 // maxChunkBytes is the per-directory cap used by the chunked diff path.
 const maxChunkBytes = 100 * 1024
 
-// DiffChunk holds the staged diff (or --stat fallback) for one directory.
+// DiffChunk holds the staged diff (truncated to fit) for one directory.
 type DiffChunk struct {
 	Dir  string
 	Diff string
@@ -41,40 +45,141 @@ func checkGitDir() error {
 	return nil
 }
 
-// DiffStaged returns the full staged diff, falling back to --stat when the
-// diff exceeds maxDiffBytes. Used by the codex backend.
+// Dir returns the absolute path of the repository's git directory.
+func Dir() (string, error) {
+	cmd := gitCmd("rev-parse", "--absolute-git-dir")
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	if err != nil {
+		return "", ErrNotGitDir
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// CommonDir returns the absolute path of the git directory shared by all
+// worktrees of the repository (the main .git directory).
+func CommonDir() (string, error) {
+	cmd := gitCmd("rev-parse", "--path-format=absolute", "--git-common-dir")
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	if err != nil {
+		return "", ErrNotGitDir
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// TopLevel returns the absolute path of the repository's working tree root.
+// It honours linked worktrees and GIT_DIR/GIT_WORK_TREE.
+func TopLevel() (string, error) {
+	cmd := gitCmd("rev-parse", "--show-toplevel")
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	if err != nil {
+		return "", ErrNotGitDir
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Prefix returns the path of the working directory relative to the working
+// tree root, with a trailing slash, or "" at the root.
+func Prefix() string {
+	cmd := gitCmd("rev-parse", "--show-prefix")
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// rootCmd returns gitCmd running in the working tree root, for commands
+// that take or print root-relative paths. A relative GIT_DIR or
+// GIT_WORK_TREE in the environment is replaced by its absolute form so it
+// survives the directory change.
+func rootCmd(args ...string) (*exec.Cmd, error) {
+	root, err := TopLevel()
+	if err != nil {
+		return nil, err
+	}
+	cmd := gitCmd(args...)
+	cmd.Dir = root
+	if os.Getenv("GIT_DIR") != "" {
+		dir, err := Dir()
+		if err != nil {
+			return nil, err
+		}
+		cmd.Env = append(cmd.Env, "GIT_DIR="+dir, "GIT_WORK_TREE="+root)
+	}
+	return cmd, nil
+}
+
+// IndexTree returns the tree object name of the staged state (git
+// write-tree), which identifies the staged content exactly.
+func IndexTree() (string, error) {
+	cmd := gitCmd("write-tree")
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to write index tree: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// DiffStaged returns the full staged diff, ordered by file importance (see
+// Classify). When it exceeds maxDiffBytes the most important files are kept
+// whole and the rest summarized. Renames and copies are detected, and
+// binary, generated and oversized files are reduced to a synopsis. The
+// context follows SetContext. Used by the codex backend.
 func DiffStaged() (string, error) {
 	if err := checkGitDir(); err != nil {
 		return "", err
 	}
-	cmd := gitCmd("diff", "--staged")
-	cmd.Stderr = io.Discard
-	out, err := cmd.Output()
+	out, err := stagedDiff()
 	if err != nil {
 		return "", fmt.Errorf("failed to read staged diff (git diff --staged): %w", err)
 	}
-	if len(out) > maxDiffBytes {
-		stat := gitCmd("diff", "--staged", "--stat")
-		stat.Stderr = io.Discard
-		statOut, statErr := stat.Output()
-		if statErr != nil {
-			return "", fmt.Errorf("failed to read staged diff stat: %w", statErr)
-		}
-		return "[diff too large; showing --stat summary only]\n" + string(statOut), nil
+	return truncateDiff(condenseStaged(out), maxDiffBytes), nil
+}
+
+// DiffStagedLines returns the staged diff without context lines (-U0) and
+// without condensing or size caps, for static analysis of the changed
+// lines.
+func DiffStagedLines() (string, error) {
+	if err := checkGitDir(); err != nil {
+		return "", err
+	}
+	cmd := gitCmd(diffStagedArgs("-M", "-U0", "--no-color")...)
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read staged diff (git diff --staged -U0): %w", err)
 	}
 	return string(out), nil
 }
 
+// Blob returns the content of path at rev, or in the index when rev is
+// empty.
+func Blob(rev, path string) ([]byte, error) {
+	cmd := gitCmd("cat-file", "blob", rev+":"+path)
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s:%s: %w", rev, path, err)
+	}
+	return out, nil
+}
+
 // DiffStagedChunks returns one DiffChunk per changed directory, each capped
-// at maxChunkBytes (falls back to --stat for that directory if exceeded).
-// Used by the claude backend to send one stream-json message per directory.
+// at maxChunkBytes (see truncateDiff), with the directories holding the most
+// important files first. Used by the claude backend to send one stream-json
+// message per directory.
 func DiffStagedChunks() ([]DiffChunk, error) {
 	if err := checkGitDir(); err != nil {
 		return nil, err
 	}
 
 	// Collect changed file paths.
-	namesCmd := gitCmd("diff", "--staged", "--name-only")
+	namesCmd := gitCmd(diffStagedArgs("--name-only")...)
 	namesCmd.Stderr = io.Discard
 	namesOut, err := namesCmd.Output()
 	if err != nil {
@@ -102,25 +207,339 @@ func DiffStagedChunks() ([]DiffChunk, error) {
 
 	chunks := make([]DiffChunk, 0, len(dirs))
 	for _, dir := range dirs {
-		diffCmd := gitCmd("diff", "--staged", "--", dir)
-		diffCmd.Stderr = io.Discard
-		diffOut, diffErr := diffCmd.Output()
+		diffOut, diffErr := stagedDiff(dir)
 		if diffErr != nil {
 			return nil, fmt.Errorf("failed to get diff for %s: %w", dir, diffErr)
 		}
-		content := string(diffOut)
-		if len(diffOut) > maxChunkBytes {
-			statCmd := gitCmd("diff", "--staged", "--stat", "--", dir)
-			statCmd.Stderr = io.Discard
-			statOut, statErr := statCmd.Output()
-			if statErr != nil {
-				return nil, fmt.Errorf("failed to get stat for %s: %w", dir, statErr)
+		content := truncateDiff(condenseStaged(diffOut), maxChunkBytes)
+		if strings.TrimSpace(content) != "" {
+			chunks = append(chunks, DiffChunk{Dir: dir, Diff: content})
+		}
+	}
+	rankChunks(chunks)
+	return chunks, nil
+}
+
+// CapDiff condenses and orders diff like DiffStaged for diffs that did not
+// come from the local repository, keeping it within maxDiffBytes.
+func CapDiff(diff string) string {
+	return truncateDiff(condenseDiff(diff, nil, nil), maxDiffBytes)
+}
+
+// truncateDiff returns diff unchanged unless it exceeds limit. Otherwise
+// whole file diffs are kept in order while they fit, which keeps the most
+// important files of a ranked diff, and the remaining files are summarized
+// with their line counts.
+func truncateDiff(diff string, limit int) string {
+	if len(diff) <= limit {
+		return diff
+	}
+	preamble, files := splitFileDiffs(diff)
+	var (
+		kept strings.Builder
+		rest strings.Builder
+	)
+	kept.WriteString(preamble)
+	for i, f := range files {
+		if rest.Len() == 0 && kept.Len()+len(f.text) <= limit {
+			kept.WriteString(f.text)
+			continue
+		}
+		if rest.Len() == 0 {
+			fmt.Fprintf(&rest, "[diff too large; %d more file(s) summarized]\n", len(files)-i)
+		}
+		rest.WriteString(summarizeDiff(f.text))
+	}
+	return kept.String() + rest.String()
+}
+
+// ChunkDiff splits a unified diff into one DiffChunk per directory, mirroring
+// DiffStagedChunks for diffs supplied by a caller rather than read from git.
+func ChunkDiff(diff string) []DiffChunk {
+	diff = condenseDiff(diff, nil, nil)
+	byDir := map[string]*strings.Builder{}
+	var current *strings.Builder
+	for line := range strings.SplitAfterSeq(diff, "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			dir := path.Dir(diffFilePath(line))
+			if byDir[dir] == nil {
+				byDir[dir] = &strings.Builder{}
 			}
-			content = "[diff too large; showing --stat only]\n" + string(statOut)
+			current = byDir[dir]
+		}
+		if current == nil {
+			continue
 		}
+		current.WriteString(line)
+	}
+
+	dirs := make([]string, 0, len(byDir))
+	for d := range byDir {
+		dirs = append(dirs, d)
+	}
+	sort.Strings(dirs)
+
+	chunks := make([]DiffChunk, 0, len(dirs))
+	for _, dir := range dirs {
+		content := truncateDiff(byDir[dir].String(), maxChunkBytes)
 		if strings.TrimSpace(content) != "" {
 			chunks = append(chunks, DiffChunk{Dir: dir, Diff: content})
 		}
 	}
-	return chunks, nil
+	rankChunks(chunks)
+	if len(chunks) == 0 && strings.TrimSpace(diff) != "" {
+		chunks = append(chunks, DiffChunk{Dir: ".", Diff: CapDiff(diff)})
+	}
+	return chunks
+}
+
+// diffFilePath extracts the post-image path from a "diff --git a/x b/x"
+// header line.
+func diffFilePath(header string) string {
+	header = strings.TrimSpace(strings.TrimPrefix(header, "diff --git "))
+	if idx := strings.LastIndex(header, " b/"); idx != -1 {
+		return header[idx+3:]
+	}
+	return strings.TrimPrefix(header, "a/")
+}
+
+// summarizeDiff renders a --stat like " path | +added -removed" line per file.
+func summarizeDiff(diff string) string {
+	var (
+		b       strings.Builder
+		file    string
+		added   int
+		removed int
+	)
+	flush := func() {
+		if file != "" {
+			fmt.Fprintf(&b, " %s | +%d -%d\n", file, added, removed)
+		}
+	}
+	for line := range strings.SplitSeq(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			file, added, removed = diffFilePath(line), 0, 0
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		case strings.HasPrefix(line, "+"):
+			added++
+		case strings.HasPrefix(line, "-"):
+			removed++
+		}
+	}
+	flush()
+	return b.String()
+}
+
+// LogEntry is one commit returned by Log.
+type LogEntry struct {
+	Hash    string
+	Message string
+}
+
+// Log returns the commits in revRange (any git log revision range, e.g.
+// "v1.2.0..HEAD"), newest first.
+func Log(revRange string) ([]LogEntry, error) {
+	if err := checkGitDir(); err != nil {
+		return nil, err
+	}
+	cmd := gitCmd("log", "--format=%H%x00%B%x1e", revRange, "--")
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read git log %s: %w", revRange, err)
+	}
+	return parseLog(string(out)), nil
+}
+
+// LogSince returns the commits reachable from HEAD that were committed at
+// or after since, newest first. A repository without commits yields none.
+func LogSince(since time.Time) ([]LogEntry, error) {
+	if err := checkGitDir(); err != nil {
+		return nil, err
+	}
+	if err := gitCmd("rev-parse", "--verify", "--quiet", "HEAD").Run(); err != nil {
+		return nil, nil
+	}
+	cmd := gitCmd("log", "--format=%H%x00%B%x1e", fmt.Sprintf("--since=@%d", since.Unix()), "HEAD", "--")
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read git log: %w", err)
+	}
+	return parseLog(string(out)), nil
+}
+
+// parseLog splits git log --format=%H%x00%B%x1e output into entries.
+func parseLog(out string) []LogEntry {
+	var entries []LogEntry
+	for record := range strings.SplitSeq(out, "\x1e") {
+		hash, msg, ok := strings.Cut(strings.TrimLeft(record, "\n"), "\x00")
+		if !ok {
+			continue
+		}
+		entries = append(entries, LogEntry{Hash: hash, Message: strings.TrimSpace(msg)})
+	}
+	return entries
+}
+
+// RevDiff returns the diff introduced by rev: a single commit (against its
+// first parent) or a revision range such as "main..feature" or "v1.2.0...HEAD".
+func RevDiff(rev string) (string, error) {
+	if err := checkGitDir(); err != nil {
+		return "", err
+	}
+	cmd := gitCmd("show", "--format=", "--no-color", "--no-ext-diff", "-M", "--diff-merges=first-parent", rev, "--")
+	if strings.Contains(rev, "..") {
+		cmd = gitCmd("diff", "--no-color", "--no-ext-diff", "-M", rev, "--")
+	}
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read the diff of %s: %w", rev, err)
+	}
+	return string(out), nil
+}
+
+// Version returns the version of the git executable, e.g. "2.43.0".
+func Version() (string, error) {
+	out, err := gitCmd("version").Output()
+	if err != nil {
+		return "", fmt.Errorf("git version failed: %w", err)
+	}
+	v := strings.TrimPrefix(strings.TrimSpace(string(out)), "git version ")
+	return v, nil
+}
+
+// HooksDir returns the absolute path of the directory git runs hooks from,
+// honouring core.hooksPath.
+func HooksDir() (string, error) {
+	cmd := gitCmd("rev-parse", "--path-format=absolute", "--git-path", "hooks")
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	if err != nil {
+		return "", ErrNotGitDir
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// CommitTree returns the tree object name and the full message of rev.
+func CommitTree(rev string) (string, string, error) {
+	cmd := gitCmd("log", "-1", "--format=%T%x00%B", rev, "--")
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read commit %s: %w", rev, err)
+	}
+	tree, message, _ := strings.Cut(string(out), "\x00")
+	return tree, strings.TrimSpace(message), nil
+}
+
+// ResolveCommit returns the full hash of the commit rev names.
+func ResolveCommit(rev string) (string, error) {
+	cmd := gitCmd("rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s is not a commit", rev)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Revert stages the inverse of commit hash with git revert --no-commit.
+// mainline selects the parent of a merge commit (0 for other commits).
+// Conflicts are returned as an error carrying git's output; the revert
+// stays in progress so they can be resolved.
+func Revert(hash string, mainline int) error {
+	args := []string{"revert", "--no-commit"}
+	if mainline > 0 {
+		args = append(args, "--mainline", strconv.Itoa(mainline))
+	}
+	cmd := gitCmd(append(args, hash)...)
+	var stderr bytes.Buffer
+	cmd.Stdout = &stderr
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git revert failed: %w\n%s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// Config returns the value of the git config key, or def when it is unset.
+func Config(key, def string) string {
+	cmd := gitCmd("config", "--get", key)
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	if err != nil {
+		return def
+	}
+	if v := strings.TrimSpace(string(out)); v != "" {
+		return v
+	}
+	return def
+}
+
+// SetConfig sets the git config key to value in the repository config, or
+// in the user's global config when global is set.
+func SetConfig(key, value string, global bool) error {
+	args := []string{"config"}
+	if global {
+		args = append(args, "--global")
+	}
+	cmd := gitCmd(append(args, key, value)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git config failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// AddNote attaches text as the note of rev under refs/notes/<ref>,
+// replacing an existing note.
+func AddNote(ref, rev, text string) error {
+	cmd := gitCmd("notes", "--ref="+ref, "add", "-f", "-F", "-", rev)
+	cmd.Stdin = strings.NewReader(text)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git notes add failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// CreateTag creates the annotated tag name on rev with message, signing it
+// when sign is set.
+func CreateTag(name, rev, message string, sign bool) error {
+	args := []string{"tag", "-a"}
+	if sign {
+		args = append(args, "-s")
+	}
+	cmd := gitCmd(append(args, "-F", "-", name, rev)...)
+	cmd.Stdin = strings.NewReader(message + "\n")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git tag failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// LatestTag returns the most recent tag reachable from rev, or an empty
+// string when there is none.
+func LatestTag(rev string) string {
+	cmd := gitCmd("describe", "--tags", "--abbrev=0", rev)
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// RemoteHead returns the default branch of remote as recorded by git clone
+// or git remote set-head (e.g. "main"), or "" when it is unknown.
+func RemoteHead(remote string) string {
+	cmd := gitCmd("symbolic-ref", "--quiet", "--short", "refs/remotes/"+remote+"/HEAD")
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.TrimSpace(string(out)), remote+"/")
 }
@@ -0,0 +1,17 @@
+package git
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseLog(t *testing.T) {
+	out := "aaa\x00feat: add x\n\nBody.\n\x1e\nbbb\x00fix: y\n\x1e\n"
+
+	got := parseLog(out)
+
+	want := []LogEntry{{Hash: "aaa", Message: "feat: add x\n\nBody."}, {Hash: "bbb", Message: "fix: y"}}
+	if !slices.Equal(got, want) {
+		t.Fatalf("parseLog() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,80 @@
+package git
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var newHunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// DiffPaths returns the unstaged diff of paths (all files when empty), or
+// the staged one when staged is set.
+func DiffPaths(staged bool, paths ...string) (string, error) {
+	if err := checkGitDir(); err != nil {
+		return "", err
+	}
+	args := []string{"diff", "--no-color", "--no-ext-diff"}
+	if staged {
+		args = append(args, "--staged")
+	}
+	cmd := gitCmd(append(append(args, "--"), paths...)...)
+	cmd.Stderr = io.Discard
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git diff failed: %w", err)
+	}
+	return string(out), nil
+}
+
+// SelectHunk returns the hunk of a unified diff that covers line of path in
+// the new version of the file, with the file header in front, or "" when no
+// hunk does. A hunk that only removes lines covers the line it removed them
+// before.
+func SelectHunk(diff, path string, line int) string {
+	var (
+		header, hunk     strings.Builder
+		inFile, selected bool
+	)
+	for l := range strings.SplitSeq(diff, "\n") {
+		if selected && (strings.HasPrefix(l, "diff --git ") || strings.HasPrefix(l, "@@")) {
+			break
+		}
+		switch {
+		case strings.HasPrefix(l, "diff --git "):
+			inFile = diffFilePath(l) == path
+			header.Reset()
+			hunk.Reset()
+		case !inFile:
+			continue
+		case strings.HasPrefix(l, "@@"):
+			m := newHunkHeader.FindStringSubmatch(l)
+			if m == nil {
+				continue
+			}
+			start, _ := strconv.Atoi(m[1])
+			count := 1
+			if m[2] != "" {
+				count, _ = strconv.Atoi(m[2])
+			}
+			end := start + count - 1
+			if count == 0 {
+				// A pure removal: start is the line before the removed ones.
+				start, end = max(start, 1), start+1
+			}
+			selected = line >= start && line <= end
+			hunk.Reset()
+		}
+		if hunk.Len() == 0 && !strings.HasPrefix(l, "@@") {
+			header.WriteString(l + "\n")
+			continue
+		}
+		hunk.WriteString(l + "\n")
+	}
+	if !selected {
+		return ""
+	}
+	return strings.TrimRight(header.String()+hunk.String(), "\n") + "\n"
+}
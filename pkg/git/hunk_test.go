@@ -0,0 +1,29 @@
+package git
+
+import "testing"
+
+func TestSelectHunk(t *testing.T) {
+	const (
+		header = "diff --git a/main.go b/main.go\nindex 1..2 100644\n--- a/main.go\n+++ b/main.go\n"
+		first  = "@@ -3,2 +3,3 @@ func main() {\n a\n-b\n+c\n+d\n"
+		second = "@@ -20,2 +21,0 @@\n-x\n-y\n"
+		other  = "diff --git a/other.go b/other.go\n--- a/other.go\n+++ b/other.go\n@@ -1 +1 @@\n-p\n+q\n"
+	)
+	diff := header + first + second + other
+
+	for _, tc := range []struct {
+		path string
+		line int
+		want string
+	}{
+		{"main.go", 4, header + first},
+		{"main.go", 21, header + second},
+		{"main.go", 10, ""},
+		{"other.go", 1, "diff --git a/other.go b/other.go\n--- a/other.go\n+++ b/other.go\n@@ -1 +1 @@\n-p\n+q\n"},
+		{"missing.go", 1, ""},
+	} {
+		if got := SelectHunk(diff, tc.path, tc.line); got != tc.want {
+			t.Errorf("SelectHunk(%s, %d) = %q, want %q", tc.path, tc.line, got, tc.want)
+		}
+	}
+}
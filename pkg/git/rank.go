@@ -0,0 +1,97 @@
+package git
+
+import (
+	"path"
+	"slices"
+	"strings"
+)
+
+// File classes, in the order files appear in prompts: the primary change
+// first, so that it survives truncation and drives the subject.
+const (
+	ClassSource    = "source"
+	ClassTest      = "test"
+	ClassConfig    = "config"
+	ClassDocs      = "docs"
+	ClassLockfile  = "lockfile"
+	ClassGenerated = "generated"
+)
+
+var (
+	classOrder = []string{ClassSource, ClassTest, ClassConfig, ClassDocs, ClassLockfile, ClassGenerated}
+
+	lockfiles = []string{
+		"go.sum", "package-lock.json", "npm-shrinkwrap.json", "yarn.lock", "pnpm-lock.yaml", "bun.lockb",
+		"Cargo.lock", "Gemfile.lock", "poetry.lock", "Pipfile.lock", "uv.lock", "composer.lock",
+		"flake.lock", "mix.lock", "Podfile.lock", "packages.lock.json", "gradle.lockfile",
+	}
+	generatedDirs     = []string{"vendor", "node_modules", "third_party", "dist"}
+	generatedSuffixes = []string{".pb.go", "_gen.go", ".gen.go", "_generated.go", ".generated.ts", ".g.dart", "_pb2.py"}
+	testDirs          = []string{"test", "tests", "__tests__", "testdata", "spec", "e2e"}
+	docExts           = []string{".md", ".mdx", ".rst", ".adoc", ".txt"}
+	docNames          = []string{"LICENSE", "COPYING", "NOTICE", "AUTHORS", "CODEOWNERS"}
+	configExts        = []string{".json", ".yaml", ".yml", ".toml", ".ini", ".cfg", ".conf", ".xml", ".properties", ".env", ".lock"}
+	configNames       = []string{"Makefile", "Dockerfile", "Containerfile", "Jenkinsfile", "Procfile", "go.mod", "go.work", "CMakeLists.txt", "Gemfile", "Pipfile", "BUILD", "BUILD.bazel", "WORKSPACE"}
+)
+
+// Classify returns the class of path (ClassSource, ClassTest, ...) from its
+// name alone.
+func Classify(p string) string {
+	base := path.Base(p)
+	dirs := strings.Split(path.Dir(p), "/")
+	ext := path.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+	switch {
+	case slices.Contains(lockfiles, base):
+		return ClassLockfile
+	case hasAny(dirs, generatedDirs), isMinifiedName(p), strings.HasPrefix(base, "zz_generated"), hasSuffixAny(base, generatedSuffixes):
+		return ClassGenerated
+	case strings.HasSuffix(stem, "_test"), strings.HasPrefix(stem, "test_"), strings.Contains(base, ".test."), strings.Contains(base, ".spec."), hasAny(dirs, testDirs):
+		return ClassTest
+	case slices.Contains(docExts, ext), slices.Contains(docNames, stem), strings.HasPrefix(strings.ToUpper(base), "README"), strings.HasPrefix(strings.ToUpper(base), "CHANGELOG"), hasAny(dirs, []string{"docs", "doc"}):
+		return ClassDocs
+	case slices.Contains(configExts, ext), slices.Contains(configNames, base), strings.HasPrefix(base, "."), hasAny(dirs, []string{".github", ".circleci", ".gitlab"}):
+		return ClassConfig
+	}
+	return ClassSource
+}
+
+// classRank returns the position of path's class in the prompt order.
+func classRank(p string) int {
+	return slices.Index(classOrder, Classify(p))
+}
+
+// rankFiles orders file diffs by class, keeping the diff order within a
+// class.
+func rankFiles(files []fileDiff) {
+	slices.SortStableFunc(files, func(a, b fileDiff) int { return classRank(a.path) - classRank(b.path) })
+}
+
+// rankChunks orders chunks by their most important file.
+func rankChunks(chunks []DiffChunk) {
+	rank := func(c DiffChunk) int {
+		best := len(classOrder)
+		for line := range strings.SplitSeq(c.Diff, "\n") {
+			var p string
+			switch {
+			case strings.HasPrefix(line, "diff --git "):
+				p = diffFilePath(line)
+			case strings.HasPrefix(line, " ") && strings.Contains(line, " | "):
+				p, _, _ = strings.Cut(strings.TrimSpace(line), " | ")
+			default:
+				continue
+			}
+			best = min(best, classRank(strings.TrimSpace(p)))
+		}
+		return best
+	}
+	slices.SortStableFunc(chunks, func(a, b DiffChunk) int { return rank(a) - rank(b) })
+}
+
+func hasAny(dirs, names []string) bool {
+	return slices.ContainsFunc(dirs, func(d string) bool { return slices.Contains(names, d) })
+}
+
+func hasSuffixAny(s string, suffixes []string) bool {
+	return slices.ContainsFunc(suffixes, func(suffix string) bool { return strings.HasSuffix(s, suffix) })
+}
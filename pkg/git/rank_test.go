@@ -0,0 +1,67 @@
+package git
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	for path, want := range map[string]string{
+		"pkg/git/git.go":            ClassSource,
+		"src/app.ts":                ClassSource,
+		"pkg/git/git_test.go":       ClassTest,
+		"tests/test_api.py":         ClassTest,
+		"web/app.spec.ts":           ClassTest,
+		"go.mod":                    ClassConfig,
+		".github/workflows/ci.yaml": ClassConfig,
+		"README.md":                 ClassDocs,
+		"docs/guide.html":           ClassDocs,
+		"go.sum":                    ClassLockfile,
+		"web/package-lock.json":     ClassLockfile,
+		"api/types.pb.go":           ClassGenerated,
+		"vendor/x/y.go":             ClassGenerated,
+	} {
+		if got := Classify(path); got != want {
+			t.Errorf("Classify(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestCondenseDiffRanksFiles(t *testing.T) {
+	file := func(p string) string {
+		return "diff --git a/" + p + " b/" + p + "\n--- a/" + p + "\n+++ b/" + p + "\n@@ -1 +1 @@\n-a\n+b\n"
+	}
+	diff := file("go.sum") + file("README.md") + file("main_test.go") + file("main.go")
+
+	got := condenseDiff(diff, nil, nil)
+
+	order := []string{"a/main.go", "a/main_test.go", "a/README.md", "a/go.sum"}
+	last := -1
+	for _, p := range order {
+		i := strings.Index(got, "diff --git "+p)
+		if i < last {
+			t.Fatalf("condenseDiff() order wrong, want %v:\n%s", order, got)
+		}
+		last = i
+	}
+}
+
+func TestTruncateDiff(t *testing.T) {
+	big := "diff --git a/main.go b/main.go\n@@ -0,0 +1 @@\n" + strings.Repeat("+code\n", 20)
+	small := "diff --git a/README.md b/README.md\n@@ -0,0 +1 @@\n+docs\n"
+
+	got := truncateDiff(big+small+small, len(big)+10)
+
+	if !strings.HasPrefix(got, big) {
+		t.Errorf("truncateDiff() dropped the first file:\n%s", got)
+	}
+	if !strings.Contains(got, "[diff too large; 2 more file(s) summarized]\n") {
+		t.Errorf("truncateDiff() missing summary marker:\n%s", got)
+	}
+	if strings.Contains(got, "+docs") {
+		t.Errorf("truncateDiff() kept content past the limit:\n%s", got)
+	}
+	if same := truncateDiff(small, 1000); same != small {
+		t.Errorf("truncateDiff() changed a diff within the limit: %q", same)
+	}
+}
@@ -0,0 +1,118 @@
+// Package gitlab finds the GitLab project of a git remote and opens merge
+// requests through the GitLab REST API.
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// TokenEnvs are the environment variables holding a personal, group or
+// project access token, in order of preference. CI_JOB_TOKEN is not among
+// them: GitLab does not let job tokens create merge requests.
+var TokenEnvs = []string{"GITLAB_TOKEN", "GITLAB_PRIVATE_TOKEN"}
+
+// Project is a GitLab project as named by a git remote.
+type Project struct {
+	// BaseURL is the GitLab instance, e.g. https://gitlab.com.
+	BaseURL string
+	// Path is the namespaced project path, e.g. group/sub/project.
+	Path string
+}
+
+// ParseRemote returns the project of a remote URL in any of the forms git
+// accepts: https://host/group/project.git, ssh://git@host:2222/group/project
+// or git@host:group/project.git. The instance is assumed to serve HTTPS on
+// the remote's host.
+func ParseRemote(remote string) (Project, error) {
+	remote = strings.TrimSpace(remote)
+	var host, path string
+	switch {
+	case strings.Contains(remote, "://"):
+		u, err := url.Parse(remote)
+		if err != nil {
+			return Project{}, err
+		}
+		host, path = u.Hostname(), u.Path
+		if u.Scheme == "http" || u.Scheme == "https" {
+			host = u.Host
+		}
+	case strings.Contains(remote, ":"):
+		host, path, _ = strings.Cut(remote, ":")
+		if _, h, ok := strings.Cut(host, "@"); ok {
+			host = h
+		}
+	default:
+		return Project{}, fmt.Errorf("remote %q is not a GitLab URL", remote)
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if host == "" || !strings.Contains(path, "/") {
+		return Project{}, fmt.Errorf("remote %q does not name a project", remote)
+	}
+	return Project{BaseURL: "https://" + host, Path: path}, nil
+}
+
+// MergeRequest is what CreateMergeRequest opens.
+type MergeRequest struct {
+	SourceBranch string `json:"source_branch"`
+	TargetBranch string `json:"target_branch"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+}
+
+// CreateMergeRequest opens mr in p with the API token and returns its web
+// URL. token is sent as PRIVATE-TOKEN.
+func CreateMergeRequest(ctx context.Context, p Project, token string, mr MergeRequest) (string, error) {
+	data, err := json.Marshal(mr)
+	if err != nil {
+		return "", err
+	}
+	endpoint := strings.TrimRight(p.BaseURL, "/") + "/api/v4/projects/" + url.PathEscape(p.Path) + "/merge_requests"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("PRIVATE-TOKEN", token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("gitlab request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusCreated {
+		var apiErr struct {
+			Message any `json:"message"`
+			Error   any `json:"error"`
+		}
+		_ = json.Unmarshal(body, &apiErr)
+		detail := apiErr.Message
+		if detail == nil {
+			detail = apiErr.Error
+		}
+		if detail == nil {
+			detail = strings.TrimSpace(string(body))
+		}
+		return "", fmt.Errorf("gitlab returned %s: %v", resp.Status, detail)
+	}
+	var created struct {
+		WebURL string `json:"web_url"`
+	}
+	if err = json.Unmarshal(body, &created); err != nil {
+		return "", err
+	}
+	if created.WebURL == "" {
+		return "", errors.New("gitlab did not return the merge request URL")
+	}
+	return created.WebURL, nil
+}
@@ -0,0 +1,57 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseRemote(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		remote string
+		want   Project
+		ok     bool
+	}{
+		{"https://gitlab.com/group/project.git", Project{"https://gitlab.com", "group/project"}, true},
+		{"https://gitlab.example.com:8443/a/b/c", Project{"https://gitlab.example.com:8443", "a/b/c"}, true},
+		{"git@gitlab.com:group/sub/project.git", Project{"https://gitlab.com", "group/sub/project"}, true},
+		{"ssh://git@gitlab.com:2222/group/project.git", Project{"https://gitlab.com", "group/project"}, true},
+		{"/srv/repos/project.git", Project{}, false},
+		{"https://gitlab.com/project", Project{}, false},
+	} {
+		got, err := ParseRemote(tc.remote)
+		if (err == nil) != tc.ok || got != tc.want {
+			t.Errorf("ParseRemote(%q) = %+v, %v; want %+v, ok=%v", tc.remote, got, err, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestCreateMergeRequest(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/api/v4/projects/group%2Fproject/merge_requests" || r.Header.Get("PRIVATE-TOKEN") != "secret" {
+			http.Error(w, `{"message":"404 Project Not Found"}`, http.StatusNotFound)
+			return
+		}
+		var mr MergeRequest
+		if err := json.NewDecoder(r.Body).Decode(&mr); err != nil || mr.Title != "feat: x" || mr.TargetBranch != "main" {
+			http.Error(w, `{"message":"bad request"}`, http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"web_url":"https://gitlab.example/group/project/-/merge_requests/1"}`))
+	}))
+	defer srv.Close()
+
+	p := Project{BaseURL: srv.URL, Path: "group/project"}
+	mr := MergeRequest{SourceBranch: "topic", TargetBranch: "main", Title: "feat: x"}
+	got, err := CreateMergeRequest(t.Context(), p, "secret", mr)
+	if err != nil || got != "https://gitlab.example/group/project/-/merge_requests/1" {
+		t.Fatalf("CreateMergeRequest() = %q, %v", got, err)
+	}
+	if _, err = CreateMergeRequest(t.Context(), p, "wrong", mr); err == nil {
+		t.Fatal("CreateMergeRequest() with a bad token succeeded")
+	}
+}
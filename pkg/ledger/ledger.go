@@ -0,0 +1,95 @@
+// Package ledger records generation runs in an append-only NDJSON file
+// under the repository's git directory (.git/git-ai/ledger.jsonl).
+package ledger
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/ndjson"
+)
+
+const fileName = "ledger.jsonl"
+
+// Entry kinds.
+const (
+	KindGenerate = "generate"
+	KindCompare  = "compare"
+	// KindPartial is a message cut short by Ctrl-C and kept for later.
+	KindPartial = "partial"
+)
+
+// Entry is one record in the usage ledger.
+type Entry struct {
+	Time    time.Time `json:"time"`
+	Kind    string    `json:"kind"`
+	Backend string    `json:"backend"`
+	Model   string    `json:"model"`
+	Message string    `json:"message,omitempty"`
+	// CostUSD is the reported or estimated cost of the run; zero when
+	// unknown.
+	CostUSD float64 `json:"cost_usd,omitempty"`
+	// Contenders lists the "backend:model" pairs that competed in a compare
+	// run; Backend/Model hold the winner.
+	Contenders []string `json:"contenders,omitempty"`
+}
+
+// Path returns the ledger location for the current repository. Linked
+// worktrees share the ledger of the main repository.
+func Path() (string, error) {
+	dir, err := git.CommonDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "git-ai", fileName), nil
+}
+
+// Append adds e to the ledger, stamping Time when unset.
+func Append(e Entry) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Read returns all ledger entries, oldest first. A missing ledger yields no
+// entries and no error; malformed lines are skipped.
+func Read() ([]Entry, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ndjson.Decode[Entry](f)
+}
@@ -0,0 +1,208 @@
+// Package metrics keeps opt-in, local-only usage metrics in an append-only
+// NDJSON file (.git/git-ai/metrics.jsonl): latency and regeneration counts
+// per generation, and whether the generated message ended up in the commit.
+// Nothing is sent anywhere; `git-cc-ai stats` reads the file.
+package metrics
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/ndjson"
+)
+
+const fileName = "metrics.jsonl"
+
+// Event kinds.
+const (
+	KindGenerate = "generate"
+	KindCommit   = "commit"
+)
+
+// Commit outcomes.
+const (
+	// OutcomeAccepted means the generated message was committed unchanged.
+	OutcomeAccepted = "accepted"
+	// OutcomeEdited means the subject survived but the message was edited.
+	OutcomeEdited = "edited"
+	// OutcomeReplaced means the committed message was written from scratch.
+	OutcomeReplaced = "replaced"
+)
+
+// Event is one metrics record. Messages are stored as hashes only.
+type Event struct {
+	Time    time.Time `json:"time"`
+	Kind    string    `json:"kind"`
+	Backend string    `json:"backend,omitempty"`
+	Model   string    `json:"model,omitempty"`
+	// Tree is the staged tree the message was generated for, used to match
+	// the generation with the commit made from it.
+	Tree          string `json:"tree"`
+	LatencyMS     int64  `json:"latency_ms,omitempty"`
+	Regenerations int    `json:"regenerations,omitempty"`
+	MessageHash   string `json:"message_hash,omitempty"`
+	SubjectHash   string `json:"subject_hash,omitempty"`
+	Outcome       string `json:"outcome,omitempty"`
+}
+
+// Hash returns the hash Event stores for a message or subject, ignoring
+// comment lines and surrounding whitespace.
+func Hash(text string) string {
+	lines := make([]string, 0, strings.Count(text, "\n")+1)
+	for line := range strings.SplitSeq(text, "\n") {
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, strings.TrimRight(line, " \t\r"))
+		}
+	}
+	sum := sha256.Sum256([]byte(strings.TrimSpace(strings.Join(lines, "\n"))))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Path returns the metrics file shared by all worktrees of the repository.
+func Path() (string, error) {
+	dir, err := git.CommonDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "git-ai", fileName), nil
+}
+
+// Append adds e to the metrics file, stamping Time when unset.
+func Append(e Event) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Read returns all events, oldest first. A missing file yields no events
+// and no error; malformed lines are skipped.
+func Read() ([]Event, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ndjson.Decode[Event](f)
+}
+
+// Outcome classifies a commit of tree with message against the latest
+// generation for the same tree in events. ok is false when no generation
+// matches (the commit was made without the tool).
+func Outcome(events []Event, tree, message string) (Event, bool) {
+	for i := len(events) - 1; i >= 0; i-- {
+		gen := events[i]
+		if gen.Kind != KindGenerate || gen.Tree != tree {
+			continue
+		}
+		commit := Event{Kind: KindCommit, Backend: gen.Backend, Model: gen.Model, Tree: tree, Outcome: OutcomeReplaced}
+		subject, _, _ := strings.Cut(strings.TrimSpace(message), "\n")
+		switch {
+		case Hash(message) == gen.MessageHash:
+			commit.Outcome = OutcomeAccepted
+		case Hash(subject) == gen.SubjectHash:
+			commit.Outcome = OutcomeEdited
+		}
+		return commit, true
+	}
+	return Event{}, false
+}
+
+// Summary aggregates the events of one backend/model pair.
+type Summary struct {
+	Backend       string  `json:"backend"`
+	Model         string  `json:"model"`
+	Generations   int     `json:"generations"`
+	Commits       int     `json:"commits"`
+	Accepted      int     `json:"accepted"`
+	Edited        int     `json:"edited"`
+	AcceptRate    float64 `json:"accept_rate"`
+	AvgLatencyMS  int64   `json:"avg_latency_ms"`
+	Regenerations int     `json:"regenerations"`
+}
+
+// Summarize groups events by backend and model, best acceptance rate
+// first.
+func Summarize(events []Event) []Summary {
+	var (
+		byKey   = map[string]*Summary{}
+		latency = map[string]int64{}
+	)
+	for _, e := range events {
+		key := e.Backend + "\x00" + e.Model
+		s := byKey[key]
+		if s == nil {
+			s = &Summary{Backend: e.Backend, Model: e.Model}
+			byKey[key] = s
+		}
+		switch e.Kind {
+		case KindGenerate:
+			s.Generations++
+			s.Regenerations += e.Regenerations
+			latency[key] += e.LatencyMS
+		case KindCommit:
+			s.Commits++
+			switch e.Outcome {
+			case OutcomeAccepted:
+				s.Accepted++
+			case OutcomeEdited:
+				s.Edited++
+			}
+		}
+	}
+	out := make([]Summary, 0, len(byKey))
+	for key, s := range byKey {
+		if s.Generations > 0 {
+			s.AvgLatencyMS = latency[key] / int64(s.Generations)
+		}
+		if s.Commits > 0 {
+			s.AcceptRate = float64(s.Accepted) / float64(s.Commits)
+		}
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].AcceptRate != out[j].AcceptRate {
+			return out[i].AcceptRate > out[j].AcceptRate
+		}
+		if out[i].Commits != out[j].Commits {
+			return out[i].Commits > out[j].Commits
+		}
+		return out[i].Backend+out[i].Model < out[j].Backend+out[j].Model
+	})
+	return out
+}
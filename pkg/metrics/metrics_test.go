@@ -0,0 +1,40 @@
+package metrics
+
+import "testing"
+
+func TestOutcomeAndSummarize(t *testing.T) {
+	t.Parallel()
+
+	message := "feat(api): add pagination\n\nPage results by cursor."
+	events := []Event{
+		{Kind: KindGenerate, Backend: "claude", Model: "sonnet", Tree: "t1", LatencyMS: 1000, MessageHash: Hash(message), SubjectHash: Hash("feat(api): add pagination")},
+		{Kind: KindGenerate, Backend: "codex", Model: "gpt", Tree: "t2", LatencyMS: 3000, Regenerations: 2, MessageHash: Hash("x"), SubjectHash: Hash("x")},
+	}
+
+	tests := []struct {
+		tree, message, want string
+	}{
+		{"t1", message + "\n# usage comment\n", OutcomeAccepted},
+		{"t1", "feat(api): add pagination\n\nEdited body.", OutcomeEdited},
+		{"t2", "fix: something else", OutcomeReplaced},
+	}
+	for _, tt := range tests {
+		got, ok := Outcome(events, tt.tree, tt.message)
+		if !ok || got.Outcome != tt.want {
+			t.Errorf("Outcome(%s) = %q, %v; want %q", tt.tree, got.Outcome, ok, tt.want)
+		}
+	}
+	if _, ok := Outcome(events, "t3", message); ok {
+		t.Error("Outcome matched a tree without a generation")
+	}
+
+	c1, _ := Outcome(events, "t1", message)
+	c2, _ := Outcome(events, "t2", "fix: other")
+	summary := Summarize(append(events, c1, c2))
+	if len(summary) != 2 || summary[0].Backend != "claude" || summary[0].AcceptRate != 1 {
+		t.Fatalf("Summarize() = %+v", summary)
+	}
+	if summary[1].AvgLatencyMS != 3000 || summary[1].Regenerations != 2 || summary[1].Accepted != 0 {
+		t.Errorf("Summarize() codex = %+v", summary[1])
+	}
+}
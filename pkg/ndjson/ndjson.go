@@ -0,0 +1,88 @@
+// Package ndjson reads newline-delimited JSON: the event streams of the
+// backend CLIs, the JSON-RPC requests of serve --stdio and the .jsonl logs
+// under .git/git-ai.
+package ndjson
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"iter"
+	"strings"
+)
+
+// Reader returns the non-blank lines of an NDJSON stream. Unlike
+// bufio.Scanner it has no line length limit, so a huge single-line event
+// (an assistant message embedding a whole diff) is never cut off, and a
+// final line without a newline, as left by a killed process, is still
+// returned.
+type Reader struct {
+	r   *bufio.Reader
+	err error
+}
+
+// NewReader returns a Reader reading from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Next returns the next non-blank line without its line ending, or io.EOF
+// after the last one.
+func (r *Reader) Next() (string, error) {
+	if r.err != nil {
+		return "", r.err
+	}
+	for {
+		line, err := r.r.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if strings.TrimSpace(line) != "" {
+			// Return the line now and the error on the next call.
+			if err != nil && !errors.Is(err, io.EOF) {
+				r.err = err
+			}
+			return line, nil
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				r.err = err
+			}
+			return "", err
+		}
+	}
+}
+
+// Lines ranges over the remaining lines. Check Err once the loop ends.
+func (r *Reader) Lines() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for {
+			line, err := r.Next()
+			if err != nil {
+				return
+			}
+			if !yield(line) {
+				return
+			}
+		}
+	}
+}
+
+// Err returns the first read error other than io.EOF.
+func (r *Reader) Err() error { return r.err }
+
+// Decode unmarshals every line of r into a T. Malformed lines, such as a
+// line truncated by a crash while it was appended, are skipped; the values
+// decoded before a read error are returned with it.
+func Decode[T any](r io.Reader) ([]T, error) {
+	var (
+		values []T
+		rd     = NewReader(r)
+	)
+	for line := range rd.Lines() {
+		var v T
+		if json.Unmarshal([]byte(line), &v) == nil {
+			values = append(values, v)
+		}
+	}
+	return values, rd.Err()
+}
@@ -0,0 +1,66 @@
+package ndjson
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReaderLines(t *testing.T) {
+	t.Parallel()
+
+	long := `{"text":"` + strings.Repeat("x", 3<<20) + `"}`
+	input := "{\"a\":1}\r\n\n  \n" + long + "\n{\"partial\":"
+	rd := NewReader(strings.NewReader(input))
+	got := slices.Collect(rd.Lines())
+	if err := rd.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+	if len(got) != 3 || got[0] != `{"a":1}` || got[1] != long || got[2] != `{"partial":` {
+		t.Errorf("Lines() returned %d lines, want the short, long and partial line", len(got))
+	}
+}
+
+type failingReader struct{ data string }
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	if f.data == "" {
+		return 0, errors.New("boom")
+	}
+	n := copy(p, f.data)
+	f.data = f.data[n:]
+	return n, nil
+}
+
+func TestReaderError(t *testing.T) {
+	t.Parallel()
+
+	rd := NewReader(&failingReader{data: "{\"a\":1}\n{\"b\""})
+	got := slices.Collect(rd.Lines())
+	if len(got) != 2 {
+		t.Errorf("Lines() = %q, want both lines before the error", got)
+	}
+	if err := rd.Err(); err == nil || err.Error() != "boom" {
+		t.Errorf("Err() = %v, want boom", err)
+	}
+	if _, err := rd.Next(); err == nil || errors.Is(err, io.EOF) {
+		t.Errorf("Next() after the error = %v, want boom", err)
+	}
+}
+
+func TestDecodeSkipsMalformedLines(t *testing.T) {
+	t.Parallel()
+
+	type entry struct {
+		N int `json:"n"`
+	}
+	got, err := Decode[entry](strings.NewReader("{\"n\":1}\nnot json\n{\"n\":2}\n{\"n\":"))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if len(got) != 2 || got[0].N != 1 || got[1].N != 2 {
+		t.Errorf("Decode() = %+v", got)
+	}
+}
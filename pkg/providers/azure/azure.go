@@ -0,0 +1,115 @@
+// Package azure generates commit messages with Azure OpenAI deployments.
+// The resource endpoint and deployment names come from the environment, the
+// API key from the environment, a key command or the keychain; no CLI is
+// needed.
+package azure
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers/chatapi"
+	"github.com/dlnilsson/git-cc-ai/pkg/secrets"
+)
+
+const (
+	// EndpointEnv is the resource endpoint, e.g.
+	// https://contoso.openai.azure.com.
+	EndpointEnv = "AZURE_OPENAI_ENDPOINT"
+	// APIKeyEnv holds the key of the resource; it can also come from a
+	// command or the keychain (see secrets).
+	APIKeyEnv = "AZURE_OPENAI_API_KEY"
+	// DeploymentEnv names the deployments to use, comma-separated; the
+	// first is the default and the others can be picked with -m.
+	DeploymentEnv = "AZURE_OPENAI_DEPLOYMENT"
+	// APIVersionEnv overrides the api-version query parameter.
+	APIVersionEnv     = "AZURE_OPENAI_API_VERSION"
+	defaultAPIVersion = "2024-10-21"
+)
+
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type request struct {
+	Messages      []chatapi.Message `json:"messages"`
+	Stream        bool              `json:"stream"`
+	StreamOptions streamOptions     `json:"stream_options"`
+	Temperature   *float64          `json:"temperature,omitempty"`
+	Seed          *int64            `json:"seed,omitempty"`
+}
+
+// deployments returns the deployment names of AZURE_OPENAI_DEPLOYMENT.
+func deployments() []string {
+	fields := strings.Split(os.Getenv(DeploymentEnv), ",")
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f = strings.TrimSpace(f); f != "" {
+			names = append(names, f)
+		}
+	}
+	return names
+}
+
+func apiVersion() string {
+	if v := strings.TrimSpace(os.Getenv(APIVersionEnv)); v != "" {
+		return v
+	}
+	return defaultAPIVersion
+}
+
+// configured returns the API key, reporting the settings that are missing.
+func configured() (string, error) {
+	var unset []string
+	if strings.TrimSpace(os.Getenv(EndpointEnv)) == "" {
+		unset = append(unset, EndpointEnv)
+	}
+	key, err := secrets.Lookup(APIKeyEnv)
+	switch {
+	case errors.Is(err, secrets.ErrNotFound):
+		unset = append(unset, APIKeyEnv)
+	case err != nil:
+		return "", err
+	}
+	if len(deployments()) == 0 {
+		unset = append(unset, DeploymentEnv)
+	}
+	if len(unset) > 0 {
+		return "", chatapi.NotConfigured("azure", unset...)
+	}
+	return key, nil
+}
+
+func Generate(ctx context.Context, reg *providers.Registry, opts providers.Options) (string, error) {
+	key, err := configured()
+	if err != nil {
+		return "", err
+	}
+	messages, err := chatapi.Messages(opts)
+	if err != nil {
+		return "", err
+	}
+	deployment := strings.TrimSpace(opts.Model)
+	if deployment == "" {
+		deployment = deployments()[0]
+	}
+	endpoint := strings.TrimSuffix(strings.TrimSpace(os.Getenv(EndpointEnv)), "/")
+	ep := chatapi.Endpoint{
+		Backend: "azure",
+		URL: endpoint + "/openai/deployments/" + url.PathEscape(deployment) +
+			"/chat/completions?api-version=" + url.QueryEscape(apiVersion()),
+		Header: http.Header{"Api-Key": {key}},
+	}
+	return chatapi.Generate(ctx, reg, opts, ep, deployment, request{
+		Messages:      messages,
+		Stream:        true,
+		StreamOptions: streamOptions{IncludeUsage: true},
+		Temperature:   opts.Temperature,
+		Seed:          opts.Seed,
+	})
+}
@@ -0,0 +1,79 @@
+package azure
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/secrets"
+)
+
+const testDiff = `diff --git a/upload/client.go b/upload/client.go
+--- a/upload/client.go
++++ b/upload/client.go
+@@ -1 +1,2 @@
+ package upload
++// retry on 503
+`
+
+func TestGenerateReplaysStream(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/success.sse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/deployments/commit-mini/chat/completions" ||
+			r.URL.Query().Get("api-version") != defaultAPIVersion ||
+			r.Header.Get("Api-Key") != "test-key" {
+			http.Error(w, "unexpected request "+r.URL.String(), http.StatusBadRequest)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write(fixture)
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv(EndpointEnv, srv.URL+"/")
+	t.Setenv(APIKeyEnv, "test-key")
+	t.Setenv(DeploymentEnv, "gpt-4o-commit, commit-mini")
+	t.Setenv(APIVersionEnv, "")
+
+	if models := (Backend{}).Models(); len(models) != 2 || (Backend{}).DefaultModel() != "gpt-4o-commit" {
+		t.Fatalf("Models() = %q", models)
+	}
+	msg, err := Generate(t.Context(), &providers.Registry{}, providers.Options{Diff: testDiff, Model: "commit-mini"})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	want := "fix(upload): retry on 503\n\n# tokens: input=1702 cached=1536 output=8 elapsed="
+	if !strings.HasPrefix(msg, want) || !strings.HasSuffix(msg, " model=commit-mini") {
+		t.Errorf("message = %q, want prefix %q", msg, want)
+	}
+	if !got.Stream || !got.StreamOptions.IncludeUsage || got.Temperature != nil {
+		t.Errorf("request = %+v", got)
+	}
+}
+
+func TestGenerateNotConfigured(t *testing.T) {
+	t.Setenv(EndpointEnv, "https://contoso.openai.azure.com")
+	t.Setenv(APIKeyEnv, "")
+	t.Setenv(secrets.CommandEnv, "")
+	t.Setenv(DeploymentEnv, "")
+
+	_, err := Generate(t.Context(), &providers.Registry{}, providers.Options{Diff: testDiff})
+	if !errors.Is(err, providers.ErrNotConfigured) {
+		t.Fatalf("Generate() error = %v, want ErrNotConfigured", err)
+	}
+	if want := "azure needs AZURE_OPENAI_API_KEY, AZURE_OPENAI_DEPLOYMENT to be set"; !strings.Contains(err.Error(), want) {
+		t.Errorf("error %q does not name the missing settings", err)
+	}
+}
@@ -0,0 +1,38 @@
+package azure
+
+import (
+	"context"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+)
+
+type Backend struct{}
+
+func (Backend) Generate(ctx context.Context, reg *providers.Registry, opts providers.Options) (string, error) {
+	return providers.Retry(ctx, reg, opts, "azure", func() (string, error) {
+		return Generate(ctx, reg, opts)
+	})
+}
+
+// Models returns the configured deployments; a deployment name is what
+// Azure OpenAI calls the model of a request.
+func (Backend) Models() []string { return deployments() }
+
+// DefaultModel returns the first configured deployment.
+func (Backend) DefaultModel() string {
+	if d := deployments(); len(d) > 0 {
+		return d[0]
+	}
+	return ""
+}
+
+// SupportsSampling reports that temperature and seed are sent with the
+// request.
+func (Backend) SupportsSampling() (bool, bool) { return true, true }
+
+// Configured reports whether the endpoint, API key and a deployment are
+// set.
+func (Backend) Configured() error {
+	_, err := configured()
+	return err
+}
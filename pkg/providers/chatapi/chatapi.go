@@ -0,0 +1,283 @@
+// Package chatapi runs the streamed chat requests of the API backends
+// (Mistral, Azure OpenAI, Vertex AI). The backends build the endpoint and
+// request body; the prompt, the server-sent event stream, interrupts, usage
+// reporting and the message trailer are handled here the same way for all
+// of them.
+package chatapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/commit"
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/ndjson"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/stream"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+// maxErrorBody caps how much of a failed response is read.
+const maxErrorBody = 64 << 10
+
+// Message is a chat message of the request.
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Endpoint is where and how a backend sends its request.
+type Endpoint struct {
+	// Backend names the backend in errors, the spinner and usage.
+	Backend string
+	URL     string
+	// Header carries the authentication of the API.
+	Header http.Header
+	// Decode decodes the server-sent events of the response; nil means
+	// OpenAI-style chat completion chunks (stream.DecodeChatCompletion).
+	Decode stream.Decoder
+}
+
+// Generate POSTs body (a chat completion request with streaming on) to ep
+// and returns the reply: the commit message with its usage trailer, or the
+// task response when opts.Task is set. model is the model or deployment the
+// request runs on, for the spinner and usage. An interrupt forwarded to reg
+// cancels the request.
+func Generate(ctx context.Context, reg *providers.Registry, opts providers.Options, ep Endpoint, model string, body any) (string, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	for key, values := range ep.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+
+	startTime := time.Now()
+	var stopSpinner func()
+	if opts.ShowSpinner {
+		stopSpinner = ui.StartSpinner(ui.RandomSpinnerMessage(), ep.Backend+" +"+model, reg)
+		defer stopSpinner()
+	}
+	reg.RegisterCancel(cancel, stopSpinner)
+	defer reg.Unregister()
+	watch := opts.WatchStall(reg)
+	defer watch.Stop()
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		if reg.WasInterrupted() || ctx.Err() != nil {
+			return "", fmt.Errorf("%s request %w", ep.Backend, providers.ErrInterrupted)
+		}
+		return "", fmt.Errorf("%s request failed: %w", ep.Backend, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusTooManyRequests {
+		err = apiError(ep.Backend, resp)
+		wait := providers.RetryAfterHeader(resp.Header)
+		if wait == 0 {
+			wait = providers.RetryHint(err.Error())
+		}
+		return "", &providers.RateLimitError{Err: err, RetryAfter: wait}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", apiError(ep.Backend, resp)
+	}
+	opts.Report(providers.Progress{Phase: providers.PhaseRunning})
+
+	var (
+		reply     strings.Builder
+		usage     stream.Usage
+		streamErr string
+	)
+	decode := ep.Decode
+	if decode == nil {
+		decode = stream.DecodeChatCompletion
+	}
+	meter := opts.NewTokenMeter(model, string(data))
+	reader := ndjson.NewReader(resp.Body)
+	for line := range reader.Lines() {
+		watch.Alive()
+		if data, ok := stream.SSEData(line); ok {
+			opts.Event(data)
+		}
+		for _, ev := range decode(line) {
+			switch ev := ev.(type) {
+			case stream.Reasoning:
+				if opts.ShowSpinner {
+					ui.SendSpinnerReasoning(ev.Text)
+				}
+				opts.Report(providers.Progress{Phase: providers.PhaseReasoning, Reasoning: ev.Text})
+			case stream.TextDelta:
+				reply.WriteString(ev.Text)
+				meter.Streamed(reply.String())
+				switch {
+				case opts.ShowSpinner && opts.Stream:
+					ui.SendSpinnerPreview(commit.Normalize(reply.String()))
+				case opts.ShowSpinner:
+					ui.SendSpinnerReasoning(strings.TrimSpace(reply.String()))
+				}
+				opts.Report(providers.Progress{Phase: providers.PhaseReasoning, Reasoning: strings.TrimSpace(reply.String()), Partial: reply.String()})
+			case stream.Usage:
+				usage = ev
+				meter.Reported(usage.OutputTokens)
+			case stream.Error:
+				streamErr = ev.Message
+			}
+		}
+	}
+	if reg.WasInterrupted() || ctx.Err() != nil {
+		return "", fmt.Errorf("%s request %w", ep.Backend, providers.ErrInterrupted)
+	}
+	if err := reader.Err(); err != nil {
+		return "", fmt.Errorf("%s stream failed: %w", ep.Backend, err)
+	}
+	if streamErr != "" {
+		return "", fmt.Errorf("%s returned an error: %s", ep.Backend, streamErr)
+	}
+
+	responseText := reply.String()
+	text := commit.Normalize(responseText)
+	if text == "" {
+		return "", fmt.Errorf("%s returned empty response", ep.Backend)
+	}
+
+	opts.ReportUsage(providers.Usage{
+		Backend:      ep.Backend,
+		Model:        model,
+		InputTokens:  usage.InputTokens,
+		OutputTokens: usage.OutputTokens,
+		CachedTokens: usage.CachedInputTokens,
+		Elapsed:      time.Since(startTime),
+	})
+
+	if opts.Task != nil {
+		return commit.StripCodeFence(responseText), nil
+	}
+	msg := opts.FormatMessage(text)
+	return appendUsageComment(msg, usage, time.Since(startTime), model), nil
+}
+
+// apiError turns a failed response into an error carrying the API's
+// message ({"message": ...}, {"detail": ...} or {"error": {"message":
+// ...}}, the last also wrapped in an array by Vertex AI) or, failing that,
+// the body.
+func apiError(backend string, resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	var body struct {
+		Message any `json:"message"`
+		Detail  any `json:"detail"`
+		Error   *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	detail := strings.TrimSpace(string(data))
+	if strings.HasPrefix(detail, "[") {
+		var bodies []json.RawMessage
+		if json.Unmarshal(data, &bodies) == nil && len(bodies) > 0 {
+			data = bodies[0]
+		}
+	}
+	if json.Unmarshal(data, &body) == nil {
+		if body.Error != nil && body.Error.Message != "" {
+			body.Message = body.Error.Message
+		}
+		for _, v := range []any{body.Message, body.Detail} {
+			if s, ok := v.(string); ok && s != "" {
+				detail = s
+				break
+			}
+		}
+	}
+	if detail == "" {
+		return fmt.Errorf("%s request failed (%s)", backend, resp.Status)
+	}
+	return fmt.Errorf("%s request failed (%s): %s", backend, resp.Status, detail)
+}
+
+// Messages returns the task as a system and a user message when opts.Task
+// is set, otherwise the commit prompt over the staged diff.
+func Messages(opts providers.Options) ([]Message, error) {
+	if opts.Task != nil {
+		return []Message{
+			{Role: "system", Content: opts.Task.Instructions},
+			{Role: "user", Content: opts.Task.Input},
+		}, nil
+	}
+	diff, changes, err := opts.StagedDiff()
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(diff) == "" && opts.Summaries == "" {
+		return nil, providers.ErrNoStagedChanges
+	}
+	opts.Report(providers.Progress{Phase: providers.PhaseDiff, DiffBytes: len(diff)})
+
+	skillText := commit.ConventionalSpec
+	if opts.NoCC {
+		skillText = commit.StandardCommitRule
+	}
+	if opts.SkillPath != "" {
+		if data, readErr := os.ReadFile(opts.SkillPath); readErr == nil {
+			trimmed := strings.TrimSpace(string(data))
+			if trimmed != "" {
+				skillText = skillText + "\nAdditional instructions:\n" + trimmed
+			}
+		}
+	}
+
+	scope, allowed := opts.ScopeFor(changes)
+	prompt := commit.BuildConventionalPrompt(commit.PromptOptions{
+		SkillText:   skillText,
+		Diff:        diff,
+		Summaries:   opts.Summaries,
+		Changes:     git.FormatChanges(changes),
+		ExtraNote:   opts.ExtraNote,
+		NoCC:        opts.NoCC,
+		Risk:        opts.Risk,
+		MaxSubject:  opts.MaxSubject,
+		SubjectOnly: opts.SubjectOnly,
+		Scope:       scope,
+		Scopes:      allowed,
+		Types:       opts.Types,
+		ScopePolicy: opts.ScopePolicy,
+		ScopeHints:  opts.ScopeHints(changes),
+		Template:    opts.Template,
+	})
+	return []Message{{Role: "user", Content: prompt}}, nil
+}
+
+func appendUsageComment(message string, usage stream.Usage, elapsed time.Duration, model string) string {
+	var b strings.Builder
+	b.WriteString(message)
+	fmt.Fprintf(&b, "\n\n# tokens: input=%d", usage.InputTokens)
+	if usage.CachedInputTokens > 0 {
+		fmt.Fprintf(&b, " cached=%d", usage.CachedInputTokens)
+	}
+	fmt.Fprintf(&b, " output=%d elapsed=%s", usage.OutputTokens, elapsed.Round(100*time.Millisecond))
+	if model != "" {
+		b.WriteString(" model=")
+		b.WriteString(model)
+	}
+	return b.String()
+}
+
+// NotConfigured returns an ErrNotConfigured error naming the unset
+// environment variables.
+func NotConfigured(backend string, unset ...string) error {
+	return fmt.Errorf("%w: %s needs %s to be set", providers.ErrNotConfigured, backend, strings.Join(unset, ", "))
+}
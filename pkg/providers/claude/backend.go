@@ -9,8 +9,14 @@ import (
 type Backend struct{}
 
 func (Backend) Generate(ctx context.Context, reg *providers.Registry, opts providers.Options) (string, error) {
-	return Generate(ctx, reg, opts)
+	return providers.Retry(ctx, reg, opts, "claude", func() (string, error) {
+		return Generate(ctx, reg, opts)
+	})
 }
 
 func (Backend) Models() []string     { return append([]string{}, allowedModels...) }
 func (Backend) DefaultModel() string { return defaultModel }
+
+// SupportsReasoning reports that the reasoning effort maps to a thinking
+// token budget.
+func (Backend) SupportsReasoning() bool { return true }
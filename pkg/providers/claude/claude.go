@@ -1,7 +1,6 @@
 package claude
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -10,24 +9,63 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/dlnilsson/git-cc-ai/pkg/commit"
 	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/ndjson"
 	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/stream"
 	"github.com/dlnilsson/git-cc-ai/pkg/ui"
 )
 
-const defaultBudgetUSD = 1.0
+// DefaultBudgetUSD is the spend limit of a run when Options.Budget is 0.
+const DefaultBudgetUSD = 1.0
+
 const defaultModel = "claude-haiku-4-5-20251001"
 
+// budgetExceededSubtype is the result subtype claude reports when
+// --max-budget-usd is hit.
+const budgetExceededSubtype = "error_max_budget_usd"
+
+// thinkingBudgets maps reasoning effort levels to the MAX_THINKING_TOKENS
+// budget given to the claude CLI.
+var thinkingBudgets = map[string]int{
+	providers.ReasoningLow:    1024,
+	providers.ReasoningMedium: 8000,
+	providers.ReasoningHigh:   31999,
+}
+
 var allowedModels = []string{
 	"claude-haiku-4-5-20251001",
 	"claude-sonnet-4-6",
 	"claude-opus-4-6",
 }
 
+// toolArgs restricts the tools of claude: none by default, only the
+// listed ones (pre-approved, so they run under --print) for a tool list,
+// and claude's own defaults for ToolsAll. The flags are passed on every
+// run, resumed sessions included.
+func toolArgs(opts providers.Options) []string {
+	if opts.UnrestrictedTools() {
+		return nil
+	}
+	list := opts.ToolList()
+	if len(list) == 0 {
+		return []string{"--tools", ""}
+	}
+	names := make([]string, 0, len(list))
+	for _, tool := range list {
+		name, _, _ := strings.Cut(tool, "(")
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return []string{"--tools", strings.Join(names, ","), "--allowed-tools", strings.Join(list, ",")}
+}
+
 func resolveModel(model string) string {
 	if strings.TrimSpace(model) != "" {
 		return model
@@ -36,41 +74,14 @@ func resolveModel(model string) string {
 }
 
 func Generate(ctx context.Context, reg *providers.Registry, opts providers.Options) (string, error) {
-	chunks, err := git.DiffStagedChunks()
+	systemPrompt, stdinPayload, stdinDesc, err := buildRequest(opts)
 	if err != nil {
 		return "", err
 	}
-	if len(chunks) == 0 {
-		return "", errors.New("no staged diff content found")
-	}
-
-	skillText := commit.ConventionalSpec
-	if opts.NoCC {
-		skillText = commit.StandardCommitRule
-	}
-	skillText = skillText + "\n\n" + "Dont sign commit messages with claude code!"
-	if opts.SkillPath != "" {
-		if data, readErr := os.ReadFile(opts.SkillPath); readErr == nil {
-			trimmed := strings.TrimSpace(string(data))
-			if trimmed != "" {
-				skillText = skillText + "\nAdditional instructions:\n" + trimmed
-			}
-		}
-	}
-
-	systemPrompt := commit.BuildSystemPrompt(commit.PromptOptions{
-		SkillText: skillText,
-		NoCC:      opts.NoCC,
-	})
-
-	stdinPayload, err := buildChunkedStreamInput(chunks, opts.ExtraNote)
-	if err != nil {
-		return "", fmt.Errorf("failed to encode stream-json input: %w", err)
-	}
 
 	budgetUSD := opts.Budget
 	if budgetUSD <= 0 {
-		budgetUSD = defaultBudgetUSD
+		budgetUSD = DefaultBudgetUSD
 	}
 	model := resolveModel(opts.Model)
 
@@ -83,10 +94,18 @@ func Generate(ctx context.Context, reg *providers.Registry, opts providers.Optio
 		"--no-session-persistence",
 		"--max-budget-usd", fmt.Sprintf("%g", budgetUSD),
 	}
+	args = append(args, toolArgs(opts)...)
+	args = append(args, opts.ExtraArgsFor("claude")...)
 	if opts.SessionID != "" {
 		args = append([]string{"--resume=" + opts.SessionID, "--fork-session"}, args...)
 	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	toolCalls := opts.NewToolCalls(cancel)
 	cmd := exec.CommandContext(ctx, "claude", args...)
+	if tokens, ok := thinkingBudgets[opts.Reasoning]; ok {
+		cmd.Env = append(cmd.Environ(), fmt.Sprintf("MAX_THINKING_TOKENS=%d", tokens))
+	}
 	cmd.Stdin = bytes.NewReader(stdinPayload)
 	setProcessGroup(cmd)
 
@@ -104,239 +123,217 @@ func Generate(ctx context.Context, reg *providers.Registry, opts providers.Optio
 	if err != nil {
 		return "", err
 	}
-	cmd.Stderr = os.Stderr
+	var stderr providers.StderrTail
+	cmd.Stderr = &stderr
+	defer stderr.Debug("claude")
 
 	if err = cmd.Start(); err != nil {
-		return "", fmt.Errorf("%w\n# %s", err, cmdString(cmd, fmt.Sprintf("%d dir chunk(s)", len(chunks))))
+		return "", fmt.Errorf("%w\n# %s", err, cmdString(cmd, stdinDesc))
 	}
 	reg.Register(cmd, stopSpinner)
 	defer reg.Unregister()
+	watch := opts.WatchStall(reg)
+	defer watch.Stop()
+	opts.Report(providers.Progress{Phase: providers.PhaseRunning})
 
 	var (
-		result        claudeResult
+		result        stream.ClaudeResult
 		lastAssistant string
 		deltaAccum    strings.Builder
 		buffer        strings.Builder
+		outputTokens  int
+		meterOpts     = opts
 	)
-	reader := bufio.NewReader(io.TeeReader(stdout, &buffer))
-	for {
-		line, readErr := reader.ReadString('\n')
-		line = strings.TrimRight(line, "\r\n")
-		if strings.TrimSpace(line) != "" {
-			if opts.ShowSpinner {
-				if delta := parseTextDelta(line); delta != "" {
-					deltaAccum.WriteString(delta)
+	meterOpts.Budget = budgetUSD
+	meter := meterOpts.NewTokenMeter(model, systemPrompt+string(stdinPayload))
+	reader := ndjson.NewReader(io.TeeReader(stdout, &buffer))
+	for line := range reader.Lines() {
+		watch.Alive()
+		opts.Event(line)
+		for _, ev := range stream.DecodeClaude(line) {
+			switch ev := ev.(type) {
+			case stream.TextDelta:
+				deltaAccum.WriteString(ev.Text)
+				meter.Streamed(deltaAccum.String())
+				switch {
+				case opts.ShowSpinner && opts.Stream:
+					ui.SendSpinnerPreview(commit.Normalize(deltaAccum.String()))
+				case opts.ShowSpinner:
 					ui.SendSpinnerReasoning(strings.TrimSpace(deltaAccum.String()))
-				} else if text := parseStreamReasoning(line); text != "" {
-					deltaAccum.Reset()
-					ui.SendSpinnerReasoning(text)
 				}
+				opts.Report(providers.Progress{Phase: providers.PhaseReasoning, Reasoning: strings.TrimSpace(deltaAccum.String()), Partial: deltaAccum.String()})
+			case stream.Reasoning:
+				deltaAccum.Reset()
+				if opts.ShowSpinner {
+					ui.SendSpinnerReasoning(ev.Text)
+				}
+				opts.Report(providers.Progress{Phase: providers.PhaseReasoning, Reasoning: ev.Text})
+			case stream.ToolCall:
+				toolCalls.Add(ev.Text)
+			case stream.Message:
+				lastAssistant = ev.Text
+			case stream.Usage:
+				outputTokens += ev.OutputTokens
+				meter.Reported(outputTokens)
+			case stream.ClaudeResult:
+				result = ev
 			}
-
-			if text := parseAssistantText(line); text != "" {
-				lastAssistant = text
-			}
-			if r, ok := parseResultEvent(line); ok {
-				result = r
-			}
-		}
-		if errors.Is(readErr, io.EOF) {
-			break
-		}
-		if readErr != nil {
-			return "", readErr
 		}
 	}
+	if err := reader.Err(); err != nil {
+		return "", err
+	}
 	if err = cmd.Wait(); err != nil {
+		if err := toolCalls.Err("claude"); err != nil {
+			return "", err
+		}
 		if reg.WasInterrupted() {
-			return "", errors.New("claude invocation interrupted")
+			return "", fmt.Errorf("claude invocation %w", providers.ErrInterrupted)
+		}
+		if result.Subtype == budgetExceededSubtype {
+			return "", fmt.Errorf("claude: %w (max %.2f USD)", providers.ErrBudgetExceeded, budgetUSD)
 		}
-		return "", fmt.Errorf("claude invocation failed\n# %s", cmdString(cmd, fmt.Sprintf("%d dir chunk(s)", len(chunks))))
+		detail := result.Result + "\n" + lastAssistant
+		return "", providers.Diagnose("claude", providers.CheckRateLimit(stderr.Wrap(fmt.Errorf("claude invocation failed\n# %s", cmdString(cmd, stdinDesc))), detail), detail)
 	}
 
 	responseText := result.Result
 	if responseText == "" && strings.HasPrefix(result.Subtype, "error_") {
-		fmt.Fprintf(os.Stderr, "claude: %s\n", result.Subtype)
+		fmt.Fprintf(ui.Status(), "claude: %s\n", result.Subtype)
 		responseText = lastAssistant
 	}
 
-	text := commit.StripCodeFence(strings.TrimSpace(responseText))
+	text := commit.Normalize(responseText)
 	if text == "" {
+		if result.Subtype == budgetExceededSubtype {
+			return "", fmt.Errorf("claude: %w (max %.2f USD)", providers.ErrBudgetExceeded, budgetUSD)
+		}
 		if result.Subtype != "" {
-			return "", fmt.Errorf("claude: %s", result.Subtype)
+			return "", providers.Diagnose("claude", providers.CheckRateLimit(fmt.Errorf("claude: %s", result.Subtype), lastAssistant), lastAssistant)
 		}
 		return "", errors.New("claude returned empty response")
 	}
 
-	msg := commit.WrapMessage(text, commit.BodyLineWidth)
+	opts.ReportUsage(providers.Usage{
+		Backend:      "claude",
+		Model:        model,
+		InputTokens:  result.Usage.InputTokens,
+		OutputTokens: result.Usage.OutputTokens,
+		CachedTokens: result.Usage.CacheReadInputTokens,
+		CostUSD:      result.TotalCostUSD,
+		SessionID:    result.SessionID,
+		Elapsed:      time.Since(startTime),
+	})
+
+	if opts.Task != nil {
+		return commit.StripCodeFence(responseText), nil
+	}
+	msg := opts.FormatMessage(text)
 	return appendUsageComment(msg, result, time.Since(startTime), budgetUSD), nil
 }
 
-// parseStreamReasoning extracts displayable reasoning text from assistant
-// message events: tool_use descriptions/commands and text content.
-// Prefers tool_use info over text within the same message.
-func parseStreamReasoning(raw string) string {
-	line := strings.TrimSpace(raw)
-	if line == "" {
-		return ""
-	}
-	var msg map[string]any
-	if err := json.Unmarshal([]byte(line), &msg); err != nil {
-		return ""
-	}
-	if msg["type"] != "assistant" {
-		return ""
-	}
-	message, _ := msg["message"].(map[string]any)
-	if message == nil {
-		return ""
-	}
-	content, _ := message["content"].([]any)
-	var toolText string
-	for _, c := range content {
-		block, _ := c.(map[string]any)
-		if block == nil {
-			continue
-		}
-		switch block["type"] {
-		case "tool_use":
-			input, _ := block["input"].(map[string]any)
-			if input == nil {
-				continue
-			}
-			desc, _ := input["description"].(string)
-			cmd, _ := input["command"].(string)
-			switch {
-			case desc != "" && cmd != "":
-				toolText = desc + ": " + cmd
-			case desc != "":
-				toolText = desc
-			case cmd != "":
-				toolText = cmd
-			}
-		case "text":
-			if toolText == "" {
-				if text, _ := block["text"].(string); strings.TrimSpace(text) != "" {
-					toolText = strings.TrimSpace(text)
-				}
-			}
+// buildRequest returns the system prompt, the stream-json stdin payload and
+// a short description of the payload for error messages. Commit requests
+// send one message per diff chunk; tasks send their input as one message.
+func buildRequest(opts providers.Options) (string, []byte, string, error) {
+	if opts.Task != nil {
+		payload, err := buildStreamInput(opts.Task.Input)
+		if err != nil {
+			return "", nil, "", fmt.Errorf("failed to encode stream-json input: %w", err)
 		}
+		return opts.Task.Instructions, append(payload, '\n'), "task input", nil
 	}
-	return toolText
-}
 
-// parseAssistantText extracts content[0].text from type "assistant" events.
-func parseAssistantText(raw string) string {
-	line := strings.TrimSpace(raw)
-	if line == "" {
-		return ""
+	var (
+		chunks  []git.DiffChunk
+		changes []git.FileChange
+		err     error
+	)
+	switch {
+	case opts.Summaries != "":
+		_, changes, err = opts.StagedDiff()
+	case opts.Diff != "":
+		chunks = git.ChunkDiff(opts.Diff)
+		changes = git.ParseChanges(opts.Diff)
+	default:
+		if chunks, err = git.DiffStagedChunks(); err == nil {
+			changes, err = git.StagedChanges()
+		}
 	}
-	var msg struct {
-		Type    string `json:"type"`
-		Message struct {
-			Content []struct {
-				Type string `json:"type"`
-				Text string `json:"text"`
-			} `json:"content"`
-		} `json:"message"`
+	if err != nil {
+		return "", nil, "", err
 	}
-	if err := json.Unmarshal([]byte(line), &msg); err != nil {
-		return ""
+	if len(chunks) == 0 && opts.Summaries == "" {
+		return "", nil, "", providers.ErrNoStagedChanges
 	}
-	if msg.Type != "assistant" || len(msg.Message.Content) == 0 {
-		return ""
+
+	skillText := commit.ConventionalSpec
+	if opts.NoCC {
+		skillText = commit.StandardCommitRule
 	}
-	if msg.Message.Content[0].Type != "text" {
-		return ""
+	skillText = skillText + "\n\n" + "Dont sign commit messages with claude code!"
+	if opts.SkillPath != "" {
+		if data, readErr := os.ReadFile(opts.SkillPath); readErr == nil {
+			trimmed := strings.TrimSpace(string(data))
+			if trimmed != "" {
+				skillText = skillText + "\nAdditional instructions:\n" + trimmed
+			}
+		}
 	}
-	return msg.Message.Content[0].Text
-}
 
-// parseTextDelta extracts text from stream_event content_block_delta
-// text_delta events.
-func parseTextDelta(raw string) string {
-	line := strings.TrimSpace(raw)
-	if line == "" {
-		return ""
-	}
-	var ev struct {
-		Type  string `json:"type"`
-		Event struct {
-			Type  string `json:"type"`
-			Delta struct {
-				Type string `json:"type"`
-				Text string `json:"text"`
-			} `json:"delta"`
-		} `json:"event"`
-	}
-	if err := json.Unmarshal([]byte(line), &ev); err != nil {
-		return ""
-	}
-	if ev.Type != "stream_event" || ev.Event.Type != "content_block_delta" || ev.Event.Delta.Type != "text_delta" {
-		return ""
-	}
-	return ev.Event.Delta.Text
-}
+	scope, allowed := opts.ScopeFor(changes)
+	systemPrompt := commit.BuildSystemPrompt(commit.PromptOptions{
+		SkillText:   skillText,
+		NoCC:        opts.NoCC,
+		Risk:        opts.Risk,
+		MaxSubject:  opts.MaxSubject,
+		SubjectOnly: opts.SubjectOnly,
+		Scope:       scope,
+		Scopes:      allowed,
+		Types:       opts.Types,
+		ScopePolicy: opts.ScopePolicy,
+		ScopeHints:  opts.ScopeHints(changes),
+		Template:    opts.Template,
+	})
 
-// parseResultEvent parses the final "result" event from stream-json output.
-func parseResultEvent(raw string) (claudeResult, bool) {
-	line := strings.TrimSpace(raw)
-	if line == "" {
-		return claudeResult{}, false
-	}
-	if !strings.Contains(line, `"type":"result"`) && !strings.Contains(line, `"type": "result"`) {
-		return claudeResult{}, false
+	diffBytes := 0
+	for _, chunk := range chunks {
+		diffBytes += len(chunk.Diff)
 	}
-	var result claudeResult
-	if err := json.Unmarshal([]byte(line), &result); err != nil {
-		return claudeResult{}, false
+	opts.Report(providers.Progress{Phase: providers.PhaseDiff, DiffBytes: diffBytes})
+
+	if opts.Summaries != "" {
+		payload, err := buildStreamInput(commit.BuildUserMessage(commit.PromptOptions{
+			Changes:   git.FormatChanges(changes),
+			Summaries: opts.Summaries,
+			ExtraNote: opts.ExtraNote,
+		}))
+		if err != nil {
+			return "", nil, "", fmt.Errorf("failed to encode stream-json input: %w", err)
+		}
+		return systemPrompt, append(payload, '\n'), "per-file summaries", nil
 	}
-	if result.Type != "result" {
-		return claudeResult{}, false
+	stdinPayload, err := buildChunkedStreamInput(git.FormatChanges(changes), chunks, opts.ExtraNote)
+	if err != nil {
+		return "", nil, "", fmt.Errorf("failed to encode stream-json input: %w", err)
 	}
-	return result, true
-}
-
-type claudeResult struct {
-	Type         string                      `json:"type"`
-	Subtype      string                      `json:"subtype"`
-	Result       string                      `json:"result"`
-	TotalCostUSD float64                     `json:"total_cost_usd"`
-	DurationMS   int                         `json:"duration_ms"`
-	DurationAPI  int                         `json:"duration_api_ms"`
-	IsError      bool                        `json:"is_error"`
-	NumTurns     int                         `json:"num_turns"`
-	SessionID    string                      `json:"session_id"`
-	Usage        claudeUsage                 `json:"usage"`
-	ModelUsage   map[string]claudeModelUsage `json:"modelUsage"`
-}
-
-type claudeUsage struct {
-	InputTokens              int           `json:"input_tokens"`
-	CacheCreationInputTokens int           `json:"cache_creation_input_tokens"`
-	CacheReadInputTokens     int           `json:"cache_read_input_tokens"`
-	OutputTokens             int           `json:"output_tokens"`
-	ServerToolUse            serverToolUse `json:"server_tool_use"`
-}
-
-type serverToolUse struct {
-	WebSearchRequests int `json:"web_search_requests"`
+	return systemPrompt, stdinPayload, fmt.Sprintf("%d dir chunk(s)", len(chunks)), nil
 }
 
-type claudeModelUsage struct {
-	InputTokens              int     `json:"inputTokens"`
-	OutputTokens             int     `json:"outputTokens"`
-	CacheReadInputTokens     int     `json:"cacheReadInputTokens"`
-	CacheCreationInputTokens int     `json:"cacheCreationInputTokens"`
-	WebSearchRequests        int     `json:"webSearchRequests"`
-	CostUSD                  float64 `json:"costUSD"`
-}
-
-// buildChunkedStreamInput encodes each DiffChunk as a separate NDJSON user
-// message followed by a final "generate commit message" message. Claude
-// responds after each message; we keep only the last result event.
-func buildChunkedStreamInput(chunks []git.DiffChunk, extraNote string) ([]byte, error) {
+// buildChunkedStreamInput encodes the change summary and each DiffChunk as
+// separate NDJSON user messages followed by a final "generate commit message"
+// message. Claude responds after each message; we keep only the last result
+// event.
+func buildChunkedStreamInput(changes string, chunks []git.DiffChunk, extraNote string) ([]byte, error) {
 	var buf bytes.Buffer
+	if section := commit.ChangesSection(changes); section != "" {
+		data, err := buildStreamInput(strings.TrimRight(section, "\n"))
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
 	for _, chunk := range chunks {
 		text := "Staged diff for " + chunk.Dir + ":\n" + chunk.Diff
 		data, err := buildStreamInput(text)
@@ -400,7 +397,7 @@ func cmdString(cmd *exec.Cmd, stdinText string) string {
 	return cmd.String() + "\n# stdin: " + s + suffix
 }
 
-func appendUsageComment(message string, cr claudeResult, elapsed time.Duration, budgetUSD float64) string {
+func appendUsageComment(message string, cr stream.ClaudeResult, elapsed time.Duration, budgetUSD float64) string {
 	if cr.SessionID == "" && cr.TotalCostUSD == 0 {
 		return message
 	}
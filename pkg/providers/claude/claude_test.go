@@ -0,0 +1,93 @@
+package claude
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers/fakecli"
+)
+
+const testDiff = `diff --git a/upload/client.go b/upload/client.go
+--- a/upload/client.go
++++ b/upload/client.go
+@@ -1 +1,2 @@
+ package upload
++// retry on 503
+`
+
+func TestGenerateReplaysStream(t *testing.T) {
+	fake := fakecli.Install(t, fakecli.CLI{Name: "claude", Fixture: "testdata/success.ndjson"})
+
+	var (
+		reasoning []string
+		usage     providers.Usage
+	)
+	msg, err := Generate(t.Context(), &providers.Registry{}, providers.Options{
+		Diff:   testDiff,
+		Budget: 0.5,
+		OnProgress: func(p providers.Progress) {
+			if p.Phase == providers.PhaseReasoning {
+				reasoning = append(reasoning, p.Reasoning)
+			}
+		},
+		OnUsage: func(u providers.Usage) { usage = u },
+	})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	for _, want := range []string{
+		"fix(upload): retry on 503\n\n# cost=$0.0123 elapsed=",
+		"\n# session=6f1c2a9e-4d1b-4c55-9d0e-2b7f0c1d9a11",
+		"\n# model=claude-sonnet-4-5-20250929 input=8 output=51 cache_read=15000 cache_create=1200",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message missing %q:\n%s", want, msg)
+		}
+	}
+	if len(reasoning) == 0 || reasoning[0] != "Check recent commit style: git log --oneline -5" {
+		t.Errorf("reasoning = %q", reasoning)
+	}
+	if usage.CostUSD != 0.0123 || usage.CachedTokens != 15000 {
+		t.Errorf("usage = %+v", usage)
+	}
+	if args := strings.Join(fake.Args(t), " "); !strings.Contains(args, "--max-budget-usd 0.5") {
+		t.Errorf("args = %s, want the budget", args)
+	}
+	if args := fake.Args(t); !slices.Contains(args, "--tools") || args[slices.Index(args, "--tools")+1] != "" {
+		t.Errorf("args = %q, want all tools disabled", args)
+	}
+	if stdin := fake.Stdin(t); !strings.Contains(stdin, "Staged diff for upload:") {
+		t.Errorf("stdin does not carry the diff chunk:\n%s", stdin)
+	}
+}
+
+func TestGenerateBudgetExceeded(t *testing.T) {
+	fakecli.Install(t, fakecli.CLI{Name: "claude", Fixture: "testdata/budget.ndjson", ExitCode: 1})
+
+	_, err := Generate(t.Context(), &providers.Registry{}, providers.Options{Diff: testDiff, Budget: 0.05})
+	if !errors.Is(err, providers.ErrBudgetExceeded) {
+		t.Fatalf("Generate() error = %v, want ErrBudgetExceeded", err)
+	}
+	if !strings.Contains(err.Error(), "max 0.05 USD") {
+		t.Errorf("error %q does not name the budget", err)
+	}
+}
+
+func TestGenerateInterrupted(t *testing.T) {
+	fake := fakecli.Install(t, fakecli.CLI{Name: "claude", Fixture: "testdata/partial.ndjson", Hang: true})
+
+	reg := &providers.Registry{}
+	go func() {
+		fake.WaitStarted(t, 5*time.Second)
+		reg.ForwardSignal(os.Interrupt)
+	}()
+	_, err := Generate(t.Context(), reg, providers.Options{Diff: testDiff})
+	if !errors.Is(err, providers.ErrInterrupted) {
+		t.Fatalf("Generate() error = %v, want ErrInterrupted", err)
+	}
+}
@@ -9,8 +9,22 @@ import (
 type Backend struct{}
 
 func (Backend) Generate(ctx context.Context, reg *providers.Registry, opts providers.Options) (string, error) {
-	return Generate(ctx, reg, opts)
+	return providers.Retry(ctx, reg, opts, "codex", func() (string, error) {
+		return Generate(ctx, reg, opts)
+	})
 }
 
 func (Backend) Models() []string     { return append([]string{}, models...) }
 func (Backend) DefaultModel() string { return defaultModel }
+
+// SupportsSampling reports that temperature and seed are passed to codex as
+// config overrides.
+func (Backend) SupportsSampling() (bool, bool) { return true, true }
+
+// SupportsReasoning reports that the reasoning effort is passed to codex as
+// the model_reasoning_effort config override.
+func (Backend) SupportsReasoning() bool { return true }
+
+// SupportsStructured reports that codex enforces commit.StructuredSchema
+// with --output-schema.
+func (Backend) SupportsStructured() bool { return true }
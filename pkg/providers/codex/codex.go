@@ -1,22 +1,22 @@
 package codex
 
 import (
-	"bufio"
 	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/dlnilsson/git-cc-ai/pkg/commit"
 	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/ndjson"
 	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/stream"
 	"github.com/dlnilsson/git-cc-ai/pkg/ui"
 )
 
@@ -39,13 +39,12 @@ func (t *threadTracker) get() string {
 	return t.threadID
 }
 
-type codexUsage struct {
-	InputTokens       int
-	CachedInputTokens int
-	OutputTokens      int
-}
-
-const defaultModel = "gpt-5-codex-mini"
+const (
+	defaultModel = "gpt-5-codex-mini"
+	// cancelGrace is how long codex may take to exit after an interrupt
+	// before it is killed.
+	cancelGrace = 3 * time.Second
+)
 
 // https://developers.openai.com/codex/models/
 var models = []string{
@@ -63,49 +62,24 @@ func Generate(ctx context.Context, reg *providers.Registry, opts providers.Optio
 		codexArgs = "exec --json"
 	)
 	var (
-		args          []string
-		buffer        strings.Builder
-		cmd           *exec.Cmd
-		diff          string
-		err           error
-		lastError     string
-		output        string
-		reasoningText string
-		skillText     string
-		stderr        io.ReadCloser
-		stdout        io.ReadCloser
-		stopSpinner   func()
-		usage         codexUsage
-		startTime     time.Time
+		args        []string
+		buffer      strings.Builder
+		cmd         *exec.Cmd
+		err         error
+		lastError   string
+		output      string
+		reply       string
+		stderr      io.ReadCloser
+		stdout      io.ReadCloser
+		stopSpinner func()
+		usage       stream.Usage
+		startTime   time.Time
 	)
 
-	diff, err = git.DiffStaged()
+	prompt, err := buildPrompt(opts)
 	if err != nil {
 		return "", err
 	}
-	if strings.TrimSpace(diff) == "" {
-		return "", errors.New("no staged diff content found")
-	}
-
-	skillText = commit.ConventionalSpec
-	if opts.NoCC {
-		skillText = commit.StandardCommitRule
-	}
-	if opts.SkillPath != "" {
-		if data, readErr := os.ReadFile(opts.SkillPath); readErr == nil {
-			trimmed := strings.TrimSpace(string(data))
-			if trimmed != "" {
-				skillText = skillText + "\nAdditional instructions:\n" + trimmed
-			}
-		}
-	}
-
-	prompt := commit.BuildConventionalPrompt(commit.PromptOptions{
-		SkillText: skillText,
-		Diff:      diff,
-		ExtraNote: opts.ExtraNote,
-		NoCC:      opts.NoCC,
-	})
 
 	model := opts.Model
 	if strings.TrimSpace(model) == "" {
@@ -114,8 +88,31 @@ func Generate(ctx context.Context, reg *providers.Registry, opts providers.Optio
 	args = splitArgs(codexArgs)
 	args = addNoAltScreenArg(args)
 	args = addModelArg(args, model)
+	args = addSamplingArgs(args, opts)
+	args = addReasoningArg(args, opts.Reasoning)
+	if !opts.UnrestrictedTools() {
+		// Codex cannot turn its shell off, so a tool list only documents
+		// intent; the read-only sandbox is what keeps files unchanged.
+		args = append(args, "--sandbox", "read-only")
+	}
+	args = append(args, opts.ExtraArgsFor("codex")...)
+	if opts.Structured && opts.Task == nil {
+		schemaPath, cleanup, err := writeSchema()
+		if err != nil {
+			return "", err
+		}
+		defer cleanup()
+		args = append(args, "--output-schema", schemaPath)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	toolCalls := opts.NewToolCalls(cancel)
 	cmd = exec.CommandContext(ctx, codexCmd, args...)
 	cmd.Stdin = strings.NewReader(prompt)
+	// On cancellation give codex a chance to flush its thread state before
+	// it is killed.
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = cancelGrace
 	setProcessGroup(cmd)
 	startTime = time.Now()
 	if opts.ShowSpinner {
@@ -136,77 +133,79 @@ func Generate(ctx context.Context, reg *providers.Registry, opts providers.Optio
 	}
 	reg.Register(cmd, stopSpinner)
 	defer reg.Unregister()
+	watch := opts.WatchStall(reg)
+	defer watch.Stop()
+	opts.Report(providers.Progress{Phase: providers.PhaseRunning})
 
 	var (
-		thread    threadTracker
-		stderrBuf strings.Builder
-		stderrWG  sync.WaitGroup
+		thread     threadTracker
+		stderrTail providers.StderrTail
+		stderrWG   sync.WaitGroup
 	)
+	defer stderrTail.Debug("codex")
 	stderrWG.Add(1)
 	go func() {
 		defer stderrWG.Done()
-		reader := bufio.NewReader(io.TeeReader(stderr, &stderrBuf))
-		for {
-			line, readErr := reader.ReadString('\n')
-			line = strings.TrimRight(line, "\r\n")
-			if strings.TrimSpace(line) != "" {
-				if id := parseThreadStartedJSON(line); id != "" {
-					thread.set(id)
+		for line := range ndjson.NewReader(io.TeeReader(stderr, &stderrTail)).Lines() {
+			for _, ev := range stream.DecodeCodex(line) {
+				if s, ok := ev.(stream.Session); ok {
+					thread.set(s.ID)
 				}
 			}
-			if errors.Is(readErr, io.EOF) {
-				break
-			}
-			if readErr != nil {
-				break
-			}
 		}
 	}()
 
-	reader := bufio.NewReader(io.TeeReader(stdout, &buffer))
-	for {
-		line, readErr := reader.ReadString('\n')
-		line = strings.TrimRight(line, "\r\n")
-		if strings.TrimSpace(line) != "" {
-			if id := parseThreadStartedJSON(line); id != "" {
-				thread.set(id)
-			}
-			if opts.ShowSpinner {
-				reasoningText = parseReasoningJSON(line)
-				if strings.TrimSpace(reasoningText) != "" {
-					ui.SendSpinnerReasoning(reasoningText)
+	meter := opts.NewTokenMeter(model, prompt)
+	reader := ndjson.NewReader(io.TeeReader(stdout, &buffer))
+	for line := range reader.Lines() {
+		watch.Alive()
+		opts.Event(line)
+		for _, ev := range stream.DecodeCodex(line) {
+			switch ev := ev.(type) {
+			case stream.Session:
+				thread.set(ev.ID)
+			case stream.Reasoning:
+				if strings.TrimSpace(ev.Text) == "" {
+					continue
 				}
-			}
-			if updated, ok := parseUsageJSON(line); ok {
-				usage = updated
-			}
-			if errMsg := parseErrorJSON(line); errMsg != "" {
-				lastError = errMsg
+				if opts.ShowSpinner {
+					ui.SendSpinnerReasoning(ev.Text)
+				}
+				opts.Report(providers.Progress{Phase: providers.PhaseReasoning, Reasoning: ev.Text})
+			case stream.ToolCall:
+				toolCalls.Add(ev.Text)
+			case stream.Message:
+				reply = ev.Text
+			case stream.Usage:
+				usage = ev
+				meter.Reported(usage.OutputTokens)
+			case stream.Error:
+				lastError = ev.Message
 			}
 		}
-		if errors.Is(readErr, io.EOF) {
-			break
-		}
-		if readErr != nil {
-			return "", readErr
-		}
 	}
-	if err = cmd.Wait(); err != nil {
-		stderrWG.Wait()
-		if lastError != "" {
-			return "", fmt.Errorf("codex invocation failed: %s", lastError)
-		}
-		if errText := strings.TrimSpace(stderrBuf.String()); errText != "" {
-			return "", fmt.Errorf("codex invocation failed: %w\n%s", err, errText)
-		}
-		return "", fmt.Errorf("codex invocation failed: %w", err)
+	if err := reader.Err(); err != nil {
+		return "", err
 	}
+	err = cmd.Wait()
 	stderrWG.Wait()
-	if reg.WasInterrupted() {
+	if id := thread.get(); id != "" && opts.OnSessionID != nil {
+		opts.OnSessionID(id)
+	}
+	if err := toolCalls.Err("codex"); err != nil {
+		return "", err
+	}
+	if reg.WasInterrupted() || ctx.Err() != nil {
 		if id := thread.get(); id != "" {
-			fmt.Fprintln(os.Stderr, id)
+			return "", fmt.Errorf("codex invocation %w (resume with: codex exec resume %s)", providers.ErrInterrupted, id)
 		}
-		return "", errors.New("codex invocation failed")
+		return "", fmt.Errorf("codex invocation %w", providers.ErrInterrupted)
+	}
+	if err != nil {
+		if lastError != "" {
+			return "", providers.CheckRateLimit(fmt.Errorf("codex invocation failed: %s", lastError), stderrTail.String())
+		}
+		return "", providers.CheckRateLimit(stderrTail.Wrap(fmt.Errorf("codex invocation failed: %w", err)), "")
 	}
 
 	output = strings.TrimSpace(buffer.String())
@@ -214,148 +213,110 @@ func Generate(ctx context.Context, reg *providers.Registry, opts providers.Optio
 		return "", nil
 	}
 
-	if parsed := parseCodexJSON(output); strings.TrimSpace(parsed) != "" {
-		text := commit.StripCodeFence(strings.TrimSpace(parsed))
-		return appendUsageComment(commit.WrapMessage(text, commit.BodyLineWidth), usage, time.Since(startTime), opts.Model), nil
+	opts.ReportUsage(providers.Usage{
+		Backend:      "codex",
+		Model:        model,
+		InputTokens:  usage.InputTokens,
+		OutputTokens: usage.OutputTokens,
+		CachedTokens: usage.CachedInputTokens,
+		SessionID:    thread.get(),
+		Elapsed:      time.Since(startTime),
+	})
+
+	finish := func(text string) string {
+		if opts.Task != nil {
+			return commit.StripCodeFence(text)
+		}
+		if opts.Structured {
+			if s, err := commit.ParseStructured(text); err == nil {
+				text = s.Message()
+			}
+		}
+		return appendUsageComment(opts.FormatMessage(text), usage, time.Since(startTime), opts.Model)
+	}
+	if strings.TrimSpace(reply) != "" {
+		return finish(reply), nil
 	}
 
 	if strings.HasPrefix(output, "{") {
-		if extracted := extractJSONField(output, []string{"output", "stdout", "result", "message"}); strings.TrimSpace(extracted) != "" {
-			text := commit.StripCodeFence(strings.TrimSpace(extracted))
-			return appendUsageComment(commit.WrapMessage(text, commit.BodyLineWidth), usage, time.Since(startTime), opts.Model), nil
+		if extracted := lastTextField(output, "output", "stdout", "result", "message"); extracted != "" {
+			return finish(extracted), nil
 		}
 	}
 
-	return appendUsageComment(commit.WrapMessage(commit.StripCodeFence(output), commit.BodyLineWidth), usage, time.Since(startTime), opts.Model), nil
+	return finish(output), nil
 }
 
-func parseErrorJSON(raw string) string {
-	line := strings.TrimSpace(raw)
-	if line == "" {
-		return ""
-	}
-	var msg map[string]any
-	if err := json.Unmarshal([]byte(line), &msg); err != nil {
-		return ""
+// buildPrompt returns the full prompt sent on stdin: the task prompt when
+// opts.Task is set, otherwise the commit prompt over the staged diff.
+func buildPrompt(opts providers.Options) (string, error) {
+	if opts.Task != nil {
+		return opts.Task.Prompt(), nil
 	}
-	if t, ok := msg["type"].(string); !ok || t != "error" {
-		return ""
+	diff, changes, err := opts.StagedDiff()
+	if err != nil {
+		return "", err
 	}
-	text, _ := msg["message"].(string)
-	// The message may itself be JSON with a "detail" field.
-	var detail struct{ Detail string }
-	if json.Unmarshal([]byte(text), &detail) == nil && detail.Detail != "" {
-		return detail.Detail
+	if strings.TrimSpace(diff) == "" && opts.Summaries == "" {
+		return "", providers.ErrNoStagedChanges
 	}
-	return text
-}
+	opts.Report(providers.Progress{Phase: providers.PhaseDiff, DiffBytes: len(diff)})
 
-func parseReasoningJSON(raw string) string {
-	line := strings.TrimSpace(raw)
-	if line == "" {
-		return ""
-	}
-	var msg map[string]any
-	if err := json.Unmarshal([]byte(line), &msg); err != nil {
-		return ""
-	}
-	if t, ok := msg["type"].(string); ok {
-		if t == "reasoning" {
-			if text, ok := msg["text"].(string); ok {
-				return text
-			}
-		}
-		if t == "item.completed" {
-			if item, ok := msg["item"].(map[string]any); ok {
-				if it, ok := item["type"].(string); ok && it == "reasoning" {
-					if text, ok := item["text"].(string); ok {
-						return text
-					}
-				}
-			}
-		}
+	skillText := commit.ConventionalSpec
+	if opts.NoCC {
+		skillText = commit.StandardCommitRule
 	}
-	return ""
-}
-
-func parseCodexJSON(raw string) string {
-	var (
-		lines = strings.Split(raw, "\n")
-		last  = ""
-	)
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-		var msg map[string]any
-		if err := json.Unmarshal([]byte(line), &msg); err != nil {
-			continue
-		}
-		if t, ok := msg["type"].(string); ok {
-			if t == "agent_message" {
-				if text, ok := msg["text"].(string); ok && strings.TrimSpace(text) != "" {
-					last = text
-				}
-				continue
-			}
-			if t == "item.completed" {
-				if item, ok := msg["item"].(map[string]any); ok {
-					if it, ok := item["type"].(string); ok && it == "agent_message" {
-						if text, ok := item["text"].(string); ok && strings.TrimSpace(text) != "" {
-							last = text
-						}
-					}
-				}
+	if opts.SkillPath != "" {
+		if data, readErr := os.ReadFile(opts.SkillPath); readErr == nil {
+			trimmed := strings.TrimSpace(string(data))
+			if trimmed != "" {
+				skillText = skillText + "\nAdditional instructions:\n" + trimmed
 			}
 		}
 	}
-	return last
+
+	scope, allowed := opts.ScopeFor(changes)
+	return commit.BuildConventionalPrompt(commit.PromptOptions{
+		SkillText:   skillText,
+		Diff:        diff,
+		Summaries:   opts.Summaries,
+		Changes:     git.FormatChanges(changes),
+		ExtraNote:   opts.ExtraNote,
+		NoCC:        opts.NoCC,
+		Risk:        opts.Risk,
+		MaxSubject:  opts.MaxSubject,
+		SubjectOnly: opts.SubjectOnly,
+		Scope:       scope,
+		Scopes:      allowed,
+		Types:       opts.Types,
+		ScopePolicy: opts.ScopePolicy,
+		ScopeHints:  opts.ScopeHints(changes),
+		Structured:  opts.Structured,
+		Template:    opts.Template,
+	}), nil
 }
 
-func parseThreadStartedJSON(raw string) string {
-	line := strings.TrimSpace(raw)
-	if line == "" {
-		return ""
-	}
-	var msg map[string]any
-	if err := json.Unmarshal([]byte(line), &msg); err != nil {
-		return ""
+// writeSchema writes commit.StructuredSchema to a temporary file for
+// --output-schema and returns its path and a function removing it.
+func writeSchema() (string, func(), error) {
+	f, err := os.CreateTemp("", "git-cc-ai-schema-*.json")
+	if err != nil {
+		return "", nil, err
 	}
-	if t, ok := msg["type"].(string); !ok || t != "thread.started" {
-		return ""
+	cleanup := func() { _ = os.Remove(f.Name()) }
+	_, err = f.WriteString(commit.StructuredSchema)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
 	}
-	if id, ok := msg["thread_id"].(string); ok {
-		return id
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to write output schema: %w", err)
 	}
-	return ""
+	return f.Name(), cleanup, nil
 }
 
-func parseUsageJSON(raw string) (codexUsage, bool) {
-	line := strings.TrimSpace(raw)
-	if line == "" {
-		return codexUsage{}, false
-	}
-	var msg map[string]any
-	if err := json.Unmarshal([]byte(line), &msg); err != nil {
-		return codexUsage{}, false
-	}
-	if t, ok := msg["type"].(string); !ok || t != "turn.completed" {
-		return codexUsage{}, false
-	}
-	usage, ok := msg["usage"].(map[string]any)
-	if !ok {
-		return codexUsage{}, false
-	}
-	return codexUsage{
-		InputTokens:       toInt(usage["input_tokens"]),
-		CachedInputTokens: toInt(usage["cached_input_tokens"]),
-		OutputTokens:      toInt(usage["output_tokens"]),
-	}, true
-}
-
-func appendUsageComment(message string, usage codexUsage, elapsed time.Duration, model string) string {
-	if usage == (codexUsage{}) {
+func appendUsageComment(message string, usage stream.Usage, elapsed time.Duration, model string) string {
+	if usage == (stream.Usage{}) {
 		return message
 	}
 	elapsedText := elapsed.Round(100 * time.Millisecond)
@@ -392,6 +353,33 @@ func addModelArg(args []string, model string) []string {
 	return out
 }
 
+// Config keys used for the sampling overrides.
+const (
+	temperatureKey = "model_temperature"
+	seedKey        = "model_seed"
+	reasoningKey   = "model_reasoning_effort"
+)
+
+// addSamplingArgs appends "-c key=value" config overrides for the sampling
+// options set in opts.
+func addSamplingArgs(args []string, opts providers.Options) []string {
+	if opts.Temperature != nil {
+		args = append(args, "-c", temperatureKey+"="+strconv.FormatFloat(*opts.Temperature, 'f', -1, 64))
+	}
+	if opts.Seed != nil {
+		args = append(args, "-c", seedKey+"="+strconv.FormatInt(*opts.Seed, 10))
+	}
+	return args
+}
+
+// addReasoningArg appends the reasoning effort config override when set.
+func addReasoningArg(args []string, reasoning string) []string {
+	if reasoning == "" {
+		return args
+	}
+	return append(args, "-c", reasoningKey+"="+reasoning)
+}
+
 func addNoAltScreenArg(args []string) []string {
 	if len(args) == 0 {
 		return []string{"--no-alt-screen"}
@@ -406,48 +394,18 @@ func addNoAltScreenArg(args []string) []string {
 	return append(args, "--no-alt-screen")
 }
 
-func toInt(value any) int {
-	switch v := value.(type) {
-	case int:
-		return v
-	case float64:
-		return int(v)
-	default:
-		return 0
-	}
-}
-
-func extractJSONField(raw string, keys []string) string {
-	for _, key := range keys {
-		var (
-			needle       = `"` + key + `":`
-			_, after, ok = strings.Cut(raw, needle)
-		)
-		if !ok {
-			continue
-		}
-		rest := after
-		rest = strings.TrimLeft(rest, " \n\r\t")
-		if strings.HasPrefix(rest, "\"") {
-			rest = rest[1:]
-			out := strings.Builder{}
-			escaped := false
-			for _, r := range rest {
-				if escaped {
-					out.WriteRune(r)
-					escaped = false
-					continue
-				}
-				if r == '\\' {
-					escaped = true
-					continue
-				}
-				if r == '"' {
-					return out.String()
-				}
-				out.WriteRune(r)
+// lastTextField returns the last string value of one of keys in the NDJSON
+// output, checking the keys of each line in order.
+func lastTextField(output string, keys ...string) string {
+	lines, _ := ndjson.Decode[map[string]any](strings.NewReader(output))
+	var text string
+	for _, fields := range lines {
+		for _, key := range keys {
+			if v, ok := fields[key].(string); ok && strings.TrimSpace(v) != "" {
+				text = v
+				break
 			}
 		}
 	}
-	return ""
+	return text
 }
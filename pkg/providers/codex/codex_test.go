@@ -0,0 +1,129 @@
+package codex
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers/fakecli"
+)
+
+const testDiff = `diff --git a/upload/client.go b/upload/client.go
+--- a/upload/client.go
++++ b/upload/client.go
+@@ -1 +1,2 @@
+ package upload
++// retry on 503
+`
+
+func TestGenerateReplaysStream(t *testing.T) {
+	fake := fakecli.Install(t, fakecli.CLI{Name: "codex", Fixture: "testdata/success.ndjson"})
+
+	var session string
+	msg, err := Generate(t.Context(), &providers.Registry{}, providers.Options{
+		Diff:        testDiff,
+		OnSessionID: func(id string) { session = id },
+	})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	want := "fix(upload): retry on 503\n\nRetry uploads up to three times with backoff.\n\n# tokens: input=24763 cached=24448 output=122 elapsed="
+	if !strings.HasPrefix(msg, want) {
+		t.Errorf("message = %q, want prefix %q", msg, want)
+	}
+	if session != "0199a213-81c0-7800-8aa1-bbab2a035a53" {
+		t.Errorf("session = %q", session)
+	}
+	if stdin := fake.Stdin(t); !strings.Contains(stdin, "+// retry on 503") {
+		t.Errorf("stdin does not carry the diff:\n%s", stdin)
+	}
+}
+
+func TestGenerateReadsOutputField(t *testing.T) {
+	fakecli.Install(t, fakecli.CLI{Name: "codex", Fixture: "testdata/output-field.ndjson"})
+
+	msg, err := Generate(t.Context(), &providers.Registry{}, providers.Options{Diff: testDiff})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	want := "fix(upload): retry on 503\n\nRetry uploads with \"backoff\"."
+	if !strings.HasPrefix(msg, want) {
+		t.Errorf("message = %q, want prefix %q", msg, want)
+	}
+}
+
+func TestGenerateReportsStreamError(t *testing.T) {
+	fakecli.Install(t, fakecli.CLI{Name: "codex", Fixture: "testdata/error.ndjson", ExitCode: 1})
+
+	_, err := Generate(t.Context(), &providers.Registry{}, providers.Options{Diff: testDiff})
+	want := "codex invocation failed: The 'gpt-5-codex-mini' model is not supported when using Codex with a ChatGPT account."
+	if err == nil || err.Error() != want {
+		t.Fatalf("Generate() error = %v, want %q", err, want)
+	}
+}
+
+func TestGenerateInterrupted(t *testing.T) {
+	fake := fakecli.Install(t, fakecli.CLI{Name: "codex", Fixture: "testdata/success.ndjson", Hang: true})
+
+	reg := &providers.Registry{}
+	go func() {
+		fake.WaitStarted(t, 5*time.Second)
+		reg.ForwardSignal(os.Interrupt)
+	}()
+	_, err := Generate(t.Context(), reg, providers.Options{Diff: testDiff})
+	if !errors.Is(err, providers.ErrInterrupted) {
+		t.Fatalf("Generate() error = %v, want ErrInterrupted", err)
+	}
+	if !strings.Contains(err.Error(), "codex exec resume 0199a213-81c0-7800-8aa1-bbab2a035a53") {
+		t.Errorf("error %q has no resume hint", err)
+	}
+}
+
+func TestGenerateCancelled(t *testing.T) {
+	fake := fakecli.Install(t, fakecli.CLI{Name: "codex", Fixture: "testdata/success.ndjson", Hang: true})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	go func() {
+		fake.WaitStarted(t, 5*time.Second)
+		cancel()
+	}()
+	start := time.Now()
+	_, err := Generate(ctx, &providers.Registry{}, providers.Options{Diff: testDiff})
+	if !errors.Is(err, providers.ErrInterrupted) {
+		t.Fatalf("Generate() error = %v, want ErrInterrupted", err)
+	}
+	if !strings.Contains(err.Error(), "codex exec resume 0199a213-81c0-7800-8aa1-bbab2a035a53") {
+		t.Errorf("error %q has no resume hint", err)
+	}
+	// The fake exits on SIGINT; reaching cancelGrace means it was killed
+	// instead of interrupted.
+	if elapsed := time.Since(start); elapsed >= cancelGrace {
+		t.Errorf("Generate() took %s, want the interrupt to stop codex before the %s grace period", elapsed, cancelGrace)
+	}
+}
+
+func TestGenerateStopsAtToolCallLimit(t *testing.T) {
+	fakecli.Install(t, fakecli.CLI{Name: "codex", Fixture: "testdata/tools.ndjson", Hang: true})
+
+	var steps []string
+	_, err := Generate(t.Context(), &providers.Registry{}, providers.Options{
+		Diff:         testDiff,
+		MaxToolCalls: 1,
+		OnProgress: func(p providers.Progress) {
+			if p.Phase == providers.PhaseReasoning {
+				steps = append(steps, p.Reasoning)
+			}
+		},
+	})
+	if !errors.Is(err, providers.ErrToolCallLimit) {
+		t.Fatalf("Generate() error = %v, want ErrToolCallLimit", err)
+	}
+	if len(steps) != 2 || steps[0] != "tool call 1/1: git log --oneline -3" {
+		t.Errorf("reasoning steps = %q", steps)
+	}
+}
@@ -0,0 +1,92 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ProviderError is a backend failure with a known cause and a hint on how
+// to fix it. It matches its Kind (ErrNotLoggedIn, ErrQuotaExhausted,
+// ErrModelUnavailable or ErrNetwork) with errors.Is.
+type ProviderError struct {
+	Err  error
+	Kind error
+	Hint string
+}
+
+func (e *ProviderError) Error() string { return e.Err.Error() + "\nhint: " + e.Hint }
+func (e *ProviderError) Unwrap() error { return e.Err }
+
+func (e *ProviderError) Is(target error) bool { return target == e.Kind }
+
+// failure is a known cause of backend failures: the pattern of its error
+// output and the hint for a backend.
+type failure struct {
+	kind    error
+	pattern *regexp.Regexp
+	hint    func(backend string) string
+}
+
+// loginHints are the sign-in commands of the backends that have one.
+var loginHints = map[string]string{
+	"claude": "run `claude login`, or set ANTHROPIC_API_KEY",
+	"codex":  "run `codex login`",
+	"gemini": "run `gemini` once to sign in, or set GEMINI_API_KEY",
+	"vertex": "run `gcloud auth application-default login`",
+}
+
+// failures are checked in order; the first match wins.
+var failures = []failure{
+	{
+		kind:    ErrNotLoggedIn,
+		pattern: regexp.MustCompile(`(?i)not logged in|please (?:run /)?log ?in|login required|invalid[ _-]?(?:x-)?api[ _-]?key|api key not valid|authentication[ _-]?(?:error|failed|required)|unauthori[sz]ed|\b401\b|invalid[ _]grant|credentials (?:are )?(?:missing|expired|invalid)`),
+		hint: func(backend string) string {
+			if hint, ok := loginHints[backend]; ok {
+				return hint
+			}
+			return fmt.Sprintf("check the %s API key; git-cc-ai doctor shows the settings it uses", backend)
+		},
+	},
+	{
+		kind:    ErrQuotaExhausted,
+		pattern: regexp.MustCompile(`(?i)insufficient[ _]quota|exceeded your current quota|credit balance is too low|out of credits|usage limit|billing|payment required|\b402\b`),
+		hint: func(backend string) string {
+			return fmt.Sprintf("check the plan and billing of your %s account, or use another backend with GIT_AI_BACKEND", backend)
+		},
+	},
+	{
+		kind:    ErrModelUnavailable,
+		pattern: regexp.MustCompile(`(?i)model[^\n]{0,80}(?:not found|does not exist|not available|not supported|is unavailable|unknown)|(?:unknown|invalid|unsupported) model|model_not_found|no access to model`),
+		hint: func(backend string) string {
+			return fmt.Sprintf("pick another model with --model or GIT_AI_MODEL_%s; your account may not have access to this one", strings.ToUpper(backend))
+		},
+	},
+	{
+		kind:    ErrNetwork,
+		pattern: regexp.MustCompile(`(?i)no such host|connection refused|connection reset|network is unreachable|i/o timeout|tls handshake timeout|could not resolve host|getaddrinfo|ENOTFOUND|ECONNREFUSED|ECONNRESET|ETIMEDOUT|EAI_AGAIN|fetch failed|stream disconnected`),
+		hint: func(string) string {
+			return "check your network connection and proxy settings (HTTPS_PROXY)"
+		},
+	},
+}
+
+// Diagnose returns err as a *ProviderError when err or detail (the error
+// output of the backend) shows a known cause, otherwise err. Interrupts,
+// budgets and errors already diagnosed are returned as is.
+func Diagnose(backend string, err error, detail string) error {
+	var diagnosed *ProviderError
+	switch {
+	case err == nil, errors.As(err, &diagnosed), errors.Is(err, ErrInterrupted), errors.Is(err, ErrBudgetExceeded),
+		errors.Is(err, ErrToolCallLimit), errors.Is(err, ErrStalled), errors.Is(err, ErrNotConfigured), errors.Is(err, ErrNoStagedChanges):
+		return err
+	}
+	text := err.Error() + "\n" + detail
+	for _, f := range failures {
+		if f.pattern.MatchString(text) {
+			return &ProviderError{Err: err, Kind: f.kind, Hint: f.hint(backend)}
+		}
+	}
+	return err
+}
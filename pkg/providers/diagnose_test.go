@@ -0,0 +1,104 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDiagnose(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend string
+		err     error
+		detail  string
+		kind    error
+		hint    string
+	}{
+		{
+			name:    "claude not logged in",
+			backend: "claude",
+			err:     errors.New("claude invocation failed"),
+			detail:  "Invalid API key · Please run /login",
+			kind:    ErrNotLoggedIn,
+			hint:    "claude login",
+		},
+		{
+			name:    "codex not logged in",
+			backend: "codex",
+			err:     errors.New("codex invocation failed: exit status 1\nstderr:\nError: Not logged in"),
+			kind:    ErrNotLoggedIn,
+			hint:    "codex login",
+		},
+		{
+			name:    "api backend unauthorized",
+			backend: "mistral",
+			err:     errors.New("mistral API error (401 Unauthorized)"),
+			kind:    ErrNotLoggedIn,
+			hint:    "mistral API key",
+		},
+		{
+			name:    "quota exhausted",
+			backend: "claude",
+			err:     errors.New("claude invocation failed"),
+			detail:  "Credit balance is too low",
+			kind:    ErrQuotaExhausted,
+			hint:    "billing",
+		},
+		{
+			name:    "rate limit with quota text keeps ErrRateLimited",
+			backend: "codex",
+			err:     &RateLimitError{Err: errors.New("codex invocation failed: insufficient_quota")},
+			kind:    ErrRateLimited,
+			hint:    "billing",
+		},
+		{
+			name:    "model not available",
+			backend: "gemini",
+			err:     errors.New("gemini invocation failed: exit status 1"),
+			detail:  "ModelNotFoundError: Requested entity was not found. model gemini-9 not found",
+			kind:    ErrModelUnavailable,
+			hint:    "GIT_AI_MODEL_GEMINI",
+		},
+		{
+			name:    "network down",
+			backend: "azure",
+			err:     fmt.Errorf("azure request failed: %w", errors.New("dial tcp: lookup example.openai.azure.com: no such host")),
+			kind:    ErrNetwork,
+			hint:    "network connection",
+		},
+		{
+			name:    "unknown failure",
+			backend: "codex",
+			err:     errors.New("codex invocation failed: exit status 2"),
+		},
+		{
+			name:    "interrupt is kept",
+			backend: "claude",
+			err:     fmt.Errorf("claude invocation %w", ErrInterrupted),
+			detail:  "401",
+			kind:    ErrInterrupted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got := Diagnose(tt.backend, tt.err, tt.detail)
+			var pe *ProviderError
+			if tt.hint == "" {
+				if errors.As(got, &pe) {
+					t.Fatalf("Diagnose() = %q, want no diagnosis", got)
+				}
+			} else if !strings.Contains(got.Error(), "\nhint: ") || !strings.Contains(got.Error(), tt.hint) {
+				t.Fatalf("Diagnose() = %q, want hint containing %q", got, tt.hint)
+			}
+			if tt.kind != nil && !errors.Is(got, tt.kind) {
+				t.Errorf("Diagnose() = %q, want errors.Is %v", got, tt.kind)
+			}
+			if again := Diagnose(tt.backend, got, tt.detail); again != got {
+				t.Errorf("Diagnose() twice = %q, want it unchanged", again)
+			}
+		})
+	}
+}
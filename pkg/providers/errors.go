@@ -0,0 +1,23 @@
+package providers
+
+import "errors"
+
+// Sentinel errors returned (possibly wrapped) by backends so callers can
+// branch on the failure mode.
+var (
+	ErrNoStagedChanges = errors.New("no staged diff content found")
+	ErrBackendMissing  = errors.New("no supported backend found in PATH (install claude, gemini or codex, or configure an API backend)")
+	ErrNotConfigured   = errors.New("backend not configured")
+	ErrBudgetExceeded  = errors.New("budget exceeded")
+	ErrToolCallLimit   = errors.New("tool call limit exceeded")
+	ErrInterrupted     = errors.New("interrupted")
+	ErrInvalidModel    = errors.New("invalid model")
+	ErrRateLimited     = errors.New("rate limited")
+	ErrStalled         = errors.New("backend stalled")
+
+	// Causes of backend failures reported by Diagnose.
+	ErrNotLoggedIn      = errors.New("not logged in")
+	ErrQuotaExhausted   = errors.New("quota exhausted")
+	ErrModelUnavailable = errors.New("model not available")
+	ErrNetwork          = errors.New("network unreachable")
+)
@@ -0,0 +1,96 @@
+package providers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// extraFlag is a CLI flag that Options.ExtraArgs may pass to a backend.
+type extraFlag struct {
+	name string
+	// value reports that the flag takes a value, either as the next
+	// argument or after "=".
+	value bool
+}
+
+// extraFlags lists, per CLI backend, the flags ExtraArgs may pass. Flags
+// that would change the output format, the model, sessions or the prompt
+// are left out, since git-cc-ai sets those itself, as are flags that lift
+// the backend's sandbox or approval checks, grant tools or load whole
+// configurations (profiles, settings files, MCP servers).
+var extraFlags = map[string][]extraFlag{
+	"codex": {
+		{"-c", true}, {"--config", true},
+		{"--oss", false},
+		{"--skip-git-repo-check", false},
+	},
+	"claude": {
+		{"--disallowed-tools", true}, {"--disallowedTools", true},
+		{"--add-dir", true},
+		{"--append-system-prompt", true},
+		{"--fallback-model", true},
+	},
+	"gemini": {
+		{"--include-directories", true},
+		{"-e", true}, {"--extensions", true},
+		{"--proxy", true},
+	},
+}
+
+// unsafeConfigPrefixes start the codex -c keys that relax its read-only
+// sandbox or approvals, start MCP servers, pass the environment to its
+// shell, switch to another profile, or change the model or the provider
+// endpoint (model, model_provider, model_providers) around GIT_AI_MODEL.
+var unsafeConfigPrefixes = []string{"sandbox_", "approval_", "mcp_servers", "shell_environment_policy", "profile", "model"}
+
+// ExtraArgsBackends lists the backends that accept ExtraArgs.
+func ExtraArgsBackends() []string {
+	names := make([]string, 0, len(extraFlags))
+	for name := range extraFlags {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ValidateExtraArgs checks args against the flags backend allows in
+// ExtraArgs: every argument must be an allowed flag or the value of the
+// flag before it.
+func ValidateExtraArgs(backend string, args []string) error {
+	allowed, ok := extraFlags[backend]
+	if !ok {
+		return fmt.Errorf("the %s backend does not take extra arguments (only %s do)", backend, strings.Join(ExtraArgsBackends(), ", "))
+	}
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		j := slices.IndexFunc(allowed, func(f extraFlag) bool { return f.name == name })
+		switch {
+		case !strings.HasPrefix(name, "-"):
+			return fmt.Errorf("%s extra argument %q is not a flag", backend, args[i])
+		case j < 0:
+			return fmt.Errorf("%s flag %s is not allowed in extra arguments", backend, name)
+		case !allowed[j].value && hasValue:
+			return fmt.Errorf("%s flag %s takes no value", backend, name)
+		case allowed[j].value && !hasValue:
+			if i+1 >= len(args) {
+				return fmt.Errorf("%s flag %s needs a value", backend, name)
+			}
+			i++
+			value = args[i]
+		}
+		if name == "-c" || name == "--config" {
+			key, _, _ := strings.Cut(value, "=")
+			key = strings.ToLower(strings.Trim(strings.TrimSpace(key), `"'`))
+			if slices.ContainsFunc(unsafeConfigPrefixes, func(prefix string) bool { return strings.HasPrefix(key, prefix) }) {
+				return fmt.Errorf("%s override %s is not allowed in extra arguments", backend, key)
+			}
+		}
+	}
+	return nil
+}
+
+// ExtraArgsFor returns the extra CLI flags for backend.
+func (o Options) ExtraArgsFor(backend string) []string {
+	return o.ExtraArgs[backend]
+}
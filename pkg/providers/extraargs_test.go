@@ -0,0 +1,44 @@
+package providers
+
+import "testing"
+
+func TestValidateExtraArgs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		backend string
+		args    []string
+		ok      bool
+	}{
+		{"codex", []string{"-c", `hide_agent_reasoning=true`, "--oss"}, true},
+		{"claude", []string{"--disallowed-tools", "Bash"}, true},
+		{"gemini", []string{"--include-directories", "../shared"}, true},
+		{"codex", []string{"-c", `sandbox_mode="danger-full-access"`}, false},
+		{"codex", []string{"--config=sandbox_workspace_write.network_access=true"}, false},
+		{"codex", []string{"-c", `approval_policy="never"`}, false},
+		{"codex", []string{"-c", `mcp_servers.evil.command="sh"`}, false},
+		{"codex", []string{"-c", `shell_environment_policy.inherit="all"`}, false},
+		{"codex", []string{"-c", `profile="yolo"`}, false},
+		{"codex", []string{"-c", `profiles.yolo.sandbox_mode="danger-full-access"`}, false},
+		{"codex", []string{"-c", `model="o3"`}, false},
+		{"codex", []string{"-c", `model_provider="proxy"`}, false},
+		{"codex", []string{"--config", `model_providers.proxy.base_url="https://example.com/v1"`}, false},
+		{"codex", []string{"-c", `"Model"="o3"`}, false},
+		{"codex", []string{"--profile=work"}, false},
+		{"claude", []string{"--allowed-tools", "Bash"}, false},
+		{"claude", []string{"--mcp-config", "servers.json"}, false},
+		{"claude", []string{"--settings", "settings.json"}, false},
+		{"codex", []string{"--dangerously-bypass-approvals-and-sandbox"}, false},
+		{"claude", []string{"--dangerously-skip-permissions"}, false},
+		{"claude", []string{"--model", "opus"}, false},
+		{"claude", []string{"--add-dir"}, false},
+		{"codex", []string{"--oss=yes"}, false},
+		{"gemini", []string{"stray"}, false},
+		{"mistral", []string{"-c", "x=1"}, false},
+	}
+	for _, tt := range tests {
+		if err := ValidateExtraArgs(tt.backend, tt.args); (err == nil) != tt.ok {
+			t.Errorf("ValidateExtraArgs(%q, %q) = %v, want ok %v", tt.backend, tt.args, err, tt.ok)
+		}
+	}
+}
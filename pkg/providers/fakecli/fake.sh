@@ -0,0 +1,17 @@
+# Fake backend CLI body; Install prepends the FAKECLI_* settings.
+printf '%s\n' "$@" > "$FAKECLI_DIR/args"
+cat > "$FAKECLI_DIR/stdin"
+trap 'exit 130' INT TERM
+if [ -n "$FAKECLI_STDERR" ]; then
+	cat "$FAKECLI_STDERR" >&2
+fi
+if [ -n "$FAKECLI_FIXTURE" ]; then
+	cat "$FAKECLI_FIXTURE"
+fi
+: > "$FAKECLI_DIR/started"
+if [ "$FAKECLI_HANG" = 1 ]; then
+	while :; do
+		sleep 0.05
+	done
+fi
+exit "$FAKECLI_EXIT"
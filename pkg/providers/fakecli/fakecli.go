@@ -0,0 +1,122 @@
+// Package fakecli installs fake claude, codex and gemini executables for
+// provider tests. A fake replays a recorded NDJSON stream on stdout,
+// records its arguments and stdin, and exits with a given code or keeps
+// running until it is interrupted, so Generate can be tested end to end
+// (usage trailers, budget and error paths, signal forwarding) without the
+// real CLIs or any network access.
+package fakecli
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+//go:embed fake.sh
+var script string
+
+// CLI describes a fake executable.
+type CLI struct {
+	// Name is the executable name: claude, codex or gemini.
+	Name string
+	// Fixture is the file replayed on stdout, relative to the test's
+	// package directory (e.g. "testdata/success.ndjson").
+	Fixture string
+	// Stderr, when set, is a file replayed on stderr.
+	Stderr string
+	// ExitCode is the exit status after the fixture was replayed.
+	ExitCode int
+	// Hang keeps the fake running after the fixture until it receives
+	// SIGINT or SIGTERM, on which it exits with status 130.
+	Hang bool
+}
+
+// Fake is an installed CLI.
+type Fake struct {
+	dir string
+}
+
+// Install writes c into a temporary directory that is put first on PATH
+// for the rest of the test. Tests using it cannot run in parallel. It skips
+// the test on Windows, where the fakes (shell scripts) cannot run.
+func Install(t testing.TB, c CLI) *Fake {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake CLIs are shell scripts")
+	}
+	dir := t.TempDir()
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	fmt.Fprintf(&b, "FAKECLI_DIR=%s\n", quote(dir))
+	fmt.Fprintf(&b, "FAKECLI_FIXTURE=%s\n", quote(absPath(t, c.Fixture)))
+	fmt.Fprintf(&b, "FAKECLI_STDERR=%s\n", quote(absPath(t, c.Stderr)))
+	fmt.Fprintf(&b, "FAKECLI_EXIT=%d\n", c.ExitCode)
+	if c.Hang {
+		b.WriteString("FAKECLI_HANG=1\n")
+	}
+	b.WriteString(script)
+	if err := os.WriteFile(filepath.Join(dir, c.Name), []byte(b.String()), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return &Fake{dir: dir}
+}
+
+// Args returns the command-line arguments of the last run.
+func (f *Fake) Args(t testing.TB) []string {
+	t.Helper()
+	return strings.Split(strings.TrimSuffix(f.read(t, "args"), "\n"), "\n")
+}
+
+// Stdin returns what the last run read on stdin.
+func (f *Fake) Stdin(t testing.TB) string {
+	t.Helper()
+	return f.read(t, "stdin")
+}
+
+// WaitStarted blocks until the fake has replayed its fixture, failing the
+// test after timeout.
+func (f *Fake) WaitStarted(t testing.TB, timeout time.Duration) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for {
+		if _, err := os.Stat(filepath.Join(f.dir, "started")); err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("fake CLI did not start within %s", timeout)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (f *Fake) read(t testing.TB, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(f.dir, name))
+	if err != nil {
+		t.Fatalf("fake CLI did not run: %v", err)
+	}
+	return string(data)
+}
+
+func absPath(t testing.TB, path string) string {
+	t.Helper()
+	if path == "" {
+		return ""
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return abs
+}
+
+// quote returns s as a single-quoted shell word.
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -9,7 +9,9 @@ import (
 type Backend struct{}
 
 func (Backend) Generate(ctx context.Context, reg *providers.Registry, opts providers.Options) (string, error) {
-	return Generate(ctx, reg, opts)
+	return providers.Retry(ctx, reg, opts, "gemini", func() (string, error) {
+		return Generate(ctx, reg, opts)
+	})
 }
 
 func (Backend) Models() []string     { return append([]string{}, models...) }
@@ -1,9 +1,7 @@
 package gemini
 
 import (
-	"bufio"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -14,7 +12,9 @@ import (
 
 	"github.com/dlnilsson/git-cc-ai/pkg/commit"
 	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/ndjson"
 	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/stream"
 	"github.com/dlnilsson/git-cc-ai/pkg/ui"
 )
 
@@ -33,33 +33,10 @@ func resolveModel(model string) string {
 }
 
 func Generate(ctx context.Context, reg *providers.Registry, opts providers.Options) (string, error) {
-	diff, err := git.DiffStaged()
+	prompt, err := buildPrompt(opts)
 	if err != nil {
 		return "", err
 	}
-	if strings.TrimSpace(diff) == "" {
-		return "", errors.New("no staged diff content found")
-	}
-
-	skillText := commit.ConventionalSpec
-	if opts.NoCC {
-		skillText = commit.StandardCommitRule
-	}
-	if opts.SkillPath != "" {
-		if data, readErr := os.ReadFile(opts.SkillPath); readErr == nil {
-			trimmed := strings.TrimSpace(string(data))
-			if trimmed != "" {
-				skillText = skillText + "\nAdditional instructions:\n" + trimmed
-			}
-		}
-	}
-
-	prompt := commit.BuildConventionalPrompt(commit.PromptOptions{
-		SkillText: skillText,
-		Diff:      diff,
-		ExtraNote: opts.ExtraNote,
-		NoCC:      opts.NoCC,
-	})
 	model := resolveModel(opts.Model)
 
 	args := []string{
@@ -70,7 +47,19 @@ func Generate(ctx context.Context, reg *providers.Registry, opts providers.Optio
 	if strings.TrimSpace(opts.SessionID) != "" {
 		args = append(args, "--resume", opts.SessionID)
 	}
+	if !opts.UnrestrictedTools() {
+		// In the default approval mode a headless run cannot approve
+		// shell commands or edits, so only the listed tools may run them.
+		args = append(args, "--approval-mode", "default")
+		for _, tool := range opts.ToolList() {
+			args = append(args, "--allowed-tools", tool)
+		}
+	}
+	args = append(args, opts.ExtraArgsFor("gemini")...)
 
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	toolCalls := opts.NewToolCalls(cancel)
 	cmd := exec.CommandContext(ctx, "gemini", args...)
 	cmd.Env = append(cmd.Environ(), "NODE_NO_WARNINGS=1")
 	setProcessGroup(cmd)
@@ -81,146 +70,156 @@ func Generate(ctx context.Context, reg *providers.Registry, opts providers.Optio
 		backendLabel := "gemini +" + model
 		stopSpinner = ui.StartSpinner(ui.RandomSpinnerMessage(), backendLabel, reg)
 		defer stopSpinner()
+		if strings.TrimSpace(opts.SessionID) != "" {
+			ui.SendSpinnerReasoning("Resuming session " + opts.SessionID)
+		}
 	}
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return "", err
 	}
-	cmd.Stderr = io.Discard
+	var stderr providers.StderrTail
+	cmd.Stderr = &stderr
+	defer stderr.Debug("gemini")
 
 	if err = cmd.Start(); err != nil {
 		return "", fmt.Errorf("gemini invocation failed: %w", err)
 	}
 	reg.Register(cmd, stopSpinner)
 	defer reg.Unregister()
+	watch := opts.WatchStall(reg)
+	defer watch.Stop()
+	opts.Report(providers.Progress{Phase: providers.PhaseRunning})
 
 	var (
 		accumulatedContent strings.Builder
 		stdoutBuf          strings.Builder
 		sessionID          string
-		stats              geminiStats
-		status             string
+		stats              stream.Usage
+		streamErr          string
 	)
 
-	reader := bufio.NewReader(io.TeeReader(stdout, &stdoutBuf))
-	for {
-		line, readErr := reader.ReadString('\n')
-		line = strings.TrimRight(line, "\r\n")
-		var raw map[string]any
-		if err := json.Unmarshal([]byte(line), &raw); err != nil {
-			if errors.Is(readErr, io.EOF) {
-				break
-			}
-			if readErr != nil {
-				return "", readErr
-			}
-			continue
-		}
-		parsed := parseGeminiEvent(raw)
-		if parsed.SessionID != "" {
-			sessionID = parsed.SessionID
-		}
-		if parsed.Status != "" {
-			status = parsed.Status
-		}
-		if parsed.Stats != (geminiStats{}) {
-			stats = parsed.Stats
-		}
-		if parsed.Role == "assistant" && parsed.Content != "" {
-			accumulatedContent.WriteString(parsed.Content)
-			if opts.ShowSpinner {
-				ui.SendSpinnerReasoning(strings.TrimSpace(accumulatedContent.String()))
+	meter := opts.NewTokenMeter(model, prompt)
+	reader := ndjson.NewReader(io.TeeReader(stdout, &stdoutBuf))
+	for line := range reader.Lines() {
+		watch.Alive()
+		opts.Event(line)
+		for _, ev := range stream.DecodeGemini(line) {
+			switch ev := ev.(type) {
+			case stream.Session:
+				sessionID = ev.ID
+			case stream.Usage:
+				stats = ev
+				meter.Reported(stats.OutputTokens)
+			case stream.Error:
+				streamErr = ev.Message
+			case stream.ToolCall:
+				toolCalls.Add(ev.Text)
+			case stream.TextDelta:
+				accumulatedContent.WriteString(ev.Text)
+				meter.Streamed(accumulatedContent.String())
+				switch {
+				case opts.ShowSpinner && opts.Stream:
+					ui.SendSpinnerPreview(commit.Normalize(accumulatedContent.String()))
+				case opts.ShowSpinner:
+					ui.SendSpinnerReasoning(strings.TrimSpace(accumulatedContent.String()))
+				}
+				opts.Report(providers.Progress{Phase: providers.PhaseReasoning, Reasoning: strings.TrimSpace(accumulatedContent.String()), Partial: accumulatedContent.String()})
 			}
 		}
-		if errors.Is(readErr, io.EOF) {
-			break
-		}
-		if readErr != nil {
-			return "", readErr
-		}
+	}
+	if err := reader.Err(); err != nil {
+		return "", err
 	}
 	if err = cmd.Wait(); err != nil {
+		if err := toolCalls.Err("gemini"); err != nil {
+			return "", err
+		}
 		if reg.WasInterrupted() {
-			return "", errors.New("gemini invocation interrupted")
+			return "", fmt.Errorf("gemini invocation %w", providers.ErrInterrupted)
 		}
-		return "", fmt.Errorf("gemini invocation failed: %w", err)
+		return "", providers.Diagnose("gemini", providers.CheckRateLimit(stderr.Wrap(fmt.Errorf("gemini invocation failed: %w", err)), streamErr), streamErr)
 	}
 
-	if status == "error" {
-		return "", errors.New("gemini returned an error")
+	if streamErr != "" {
+		return "", providers.CheckRateLimit(errors.New(streamErr), "")
 	}
 
 	responseText := accumulatedContent.String()
-	text := commit.StripCodeFence(strings.TrimSpace(responseText))
+	text := commit.Normalize(responseText)
 	if text == "" {
 		return "", errors.New("gemini returned empty response")
 	}
 
-	msg := commit.WrapMessage(text, commit.BodyLineWidth)
-	return appendUsageComment(msg, sessionID, stats, time.Since(startTime), model), nil
-}
-
-type geminiEvent struct {
-	SessionID string
-	Role      string
-	Content   string
-	Status    string
-	Stats     geminiStats
-}
+	opts.ReportUsage(providers.Usage{
+		Backend:      "gemini",
+		Model:        model,
+		InputTokens:  stats.InputTokens,
+		OutputTokens: stats.OutputTokens,
+		SessionID:    sessionID,
+		Elapsed:      time.Since(startTime),
+	})
+	if sessionID != "" && opts.OnSessionID != nil {
+		opts.OnSessionID(sessionID)
+	}
 
-type geminiStats struct {
-	TotalTokens  int `json:"total_tokens"`
-	InputTokens  int `json:"input_tokens"`
-	OutputTokens int `json:"output_tokens"`
-	DurationMS   int `json:"duration_ms"`
+	if opts.Task != nil {
+		return commit.StripCodeFence(responseText), nil
+	}
+	msg := opts.FormatMessage(text)
+	return appendUsageComment(msg, sessionID, stats, time.Since(startTime), model), nil
 }
 
-func parseGeminiEvent(raw map[string]any) geminiEvent {
-	ev := geminiEvent{
-		SessionID: asString(raw["session_id"]),
-		Role:      asString(raw["role"]),
-		Status:    asString(raw["status"]),
+// buildPrompt returns the task prompt when opts.Task is set, otherwise the
+// commit prompt over the staged diff.
+func buildPrompt(opts providers.Options) (string, error) {
+	if opts.Task != nil {
+		return opts.Task.Prompt(), nil
 	}
-	if asString(raw["type"]) == "result" {
-		if stats, ok := raw["stats"].(map[string]any); ok {
-			ev.Stats = parseGeminiStats(stats)
-		}
+	diff, changes, err := opts.StagedDiff()
+	if err != nil {
+		return "", err
 	}
-	if asString(raw["type"]) == "message" {
-		ev.Content = asString(raw["content"])
+	if strings.TrimSpace(diff) == "" && opts.Summaries == "" {
+		return "", providers.ErrNoStagedChanges
 	}
-	return ev
-}
+	opts.Report(providers.Progress{Phase: providers.PhaseDiff, DiffBytes: len(diff)})
 
-func parseGeminiStats(raw map[string]any) geminiStats {
-	return geminiStats{
-		TotalTokens:  toInt(raw["total_tokens"]),
-		InputTokens:  toInt(raw["input_tokens"]),
-		OutputTokens: toInt(raw["output_tokens"]),
-		DurationMS:   toInt(raw["duration_ms"]),
+	skillText := commit.ConventionalSpec
+	if opts.NoCC {
+		skillText = commit.StandardCommitRule
 	}
-}
-
-func asString(v any) string {
-	s, _ := v.(string)
-	return s
-}
-
-func toInt(v any) int {
-	switch n := v.(type) {
-	case int:
-		return n
-	case int64:
-		return int(n)
-	case float64:
-		return int(n)
-	default:
-		return 0
+	if opts.SkillPath != "" {
+		if data, readErr := os.ReadFile(opts.SkillPath); readErr == nil {
+			trimmed := strings.TrimSpace(string(data))
+			if trimmed != "" {
+				skillText = skillText + "\nAdditional instructions:\n" + trimmed
+			}
+		}
 	}
+
+	scope, allowed := opts.ScopeFor(changes)
+	return commit.BuildConventionalPrompt(commit.PromptOptions{
+		SkillText:   skillText,
+		Diff:        diff,
+		Summaries:   opts.Summaries,
+		Changes:     git.FormatChanges(changes),
+		ExtraNote:   opts.ExtraNote,
+		NoCC:        opts.NoCC,
+		Risk:        opts.Risk,
+		MaxSubject:  opts.MaxSubject,
+		SubjectOnly: opts.SubjectOnly,
+		Scope:       scope,
+		Scopes:      allowed,
+		Types:       opts.Types,
+		ScopePolicy: opts.ScopePolicy,
+		ScopeHints:  opts.ScopeHints(changes),
+		Template:    opts.Template,
+	}), nil
 }
 
-func appendUsageComment(message string, sessionID string, stats geminiStats, elapsed time.Duration, model string) string {
+func appendUsageComment(message string, sessionID string, stats stream.Usage, elapsed time.Duration, model string) string {
 	elapsedText := elapsed.Round(100 * time.Millisecond)
 
 	var b strings.Builder
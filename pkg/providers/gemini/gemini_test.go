@@ -0,0 +1,63 @@
+package gemini
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers/fakecli"
+)
+
+const testDiff = `diff --git a/upload/client.go b/upload/client.go
+--- a/upload/client.go
++++ b/upload/client.go
+@@ -1 +1,2 @@
+ package upload
++// retry on 503
+`
+
+func TestGenerateReplaysStream(t *testing.T) {
+	fake := fakecli.Install(t, fakecli.CLI{Name: "gemini", Fixture: "testdata/success.ndjson"})
+
+	msg, err := Generate(t.Context(), &providers.Registry{}, providers.Options{Diff: testDiff})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	for _, want := range []string{
+		"fix(upload): retry on 503\n\n# tokens: input=1790 output=40 elapsed=",
+		"\n# session=c5b1d6a0-3f52-4d7e-9a39-0c6f2f1e8b77",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message missing %q:\n%s", want, msg)
+		}
+	}
+	if args := strings.Join(fake.Args(t), "\n"); !strings.Contains(args, "+// retry on 503") {
+		t.Errorf("prompt argument does not carry the diff:\n%s", args)
+	}
+}
+
+func TestGenerateReportsStreamError(t *testing.T) {
+	fakecli.Install(t, fakecli.CLI{Name: "gemini", Fixture: "testdata/error.ndjson"})
+
+	_, err := Generate(t.Context(), &providers.Registry{}, providers.Options{Diff: testDiff})
+	if err == nil || err.Error() != "gemini returned an error" {
+		t.Fatalf("Generate() error = %v, want the stream error", err)
+	}
+}
+
+func TestGenerateInterrupted(t *testing.T) {
+	fake := fakecli.Install(t, fakecli.CLI{Name: "gemini", Fixture: "testdata/success.ndjson", Hang: true})
+
+	reg := &providers.Registry{}
+	go func() {
+		fake.WaitStarted(t, 5*time.Second)
+		reg.ForwardSignal(os.Interrupt)
+	}()
+	_, err := Generate(t.Context(), reg, providers.Options{Diff: testDiff})
+	if !errors.Is(err, providers.ErrInterrupted) {
+		t.Fatalf("Generate() error = %v, want ErrInterrupted", err)
+	}
+}
@@ -0,0 +1,25 @@
+package mistral
+
+import (
+	"context"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+)
+
+type Backend struct{}
+
+func (Backend) Generate(ctx context.Context, reg *providers.Registry, opts providers.Options) (string, error) {
+	return providers.Retry(ctx, reg, opts, "mistral", func() (string, error) {
+		return Generate(ctx, reg, opts)
+	})
+}
+
+func (Backend) Models() []string     { return append([]string{}, models...) }
+func (Backend) DefaultModel() string { return defaultModel }
+
+// SupportsSampling reports that temperature and seed (random_seed) are sent
+// with the request.
+func (Backend) SupportsSampling() (bool, bool) { return true, true }
+
+// Configured reports whether an API key is available.
+func (Backend) Configured() error { return configured() }
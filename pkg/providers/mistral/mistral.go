@@ -0,0 +1,96 @@
+// Package mistral generates commit messages with Mistral's chat completions
+// API (codestral and the mistral models). Unlike the CLI backends it needs
+// no executable: requests go straight to the API with MISTRAL_API_KEY, read
+// from the environment, a key command or the keychain.
+package mistral
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers/chatapi"
+	"github.com/dlnilsson/git-cc-ai/pkg/secrets"
+)
+
+const (
+	defaultModel = "codestral-latest"
+	// APIKeyEnv holds the API key; the backend is unavailable without it.
+	// The key can also come from a command or the keychain (see secrets).
+	APIKeyEnv = "MISTRAL_API_KEY"
+	// BaseURLEnv overrides the API base URL, e.g. with
+	// https://codestral.mistral.ai/v1 for codestral-only keys.
+	BaseURLEnv     = "MISTRAL_BASE_URL"
+	defaultBaseURL = "https://api.mistral.ai/v1"
+)
+
+var models = []string{
+	"codestral-latest",
+	"mistral-large-latest",
+	"mistral-medium-latest",
+	"mistral-small-latest",
+}
+
+type request struct {
+	Model       string            `json:"model"`
+	Messages    []chatapi.Message `json:"messages"`
+	Stream      bool              `json:"stream"`
+	Temperature *float64          `json:"temperature,omitempty"`
+	RandomSeed  *int64            `json:"random_seed,omitempty"`
+}
+
+func resolveModel(model string) string {
+	if strings.TrimSpace(model) != "" {
+		return model
+	}
+	return defaultModel
+}
+
+func baseURL() string {
+	if u := strings.TrimSpace(os.Getenv(BaseURLEnv)); u != "" {
+		return strings.TrimSuffix(u, "/")
+	}
+	return defaultBaseURL
+}
+
+// apiKey returns the API key, reporting ErrNotConfigured without one.
+func apiKey() (string, error) {
+	key, err := secrets.Lookup(APIKeyEnv)
+	if errors.Is(err, secrets.ErrNotFound) {
+		return "", chatapi.NotConfigured("mistral", APIKeyEnv)
+	}
+	return key, err
+}
+
+// configured reports a missing API key.
+func configured() error {
+	_, err := apiKey()
+	return err
+}
+
+func Generate(ctx context.Context, reg *providers.Registry, opts providers.Options) (string, error) {
+	key, err := apiKey()
+	if err != nil {
+		return "", err
+	}
+	messages, err := chatapi.Messages(opts)
+	if err != nil {
+		return "", err
+	}
+	model := resolveModel(opts.Model)
+	ep := chatapi.Endpoint{
+		Backend: "mistral",
+		URL:     baseURL() + "/chat/completions",
+		Header:  http.Header{"Authorization": {"Bearer " + key}},
+	}
+	return chatapi.Generate(ctx, reg, opts, ep, model, request{
+		Model:       model,
+		Messages:    messages,
+		Stream:      true,
+		Temperature: opts.Temperature,
+		RandomSeed:  opts.Seed,
+	})
+}
@@ -0,0 +1,180 @@
+package mistral
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/secrets"
+)
+
+const testDiff = `diff --git a/upload/client.go b/upload/client.go
+--- a/upload/client.go
++++ b/upload/client.go
+@@ -1 +1,2 @@
+ package upload
++// retry on 503
+`
+
+// serve points the backend at a test server running handler.
+func serve(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	t.Setenv(APIKeyEnv, "test-key")
+	t.Setenv(BaseURLEnv, srv.URL+"/v1")
+}
+
+func TestGenerateReplaysStream(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/success.sse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got request
+	serve(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer test-key" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write(fixture)
+	})
+
+	var (
+		seed  int64 = 42
+		usage providers.Usage
+	)
+	msg, err := Generate(t.Context(), &providers.Registry{}, providers.Options{
+		Diff:    testDiff,
+		Model:   "mistral-small-latest",
+		Seed:    &seed,
+		OnUsage: func(u providers.Usage) { usage = u },
+	})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	want := "fix(upload): retry on 503\n\n# tokens: input=1650 output=9 elapsed="
+	if !strings.HasPrefix(msg, want) || !strings.HasSuffix(msg, " model=mistral-small-latest") {
+		t.Errorf("message = %q, want prefix %q", msg, want)
+	}
+	if usage.InputTokens != 1650 || usage.OutputTokens != 9 || usage.Backend != "mistral" {
+		t.Errorf("usage = %+v", usage)
+	}
+	if got.Model != "mistral-small-latest" || !got.Stream || got.RandomSeed == nil || *got.RandomSeed != 42 {
+		t.Errorf("request = %+v", got)
+	}
+	if len(got.Messages) != 1 || !strings.Contains(got.Messages[0].Content, "+// retry on 503") {
+		t.Errorf("request messages do not carry the diff: %+v", got.Messages)
+	}
+}
+
+func TestGenerateReportsAPIError(t *testing.T) {
+	serve(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = io.WriteString(w, `{"message":"Unauthorized","request_id":"4c1f"}`)
+	})
+
+	_, err := Generate(t.Context(), &providers.Registry{}, providers.Options{Diff: testDiff})
+	want := "mistral request failed (401 Unauthorized): Unauthorized"
+	if err == nil || err.Error() != want {
+		t.Fatalf("Generate() error = %v, want %q", err, want)
+	}
+}
+
+func TestBackendRetriesRateLimit(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/success.sse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests := 0
+	serve(t, func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After-Ms", "20")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = io.WriteString(w, `{"message":"Requests rate limit exceeded"}`)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write(fixture)
+	})
+
+	var waits []time.Duration
+	msg, err := Backend{}.Generate(t.Context(), &providers.Registry{}, providers.Options{
+		Diff: testDiff,
+		OnProgress: func(p providers.Progress) {
+			if p.Phase == providers.PhaseWaiting {
+				waits = append(waits, p.Wait)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if !strings.HasPrefix(msg, "fix(upload): retry on 503") {
+		t.Errorf("message = %q", msg)
+	}
+	if requests != 2 || len(waits) != 1 || waits[0] != 20*time.Millisecond {
+		t.Errorf("requests = %d, waits = %v; want 2 requests after one 20ms wait", requests, waits)
+	}
+}
+
+func TestBackendGivesUpOnLongRateLimit(t *testing.T) {
+	requests := 0
+	serve(t, func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = io.WriteString(w, `{"message":"Requests rate limit exceeded"}`)
+	})
+
+	_, err := Backend{}.Generate(t.Context(), &providers.Registry{}, providers.Options{Diff: testDiff})
+	if !errors.Is(err, providers.ErrRateLimited) {
+		t.Fatalf("Generate() error = %v, want ErrRateLimited", err)
+	}
+	if requests != 1 {
+		t.Errorf("requests = %d, want 1 (the hinted wait exceeds the budget)", requests)
+	}
+}
+
+func TestGenerateNeedsAPIKey(t *testing.T) {
+	t.Setenv(APIKeyEnv, "")
+	t.Setenv(secrets.CommandEnv, "")
+
+	_, err := Generate(t.Context(), &providers.Registry{}, providers.Options{Diff: testDiff})
+	if !errors.Is(err, providers.ErrNotConfigured) {
+		t.Fatalf("Generate() error = %v, want ErrNotConfigured", err)
+	}
+}
+
+func TestGenerateInterrupted(t *testing.T) {
+	started := make(chan struct{})
+	serve(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: {\"choices\":[{\"delta\":{\"content\":\"fix\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		close(started)
+		<-r.Context().Done()
+	})
+
+	reg := &providers.Registry{}
+	go func() {
+		<-started
+		reg.ForwardSignal(os.Interrupt)
+	}()
+	_, err := Generate(t.Context(), reg, providers.Options{Diff: testDiff})
+	if !errors.Is(err, providers.ErrInterrupted) {
+		t.Fatalf("Generate() error = %v, want ErrInterrupted", err)
+	}
+}
@@ -0,0 +1,122 @@
+package providers
+
+import (
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+// modelPrice is the list price of a model family in USD per million
+// tokens, with its context window in tokens.
+type modelPrice struct {
+	match         string
+	input, output float64
+	context       int
+}
+
+// modelPrices are matched in order against the model name, so more
+// specific names come first. They only feed the running estimate in the
+// spinner and the model picker; the claude backend reports its actual cost
+// when it finishes.
+var modelPrices = []modelPrice{
+	{"codex-mini", 0.25, 2, 200_000},
+	{"gpt-5", 1.25, 10, 400_000},
+	{"opus", 5, 25, 200_000},
+	{"sonnet", 3, 15, 200_000},
+	{"haiku", 1, 5, 200_000},
+	{"gemini-2.5-pro", 1.25, 10, 1_048_576},
+	{"gemini-2.5-flash", 0.30, 2.50, 1_048_576},
+	{"codestral", 0.30, 0.90, 256_000},
+	{"mistral-large", 2, 6, 128_000},
+	{"mistral-medium", 0.40, 2, 128_000},
+	{"mistral-small", 0.10, 0.30, 128_000},
+}
+
+// ModelInfo describes a model for the model picker.
+type ModelInfo struct {
+	// Tier is the price tier by output price: "$", "$$" or "$$$".
+	Tier string
+	// ContextWindow is the number of tokens the model accepts.
+	ContextWindow int
+}
+
+// DescribeModel returns the price tier and context window of model, and
+// false when the model is unknown.
+func DescribeModel(model string) (ModelInfo, bool) {
+	p, ok := lookupPrice(model)
+	if !ok {
+		return ModelInfo{}, false
+	}
+	tier := "$$$"
+	switch {
+	case p.output <= 2.5:
+		tier = "$"
+	case p.output <= 10:
+		tier = "$$"
+	}
+	return ModelInfo{Tier: tier, ContextWindow: p.context}, true
+}
+
+func lookupPrice(model string) (modelPrice, bool) {
+	model = strings.ToLower(model)
+	for _, p := range modelPrices {
+		if strings.Contains(model, p.match) {
+			return p, true
+		}
+	}
+	return modelPrice{}, false
+}
+
+// EstimateCost returns the list-price cost in USD of input and output
+// tokens of model, and false when the price of the model is unknown (e.g.
+// Azure deployment names).
+func EstimateCost(model string, input, output int) (float64, bool) {
+	p, ok := lookupPrice(model)
+	if !ok {
+		return 0, false
+	}
+	return (float64(input)*p.input + float64(output)*p.output) / 1e6, true
+}
+
+// EstimateTokens approximates the number of tokens in text at four bytes
+// per token.
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// TokenMeter follows the output of one backend run for the spinner's
+// token count, throughput and cost, and reports the token counts the
+// backend gives through Options.Report.
+type TokenMeter struct {
+	opts     Options
+	model    string
+	input    int
+	reported int
+}
+
+// NewTokenMeter returns a meter for a run of model whose request is
+// prompt.
+func (o Options) NewTokenMeter(model, prompt string) *TokenMeter {
+	return &TokenMeter{opts: o, model: model, input: EstimateTokens(prompt)}
+}
+
+// Streamed updates the spinner from the reply text streamed since the last
+// reported count.
+func (m *TokenMeter) Streamed(text string) {
+	m.show(m.reported+EstimateTokens(text), true)
+}
+
+// Reported records the output token count the backend reported so far.
+func (m *TokenMeter) Reported(output int) {
+	m.reported = output
+	m.opts.Report(Progress{Phase: PhaseTokens, OutputTokens: output})
+	m.show(output, false)
+}
+
+func (m *TokenMeter) show(output int, estimated bool) {
+	if !m.opts.ShowSpinner {
+		return
+	}
+	cost, _ := EstimateCost(m.model, m.input, output)
+	ui.SendSpinnerUsage(ui.SpinnerUsage{OutputTokens: output, Estimated: estimated, CostUSD: cost, BudgetUSD: m.opts.Budget})
+}
@@ -0,0 +1,56 @@
+package providers
+
+import "testing"
+
+func TestEstimateCost(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		model string
+		want  float64
+		ok    bool
+	}{
+		{"claude-sonnet-4-6", 3 + 15, true},
+		{"gpt-5.1-codex-mini", 0.25 + 2, true},
+		{"gpt-5.2-codex", 1.25 + 10, true},
+		{"my-azure-deployment", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := EstimateCost(tt.model, 1e6, 1e6)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("EstimateCost(%q) = %v, %v, want %v, %v", tt.model, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	t.Parallel()
+
+	if got := EstimateTokens("feat: add export"); got != 4 {
+		t.Errorf("EstimateTokens() = %d, want 4", got)
+	}
+	if got := EstimateTokens(""); got != 0 {
+		t.Errorf("EstimateTokens(\"\") = %d, want 0", got)
+	}
+}
+
+func TestDescribeModel(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		model string
+		want  ModelInfo
+		ok    bool
+	}{
+		{"gpt-5.1-codex-mini", ModelInfo{Tier: "$", ContextWindow: 200_000}, true},
+		{"gpt-5.2-codex", ModelInfo{Tier: "$$", ContextWindow: 400_000}, true},
+		{"claude-opus-4-6", ModelInfo{Tier: "$$$", ContextWindow: 200_000}, true},
+		{"my-azure-deployment", ModelInfo{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := DescribeModel(tt.model)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("DescribeModel(%q) = %+v, %v, want %+v, %v", tt.model, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -1,15 +1,240 @@
 package providers
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/commit"
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+	"github.com/dlnilsson/git-cc-ai/pkg/scopes"
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
 
 type Options struct {
+	// Diff, when set, is used instead of reading the staged diff from git
+	// (e.g. when serving requests for remote clients).
+	Diff        string
 	SkillPath   string
 	ExtraNote   string
 	Model       string
 	SessionID   string
 	ShowSpinner bool
-	NoCC        bool
-	Budget      float64 // max spend in USD; 0 means use backend default
+	// Stream renders the message incrementally below the spinner as text
+	// deltas arrive, for backends that stream them (claude, gemini).
+	Stream bool
+	NoCC   bool
+	Risk   bool    // request Risk/Affects/Migration footers
+	Budget float64 // max spend in USD; 0 means use backend default
+	// RateLimitWait is the total time Retry may wait for rate limits to
+	// clear; 0 means DefaultRateLimitWait and a negative value disables
+	// retries.
+	RateLimitWait time.Duration
+	// StallTimeout is how long a backend may run without output before it
+	// is stopped as stalled; 0 means DefaultStallTimeout and a negative
+	// value disables the watchdog.
+	StallTimeout time.Duration
+	// Temperature and Seed, when set, are passed to backends that support
+	// sampling controls (see SamplingBackend) for reproducible output.
+	Temperature *float64
+	Seed        *int64
+	// Reasoning is the reasoning effort (ReasoningLow, ReasoningMedium,
+	// ReasoningHigh) for backends that support it; empty uses the default.
+	Reasoning string
+	// MaxSubject is the subject length limit given to the model (0: none).
+	MaxSubject int
+	// SubjectOnly requests a single-line message; any body is dropped.
+	SubjectOnly bool
+	// StripPatterns are extra boilerplate line patterns removed from the
+	// message in addition to commit.Boilerplate.
+	StripPatterns []*regexp.Regexp
+	// Structured requests the message as JSON matching
+	// commit.StructuredSchema from backends that enforce output schemas
+	// (see StructuredBackend); the text is assembled locally.
+	Structured bool
+	// Template, when set, has the model fill the slots of a team message
+	// template, which FormatMessage substitutes and validates.
+	Template *commit.Template
+	// Summaries, when set, replaces the diff in the commit prompt with
+	// per-file summaries from a cheaper first pass (two-stage generation).
+	Summaries string
+	// Types, when set, restricts the Conventional Commits types the model
+	// may use; empty leaves the choice to the model.
+	Types []string
+	// Scopes, when set, maps the changed paths to the scope the model must
+	// use (.git-ai/scopes.yaml).
+	Scopes *scopes.Map
+	// ScopePolicy is commit.ScopeOptional (or empty), commit.ScopeRequired
+	// or commit.ScopeForbidden.
+	ScopePolicy string
+	// Scope, when set, is the scope the user picked for the change (--scope
+	// or the scope picker); it wins over the scope map.
+	Scope string
+	// Tools is ToolsNone (or empty) to deny the model any tools, ToolsAll
+	// to keep the backend's defaults, or a comma-separated list of tools
+	// the model may use, in the backend's syntax.
+	Tools string
+	// MaxToolCalls stops a run that makes more tool calls than this; 0
+	// means no limit.
+	MaxToolCalls int
+	// ExtraArgs maps a backend name to extra CLI flags appended to its
+	// command line (see ValidateExtraArgs); backends without a CLI ignore
+	// it.
+	ExtraArgs map[string][]string
+	// Task, when set, replaces the commit-message prompt with a free-form
+	// request (release notes, reviews, ...). The staged diff is not read and
+	// the response is returned without commit wrapping or usage comments.
+	Task *Task
+	// OnSessionID, when set, is called with the backend session ID reported
+	// by a successful run so the caller can persist it for later resumes.
+	OnSessionID func(id string)
+	// OnProgress, when set, receives progress updates (e.g. for editor
+	// integrations) independently of the terminal spinner.
+	OnProgress func(Progress)
+	// OnUsage, when set, receives the token/cost accounting of a successful
+	// run in structured form (the same data as the usage comment trailer).
+	OnUsage func(Usage)
+	// OnEvent, when set, receives every raw output line (NDJSON event) of
+	// the backend CLI, e.g. to save a transcript of the run.
+	OnEvent func(line string)
+}
+
+// ScopeFor returns the scope for changes, picked or mapped, and the full
+// set of allowed scopes. Both are empty when scopes are forbidden, and
+// without a picked scope or a scope map.
+func (o Options) ScopeFor(changes []git.FileChange) (string, []string) {
+	switch {
+	case o.ScopePolicy == commit.ScopeForbidden:
+		return "", nil
+	case o.Scopes == nil:
+		return o.Scope, nil
+	case o.Scope != "":
+		return o.Scope, o.Scopes.Allowed()
+	}
+	return o.Scopes.Resolve(git.ChangedPaths(changes)), o.Scopes.Allowed()
+}
+
+// ScopeHints returns candidate scopes derived from the changed paths when a
+// scope is required and no scope map lists the allowed ones.
+func (o Options) ScopeHints(changes []git.FileChange) []string {
+	if o.ScopePolicy != commit.ScopeRequired || o.Scopes != nil {
+		return nil
+	}
+	return commit.CandidateScopes(git.ChangedPaths(changes))
+}
+
+// StagedDiff returns the diff to describe (Diff, capped, or the staged
+// diff) and its per-file changes. With Summaries set only the changes are
+// read, since the summaries stand in for the diff.
+func (o Options) StagedDiff() (string, []git.FileChange, error) {
+	switch {
+	case o.Summaries != "" && o.Diff != "":
+		return "", git.ParseChanges(o.Diff), nil
+	case o.Summaries != "":
+		changes, err := git.StagedChanges()
+		return "", changes, err
+	case o.Diff != "":
+		return git.CapDiff(o.Diff), git.ParseChanges(o.Diff), nil
+	}
+	diff, err := git.DiffStaged()
+	if err != nil {
+		return "", nil, err
+	}
+	changes, err := git.StagedChanges()
+	return diff, changes, err
+}
+
+// Task is a free-form generation request.
+type Task struct {
+	// Instructions are the system-level rules for the response.
+	Instructions string
+	// Input is the material to work on (commit list, diff, ...).
+	Input string
+}
+
+// Prompt returns the task as a single prompt for backends without a
+// separate system prompt.
+func (t Task) Prompt() string {
+	return t.Instructions + "\n\n" + t.Input
+}
+
+// Progress phases.
+const (
+	PhaseDiff      = "diff"      // diff collected; DiffBytes is set
+	PhaseRunning   = "running"   // backend process started
+	PhaseReasoning = "reasoning" // Reasoning is set
+	PhaseTokens    = "tokens"    // OutputTokens is set
+	PhaseWaiting   = "waiting"   // rate limited before a retry; Wait is set
+)
+
+// Progress is an incremental update reported while a backend runs.
+type Progress struct {
+	Phase string
+	// DiffBytes is the size of the diff sent to the backend.
+	DiffBytes int
+	// Reasoning is the latest reasoning or tool-use text shown in the spinner.
+	Reasoning string
+	// Partial is the response streamed so far, set with Reasoning while the
+	// message itself streams.
+	Partial string
+	// OutputTokens is the number of output tokens generated so far.
+	OutputTokens int
+	// Wait is how long the backend waits for a rate limit to clear.
+	Wait time.Duration
+}
+
+// Usage is the token/cost accounting reported by a backend for one run.
+type Usage struct {
+	Backend      string        `json:"backend"`
+	Model        string        `json:"model"`
+	InputTokens  int           `json:"input_tokens"`
+	OutputTokens int           `json:"output_tokens"`
+	CachedTokens int           `json:"cached_tokens,omitempty"`
+	CostUSD      float64       `json:"cost_usd,omitempty"`
+	SessionID    string        `json:"session_id,omitempty"`
+	Elapsed      time.Duration `json:"elapsed_ns"`
+}
+
+// FormatMessage post-processes a generated commit message: the template
+// is filled, the raw output normalized, boilerplate stripped, the body
+// wrapped, and everything but the subject dropped in subject-only mode.
+func (o Options) FormatMessage(text string) string {
+	if o.Template != nil {
+		filled, err := o.Template.Fill(text)
+		if err != nil {
+			fmt.Fprintf(ui.Status(), "warning: %v; using the reply as is\n", err)
+		} else {
+			text = filled
+		}
+	}
+	msg := commit.WrapMessage(commit.Sanitize(text, o.StripPatterns), commit.BodyLineWidth)
+	if o.SubjectOnly {
+		msg = commit.Subject(msg)
+	}
+	return commit.EscapeComments(msg)
+}
+
+// ReportUsage forwards u to OnUsage when a listener is set.
+func (o Options) ReportUsage(u Usage) {
+	if o.OnUsage != nil {
+		o.OnUsage(u)
+	}
+}
+
+// Event forwards a raw backend output line to OnEvent when a listener is
+// set.
+func (o Options) Event(line string) {
+	if o.OnEvent != nil {
+		o.OnEvent(line)
+	}
+}
+
+// Report forwards p to OnProgress when a listener is set.
+func (o Options) Report(p Progress) {
+	if o.OnProgress != nil {
+		o.OnProgress(p)
+	}
 }
 
 type Backend interface {
@@ -17,3 +242,67 @@ type Backend interface {
 	Models() []string
 	DefaultModel() string
 }
+
+// ConfiguredBackend is implemented by backends that call an API instead of
+// a CLI in PATH. Configured returns an error wrapping ErrNotConfigured that
+// names the missing settings, or nil when the backend can run.
+type ConfiguredBackend interface {
+	Configured() error
+}
+
+// SamplingBackend is implemented by backends that can pass Temperature and
+// Seed through to the model.
+type SamplingBackend interface {
+	SupportsSampling() (temperature, seed bool)
+}
+
+// Reasoning effort levels.
+const (
+	ReasoningLow    = "low"
+	ReasoningMedium = "medium"
+	ReasoningHigh   = "high"
+)
+
+// ReasoningLevels lists the accepted Options.Reasoning values.
+var ReasoningLevels = []string{ReasoningLow, ReasoningMedium, ReasoningHigh}
+
+// ReasoningBackend is implemented by backends that can adjust how much the
+// model reasons before answering.
+type ReasoningBackend interface {
+	SupportsReasoning() bool
+}
+
+// SupportsReasoning reports whether b honours Options.Reasoning.
+func SupportsReasoning(b Backend) bool {
+	r, ok := b.(ReasoningBackend)
+	return ok && r.SupportsReasoning()
+}
+
+// StructuredBackend is implemented by backends that can constrain the
+// response to a JSON schema.
+type StructuredBackend interface {
+	SupportsStructured() bool
+}
+
+// SupportsStructured reports whether b honours Options.Structured.
+func SupportsStructured(b Backend) bool {
+	s, ok := b.(StructuredBackend)
+	return ok && s.SupportsStructured()
+}
+
+// UnsupportedSampling returns the sampling options set in opts that b
+// cannot honour ("temperature", "seed").
+func UnsupportedSampling(b Backend, opts Options) []string {
+	var temperature, seed bool
+	if s, ok := b.(SamplingBackend); ok {
+		temperature, seed = s.SupportsSampling()
+	}
+	var unsupported []string
+	if opts.Temperature != nil && !temperature {
+		unsupported = append(unsupported, "temperature")
+	}
+	if opts.Seed != nil && !seed {
+		unsupported = append(unsupported, "seed")
+	}
+	return unsupported
+}
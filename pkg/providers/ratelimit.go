@@ -0,0 +1,177 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+const (
+	// DefaultRateLimitWait is how long Retry waits in total for rate limits
+	// to clear when Options.RateLimitWait is zero.
+	DefaultRateLimitWait = 2 * time.Minute
+	// firstBackoff is the first wait when the provider gives no hint; it
+	// doubles with every attempt up to maxBackoff.
+	firstBackoff = 5 * time.Second
+	maxBackoff   = time.Minute
+)
+
+var (
+	rateLimitPattern = regexp.MustCompile(`(?i)\b429\b|rate[ _-]?limit|too many requests|resource_exhausted|quota exceeded|exceeded your current quota`)
+	// retryHintPattern matches hints such as "Please try again in 20s",
+	// "retry in 12.5s", "retry after 2 minutes" and "retryDelay": "38s".
+	retryHintPattern = regexp.MustCompile(`(?i)(?:(?:retry|try again)\s+(?:in|after)\s+|"retryDelay"\s*:\s*")(\d+(?:\.\d+)?)\s*(ms|milliseconds?|s|secs?|seconds?|m|mins?|minutes?|h|hours?|d|days?)\b`)
+)
+
+// RateLimitError reports that a backend turned a request down because of a
+// rate limit or quota. It matches ErrRateLimited with errors.Is.
+type RateLimitError struct {
+	Err error
+	// RetryAfter is the wait the provider asked for; zero without a hint.
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string { return e.Err.Error() }
+func (e *RateLimitError) Unwrap() error { return e.Err }
+
+func (e *RateLimitError) Is(target error) bool { return target == ErrRateLimited }
+
+// CheckRateLimit returns err as a *RateLimitError when err or detail (the
+// error output of the backend, such as its stderr or error event) reports
+// a rate limit, with the wait of a retry hint in either; otherwise err.
+func CheckRateLimit(err error, detail string) error {
+	if err == nil {
+		return nil
+	}
+	text := err.Error() + "\n" + detail
+	if !rateLimitPattern.MatchString(text) {
+		return err
+	}
+	return &RateLimitError{Err: err, RetryAfter: RetryHint(text)}
+}
+
+// RetryHint returns the wait of the first retry hint in text, or zero.
+func RetryHint(text string) time.Duration {
+	m := retryHintPattern.FindStringSubmatch(text)
+	if m == nil {
+		return 0
+	}
+	n, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0
+	}
+	unit := time.Second
+	switch u := strings.ToLower(m[2]); {
+	case strings.HasPrefix(u, "ms"), strings.HasPrefix(u, "milli"):
+		unit = time.Millisecond
+	case strings.HasPrefix(u, "m"):
+		unit = time.Minute
+	case strings.HasPrefix(u, "h"):
+		unit = time.Hour
+	case strings.HasPrefix(u, "d"):
+		unit = 24 * time.Hour
+	}
+	return time.Duration(n * float64(unit))
+}
+
+// RetryAfterHeader returns the wait of the retry-after-ms or Retry-After
+// (seconds or an HTTP date) response header, or zero.
+func RetryAfterHeader(h http.Header) time.Duration {
+	if ms, err := strconv.ParseFloat(strings.TrimSpace(h.Get("Retry-After-Ms")), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	v := strings.TrimSpace(h.Get("Retry-After"))
+	if secs, err := strconv.ParseFloat(v, 64); err == nil && secs > 0 {
+		return time.Duration(secs * float64(time.Second))
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		return max(time.Until(at), 0)
+	}
+	return 0
+}
+
+// Retry runs generate and runs it again while it fails with a
+// *RateLimitError, waiting for the hinted time (or backing off without a
+// hint) as long as the waits fit in opts.RateLimitWait. The wait is shown
+// in the spinner and an interrupt forwarded to reg ends it. A run stopped
+// by its Watchdog is retried once before Retry fails with ErrStalled.
+// Other failures are returned through Diagnose.
+func Retry(ctx context.Context, reg *Registry, opts Options, backend string, generate func() (string, error)) (string, error) {
+	budget := opts.RateLimitWait
+	if budget == 0 {
+		budget = DefaultRateLimitWait
+	}
+	var (
+		waited  time.Duration
+		stalled bool
+	)
+	for attempt := 1; ; attempt++ {
+		msg, err := generate()
+		if err != nil && reg.WasStalled() {
+			if stalled {
+				return "", fmt.Errorf("%s %w: no output for %s (see GIT_AI_STALL_TIMEOUT)", backend, ErrStalled, opts.stallTimeout())
+			}
+			stalled = true
+			fmt.Fprintf(ui.Status(), "warning: %s produced no output for %s; retrying\n", backend, opts.stallTimeout())
+			continue
+		}
+		var rl *RateLimitError
+		if err == nil || budget < 0 || !errors.As(err, &rl) {
+			return msg, Diagnose(backend, err, "")
+		}
+		wait := rl.RetryAfter
+		if wait <= 0 {
+			wait = min(firstBackoff<<(attempt-1), maxBackoff)
+		}
+		if waited+wait > budget {
+			return "", Diagnose(backend, fmt.Errorf("%w (rate limited; a retry in %s would exceed the %s wait budget, see GIT_AI_RATE_LIMIT_WAIT)", err, wait.Round(time.Second), budget), "")
+		}
+		if err = waitRateLimit(ctx, reg, opts, backend, wait, attempt+1); err != nil {
+			return "", err
+		}
+		waited += wait
+	}
+}
+
+// waitRateLimit sleeps for wait before the given attempt, counting down in
+// the spinner.
+func waitRateLimit(ctx context.Context, reg *Registry, opts Options, backend string, wait time.Duration, attempt int) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	opts.Report(Progress{Phase: PhaseWaiting, Wait: wait})
+	message := fmt.Sprintf("%s is rate limited; retrying in %s (attempt %d)", backend, wait.Round(time.Second), attempt)
+	stopSpinner := func() {}
+	if opts.ShowSpinner {
+		stopSpinner = ui.StartSpinner(message, backend, reg)
+	} else {
+		fmt.Fprintln(ui.Status(), message)
+	}
+	defer stopSpinner()
+	reg.RegisterCancel(cancel, stopSpinner)
+	defer reg.Unregister()
+
+	deadline := time.Now().Add(wait)
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+	for {
+		select {
+		case <-timer.C:
+			return nil
+		case <-tick.C:
+			if opts.ShowSpinner && !ui.Plain() {
+				ui.SendSpinnerReasoning(fmt.Sprintf("Retrying in %s", time.Until(deadline).Round(time.Second)))
+			}
+		case <-ctx.Done():
+			return fmt.Errorf("%s rate-limit wait %w", backend, ErrInterrupted)
+		}
+	}
+}
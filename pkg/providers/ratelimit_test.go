@@ -0,0 +1,129 @@
+package providers
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCheckRateLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     string
+		detail  string
+		limited bool
+		wait    time.Duration
+	}{
+		{
+			name:    "codex usage limit",
+			err:     "codex invocation failed: Rate limit reached for gpt-5 on tokens per min (TPM). Please try again in 1.5s.",
+			limited: true,
+			wait:    1500 * time.Millisecond,
+		},
+		{
+			name:    "gemini quota in stderr",
+			err:     "gemini invocation failed: exit status 1",
+			detail:  `[API Error: {"error":{"code":429,"status":"RESOURCE_EXHAUSTED","details":[{"retryDelay":"38s"}]}}]`,
+			limited: true,
+			wait:    38 * time.Second,
+		},
+		{
+			name:    "claude without hint",
+			err:     "claude invocation failed",
+			detail:  `API Error: 429 {"type":"error","error":{"type":"rate_limit_error"}}`,
+			limited: true,
+		},
+		{
+			name:    "retry after minutes",
+			err:     "Too Many Requests: retry after 2 minutes",
+			limited: true,
+			wait:    2 * time.Minute,
+		},
+		{
+			name: "other failure",
+			err:  "codex invocation failed: model not found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orig := errors.New(tt.err)
+			err := CheckRateLimit(orig, tt.detail)
+			var rl *RateLimitError
+			if got := errors.As(err, &rl); got != tt.limited {
+				t.Fatalf("CheckRateLimit() = %v, rate limited %v, want %v", err, got, tt.limited)
+			}
+			if !errors.Is(err, orig) {
+				t.Errorf("CheckRateLimit() does not wrap the original error")
+			}
+			if tt.limited && rl.RetryAfter != tt.wait {
+				t.Errorf("RetryAfter = %s, want %s", rl.RetryAfter, tt.wait)
+			}
+		})
+	}
+}
+
+func TestRetryAfterHeader(t *testing.T) {
+	tests := []struct {
+		header http.Header
+		want   time.Duration
+	}{
+		{http.Header{"Retry-After": {"12"}}, 12 * time.Second},
+		{http.Header{"Retry-After-Ms": {"250"}, "Retry-After": {"1"}}, 250 * time.Millisecond},
+		{http.Header{"Retry-After": {"soon"}}, 0},
+		{http.Header{}, 0},
+	}
+	for _, tt := range tests {
+		if got := RetryAfterHeader(tt.header); got != tt.want {
+			t.Errorf("RetryAfterHeader(%v) = %s, want %s", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	limited := &RateLimitError{Err: errors.New("429 Too Many Requests"), RetryAfter: 10 * time.Millisecond}
+	tests := []struct {
+		name     string
+		budget   time.Duration
+		failures int
+		wantRuns int
+		wantErr  error
+	}{
+		{name: "succeeds after waits", budget: time.Second, failures: 2, wantRuns: 3},
+		{name: "budget exhausted", budget: 25 * time.Millisecond, failures: 5, wantRuns: 3, wantErr: ErrRateLimited},
+		{name: "disabled", budget: -1, failures: 1, wantRuns: 1, wantErr: ErrRateLimited},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runs := 0
+			msg, err := Retry(t.Context(), &Registry{}, Options{RateLimitWait: tt.budget}, "codex", func() (string, error) {
+				runs++
+				if runs <= tt.failures {
+					return "", limited
+				}
+				return "feat: ok", nil
+			})
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && msg != "feat: ok") {
+				t.Fatalf("Retry() = %q, %v, want error %v", msg, err, tt.wantErr)
+			}
+			if runs != tt.wantRuns {
+				t.Errorf("runs = %d, want %d", runs, tt.wantRuns)
+			}
+		})
+	}
+}
+
+func TestRetryInterrupted(t *testing.T) {
+	var reg Registry
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		reg.ForwardSignal(os.Interrupt)
+	}()
+	_, err := Retry(t.Context(), &reg, Options{}, "codex", func() (string, error) {
+		return "", &RateLimitError{Err: errors.New("rate limited"), RetryAfter: time.Minute}
+	})
+	if !errors.Is(err, ErrInterrupted) {
+		t.Fatalf("Retry() error = %v, want ErrInterrupted", err)
+	}
+}
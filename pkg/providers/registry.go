@@ -4,13 +4,16 @@ import (
 	"os"
 	"os/exec"
 	"sync"
+	"syscall"
 )
 
 type Registry struct {
 	mu          sync.Mutex
 	cmd         *exec.Cmd
+	cancel      func()
 	stopSpinner func()
 	interrupted bool
+	stalled     bool
 }
 
 func (r *Registry) Register(cmd *exec.Cmd, stopSpinner func()) {
@@ -19,12 +22,26 @@ func (r *Registry) Register(cmd *exec.Cmd, stopSpinner func()) {
 	r.cmd = cmd
 	r.stopSpinner = stopSpinner
 	r.interrupted = false
+	r.stalled = false
+}
+
+// RegisterCancel registers an in-process run, such as the HTTP request of an
+// API backend, that an interrupt cancels by calling cancel instead of
+// signalling a process.
+func (r *Registry) RegisterCancel(cancel func(), stopSpinner func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cancel = cancel
+	r.stopSpinner = stopSpinner
+	r.interrupted = false
+	r.stalled = false
 }
 
 func (r *Registry) Unregister() {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.cmd = nil
+	r.cancel = nil
 	r.stopSpinner = nil
 }
 
@@ -34,13 +51,43 @@ func (r *Registry) WasInterrupted() bool {
 	return r.interrupted
 }
 
+// WasStalled reports whether the last run was stopped by a Watchdog.
+func (r *Registry) WasStalled() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stalled
+}
+
+// stall stops the registered run because it stopped producing output: an
+// in-process run is cancelled and a process group terminated.
+func (r *Registry) stall() {
+	r.mu.Lock()
+	cmd, cancel := r.cmd, r.cancel
+	r.stalled = true
+	r.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	if cmd == nil || cmd.Process == nil {
+		return
+	}
+	if forwardToProcessGroup(cmd, syscall.SIGTERM) {
+		return
+	}
+	_ = cmd.Process.Kill()
+}
+
 func (r *Registry) ForwardSignal(sig os.Signal) {
 	r.mu.Lock()
-	cmd := r.cmd
-	if sig == os.Interrupt {
+	cmd, cancel := r.cmd, r.cancel
+	interrupt := sig == os.Interrupt || sig == syscall.SIGTERM
+	if interrupt {
 		r.interrupted = true
 	}
 	r.mu.Unlock()
+	if cancel != nil && interrupt {
+		cancel()
+	}
 	if cmd == nil || cmd.Process == nil {
 		return
 	}
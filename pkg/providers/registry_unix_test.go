@@ -0,0 +1,59 @@
+//go:build !windows
+
+package providers
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestForwardSignalKillsProcessGroup(t *testing.T) {
+	tests := []struct {
+		name string
+		stop func(*Registry)
+	}{
+		{name: "interrupt", stop: func(r *Registry) { r.ForwardSignal(syscall.SIGTERM) }},
+		{name: "stall", stop: func(r *Registry) { r.stall() }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The shell starts a child in its process group, as backend CLIs
+			// start tools and helpers. The child holds the pipe open, so
+			// reading it to the end shows that the child is gone too.
+			r, w, err := os.Pipe()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+			cmd := exec.Command("sh", "-c", "sleep 30 & echo started; wait")
+			cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+			cmd.Stdout = w
+			err = cmd.Start()
+			w.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			out := bufio.NewReader(r)
+			if _, err = out.ReadString('\n'); err != nil {
+				t.Fatal(err)
+			}
+
+			var reg Registry
+			reg.Register(cmd, nil)
+			tt.stop(&reg)
+			_ = cmd.Wait()
+
+			if err = r.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+				t.Fatal(err)
+			}
+			if _, err = io.Copy(io.Discard, out); err != nil {
+				t.Fatalf("the child of the process group is still running: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package providers
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+const (
+	// DefaultStallTimeout is how long a backend may run without output
+	// when Options.StallTimeout is zero.
+	DefaultStallTimeout = 5 * time.Minute
+	// stallWarning is the silence after which the spinner warns that the
+	// backend may be stalled.
+	stallWarning = 30 * time.Second
+	// stallCheck is how often the watchdog looks at the last output.
+	stallCheck = time.Second
+)
+
+// Watchdog stops a backend run that produces no output for the stall
+// timeout, as a hung CLI would otherwise leave the spinner running
+// forever. Backends call Alive for every event they read.
+type Watchdog struct {
+	mu      sync.Mutex
+	last    time.Time
+	warned  bool
+	stop    chan struct{}
+	stopped sync.Once
+}
+
+// WatchStall starts a Watchdog for the run registered in reg. It warns in
+// the spinner after stallWarning without output and stops the run after
+// opts.StallTimeout; Retry then reports ErrStalled. Call Stop when the
+// run ends.
+func (o Options) WatchStall(reg *Registry) *Watchdog {
+	w := &Watchdog{last: time.Now(), stop: make(chan struct{})}
+	timeout := o.stallTimeout()
+	if timeout < 0 {
+		return w
+	}
+	go func() {
+		tick := time.NewTicker(min(stallCheck, timeout))
+		defer tick.Stop()
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-tick.C:
+			}
+			w.mu.Lock()
+			idle := time.Since(w.last)
+			warn := !w.warned && idle >= min(stallWarning, timeout)
+			if warn {
+				w.warned = true
+			}
+			w.mu.Unlock()
+			if idle >= timeout {
+				ui.Debugf("no backend output for %s; stopping it", idle.Round(time.Second))
+				reg.stall()
+				return
+			}
+			if warn && o.ShowSpinner {
+				ui.SendSpinnerReasoning(fmt.Sprintf("No output for %s; the backend may be stalled (giving up after %s)", idle.Round(time.Second), timeout))
+			}
+		}
+	}()
+	return w
+}
+
+// Alive records output from the backend.
+func (w *Watchdog) Alive() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.last = time.Now()
+	w.warned = false
+}
+
+// Stop ends the watchdog.
+func (w *Watchdog) Stop() {
+	w.stopped.Do(func() { close(w.stop) })
+}
+
+// stallTimeout returns the effective stall timeout of o.
+func (o Options) stallTimeout() time.Duration {
+	if o.StallTimeout == 0 {
+		return DefaultStallTimeout
+	}
+	return o.StallTimeout
+}
@@ -0,0 +1,66 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryStalled(t *testing.T) {
+	tests := []struct {
+		name     string
+		stalls   int
+		wantRuns int
+		wantErr  error
+	}{
+		{name: "recovers on retry", stalls: 1, wantRuns: 2},
+		{name: "stalls twice", stalls: 2, wantRuns: 2, wantErr: ErrStalled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				reg  Registry
+				runs int
+				opts = Options{StallTimeout: 20 * time.Millisecond}
+			)
+			msg, err := Retry(t.Context(), &reg, opts, "codex", func() (string, error) {
+				runs++
+				ctx, cancel := context.WithCancel(t.Context())
+				defer cancel()
+				reg.RegisterCancel(cancel, nil)
+				defer reg.Unregister()
+				watch := opts.WatchStall(&reg)
+				defer watch.Stop()
+				if runs <= tt.stalls {
+					<-ctx.Done()
+					return "", ctx.Err()
+				}
+				return "feat: ok", nil
+			})
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && msg != "feat: ok") {
+				t.Fatalf("Retry() = %q, %v, want error %v", msg, err, tt.wantErr)
+			}
+			if runs != tt.wantRuns {
+				t.Errorf("runs = %d, want %d", runs, tt.wantRuns)
+			}
+		})
+	}
+}
+
+func TestWatchStallAlive(t *testing.T) {
+	var (
+		reg  Registry
+		opts = Options{StallTimeout: 50 * time.Millisecond}
+	)
+	reg.RegisterCancel(func() {}, nil)
+	watch := opts.WatchStall(&reg)
+	defer watch.Stop()
+	for range 10 {
+		time.Sleep(10 * time.Millisecond)
+		watch.Alive()
+	}
+	if reg.WasStalled() {
+		t.Fatal("run with steady output was stopped as stalled")
+	}
+}
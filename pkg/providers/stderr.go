@@ -0,0 +1,67 @@
+package providers
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+// stderrTailBytes is how much of a backend's stderr StderrTail keeps.
+const stderrTailBytes = 4 << 10
+
+// StderrTail is the stderr of a backend CLI: it keeps the last
+// stderrTailBytes written, so the output can be reported with an error
+// instead of being printed over the spinner. It is safe for concurrent
+// use.
+type StderrTail struct {
+	mu  sync.Mutex
+	buf []byte
+	// cut is set when the oldest kept line lost its start.
+	cut bool
+}
+
+// Write keeps the end of p, dropping the oldest output beyond
+// stderrTailBytes.
+func (t *StderrTail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if over := len(t.buf) - stderrTailBytes; over > 0 {
+		t.cut = t.buf[over-1] != '\n'
+		t.buf = append(t.buf[:0], t.buf[over:]...)
+	}
+	return len(p), nil
+}
+
+// String returns the kept output without surrounding blank space,
+// starting at the first complete line.
+func (t *StderrTail) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	text := string(t.buf)
+	if t.cut {
+		if _, rest, ok := strings.Cut(text, "\n"); ok {
+			text = rest
+		}
+	}
+	return strings.TrimSpace(text)
+}
+
+// Wrap adds the kept output to err; err is returned as is when nothing
+// was written.
+func (t *StderrTail) Wrap(err error) error {
+	tail := t.String()
+	if err == nil || tail == "" {
+		return err
+	}
+	return fmt.Errorf("%w\nstderr:\n%s", err, tail)
+}
+
+// Debug logs the kept output of backend in verbose mode.
+func (t *StderrTail) Debug(backend string) {
+	if tail := t.String(); tail != "" {
+		ui.Debugf("%s stderr:\n%s", backend, tail)
+	}
+}
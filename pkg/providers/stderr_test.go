@@ -0,0 +1,31 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestStderrTail(t *testing.T) {
+	var tail StderrTail
+	for i := range 1000 {
+		fmt.Fprintf(&tail, "line %d\n", i)
+	}
+	got := tail.String()
+	if len(got) > stderrTailBytes {
+		t.Fatalf("kept %d bytes, want at most %d", len(got), stderrTailBytes)
+	}
+	if !strings.HasPrefix(got, "line ") || !strings.HasSuffix(got, "line 999") {
+		t.Errorf("tail = %q..., want complete lines ending in line 999", got[:20])
+	}
+
+	err := tail.Wrap(errors.New("codex invocation failed"))
+	if !strings.HasPrefix(err.Error(), "codex invocation failed\nstderr:\n") {
+		t.Errorf("Wrap() = %q", err)
+	}
+	var empty StderrTail
+	if err := empty.Wrap(errors.New("failed")); err.Error() != "failed" {
+		t.Errorf("Wrap() without output = %q, want the error as is", err)
+	}
+}
@@ -0,0 +1,126 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/ui"
+)
+
+// Tool access levels for Options.Tools.
+const (
+	// ToolsNone lets the model use no tools: it works from the prompt
+	// alone and cannot run commands or change files.
+	ToolsNone = "none"
+	// ToolsAll keeps the backend CLI's own tool defaults.
+	ToolsAll = "all"
+)
+
+// ToolList returns the tools of a tool list in Options.Tools, in the
+// backend's syntax (e.g. claude's "Bash(git log:*)"), or nil for ToolsNone
+// and ToolsAll. Items are separated by commas outside parentheses.
+func (o Options) ToolList() []string {
+	switch t := strings.TrimSpace(o.Tools); strings.ToLower(t) {
+	case "", ToolsNone, ToolsAll:
+		return nil
+	default:
+		var (
+			tools []string
+			depth int
+			start int
+		)
+		for i, r := range t {
+			switch {
+			case r == '(':
+				depth++
+			case r == ')' && depth > 0:
+				depth--
+			case r == ',' && depth == 0:
+				tools = appendTool(tools, t[start:i])
+				start = i + 1
+			}
+		}
+		return appendTool(tools, t[start:])
+	}
+}
+
+// UnrestrictedTools reports whether Options.Tools is ToolsAll.
+func (o Options) UnrestrictedTools() bool {
+	return strings.EqualFold(strings.TrimSpace(o.Tools), ToolsAll)
+}
+
+func appendTool(tools []string, tool string) []string {
+	if tool = strings.TrimSpace(tool); tool != "" {
+		tools = append(tools, tool)
+	}
+	return tools
+}
+
+// deepContextTools are the read-only tools of deep context mode per
+// backend, in its tool syntax. Codex has no tool list; its read-only
+// sandbox keeps the investigation from changing anything.
+var deepContextTools = map[string]string{
+	"claude": "Bash(git log:*),Bash(git show:*),Bash(git blame:*),Bash(ls:*),Read,Grep,Glob",
+	"gemini": "run_shell_command(git log),run_shell_command(git show),run_shell_command(git blame),run_shell_command(ls),read_file,read_many_files,glob,search_file_content,list_directory",
+	"codex":  "shell",
+}
+
+// DeepContextTools returns the read-only tools of deep context mode for
+// backend, and false when the backend cannot use tools.
+func DeepContextTools(backend string) (string, bool) {
+	tools, ok := deepContextTools[backend]
+	return tools, ok
+}
+
+// DeepContextNote asks the model to investigate the intent of a change
+// with at most maxCalls read-only tool calls (0: no limit).
+func DeepContextNote(maxCalls int) string {
+	limit := ""
+	if maxCalls > 0 {
+		limit = fmt.Sprintf(" Use at most %d tool calls.", maxCalls)
+	}
+	return "Before writing the message you may investigate the repository with read-only commands (git log, git show, git blame, ls) and file reads to understand why the change was made, e.g. what the touched code is used for and how similar changes were described. Do not modify anything." + limit + " The diff remains the source of truth for what changed."
+}
+
+// ToolCalls follows the tool calls of one backend run: each is shown in
+// the spinner and reported as reasoning, and the run is cancelled once it
+// makes more than Options.MaxToolCalls.
+type ToolCalls struct {
+	opts     Options
+	cancel   context.CancelFunc
+	n        int
+	exceeded bool
+}
+
+// NewToolCalls returns a tracker that calls cancel, which must stop the
+// backend process, when the limit is exceeded.
+func (o Options) NewToolCalls(cancel context.CancelFunc) *ToolCalls {
+	return &ToolCalls{opts: o, cancel: cancel}
+}
+
+// Add records a tool call described by text.
+func (t *ToolCalls) Add(text string) {
+	t.n++
+	step := fmt.Sprintf("tool call %d: %s", t.n, text)
+	if t.opts.MaxToolCalls > 0 {
+		step = fmt.Sprintf("tool call %d/%d: %s", t.n, t.opts.MaxToolCalls, text)
+	}
+	if t.opts.ShowSpinner {
+		ui.SendSpinnerReasoning(step)
+	}
+	t.opts.Report(Progress{Phase: PhaseReasoning, Reasoning: step})
+	if t.opts.MaxToolCalls > 0 && t.n > t.opts.MaxToolCalls && !t.exceeded {
+		t.exceeded = true
+		t.cancel()
+	}
+}
+
+// Err returns ErrToolCallLimit, naming backend, when the run was stopped
+// for making too many tool calls, and nil otherwise.
+func (t *ToolCalls) Err(backend string) error {
+	if !t.exceeded {
+		return nil
+	}
+	return fmt.Errorf("%s: %w (%d allowed; raise GIT_AI_MAX_TOOL_CALLS)", backend, ErrToolCallLimit, t.opts.MaxToolCalls)
+}
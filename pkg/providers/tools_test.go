@@ -0,0 +1,28 @@
+package providers
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestToolList(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		tools string
+		want  []string
+	}{
+		{"", nil},
+		{"none", nil},
+		{"ALL", nil},
+		{"Read, Bash(git log:*,git show:*) ,Grep", []string{"Read", "Bash(git log:*,git show:*)", "Grep"}},
+	}
+	for _, tt := range tests {
+		if got := (Options{Tools: tt.tools}).ToolList(); !slices.Equal(got, tt.want) {
+			t.Errorf("ToolList(%q) = %q, want %q", tt.tools, got, tt.want)
+		}
+	}
+	if !(Options{Tools: "all"}).UnrestrictedTools() || (Options{}).UnrestrictedTools() {
+		t.Error("UnrestrictedTools() is only true for all")
+	}
+}
@@ -0,0 +1,258 @@
+package vertex
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// CredentialsEnv names a credentials file, overriding the file gcloud
+	// auth application-default login writes.
+	CredentialsEnv  = "GOOGLE_APPLICATION_CREDENTIALS"
+	defaultTokenURI = "https://oauth2.googleapis.com/token"
+	cloudPlatform   = "https://www.googleapis.com/auth/cloud-platform"
+	// metadataHostEnv overrides the GCE metadata server host.
+	metadataHostEnv     = "GCE_METADATA_HOST"
+	defaultMetadataHost = "metadata.google.internal"
+	// tokenSlack renews a cached token this long before it expires.
+	tokenSlack = time.Minute
+)
+
+var (
+	errNoCredentials = errors.New("no application default credentials found (run gcloud auth application-default login or set " + CredentialsEnv + ")")
+
+	tokenMu     sync.Mutex
+	cachedToken accessToken
+)
+
+// credentials is an application default credentials file: an
+// authorized_user file from gcloud or a service_account key.
+type credentials struct {
+	Type           string `json:"type"`
+	ClientID       string `json:"client_id"`
+	ClientSecret   string `json:"client_secret"`
+	RefreshToken   string `json:"refresh_token"`
+	ClientEmail    string `json:"client_email"`
+	PrivateKey     string `json:"private_key"`
+	PrivateKeyID   string `json:"private_key_id"`
+	TokenURI       string `json:"token_uri"`
+	ProjectID      string `json:"project_id"`
+	QuotaProjectID string `json:"quota_project_id"`
+}
+
+type accessToken struct {
+	value  string
+	expiry time.Time
+}
+
+// credentialsPath returns the credentials file to use: CredentialsEnv, or
+// the well-known file of gcloud when it exists.
+func credentialsPath() string {
+	if p := strings.TrimSpace(os.Getenv(CredentialsEnv)); p != "" {
+		return p
+	}
+	dir := os.Getenv("CLOUDSDK_CONFIG")
+	if dir == "" {
+		if runtime.GOOS == "windows" {
+			dir = filepath.Join(os.Getenv("APPDATA"), "gcloud")
+		} else if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, ".config", "gcloud")
+		}
+	}
+	p := filepath.Join(dir, "application_default_credentials.json")
+	if _, err := os.Stat(p); err != nil {
+		return ""
+	}
+	return p
+}
+
+// loadCredentials reads the credentials file; nil without one, in which
+// case the metadata server of the instance is asked for tokens.
+func loadCredentials() (*credentials, error) {
+	path := credentialsPath()
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials: %w", err)
+	}
+	var c credentials
+	if err = json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// token returns an OAuth2 access token for the cloud-platform scope, from
+// the cache while it is valid.
+func token(ctx context.Context) (string, error) {
+	tokenMu.Lock()
+	defer tokenMu.Unlock()
+	if cachedToken.value != "" && time.Now().Add(tokenSlack).Before(cachedToken.expiry) {
+		return cachedToken.value, nil
+	}
+	creds, err := loadCredentials()
+	if err != nil {
+		return "", err
+	}
+	var t accessToken
+	switch {
+	case creds == nil:
+		t, err = metadataToken(ctx)
+	case creds.Type == "authorized_user":
+		t, err = exchange(ctx, tokenURI(creds), url.Values{
+			"grant_type":    {"refresh_token"},
+			"client_id":     {creds.ClientID},
+			"client_secret": {creds.ClientSecret},
+			"refresh_token": {creds.RefreshToken},
+		})
+	case creds.Type == "service_account":
+		var assertion string
+		if assertion, err = signedJWT(creds); err == nil {
+			t, err = exchange(ctx, tokenURI(creds), url.Values{
+				"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+				"assertion":  {assertion},
+			})
+		}
+	default:
+		return "", fmt.Errorf("unsupported credentials type %q (use authorized_user or service_account)", creds.Type)
+	}
+	if err != nil {
+		return "", err
+	}
+	cachedToken = t
+	return t.value, nil
+}
+
+func tokenURI(c *credentials) string {
+	if c.TokenURI != "" {
+		return c.TokenURI
+	}
+	return defaultTokenURI
+}
+
+// tokenResponse is the reply of the token endpoint and the metadata server.
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func (r tokenResponse) token() accessToken {
+	return accessToken{value: r.AccessToken, expiry: time.Now().Add(time.Duration(r.ExpiresIn) * time.Second)}
+}
+
+// exchange posts form to the OAuth2 token endpoint.
+func exchange(ctx context.Context, endpoint string, form url.Values) (accessToken, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return accessToken{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return doToken(req)
+}
+
+// metadataToken asks the metadata server of a Google Cloud instance for a
+// token of its service account.
+func metadataToken(ctx context.Context) (accessToken, error) {
+	host := strings.TrimSpace(os.Getenv(metadataHostEnv))
+	if host == "" {
+		host = defaultMetadataHost
+	}
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+host+"/computeMetadata/v1/instance/service-accounts/default/token", nil)
+	if err != nil {
+		return accessToken{}, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	t, err := doToken(req)
+	if err != nil {
+		return accessToken{}, fmt.Errorf("%w: %v", errNoCredentials, err)
+	}
+	return t, nil
+}
+
+func doToken(req *http.Request) (accessToken, error) {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return accessToken{}, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return accessToken{}, err
+	}
+	var r tokenResponse
+	_ = json.Unmarshal(data, &r)
+	switch {
+	case resp.StatusCode != http.StatusOK && r.Error != "":
+		return accessToken{}, fmt.Errorf("token request failed (%s): %s %s", resp.Status, r.Error, r.ErrorDescription)
+	case resp.StatusCode != http.StatusOK:
+		return accessToken{}, fmt.Errorf("token request failed (%s)", resp.Status)
+	case r.AccessToken == "":
+		return accessToken{}, errors.New("token response has no access_token")
+	}
+	return r.token(), nil
+}
+
+// signedJWT returns the RS256-signed assertion a service account exchanges
+// for an access token.
+func signedJWT(c *credentials) (string, error) {
+	block, _ := pem.Decode([]byte(c.PrivateKey))
+	if block == nil {
+		return "", errors.New("service account private_key is not PEM")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return "", fmt.Errorf("failed to parse service account private_key: %w", err)
+		}
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return "", errors.New("service account private_key is not an RSA key")
+	}
+	now := time.Now()
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": c.PrivateKeyID})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]any{
+		"iss":   c.ClientEmail,
+		"scope": cloudPlatform,
+		"aud":   tokenURI(c),
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
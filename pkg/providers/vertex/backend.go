@@ -0,0 +1,28 @@
+package vertex
+
+import (
+	"context"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers/gemini"
+)
+
+type Backend struct{}
+
+func (Backend) Generate(ctx context.Context, reg *providers.Registry, opts providers.Options) (string, error) {
+	return providers.Retry(ctx, reg, opts, "vertex", func() (string, error) {
+		return Generate(ctx, reg, opts)
+	})
+}
+
+// Models returns the models of the gemini backend.
+func (Backend) Models() []string     { return gemini.Backend{}.Models() }
+func (Backend) DefaultModel() string { return gemini.Backend{}.DefaultModel() }
+
+// SupportsSampling reports that temperature and seed are sent in the
+// generation config.
+func (Backend) SupportsSampling() (bool, bool) { return true, true }
+
+// Configured reports whether a project is set or known from the
+// credentials.
+func (Backend) Configured() error { return configured() }
@@ -0,0 +1,136 @@
+// Package vertex generates commit messages with Gemini models on Vertex AI,
+// for organisations that allow Google Cloud but not the consumer gemini
+// CLI. Requests are authenticated with application default credentials and
+// use the same models as the gemini backend.
+package vertex
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers/chatapi"
+	"github.com/dlnilsson/git-cc-ai/pkg/providers/gemini"
+	"github.com/dlnilsson/git-cc-ai/pkg/stream"
+)
+
+const (
+	// ProjectEnv is the Google Cloud project; without it the project of the
+	// credentials is used.
+	ProjectEnv = "GOOGLE_CLOUD_PROJECT"
+	// LocationEnv is the Vertex AI region, or "global".
+	LocationEnv     = "GOOGLE_CLOUD_LOCATION"
+	defaultLocation = "us-central1"
+	// BaseURLEnv overrides the API endpoint, e.g. for Private Service
+	// Connect.
+	BaseURLEnv = "VERTEX_BASE_URL"
+)
+
+type part struct {
+	Text string `json:"text"`
+}
+
+type content struct {
+	Role  string `json:"role,omitempty"`
+	Parts []part `json:"parts"`
+}
+
+type generationConfig struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	Seed        *int64   `json:"seed,omitempty"`
+}
+
+type request struct {
+	Contents          []content         `json:"contents"`
+	SystemInstruction *content          `json:"systemInstruction,omitempty"`
+	GenerationConfig  *generationConfig `json:"generationConfig,omitempty"`
+}
+
+func resolveModel(model string) string {
+	if strings.TrimSpace(model) != "" {
+		return model
+	}
+	return gemini.Backend{}.DefaultModel()
+}
+
+// project returns ProjectEnv or the project of the credentials file.
+func project() string {
+	if p := strings.TrimSpace(os.Getenv(ProjectEnv)); p != "" {
+		return p
+	}
+	if c, err := loadCredentials(); err == nil && c != nil {
+		if c.QuotaProjectID != "" {
+			return c.QuotaProjectID
+		}
+		return c.ProjectID
+	}
+	return ""
+}
+
+func location() string {
+	if l := strings.TrimSpace(os.Getenv(LocationEnv)); l != "" {
+		return l
+	}
+	return defaultLocation
+}
+
+func baseURL(loc string) string {
+	if u := strings.TrimSpace(os.Getenv(BaseURLEnv)); u != "" {
+		return strings.TrimSuffix(u, "/")
+	}
+	if loc == "global" {
+		return "https://aiplatform.googleapis.com"
+	}
+	return "https://" + loc + "-aiplatform.googleapis.com"
+}
+
+// configured reports a missing project; credentials are only resolved when
+// a request is made, since the metadata server cannot be probed cheaply.
+func configured() error {
+	if project() == "" {
+		return chatapi.NotConfigured("vertex", ProjectEnv)
+	}
+	return nil
+}
+
+func Generate(ctx context.Context, reg *providers.Registry, opts providers.Options) (string, error) {
+	if err := configured(); err != nil {
+		return "", err
+	}
+	messages, err := chatapi.Messages(opts)
+	if err != nil {
+		return "", err
+	}
+	accessToken, err := token(ctx)
+	if err != nil {
+		return "", err
+	}
+	model := resolveModel(opts.Model)
+	loc := location()
+
+	var body request
+	for _, m := range messages {
+		c := content{Parts: []part{{Text: m.Content}}}
+		if m.Role == "system" {
+			body.SystemInstruction = &c
+			continue
+		}
+		c.Role = m.Role
+		body.Contents = append(body.Contents, c)
+	}
+	if opts.Temperature != nil || opts.Seed != nil {
+		body.GenerationConfig = &generationConfig{Temperature: opts.Temperature, Seed: opts.Seed}
+	}
+
+	ep := chatapi.Endpoint{
+		Backend: "vertex",
+		URL: baseURL(loc) + "/v1/projects/" + url.PathEscape(project()) + "/locations/" + url.PathEscape(loc) +
+			"/publishers/google/models/" + url.PathEscape(model) + ":streamGenerateContent?alt=sse",
+		Header: http.Header{"Authorization": {"Bearer " + accessToken}},
+		Decode: stream.DecodeVertex,
+	}
+	return chatapi.Generate(ctx, reg, opts, ep, model, body)
+}
@@ -0,0 +1,177 @@
+package vertex
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/providers"
+)
+
+const testDiff = `diff --git a/upload/client.go b/upload/client.go
+--- a/upload/client.go
++++ b/upload/client.go
+@@ -1 +1,2 @@
+ package upload
++// retry on 503
+`
+
+// serviceAccount writes a service account key whose token_uri points at
+// srv and returns the key for verifying assertions.
+func serviceAccount(t *testing.T, srv *httptest.Server) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(credentials{
+		Type:         "service_account",
+		ProjectID:    "acme-dev",
+		ClientEmail:  "git-ai@acme-dev.iam.gserviceaccount.com",
+		PrivateKeyID: "k1",
+		PrivateKey:   string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		TokenURI:     srv.URL + "/token",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "sa.json")
+	if err = os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(CredentialsEnv, path)
+	t.Setenv(ProjectEnv, "")
+	t.Setenv(LocationEnv, "europe-west4")
+	t.Setenv(BaseURLEnv, srv.URL)
+	t.Cleanup(func() { cachedToken = accessToken{} })
+	return key
+}
+
+// verifyAssertion checks the signature and audience of a JWT assertion.
+func verifyAssertion(key *rsa.PrivateKey, assertion, audience string) error {
+	parts := strings.Split(assertion, ".")
+	if len(parts) != 3 {
+		return errors.New("assertion is not a JWT")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err = rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, sum[:], sig); err != nil {
+		return err
+	}
+	claims, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return err
+	}
+	var c struct{ Aud, Scope string }
+	if err = json.Unmarshal(claims, &c); err != nil {
+		return err
+	}
+	if c.Aud != audience || c.Scope != cloudPlatform {
+		return errors.New("unexpected claims " + string(claims))
+	}
+	return nil
+}
+
+func TestGenerateReplaysStream(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/success.sse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		key       *rsa.PrivateKey
+		got       request
+		tokenHits int
+	)
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		tokenHits++
+		if err := verifyAssertion(key, r.FormValue("assertion"), srv.URL+"/token"); err != nil {
+			http.Error(w, `{"error":"invalid_grant","error_description":"`+err.Error()+`"}`, http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"ya29.test","expires_in":3599,"token_type":"Bearer"}`))
+	})
+	mux.HandleFunc("POST /v1/projects/acme-dev/locations/europe-west4/publishers/google/models/gemini-2.5-pro:streamGenerateContent", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer ya29.test" || r.URL.Query().Get("alt") != "sse" {
+			http.Error(w, `[{"error":{"code":401,"message":"Request had invalid authentication credentials.","status":"UNAUTHENTICATED"}}]`, http.StatusUnauthorized)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write(fixture)
+	})
+	key = serviceAccount(t, srv)
+
+	var reasoning []string
+	opts := providers.Options{
+		Diff:  testDiff,
+		Model: "gemini-2.5-pro",
+		OnProgress: func(p providers.Progress) {
+			if p.Phase == providers.PhaseReasoning {
+				reasoning = append(reasoning, p.Reasoning)
+			}
+		},
+	}
+	for range 2 {
+		msg, err := Generate(t.Context(), &providers.Registry{}, opts)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		want := "fix(upload): retry on 503\n\n# tokens: input=1810 cached=1024 output=121 elapsed="
+		if !strings.HasPrefix(msg, want) || !strings.HasSuffix(msg, " model=gemini-2.5-pro") {
+			t.Errorf("message = %q, want prefix %q", msg, want)
+		}
+	}
+	if tokenHits != 1 {
+		t.Errorf("token endpoint hit %d times, want 1 (cached)", tokenHits)
+	}
+	if len(reasoning) == 0 || reasoning[0] != "**Reading the diff** The upload client now retries." {
+		t.Errorf("reasoning = %q", reasoning)
+	}
+	if len(got.Contents) != 1 || got.Contents[0].Role != "user" || !strings.Contains(got.Contents[0].Parts[0].Text, "+// retry on 503") {
+		t.Errorf("request contents do not carry the diff: %+v", got.Contents)
+	}
+}
+
+func TestGenerateReportsAPIError(t *testing.T) {
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"access_token":"ya29.test","expires_in":3599}`))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `[{"error":{"code":403,"message":"Permission 'aiplatform.endpoints.predict' denied","status":"PERMISSION_DENIED"}}]`, http.StatusForbidden)
+	})
+	serviceAccount(t, srv)
+
+	_, err := Generate(t.Context(), &providers.Registry{}, providers.Options{Diff: testDiff})
+	want := "vertex request failed (403 Forbidden): Permission 'aiplatform.endpoints.predict' denied"
+	if err == nil || err.Error() != want {
+		t.Fatalf("Generate() error = %v, want %q", err, want)
+	}
+}
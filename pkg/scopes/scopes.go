@@ -0,0 +1,176 @@
+// Package scopes maps repository paths to canonical Conventional Commit
+// scopes, as configured in .git-ai/scopes.yaml:
+//
+//	scopes:
+//	  services/payments: payments
+//	  services/auth: auth
+//	  web: ui
+//	fallback: multi
+//
+// A change touching several scopes gets a comma-separated scope
+// ("auth,payments") unless fallback names a single scope to use instead.
+package scopes
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// File is the location of the mapping file relative to the repository root.
+const File = ".git-ai/scopes.yaml"
+
+// Rule maps every path under Prefix to Scope.
+type Rule struct {
+	Prefix string
+	Scope  string
+}
+
+// Map is a parsed scopes.yaml.
+type Map struct {
+	Rules []Rule
+	// Fallback is the scope used when a change spans several scopes. Empty
+	// means a comma-separated list of them.
+	Fallback string
+}
+
+// Load reads the mapping file at path. Returns nil (no error) if the file
+// does not exist.
+func Load(path string) (*Map, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	m, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return m, nil
+}
+
+// Parse reads the small YAML subset scopes.yaml uses: a "scopes" mapping of
+// path prefix to scope and an optional "fallback" scalar. Comments and
+// quoted scalars are supported.
+func Parse(r io.Reader) (*Map, error) {
+	var (
+		m        Map
+		inScopes bool
+		lineNo   int
+	)
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		lineNo++
+		raw := stripComment(sc.Text())
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		indented := raw[0] == ' ' || raw[0] == '\t'
+		key, value, ok := strings.Cut(strings.TrimSpace(raw), ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", lineNo)
+		}
+		key, value = unquote(strings.TrimSpace(key)), unquote(strings.TrimSpace(value))
+		switch {
+		case indented && inScopes:
+			if key == "" || value == "" {
+				return nil, fmt.Errorf("line %d: expected \"path: scope\"", lineNo)
+			}
+			m.Rules = append(m.Rules, Rule{Prefix: strings.Trim(path.Clean(key), "/"), Scope: value})
+		case indented:
+			return nil, fmt.Errorf("line %d: unexpected indentation", lineNo)
+		case key == "scopes":
+			if value != "" {
+				return nil, fmt.Errorf("line %d: scopes must be a mapping", lineNo)
+			}
+			inScopes = true
+		case key == "fallback":
+			inScopes = false
+			m.Fallback = value
+		default:
+			return nil, fmt.Errorf("line %d: unknown key %q", lineNo, key)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(m.Rules) == 0 {
+		return nil, errors.New("no scopes defined")
+	}
+	// Longest prefix first so the most specific rule wins.
+	sort.SliceStable(m.Rules, func(i, j int) bool { return len(m.Rules[i].Prefix) > len(m.Rules[j].Prefix) })
+	return &m, nil
+}
+
+func stripComment(line string) string {
+	if i := strings.Index(line, " #"); i != -1 {
+		line = line[:i]
+	}
+	if strings.HasPrefix(strings.TrimSpace(line), "#") {
+		return ""
+	}
+	return strings.TrimRight(line, " \t")
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// Scope returns the scope of file, or an empty string when no rule matches.
+func (m *Map) Scope(file string) string {
+	for _, r := range m.Rules {
+		if r.Prefix == "." || file == r.Prefix || strings.HasPrefix(file, r.Prefix+"/") {
+			return r.Scope
+		}
+	}
+	return ""
+}
+
+// Resolve returns the scope for a change touching files: the single mapped
+// scope, the fallback or a comma-separated list when several are touched,
+// or an empty string when none of the files is mapped.
+func (m *Map) Resolve(files []string) string {
+	var touched []string
+	for _, f := range files {
+		if s := m.Scope(f); s != "" && !slices.Contains(touched, s) {
+			touched = append(touched, s)
+		}
+	}
+	switch {
+	case len(touched) == 0:
+		return ""
+	case len(touched) == 1:
+		return touched[0]
+	case m.Fallback != "":
+		return m.Fallback
+	}
+	sort.Strings(touched)
+	return strings.Join(touched, ",")
+}
+
+// Allowed returns the distinct scopes the map can produce, sorted.
+func (m *Map) Allowed() []string {
+	allowed := make([]string, 0, len(m.Rules)+1)
+	for _, r := range m.Rules {
+		if !slices.Contains(allowed, r.Scope) {
+			allowed = append(allowed, r.Scope)
+		}
+	}
+	if m.Fallback != "" && !slices.Contains(allowed, m.Fallback) {
+		allowed = append(allowed, m.Fallback)
+	}
+	sort.Strings(allowed)
+	return allowed
+}
@@ -0,0 +1,61 @@
+package scopes
+
+import (
+	"strings"
+	"testing"
+)
+
+const sample = `# monorepo scopes
+scopes:
+  services/payments: payments
+  services/payments/api: payments-api
+  "services/auth": auth   # login and sessions
+  web: ui
+`
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+
+	m, err := Parse(strings.NewReader(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		files []string
+		want  string
+	}{
+		{[]string{"services/payments/charge.go"}, "payments"},
+		{[]string{"services/payments/api/v1.go"}, "payments-api"},
+		{[]string{"services/paymentsx/a.go"}, ""},
+		{[]string{"web/app.ts", "services/auth/login.go", "web/index.html"}, "auth,ui"},
+		{[]string{"README.md"}, ""},
+	}
+	for _, tt := range tests {
+		if got := m.Resolve(tt.files); got != tt.want {
+			t.Errorf("Resolve(%v) = %q, want %q", tt.files, got, tt.want)
+		}
+	}
+
+	m.Fallback = "multi"
+	if got := m.Resolve([]string{"web/a", "services/auth/b"}); got != "multi" {
+		t.Errorf("Resolve() with fallback = %q, want multi", got)
+	}
+	if got := strings.Join(m.Allowed(), ","); got != "auth,multi,payments,payments-api,ui" {
+		t.Errorf("Allowed() = %q", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, in := range []string{
+		"",
+		"scopes:\n  web\n",
+		"other: x\n",
+		"  web: ui\n",
+	} {
+		if _, err := Parse(strings.NewReader(in)); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", in)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+//go:build darwin
+
+package secrets
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// keychainLookup reads a generic password of the macOS Keychain, stored
+// with: security add-generic-password -s git-ai -a NAME -w
+func keychainLookup(name string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", Service, "-a", name, "-w").Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) || errors.Is(err, exec.ErrNotFound) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
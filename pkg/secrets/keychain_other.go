@@ -0,0 +1,23 @@
+//go:build !darwin && !windows
+
+package secrets
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// keychainLookup reads a secret of the Secret Service (GNOME Keyring,
+// KWallet) with secret-tool, stored with:
+// secret-tool store --label=NAME service git-ai account NAME
+func keychainLookup(name string) (string, error) {
+	out, err := exec.Command("secret-tool", "lookup", "service", Service, "account", name).Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) || errors.Is(err, exec.ErrNotFound) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
@@ -0,0 +1,71 @@
+//go:build windows
+
+package secrets
+
+import (
+	"errors"
+	"syscall"
+	"unicode/utf16"
+	"unsafe"
+)
+
+const (
+	credTypeGeneric = 1
+	errorNotFound   = syscall.Errno(1168)
+)
+
+var (
+	advapi32     = syscall.NewLazyDLL("advapi32.dll")
+	procCredRead = advapi32.NewProc("CredReadW")
+	procCredFree = advapi32.NewProc("CredFree")
+)
+
+// credential mirrors CREDENTIALW.
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// keychainLookup reads a generic credential of the Windows Credential
+// Manager with target git-ai:NAME, stored with:
+// cmdkey /generic:git-ai:NAME /user:git-ai /pass
+func keychainLookup(name string) (string, error) {
+	target, err := syscall.UTF16PtrFromString(Service + ":" + name)
+	if err != nil {
+		return "", err
+	}
+	var cred *credential
+	ok, _, err := procCredRead.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if ok == 0 {
+		if errors.Is(err, errorNotFound) {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+	blob := unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)
+	return decodeBlob(blob), nil
+}
+
+// decodeBlob returns the secret of a credential blob: UTF-16 as written by
+// cmdkey and the credential dialogs, or plain bytes.
+func decodeBlob(blob []byte) string {
+	if len(blob)%2 != 0 || len(blob) < 2 || blob[1] != 0 {
+		return string(blob)
+	}
+	units := make([]uint16, 0, len(blob)/2)
+	for i := 0; i+1 < len(blob); i += 2 {
+		units = append(units, uint16(blob[i])|uint16(blob[i+1])<<8)
+	}
+	return string(utf16.Decode(units))
+}
@@ -0,0 +1,114 @@
+// Package secrets looks up the API keys of the API backends without
+// requiring them in plaintext environment variables: a key is read from
+// its environment variable, from a command that prints it, or from the
+// keychain of the operating system.
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+const (
+	// CommandEnv is a command printing the key on stdout, such as
+	// "pass show mistral". It runs for every key that is not set otherwise,
+	// with NameEnv telling it which one is asked for; a <NAME>_CMD variable
+	// (e.g. MISTRAL_API_KEY_CMD) takes precedence for that key.
+	CommandEnv = "GIT_AI_KEY_CMD"
+	// NameEnv is set for the command to the variable being looked up.
+	NameEnv = "GIT_AI_KEY_NAME"
+	// CommandSuffix turns a variable name into the name of its own command.
+	CommandSuffix = "_CMD"
+	// Service is the keychain service the keys are stored under; the
+	// account is the variable name.
+	Service = "git-ai"
+)
+
+var (
+	// ErrNotFound is returned when no source has the key.
+	ErrNotFound = errors.New("secret not found")
+
+	// keychain reads a key from the keychain of the OS; tests replace it.
+	keychain = keychainLookup
+
+	mu    sync.Mutex
+	cache = map[string]string{}
+)
+
+// Lookup returns the key named by the environment variable name from, in
+// order: the variable itself, the command of name+CommandSuffix, the
+// command of CommandEnv, and the keychain. A key found is cached for the
+// life of the process so the command runs at most once; failures are not,
+// so a long-running server recovers once the keychain or password store
+// is unlocked. It returns ErrNotFound when no source has the key, and the
+// error of a command that fails.
+func Lookup(name string) (string, error) {
+	if v := strings.TrimSpace(os.Getenv(name)); v != "" {
+		return v, nil
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if value, ok := cache[name]; ok {
+		return value, nil
+	}
+	value, err := lookup(name)
+	if err == nil {
+		cache[name] = value
+	}
+	return value, err
+}
+
+func lookup(name string) (string, error) {
+	for _, env := range []string{name + CommandSuffix, CommandEnv} {
+		if command := strings.TrimSpace(os.Getenv(env)); command != "" {
+			return run(env, command, name)
+		}
+	}
+	value, err := keychain(name)
+	if err != nil {
+		return "", err
+	}
+	if value = strings.TrimSpace(value); value == "" {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+// run runs command through the shell and returns its trimmed output.
+// Stderr and, when it is a terminal, stdin are passed on, so the command
+// can ask for a passphrase. Other stdin, such as the JSON-RPC stream of
+// serve --stdio, is not the command's to read.
+func run(env, command, name string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	var out bytes.Buffer
+	cmd.Env = append(os.Environ(), NameEnv+"="+name)
+	if stdinIsTerminal() {
+		cmd.Stdin = os.Stdin
+	}
+	cmd.Stdout = &out
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s failed for %s: %w", env, name, err)
+	}
+	value := strings.TrimSpace(out.String())
+	if value == "" {
+		return "", fmt.Errorf("%s printed no key for %s", env, name)
+	}
+	return value, nil
+}
+
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
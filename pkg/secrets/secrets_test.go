@@ -0,0 +1,155 @@
+package secrets
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// stubKeychain replaces the OS keychain with values and clears the cache.
+func stubKeychain(t *testing.T, values map[string]string) *int {
+	t.Helper()
+	calls := 0
+	orig := keychain
+	keychain = func(name string) (string, error) {
+		calls++
+		if v, ok := values[name]; ok {
+			return v, nil
+		}
+		return "", ErrNotFound
+	}
+	t.Cleanup(func() {
+		keychain = orig
+		cache = map[string]string{}
+	})
+	cache = map[string]string{}
+	t.Setenv(CommandEnv, "")
+	return &calls
+}
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		chain   map[string]string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "environment wins",
+			env:   map[string]string{"MISTRAL_API_KEY": " env-key ", CommandEnv: "echo cmd-key"},
+			chain: map[string]string{"MISTRAL_API_KEY": "chain-key"},
+			want:  "env-key",
+		},
+		{
+			name: "own command before the shared one",
+			env:  map[string]string{"MISTRAL_API_KEY_CMD": "echo own-key", CommandEnv: "echo shared-key"},
+			want: "own-key",
+		},
+		{
+			name: "shared command gets the name",
+			env:  map[string]string{CommandEnv: `printf 'key-for-%s\n' "$GIT_AI_KEY_NAME"`},
+			want: "key-for-MISTRAL_API_KEY",
+		},
+		{
+			name:  "keychain",
+			chain: map[string]string{"MISTRAL_API_KEY": "chain-key\n"},
+			want:  "chain-key",
+		},
+		{
+			name:    "failing command",
+			env:     map[string]string{CommandEnv: "exit 3"},
+			chain:   map[string]string{"MISTRAL_API_KEY": "chain-key"},
+			wantErr: "GIT_AI_KEY_CMD failed for MISTRAL_API_KEY: exit status 3",
+		},
+		{
+			name:    "command without output",
+			env:     map[string]string{"MISTRAL_API_KEY_CMD": "true"},
+			wantErr: "MISTRAL_API_KEY_CMD printed no key for MISTRAL_API_KEY",
+		},
+		{
+			name:    "not found",
+			wantErr: ErrNotFound.Error(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubKeychain(t, tt.chain)
+			t.Setenv("MISTRAL_API_KEY", "")
+			t.Setenv("MISTRAL_API_KEY_CMD", "")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			got, err := Lookup("MISTRAL_API_KEY")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Lookup() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Lookup() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Lookup() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookupCaches(t *testing.T) {
+	calls := stubKeychain(t, map[string]string{"AZURE_OPENAI_API_KEY": "chain-key"})
+	t.Setenv("AZURE_OPENAI_API_KEY", "")
+	for range 3 {
+		if got, err := Lookup("AZURE_OPENAI_API_KEY"); err != nil || got != "chain-key" {
+			t.Fatalf("Lookup() = %q, %v, want chain-key", got, err)
+		}
+	}
+	if *calls != 1 {
+		t.Errorf("keychain read %d times, want 1", *calls)
+	}
+}
+
+func TestLookupRetriesFailures(t *testing.T) {
+	calls := stubKeychain(t, nil)
+	t.Setenv("AZURE_OPENAI_API_KEY", "")
+	for range 3 {
+		if _, err := Lookup("AZURE_OPENAI_API_KEY"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("Lookup() error = %v, want ErrNotFound", err)
+		}
+	}
+	if *calls != 3 {
+		t.Errorf("keychain read %d times, want 3", *calls)
+	}
+}
+
+func TestLookupKeepsPipedStdin(t *testing.T) {
+	stubKeychain(t, nil)
+	t.Setenv("MISTRAL_API_KEY", "")
+	t.Setenv("MISTRAL_API_KEY_CMD", "cat")
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if _, err = w.WriteString(`{"jsonrpc":"2.0","id":1,"method":"models"}` + "\n"); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	orig := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() { os.Stdin = orig })
+
+	if _, err = Lookup("MISTRAL_API_KEY"); err == nil {
+		t.Fatal("the key command read the piped stdin")
+	}
+	rest, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(rest), `{"jsonrpc"`) {
+		t.Fatalf("stdin left = %q, want the request untouched", rest)
+	}
+}
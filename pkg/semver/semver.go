@@ -0,0 +1,134 @@
+// Package semver derives the next semantic version bump from Conventional
+// Commit messages, in the spirit of semantic-release's commit analyzer.
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/ccparse"
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+)
+
+// Bump is the size of a version increment.
+type Bump int
+
+const (
+	BumpNone Bump = iota
+	BumpPatch
+	BumpMinor
+	BumpMajor
+)
+
+func (b Bump) String() string {
+	switch b {
+	case BumpPatch:
+		return "patch"
+	case BumpMinor:
+		return "minor"
+	case BumpMajor:
+		return "major"
+	default:
+		return "none"
+	}
+}
+
+// MarshalText encodes the bump by name.
+func (b Bump) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// Driver is a commit that contributes to the release bump.
+type Driver struct {
+	Hash   string `json:"hash"`
+	Header string `json:"header"`
+	Bump   Bump   `json:"bump"`
+}
+
+// BumpFor returns the bump a single commit message calls for: breaking
+// changes are major, feat is minor, fix and perf are patch.
+func BumpFor(msg string) Bump {
+	c, err := ccparse.Parse(msg)
+	switch {
+	case err != nil:
+		return BumpNone
+	case c.IsBreaking():
+		return BumpMajor
+	case strings.EqualFold(c.Type, "feat"):
+		return BumpMinor
+	case strings.EqualFold(c.Type, "fix"), strings.EqualFold(c.Type, "perf"):
+		return BumpPatch
+	default:
+		return BumpNone
+	}
+}
+
+// Analyze returns the overall bump for entries and the commits that drove
+// it (every commit with a non-zero bump), in input order.
+func Analyze(entries []git.LogEntry) (Bump, []Driver) {
+	var (
+		overall Bump
+		drivers []Driver
+	)
+	for _, e := range entries {
+		b := BumpFor(e.Message)
+		if b == BumpNone {
+			continue
+		}
+		overall = max(overall, b)
+		c, _ := ccparse.Parse(e.Message)
+		drivers = append(drivers, Driver{Hash: e.Hash, Header: c.Header(), Bump: b})
+	}
+	return overall, drivers
+}
+
+// Version is a MAJOR.MINOR.PATCH version with an optional "v" prefix.
+type Version struct {
+	Prefix string
+	Major  int
+	Minor  int
+	Patch  int
+}
+
+// ParseVersion parses tags such as "v1.4.0" or "2.0.1". Pre-release and
+// build suffixes are not supported.
+func ParseVersion(s string) (Version, bool) {
+	var v Version
+	s = strings.TrimSpace(s)
+	if rest, ok := strings.CutPrefix(s, "v"); ok {
+		v.Prefix, s = "v", rest
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return Version{}, false
+	}
+	nums := make([]int, 0, 3)
+	for _, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return Version{}, false
+		}
+		nums = append(nums, n)
+	}
+	v.Major, v.Minor, v.Patch = nums[0], nums[1], nums[2]
+	return v, true
+}
+
+// Apply returns v incremented by b.
+func (v Version) Apply(b Bump) Version {
+	switch b {
+	case BumpMajor:
+		return Version{Prefix: v.Prefix, Major: v.Major + 1}
+	case BumpMinor:
+		return Version{Prefix: v.Prefix, Major: v.Major, Minor: v.Minor + 1}
+	case BumpPatch:
+		return Version{Prefix: v.Prefix, Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+	default:
+		return v
+	}
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%s%d.%d.%d", v.Prefix, v.Major, v.Minor, v.Patch)
+}
@@ -0,0 +1,48 @@
+package semver
+
+import (
+	"testing"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+)
+
+func TestAnalyze(t *testing.T) {
+	t.Parallel()
+
+	entries := []git.LogEntry{
+		{Hash: "c", Message: "docs: readme"},
+		{Hash: "b", Message: "feat(api): add endpoint"},
+		{Hash: "a", Message: "fix: typo"},
+	}
+	bump, drivers := Analyze(entries)
+	if bump != BumpMinor {
+		t.Fatalf("bump = %s, want minor", bump)
+	}
+	if len(drivers) != 2 || drivers[0].Hash != "b" {
+		t.Fatalf("unexpected drivers: %+v", drivers)
+	}
+
+	bump, _ = Analyze(append(entries, git.LogEntry{Hash: "d", Message: "refactor: x\n\nBREAKING CHANGE: removed y"}))
+	if bump != BumpMajor {
+		t.Fatalf("bump = %s, want major", bump)
+	}
+}
+
+func TestVersionApply(t *testing.T) {
+	t.Parallel()
+
+	v, ok := ParseVersion("v1.4.2")
+	if !ok {
+		t.Fatal("failed to parse version")
+	}
+	for bump, want := range map[Bump]string{
+		BumpNone:  "v1.4.2",
+		BumpPatch: "v1.4.3",
+		BumpMinor: "v1.5.0",
+		BumpMajor: "v2.0.0",
+	} {
+		if got := v.Apply(bump).String(); got != want {
+			t.Errorf("Apply(%s) = %s, want %s", bump, got, want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package stream
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+type chatLine struct {
+	Object  string `json:"object"`
+	Message string `json:"message"`
+	Error   *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens        int `json:"prompt_tokens"`
+		CompletionTokens    int `json:"completion_tokens"`
+		PromptTokensDetails *struct {
+			CachedTokens int `json:"cached_tokens"`
+		} `json:"prompt_tokens_details"`
+	} `json:"usage"`
+}
+
+// DecodeChatCompletion decodes a line of the server-sent events of a
+// streamed OpenAI-style chat completion ("data: {...}"), as sent by the
+// Mistral and Azure OpenAI APIs. Every chunk carries a delta of the reply;
+// the last one also carries the token usage. Errors come as Mistral's
+// {"object": "error"} or OpenAI's {"error": {...}} chunks, and a reply cut
+// off by a content filter is reported as an error too.
+func DecodeChatCompletion(line string) []Event {
+	data, ok := SSEData(line)
+	if !ok {
+		return nil
+	}
+	var l chatLine
+	if err := json.Unmarshal([]byte(data), &l); err != nil {
+		return nil
+	}
+	switch {
+	case l.Object == "error":
+		return []Event{Error{Message: l.Message}}
+	case l.Error != nil:
+		return []Event{Error{Message: l.Error.Message}}
+	}
+	var events []Event
+	for _, c := range l.Choices {
+		if c.Delta.Content != "" {
+			events = append(events, TextDelta{Text: c.Delta.Content})
+		}
+		if c.FinishReason == "content_filter" {
+			events = append(events, Error{Message: "the response was blocked by the content filter"})
+		}
+	}
+	if u := l.Usage; u != nil {
+		usage := Usage{InputTokens: u.PromptTokens, OutputTokens: u.CompletionTokens}
+		if d := u.PromptTokensDetails; d != nil {
+			usage.CachedInputTokens = d.CachedTokens
+		}
+		events = append(events, usage)
+	}
+	return events
+}
+
+// SSEData returns the payload of a server-sent events "data:" line. The
+// "[DONE]" sentinel that ends OpenAI-style streams and every other field
+// (event:, id:, comments) report false.
+func SSEData(line string) (string, bool) {
+	data, ok := strings.CutPrefix(line, "data:")
+	data = strings.TrimSpace(data)
+	if !ok || data == "" || data == "[DONE]" {
+		return "", false
+	}
+	return data, true
+}
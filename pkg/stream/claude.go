@@ -0,0 +1,158 @@
+package stream
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// ClaudeResult is the final "result" event of a claude run.
+type ClaudeResult struct {
+	Type         string                      `json:"type"`
+	Subtype      string                      `json:"subtype"`
+	Result       string                      `json:"result"`
+	TotalCostUSD float64                     `json:"total_cost_usd"`
+	DurationMS   int                         `json:"duration_ms"`
+	DurationAPI  int                         `json:"duration_api_ms"`
+	IsError      bool                        `json:"is_error"`
+	NumTurns     int                         `json:"num_turns"`
+	SessionID    string                      `json:"session_id"`
+	Usage        ClaudeUsage                 `json:"usage"`
+	ModelUsage   map[string]ClaudeModelUsage `json:"modelUsage"`
+}
+
+// ClaudeUsage is the token accounting of a ClaudeResult.
+type ClaudeUsage struct {
+	InputTokens              int           `json:"input_tokens"`
+	CacheCreationInputTokens int           `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int           `json:"cache_read_input_tokens"`
+	OutputTokens             int           `json:"output_tokens"`
+	ServerToolUse            ServerToolUse `json:"server_tool_use"`
+}
+
+// ServerToolUse counts server-side tool calls of a claude run.
+type ServerToolUse struct {
+	WebSearchRequests int `json:"web_search_requests"`
+}
+
+// ClaudeModelUsage is the per-model accounting of a ClaudeResult.
+type ClaudeModelUsage struct {
+	InputTokens              int     `json:"inputTokens"`
+	OutputTokens             int     `json:"outputTokens"`
+	CacheReadInputTokens     int     `json:"cacheReadInputTokens"`
+	CacheCreationInputTokens int     `json:"cacheCreationInputTokens"`
+	WebSearchRequests        int     `json:"webSearchRequests"`
+	CostUSD                  float64 `json:"costUSD"`
+}
+
+type claudeLine struct {
+	Type    string `json:"type"`
+	Message struct {
+		Content []struct {
+			Type  string `json:"type"`
+			Text  string `json:"text"`
+			Name  string `json:"name"`
+			Input struct {
+				Description string `json:"description"`
+				Command     string `json:"command"`
+				FilePath    string `json:"file_path"`
+				Pattern     string `json:"pattern"`
+			} `json:"input"`
+		} `json:"content"`
+		Usage struct {
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+	Event struct {
+		Type  string `json:"type"`
+		Delta struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"delta"`
+	} `json:"event"`
+}
+
+// DecodeClaude decodes a line of claude stream-json output. Assistant
+// messages yield their tool-use or text step as Reasoning, each tool_use
+// block as a ToolCall, a leading text block as Message and their output
+// tokens as Usage; text_delta stream events yield TextDelta and the final
+// event a ClaudeResult.
+func DecodeClaude(line string) []Event {
+	var l claudeLine
+	if err := json.Unmarshal([]byte(line), &l); err != nil {
+		return nil
+	}
+	switch l.Type {
+	case "stream_event":
+		if l.Event.Type == "content_block_delta" && l.Event.Delta.Type == "text_delta" && l.Event.Delta.Text != "" {
+			return []Event{TextDelta{Text: l.Event.Delta.Text}}
+		}
+	case "assistant":
+		var events []Event
+		if step := claudeStep(l); step != "" {
+			events = append(events, Reasoning{Text: step})
+		}
+		for _, c := range l.Message.Content {
+			if c.Type == "tool_use" {
+				events = append(events, ToolCall{Text: joinNonEmpty(": ", c.Name, firstNonEmpty(c.Input.Command, c.Input.FilePath, c.Input.Pattern))})
+			}
+		}
+		if c := l.Message.Content; len(c) > 0 && c[0].Type == "text" && c[0].Text != "" {
+			events = append(events, Message{Text: c[0].Text})
+		}
+		if n := l.Message.Usage.OutputTokens; n > 0 {
+			events = append(events, Usage{OutputTokens: n})
+		}
+		return events
+	case "result":
+		var r ClaudeResult
+		if json.Unmarshal([]byte(line), &r) == nil {
+			return []Event{r}
+		}
+	}
+	return nil
+}
+
+// claudeStep returns the displayable step of an assistant message: the
+// last tool_use description and/or command, else its first non-blank text.
+func claudeStep(l claudeLine) string {
+	var step string
+	for _, c := range l.Message.Content {
+		switch c.Type {
+		case "tool_use":
+			desc, cmd := c.Input.Description, c.Input.Command
+			switch {
+			case desc != "" && cmd != "":
+				step = desc + ": " + cmd
+			case desc != "":
+				step = desc
+			case cmd != "":
+				step = cmd
+			}
+		case "text":
+			if step == "" {
+				step = strings.TrimSpace(c.Text)
+			}
+		}
+	}
+	return step
+}
+
+// joinNonEmpty joins the non-empty parts with sep.
+func joinNonEmpty(sep string, parts ...string) string {
+	kept := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p != "" {
+			kept = append(kept, p)
+		}
+	}
+	return strings.Join(kept, sep)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
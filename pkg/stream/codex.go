@@ -0,0 +1,83 @@
+package stream
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+type codexLine struct {
+	Type     string `json:"type"`
+	Text     string `json:"text"`
+	Message  string `json:"message"`
+	ThreadID string `json:"thread_id"`
+	Item     struct {
+		Type    string `json:"type"`
+		Text    string `json:"text"`
+		Command string `json:"command"`
+	} `json:"item"`
+	Usage *struct {
+		InputTokens       int `json:"input_tokens"`
+		CachedInputTokens int `json:"cached_input_tokens"`
+		OutputTokens      int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// DecodeCodex decodes a line of codex exec --json output (stdout or
+// stderr). Both the item.completed events of current releases and the flat
+// events of older ones are understood.
+func DecodeCodex(line string) []Event {
+	var l codexLine
+	if err := json.Unmarshal([]byte(line), &l); err != nil {
+		return nil
+	}
+	switch l.Type {
+	case "thread.started":
+		if l.ThreadID != "" {
+			return []Event{Session{ID: l.ThreadID}}
+		}
+	case "reasoning":
+		if l.Text != "" {
+			return []Event{Reasoning{Text: l.Text}}
+		}
+	case "agent_message":
+		if strings.TrimSpace(l.Text) != "" {
+			return []Event{Message{Text: l.Text}}
+		}
+	case "item.started":
+		if l.Item.Type == "command_execution" && l.Item.Command != "" {
+			return []Event{ToolCall{Text: unwrapShell(l.Item.Command)}}
+		}
+	case "item.completed":
+		switch {
+		case l.Item.Type == "reasoning" && l.Item.Text != "":
+			return []Event{Reasoning{Text: l.Item.Text}}
+		case l.Item.Type == "agent_message" && strings.TrimSpace(l.Item.Text) != "":
+			return []Event{Message{Text: l.Item.Text}}
+		}
+	case "turn.completed":
+		if u := l.Usage; u != nil {
+			return []Event{Usage{InputTokens: u.InputTokens, CachedInputTokens: u.CachedInputTokens, OutputTokens: u.OutputTokens}}
+		}
+	case "error":
+		// The message may itself be JSON with a "detail" field.
+		var detail struct{ Detail string }
+		if json.Unmarshal([]byte(l.Message), &detail) == nil && detail.Detail != "" {
+			return []Event{Error{Message: detail.Detail}}
+		}
+		if l.Message != "" {
+			return []Event{Error{Message: l.Message}}
+		}
+	}
+	return nil
+}
+
+// unwrapShell returns the script of a `bash -lc '...'` command line, as
+// codex wraps the commands it runs, or command unchanged.
+func unwrapShell(command string) string {
+	for _, shell := range []string{"bash -lc ", "sh -c ", "zsh -lc "} {
+		if script, ok := strings.CutPrefix(command, shell); ok {
+			return strings.Trim(script, `'"`)
+		}
+	}
+	return command
+}
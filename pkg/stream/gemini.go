@@ -0,0 +1,54 @@
+package stream
+
+import "encoding/json"
+
+type geminiLine struct {
+	Type      string `json:"type"`
+	SessionID string `json:"session_id"`
+	Role      string `json:"role"`
+	Content   string `json:"content"`
+	Status    string `json:"status"`
+	ToolName  string `json:"tool_name"`
+	// Parameters are the arguments of a tool_use event.
+	Parameters struct {
+		Command  string `json:"command"`
+		FilePath string `json:"file_path"`
+		Path     string `json:"path"`
+		Pattern  string `json:"pattern"`
+	} `json:"parameters"`
+	Stats *struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"stats"`
+}
+
+// DecodeGemini decodes a line of gemini stream-json output. Assistant
+// message events are deltas of the reply, tool_use events are ToolCalls
+// and the result event carries the token stats and an "error" status.
+func DecodeGemini(line string) []Event {
+	var l geminiLine
+	if err := json.Unmarshal([]byte(line), &l); err != nil {
+		return nil
+	}
+	var events []Event
+	if l.SessionID != "" {
+		events = append(events, Session{ID: l.SessionID})
+	}
+	switch l.Type {
+	case "message":
+		if l.Role == "assistant" && l.Content != "" {
+			events = append(events, TextDelta{Text: l.Content})
+		}
+	case "tool_use":
+		p := l.Parameters
+		events = append(events, ToolCall{Text: joinNonEmpty(": ", l.ToolName, firstNonEmpty(p.Command, p.FilePath, p.Path, p.Pattern))})
+	case "result":
+		if s := l.Stats; s != nil {
+			events = append(events, Usage{InputTokens: s.InputTokens, OutputTokens: s.OutputTokens})
+		}
+	}
+	if l.Status == "error" {
+		events = append(events, Error{Message: "gemini returned an error"})
+	}
+	return events
+}
@@ -0,0 +1,66 @@
+// Package stream decodes the NDJSON event streams of the backend CLIs
+// (claude --output-format=stream-json, codex exec --json, gemini
+// --output-format stream-json) and the server-sent events of the HTTP
+// backends (Mistral, Azure OpenAI, Vertex AI) into typed events, so the providers share one set of
+// event types instead of probing map[string]any themselves.
+package stream
+
+// Event is one decoded stream event: Session, Reasoning, ToolCall,
+// TextDelta, Message, Usage, Error or ClaudeResult.
+type Event interface {
+	event()
+}
+
+// Decoder decodes one line of a backend stream into its events. Lines that
+// are not JSON or carry nothing of interest decode to no events.
+type Decoder func(line string) []Event
+
+// Session reports the backend session (thread) ID, for later resumes.
+type Session struct {
+	ID string
+}
+
+// Reasoning is a reasoning or tool-use step, as shown below the spinner.
+type Reasoning struct {
+	Text string
+}
+
+// ToolCall is a tool the model invoked, such as a shell command or a file
+// read, described for display.
+type ToolCall struct {
+	Text string
+}
+
+// TextDelta is an increment of the assistant's reply; the reply is the
+// concatenation of all deltas.
+type TextDelta struct {
+	Text string
+}
+
+// Message is a complete assistant message. The last one is the reply of
+// backends that do not stream deltas.
+type Message struct {
+	Text string
+}
+
+// Usage is a token count report. Codex and gemini report totals for the
+// run; claude reports the output tokens of each assistant message.
+type Usage struct {
+	InputTokens       int
+	CachedInputTokens int
+	OutputTokens      int
+}
+
+// Error is an error reported inside the stream.
+type Error struct {
+	Message string
+}
+
+func (Session) event()      {}
+func (Reasoning) event()    {}
+func (ToolCall) event()     {}
+func (TextDelta) event()    {}
+func (Message) event()      {}
+func (Usage) event()        {}
+func (Error) event()        {}
+func (ClaudeResult) event() {}
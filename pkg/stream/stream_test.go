@@ -0,0 +1,151 @@
+package stream
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/ndjson"
+)
+
+func TestDecodeFixtures(t *testing.T) {
+	t.Parallel()
+
+	const (
+		claudeSession = "6f1c2a9e-4d1b-4c55-9d0e-2b7f0c1d9a11"
+		codexThread   = "0199a213-81c0-7800-8aa1-bbab2a035a53"
+		geminiSession = "c5b1d6a0-3f52-4d7e-9a39-0c6f2f1e8b77"
+	)
+	tests := []struct {
+		fixture string
+		decode  Decoder
+		want    []Event
+	}{
+		{
+			fixture: "claude.ndjson",
+			decode:  DecodeClaude,
+			want: []Event{
+				Reasoning{Text: "Check recent commit style: git log --oneline -5"},
+				ToolCall{Text: "Bash: git log --oneline -5"},
+				Usage{OutputTokens: 42},
+				TextDelta{Text: "fix(upload): "},
+				TextDelta{Text: "retry on 503"},
+				Reasoning{Text: "fix(upload): retry on 503"},
+				Message{Text: "fix(upload): retry on 503"},
+				Usage{OutputTokens: 9},
+				ClaudeResult{
+					Type:         "result",
+					Subtype:      "success",
+					Result:       "fix(upload): retry on 503",
+					TotalCostUSD: 0.0123,
+					DurationMS:   5120,
+					DurationAPI:  4870,
+					NumTurns:     2,
+					SessionID:    claudeSession,
+					Usage: ClaudeUsage{
+						InputTokens:              8,
+						CacheCreationInputTokens: 1200,
+						CacheReadInputTokens:     15000,
+						OutputTokens:             51,
+					},
+					ModelUsage: map[string]ClaudeModelUsage{
+						"claude-sonnet-4-5-20250929": {
+							InputTokens:              8,
+							OutputTokens:             51,
+							CacheReadInputTokens:     15000,
+							CacheCreationInputTokens: 1200,
+							CostUSD:                  0.0123,
+						},
+					},
+				},
+			},
+		},
+		{
+			fixture: "codex.ndjson",
+			decode:  DecodeCodex,
+			want: []Event{
+				Session{ID: codexThread},
+				Reasoning{Text: "**Reviewing the staged diff**\n\nThe change adds a retry loop to the upload client."},
+				ToolCall{Text: "git log --oneline -3"},
+				Message{Text: "fix(upload): retry on 503\n\nRetry uploads up to three times with backoff."},
+				Usage{InputTokens: 24763, CachedInputTokens: 24448, OutputTokens: 122},
+				Error{Message: "The 'gpt-5-codex-mini' model is not supported when using Codex with a ChatGPT account."},
+				Error{Message: "stream disconnected before completion"},
+			},
+		},
+		{
+			fixture: "gemini.ndjson",
+			decode:  DecodeGemini,
+			want: []Event{
+				Session{ID: geminiSession},
+				ToolCall{Text: "run_shell_command: git log --oneline -3"},
+				TextDelta{Text: "fix(upload): "},
+				TextDelta{Text: "retry on 503"},
+				Usage{InputTokens: 1790, OutputTokens: 40},
+				Error{Message: "gemini returned an error"},
+			},
+		},
+		{
+			fixture: "mistral.sse",
+			decode:  DecodeChatCompletion,
+			want: []Event{
+				TextDelta{Text: "fix(upload): "},
+				TextDelta{Text: "retry on 503"},
+				Usage{InputTokens: 1650, OutputTokens: 9},
+				Error{Message: "Service tier capacity exceeded for this model."},
+			},
+		},
+		{
+			fixture: "azure.sse",
+			decode:  DecodeChatCompletion,
+			want: []Event{
+				TextDelta{Text: "fix(upload): retry on 503"},
+				Usage{InputTokens: 1702, CachedInputTokens: 1536, OutputTokens: 8},
+				Error{Message: "the response was blocked by the content filter"},
+				Error{Message: "Requests to the ChatCompletions_Create Operation have exceeded call rate limit."},
+			},
+		},
+		{
+			fixture: "vertex.sse",
+			decode:  DecodeVertex,
+			want: []Event{
+				Reasoning{Text: "**Reading the diff** The upload client now retries."},
+				TextDelta{Text: "fix(upload): "},
+				TextDelta{Text: "retry on 503"},
+				Usage{InputTokens: 1810, CachedInputTokens: 1024, OutputTokens: 121},
+				Error{Message: "the response was blocked: SAFETY"},
+				Error{Message: "the prompt was blocked: PROHIBITED_CONTENT"},
+				Error{Message: "Resource exhausted. Please try again later."},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := os.Open(filepath.Join("testdata", tt.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			var got []Event
+			reader := ndjson.NewReader(f)
+			for line := range reader.Lines() {
+				got = append(got, tt.decode(line)...)
+			}
+			if err := reader.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("decoded %d events, want %d:\n%#v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				if !reflect.DeepEqual(got[i], tt.want[i]) {
+					t.Errorf("event %d = %#v, want %#v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package stream
+
+import (
+	"encoding/json"
+	"slices"
+)
+
+type vertexLine struct {
+	Candidates []struct {
+		Content struct {
+			Parts []struct {
+				Text    string `json:"text"`
+				Thought bool   `json:"thought"`
+			} `json:"parts"`
+		} `json:"content"`
+		FinishReason string `json:"finishReason"`
+	} `json:"candidates"`
+	PromptFeedback *struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback"`
+	UsageMetadata *struct {
+		PromptTokenCount        int `json:"promptTokenCount"`
+		CachedContentTokenCount int `json:"cachedContentTokenCount"`
+		CandidatesTokenCount    int `json:"candidatesTokenCount"`
+		ThoughtsTokenCount      int `json:"thoughtsTokenCount"`
+	} `json:"usageMetadata"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// blockedFinishReasons are the finish reasons of a reply cut off by a
+// safety or policy filter.
+var blockedFinishReasons = []string{"SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII"}
+
+// DecodeVertex decodes a line of the server-sent events of a Vertex AI
+// streamGenerateContent request (alt=sse). Text parts are deltas of the
+// reply and thought parts are reasoning. Only the last chunk carries token
+// counts in its usage metadata.
+func DecodeVertex(line string) []Event {
+	data, ok := SSEData(line)
+	if !ok {
+		return nil
+	}
+	var l vertexLine
+	if err := json.Unmarshal([]byte(data), &l); err != nil {
+		return nil
+	}
+	if l.Error != nil {
+		return []Event{Error{Message: l.Error.Message}}
+	}
+	if f := l.PromptFeedback; f != nil && f.BlockReason != "" {
+		return []Event{Error{Message: "the prompt was blocked: " + f.BlockReason}}
+	}
+	var events []Event
+	for _, c := range l.Candidates {
+		for _, p := range c.Content.Parts {
+			switch {
+			case p.Text == "":
+			case p.Thought:
+				events = append(events, Reasoning{Text: p.Text})
+			default:
+				events = append(events, TextDelta{Text: p.Text})
+			}
+		}
+		if slices.Contains(blockedFinishReasons, c.FinishReason) {
+			events = append(events, Error{Message: "the response was blocked: " + c.FinishReason})
+		}
+	}
+	if u := l.UsageMetadata; u != nil && u.PromptTokenCount > 0 {
+		events = append(events, Usage{
+			InputTokens:       u.PromptTokenCount,
+			CachedInputTokens: u.CachedContentTokenCount,
+			OutputTokens:      u.CandidatesTokenCount + u.ThoughtsTokenCount,
+		})
+	}
+	return events
+}
@@ -0,0 +1,130 @@
+// Package transcript persists the reasoning and raw backend output of the
+// last run (.git/git-ai/last-run.json) so it can be read after the spinner
+// is gone (git-cc-ai last).
+package transcript
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dlnilsson/git-cc-ai/pkg/git"
+)
+
+const fileName = "last-run.json"
+
+// Step is one reasoning or tool-use update shown by the spinner.
+type Step struct {
+	Time time.Time `json:"time"`
+	Text string    `json:"text"`
+}
+
+// Transcript is the record of one generation.
+type Transcript struct {
+	Time    time.Time `json:"time"`
+	Backend string    `json:"backend"`
+	Model   string    `json:"model,omitempty"`
+	Message string    `json:"message,omitempty"`
+	// Usage is the backend's token/cost report, one "key=value ..." line
+	// per trailer line.
+	Usage string `json:"usage,omitempty"`
+	// Error is set when the run failed.
+	Error     string `json:"error,omitempty"`
+	Reasoning []Step `json:"reasoning,omitempty"`
+	// Events are the raw output lines (NDJSON) of the backend CLI.
+	Events []string `json:"events,omitempty"`
+}
+
+// Path returns the transcript file of the current worktree.
+func Path() (string, error) {
+	dir, err := git.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "git-ai", fileName), nil
+}
+
+// Load returns the last transcript, or nil (no error) when none was saved.
+func Load() (*Transcript, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var t Transcript
+	if err = json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &t, nil
+}
+
+// Save replaces the last transcript with t, stamping Time when unset.
+func Save(t Transcript) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if t.Time.IsZero() {
+		t.Time = time.Now()
+	}
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// AddReasoning records a reasoning update. Streaming backends report the
+// text accumulated so far, so an update extending the previous step
+// replaces it instead of repeating it.
+func (t *Transcript) AddReasoning(text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	if n := len(t.Reasoning); n > 0 && strings.HasPrefix(text, t.Reasoning[n-1].Text) {
+		t.Reasoning[n-1].Text = text
+		return
+	}
+	t.Reasoning = append(t.Reasoning, Step{Time: time.Now(), Text: text})
+}
+
+// Format renders t for the terminal: a header line, the numbered reasoning
+// steps, the message and the usage report.
+func (t Transcript) Format() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s  %s", t.Time.Local().Format(time.DateTime), t.Backend)
+	if t.Model != "" {
+		b.WriteString(" " + t.Model)
+	}
+	b.WriteString("\n")
+	if len(t.Reasoning) > 0 {
+		b.WriteString("\nReasoning:\n")
+		for i, s := range t.Reasoning {
+			fmt.Fprintf(&b, "%3d. [+%s] %s\n", i+1, s.Time.Sub(t.Time).Round(100*time.Millisecond), strings.ReplaceAll(s.Text, "\n", "\n     "))
+		}
+	}
+	if t.Error != "" {
+		b.WriteString("\nError:\n" + t.Error + "\n")
+	}
+	if t.Message != "" {
+		b.WriteString("\nMessage:\n" + t.Message + "\n")
+	}
+	if t.Usage != "" {
+		b.WriteString("\nUsage:\n" + t.Usage + "\n")
+	}
+	return b.String()
+}
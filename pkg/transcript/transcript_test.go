@@ -0,0 +1,51 @@
+package transcript
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAddReasoningMergesStreamedText(t *testing.T) {
+	t.Parallel()
+
+	var tr Transcript
+	for _, text := range []string{"Reading", "Reading the diff", "  ", "Running git log", "Running git log"} {
+		tr.AddReasoning(text)
+	}
+	got := make([]string, 0, len(tr.Reasoning))
+	for _, s := range tr.Reasoning {
+		got = append(got, s.Text)
+	}
+	if want := "Reading the diff|Running git log"; strings.Join(got, "|") != want {
+		t.Errorf("Reasoning = %q, want %q", strings.Join(got, "|"), want)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	tr := Transcript{
+		Time:      start,
+		Backend:   "codex",
+		Model:     "gpt-5-codex",
+		Message:   "fix: handle empty diff",
+		Usage:     "tokens: in=10 out=5",
+		Reasoning: []Step{{Time: start.Add(1500 * time.Millisecond), Text: "Inspecting\nthe diff"}},
+	}
+	out := tr.Format()
+	for _, want := range []string{
+		" codex gpt-5-codex\n",
+		"  1. [+1.5s] Inspecting\n     the diff\n",
+		"\nMessage:\nfix: handle empty diff\n",
+		"\nUsage:\ntokens: in=10 out=5\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Format() missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Error:") {
+		t.Errorf("Format() printed an empty error:\n%s", out)
+	}
+}
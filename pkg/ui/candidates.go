@@ -0,0 +1,94 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// ErrRegenerate is returned by SelectCandidate when the user asks for a new
+// set of candidates.
+var ErrRegenerate = errors.New("regenerate requested")
+
+type candidateModel struct {
+	candidates []Candidate
+	cursor     int
+	selected   int
+	regenerate bool
+	done       bool
+}
+
+// SelectCandidate shows candidates as a list with a preview of the
+// highlighted message and returns the chosen index. Pressing r returns
+// ErrRegenerate; in plain mode it returns ErrNotInteractive.
+func SelectCandidate(candidates []Candidate) (int, error) {
+	if plain {
+		return -1, ErrNotInteractive
+	}
+	if len(candidates) == 0 {
+		return -1, errors.New("no candidates available for selection")
+	}
+	m := candidateModel{candidates: candidates, selected: -1}
+	p := tea.NewProgram(m, tea.WithOutput(getTerminalOutput()))
+	final, err := p.Run()
+	if err != nil {
+		return -1, err
+	}
+	fm := final.(candidateModel)
+	if fm.regenerate {
+		return -1, ErrRegenerate
+	}
+	if fm.selected < 0 {
+		return -1, errors.New("no candidate selected")
+	}
+	return fm.selected, nil
+}
+
+func (m candidateModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m candidateModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc", "q":
+			return m, tea.Quit
+		case "enter":
+			m.selected = m.cursor
+			m.done = true
+			return m, tea.Quit
+		case "r":
+			m.regenerate = true
+			m.done = true
+			return m, tea.Quit
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.candidates)-1 {
+				m.cursor++
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m candidateModel) View() tea.View {
+	if m.done {
+		return tea.NewView("\r\033[2K")
+	}
+	var b strings.Builder
+	b.WriteString("\nSelect a commit message:\n\n")
+	for i, c := range m.candidates {
+		subject, _, _ := strings.Cut(strings.TrimSpace(c.Message), "\n")
+		b.WriteString(fmt.Sprintf(" %s %s\n", cursor(m.cursor == i), subject))
+	}
+	b.WriteString("\n")
+	b.WriteString(compareBorder.Render(strings.TrimSpace(m.candidates[m.cursor].Message)))
+	b.WriteString("\n\nEnter to select, r to regenerate, q/esc to cancel.\n")
+	return tea.NewView(b.String())
+}
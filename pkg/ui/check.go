@@ -0,0 +1,35 @@
+package ui
+
+import "charm.land/lipgloss/v2"
+
+// Check levels of a report such as git-cc-ai doctor.
+const (
+	CheckOK   = "ok"
+	CheckWarn = "warn"
+	CheckFail = "fail"
+	CheckSkip = "skip" // not applicable, e.g. a backend that is not installed
+)
+
+var checkMarks = map[string]struct {
+	mark, plain string
+	color       string
+}{
+	CheckOK:   {"✓", "[ok]  ", "2"},
+	CheckWarn: {"!", "[warn]", "3"},
+	CheckFail: {"✗", "[FAIL]", "1"},
+	CheckSkip: {"-", "[-]   ", "241"},
+}
+
+// CheckMark returns the mark of a check level for stdout: a colored symbol
+// on a terminal, a bracketed word in plain mode or when stdout is
+// redirected (e.g. pasted into a support request).
+func CheckMark(level string) string {
+	m, ok := checkMarks[level]
+	if !ok {
+		return level
+	}
+	if plain || !stdoutIsTerminal() {
+		return m.plain
+	}
+	return lipgloss.NewStyle().Foreground(themeColor(m.color)).Bold(true).Render(m.mark)
+}
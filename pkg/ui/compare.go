@@ -0,0 +1,97 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+)
+
+// Candidate is one generated message offered for selection.
+type Candidate struct {
+	Label   string
+	Message string
+}
+
+type compareModel struct {
+	candidates []Candidate
+	cursor     int
+	width      int
+	selected   int
+	done       bool
+}
+
+var compareLabel = lipgloss.NewStyle().Bold(true)
+
+// SelectCandidateSideBySide renders candidates in columns and returns the
+// index of the chosen one. In plain mode it returns ErrNotInteractive.
+func SelectCandidateSideBySide(candidates []Candidate) (int, error) {
+	if plain {
+		return -1, ErrNotInteractive
+	}
+	if len(candidates) == 0 {
+		return -1, errors.New("no candidates available for selection")
+	}
+	m := compareModel{candidates: candidates, selected: -1, width: 120}
+	p := tea.NewProgram(m, tea.WithOutput(getTerminalOutput()))
+	final, err := p.Run()
+	if err != nil {
+		return -1, err
+	}
+	selected := final.(compareModel).selected
+	if selected < 0 {
+		return -1, errors.New("no candidate selected")
+	}
+	return selected, nil
+}
+
+func (m compareModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m compareModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+	case tea.KeyPressMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc", "q":
+			return m, tea.Quit
+		case "enter":
+			m.selected = m.cursor
+			m.done = true
+			return m, tea.Quit
+		case "left", "h", "shift+tab":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "right", "l", "tab":
+			if m.cursor < len(m.candidates)-1 {
+				m.cursor++
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m compareModel) View() tea.View {
+	if m.done {
+		return tea.NewView("\r\033[2K")
+	}
+	colWidth := max(m.width/len(m.candidates), 24)
+	cols := make([]string, 0, len(m.candidates))
+	for i, c := range m.candidates {
+		style := compareBorder
+		if i == m.cursor {
+			style = compareSelected
+		}
+		body := compareLabel.Render(c.Label) + "\n\n" + strings.TrimSpace(c.Message)
+		cols = append(cols, style.Width(colWidth).Render(body))
+	}
+	var b strings.Builder
+	b.WriteString("\nCompare candidates:\n\n")
+	b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, cols...))
+	b.WriteString("\n\n←/→ to move, Enter to select, q/esc to cancel.\n")
+	return tea.NewView(b.String())
+}
@@ -0,0 +1,36 @@
+package ui
+
+import (
+	"os"
+
+	"github.com/charmbracelet/glamour"
+)
+
+// markdownWidth is the word-wrap width of rendered Markdown documents.
+const markdownWidth = 100
+
+// RenderMarkdown renders a Markdown document for the terminal. The text is
+// returned unchanged in plain mode, when stdout is not a terminal, or when
+// rendering fails.
+func RenderMarkdown(text string) string {
+	if plain || !stdoutIsTerminal() {
+		return text
+	}
+	renderer, err := glamour.NewTermRenderer(
+		markdownStyle(),
+		glamour.WithWordWrap(markdownWidth),
+	)
+	if err != nil {
+		return text
+	}
+	out, err := renderer.Render(text)
+	if err != nil {
+		return text
+	}
+	return out
+}
+
+func stdoutIsTerminal() bool {
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
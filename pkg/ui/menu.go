@@ -8,42 +8,51 @@ import (
 	tea "charm.land/bubbletea/v2"
 )
 
-type modelSelectModel struct {
+type selectModel struct {
+	title    string
 	choices  []string
 	cursor   int
-	selected string
+	selected int
 	done     bool
 }
 
-func SelectModelMenu(choices []string) (string, error) {
-	if len(choices) == 0 {
-		return "", errors.New("no models available for selection")
+// errNothingSelected is returned by SelectOption when the menu is
+// cancelled.
+var errNothingSelected = errors.New("nothing selected")
+
+// SelectOption lets the user pick one of options under title and returns
+// its index. In plain mode it returns ErrNotInteractive.
+func SelectOption(title string, options []string) (int, error) {
+	if plain {
+		return -1, ErrNotInteractive
+	}
+	if len(options) == 0 {
+		return -1, errors.New("no options available for selection")
 	}
-	m := modelSelectModel{choices: choices}
-	p := tea.NewProgram(m)
+	p := tea.NewProgram(selectModel{title: title, choices: options, selected: -1}, tea.WithOutput(getTerminalOutput()))
 	final, err := p.Run()
 	if err != nil {
-		return "", err
+		return -1, err
 	}
-	selected := final.(modelSelectModel).selected
-	if strings.TrimSpace(selected) == "" {
-		return "", errors.New("no model selected")
+	selected := final.(selectModel).selected
+	if selected < 0 {
+		return -1, errNothingSelected
 	}
 	return selected, nil
 }
 
-func (m modelSelectModel) Init() tea.Cmd {
+func (m selectModel) Init() tea.Cmd {
 	return nil
 }
 
-func (m modelSelectModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+func (m selectModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyPressMsg:
 		switch msg.String() {
 		case "ctrl+c", "esc", "q":
 			return m, tea.Quit
 		case "enter":
-			m.selected = m.choices[m.cursor]
+			m.selected = m.cursor
 			m.done = true
 			return m, tea.Quit
 		case "up", "k":
@@ -59,18 +68,14 @@ func (m modelSelectModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-func (m modelSelectModel) View() tea.View {
+func (m selectModel) View() tea.View {
 	if m.done {
 		return tea.NewView("\r\033[2K")
 	}
 	var b strings.Builder
-	b.WriteString("\nSelect a model:\n\n")
+	b.WriteString("\n" + m.title + "\n\n")
 	for i, choice := range m.choices {
-		cursor := " "
-		if m.cursor == i {
-			cursor = ">"
-		}
-		b.WriteString(fmt.Sprintf(" %s %s\n", cursor, choice))
+		b.WriteString(fmt.Sprintf(" %s %s\n", cursor(m.cursor == i), choice))
 	}
 	b.WriteString("\nEnter to select, q/esc to cancel.\n")
 	return tea.NewView(b.String())
@@ -0,0 +1,169 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// ModelChoice is one model offered by SelectModelMenu.
+type ModelChoice struct {
+	Name string
+	// Tier is the price tier ("$", "$$" or "$$$"); empty when unknown.
+	Tier string
+	// ContextWindow is the context window in tokens; 0 when unknown.
+	ContextWindow int
+	// Default marks the backend's default model.
+	Default bool
+}
+
+type modelMenu struct {
+	choices  []ModelChoice
+	filter   string
+	matches  []int
+	cursor   int
+	selected int
+	done     bool
+}
+
+// SelectModelMenu lets the user pick one of choices, filtering them by
+// typing a fuzzy pattern. The cursor starts on preselect when it is one of
+// the choices. In plain mode it returns ErrNotInteractive.
+func SelectModelMenu(choices []ModelChoice, preselect string) (string, error) {
+	if plain {
+		return "", ErrNotInteractive
+	}
+	if len(choices) == 0 {
+		return "", errors.New("no models available for selection")
+	}
+	m := modelMenu{choices: choices, selected: -1}
+	m.refilter()
+	for i, c := range choices {
+		if c.Name == preselect {
+			m.cursor = i
+		}
+	}
+	final, err := tea.NewProgram(m, tea.WithOutput(getTerminalOutput())).Run()
+	if err != nil {
+		return "", err
+	}
+	selected := final.(modelMenu).selected
+	if selected < 0 {
+		return "", errors.New("no model selected")
+	}
+	return choices[selected].Name, nil
+}
+
+// FuzzyMatch reports whether the letters of pattern appear in s in order,
+// ignoring case, so "g5m" matches "gpt-5.1-codex-mini".
+func FuzzyMatch(pattern, s string) bool {
+	s = strings.ToLower(s)
+	for _, r := range strings.ToLower(pattern) {
+		i := strings.IndexRune(s, r)
+		if i < 0 {
+			return false
+		}
+		s = s[i+utf8.RuneLen(r):]
+	}
+	return true
+}
+
+// refilter recomputes the matches for the filter, keeping the cursor on
+// the same model when it still matches.
+func (m *modelMenu) refilter() {
+	current := -1
+	if m.cursor < len(m.matches) {
+		current = m.matches[m.cursor]
+	}
+	m.matches = m.matches[:0]
+	m.cursor = 0
+	for i, c := range m.choices {
+		if FuzzyMatch(m.filter, c.Name) {
+			if i == current {
+				m.cursor = len(m.matches)
+			}
+			m.matches = append(m.matches, i)
+		}
+	}
+}
+
+func (m modelMenu) Init() tea.Cmd {
+	return nil
+}
+
+func (m modelMenu) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return m, nil
+	}
+	switch key.String() {
+	case "ctrl+c", "esc":
+		return m, tea.Quit
+	case "enter":
+		if len(m.matches) == 0 {
+			return m, nil
+		}
+		m.selected = m.matches[m.cursor]
+		m.done = true
+		return m, tea.Quit
+	case "up", "ctrl+p":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "ctrl+n":
+		if m.cursor < len(m.matches)-1 {
+			m.cursor++
+		}
+	case "backspace":
+		if m.filter != "" {
+			_, size := utf8.DecodeLastRuneInString(m.filter)
+			m.filter = m.filter[:len(m.filter)-size]
+			m.refilter()
+		}
+	default:
+		if key.Text != "" {
+			m.filter += key.Text
+			m.refilter()
+		}
+	}
+	return m, nil
+}
+
+func (m modelMenu) View() tea.View {
+	if m.done {
+		return tea.NewView("\r\033[2K")
+	}
+	width := 0
+	for _, c := range m.choices {
+		width = max(width, len(c.Name))
+	}
+	var b strings.Builder
+	b.WriteString("\nSelect a model: " + m.filter + "\n\n")
+	for i, idx := range m.matches {
+		c := m.choices[idx]
+		line := fmt.Sprintf("%-*s  %-3s  %s", width, c.Name, c.Tier, formatContextWindow(c.ContextWindow))
+		if c.Default {
+			line += "  (default)"
+		}
+		fmt.Fprintf(&b, " %s %s\n", cursor(m.cursor == i), strings.TrimRight(line, " "))
+	}
+	if len(m.matches) == 0 {
+		b.WriteString("   no model matches\n")
+	}
+	b.WriteString("\nType to filter, Enter to select, esc to cancel.\n")
+	return tea.NewView(b.String())
+}
+
+// formatContextWindow renders a context window as "400k ctx" or "1M ctx".
+func formatContextWindow(tokens int) string {
+	switch {
+	case tokens <= 0:
+		return ""
+	case tokens >= 1_000_000:
+		return fmt.Sprintf("%dM ctx", tokens/1_000_000)
+	}
+	return fmt.Sprintf("%dk ctx", tokens/1000)
+}
@@ -0,0 +1,238 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// ErrSetupCancelled is returned by Onboard when the user leaves the wizard.
+var ErrSetupCancelled = errors.New("setup cancelled")
+
+// SetupBackend is a backend offered by the first-run wizard.
+type SetupBackend struct {
+	Name string
+	// Detected reports that the backend's CLI is in PATH or its API is
+	// configured.
+	Detected bool
+	Models   []ModelChoice
+}
+
+// Setup is what the user chose in the first-run wizard.
+type Setup struct {
+	Backend string
+	// Model is empty for the backend's default.
+	Model string
+	// Standard selects plain commit messages instead of Conventional
+	// Commits.
+	Standard bool
+	// InstallHook asks for the post-commit hook.
+	InstallHook bool
+}
+
+type onboardingStep int
+
+const (
+	stepBackend onboardingStep = iota
+	stepModel
+	stepStyle
+	stepHook
+	stepConfirm
+)
+
+var onboardingStyles = []string{
+	"Conventional Commits (feat(scope): subject)",
+	"Standard (a plain imperative subject)",
+}
+
+var onboardingHook = []string{
+	"Yes, install the post-commit hook",
+	"No, skip it",
+}
+
+type onboardingModel struct {
+	backends []SetupBackend
+	step     onboardingStep
+	cursor   int
+	setup    Setup
+	backend  int
+	done     bool
+	finished bool
+}
+
+// Onboard walks the user through choosing a backend (detected ones are
+// marked and the first is preselected), a model, the commit style and the
+// post-commit hook, then asks to confirm. It returns ErrSetupCancelled when
+// the user leaves and ErrNotInteractive in plain mode.
+func Onboard(backends []SetupBackend) (Setup, error) {
+	if plain {
+		return Setup{}, ErrNotInteractive
+	}
+	if len(backends) == 0 {
+		return Setup{}, errors.New("no backends available for setup")
+	}
+	m := onboardingModel{backends: backends}
+	for i, b := range backends {
+		if b.Detected {
+			m.cursor = i
+			break
+		}
+	}
+	final, err := tea.NewProgram(m, tea.WithOutput(getTerminalOutput())).Run()
+	if err != nil {
+		return Setup{}, err
+	}
+	fm := final.(onboardingModel)
+	if !fm.finished {
+		return Setup{}, ErrSetupCancelled
+	}
+	return fm.setup, nil
+}
+
+// options returns the rows of the current step.
+func (m onboardingModel) options() []string {
+	switch m.step {
+	case stepBackend:
+		rows := make([]string, len(m.backends))
+		for i, b := range m.backends {
+			rows[i] = b.Name
+			if b.Detected {
+				rows[i] += "  (detected)"
+			}
+		}
+		return rows
+	case stepModel:
+		models := m.backends[m.backend].Models
+		rows := make([]string, len(models))
+		width := 0
+		for _, c := range models {
+			width = max(width, len(c.Name))
+		}
+		for i, c := range models {
+			rows[i] = strings.TrimRight(fmt.Sprintf("%-*s  %-3s  %s", width, c.Name, c.Tier, formatContextWindow(c.ContextWindow)), " ")
+			if c.Default {
+				rows[i] += "  (default)"
+			}
+		}
+		return rows
+	case stepStyle:
+		return onboardingStyles
+	case stepHook:
+		return onboardingHook
+	}
+	return nil
+}
+
+func (m onboardingModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m onboardingModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyPressMsg)
+	if !ok {
+		return m, nil
+	}
+	switch key.String() {
+	case "ctrl+c", "esc", "q":
+		m.done = true
+		return m, tea.Quit
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.options())-1 {
+			m.cursor++
+		}
+	case "left", "h", "backspace":
+		if m.step > stepBackend {
+			m.step--
+			if m.step == stepModel && len(m.backends[m.backend].Models) == 0 {
+				m.step--
+			}
+			m.cursor = 0
+		}
+	case "enter":
+		return m.choose()
+	}
+	return m, nil
+}
+
+// choose records the highlighted row and moves to the next step.
+func (m onboardingModel) choose() (tea.Model, tea.Cmd) {
+	switch m.step {
+	case stepBackend:
+		m.backend = m.cursor
+		m.setup.Backend = m.backends[m.cursor].Name
+		m.cursor = 0
+		for i, c := range m.backends[m.backend].Models {
+			if c.Default {
+				m.cursor = i
+			}
+		}
+		if len(m.backends[m.backend].Models) == 0 {
+			m.setup.Model = ""
+			m.step = stepStyle
+			m.cursor = 0
+			return m, nil
+		}
+	case stepModel:
+		m.setup.Model = ""
+		if c := m.backends[m.backend].Models[m.cursor]; !c.Default {
+			m.setup.Model = c.Name
+		}
+		m.cursor = 0
+	case stepStyle:
+		m.setup.Standard = m.cursor == 1
+		m.cursor = 0
+	case stepHook:
+		m.setup.InstallHook = m.cursor == 0
+		m.cursor = 0
+	case stepConfirm:
+		m.finished = true
+		m.done = true
+		return m, tea.Quit
+	}
+	m.step++
+	return m, nil
+}
+
+var onboardingTitles = map[onboardingStep]string{
+	stepBackend: "Which backend should write your commit messages?",
+	stepModel:   "Which model?",
+	stepStyle:   "Which commit style?",
+	stepHook:    "Install a post-commit hook so git-cc-ai learns from the edits you make to its messages?",
+}
+
+func (m onboardingModel) View() tea.View {
+	if m.done {
+		return tea.NewView("\r\033[2K")
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "\nWelcome to git-cc-ai! Step %d of 5.\n\n", int(m.step)+1)
+	if m.step == stepConfirm {
+		model := m.setup.Model
+		if model == "" {
+			model = "default"
+		}
+		style := "Conventional Commits"
+		if m.setup.Standard {
+			style = "standard"
+		}
+		hook := "no"
+		if m.setup.InstallHook {
+			hook = "yes"
+		}
+		fmt.Fprintf(&b, "Backend: %s\nModel:   %s\nStyle:   %s\nHook:    %s\n", m.setup.Backend, model, style, hook)
+		b.WriteString("\nEnter to save to .agentrc and generate, ← to go back, q/esc to cancel.\n")
+		return tea.NewView(b.String())
+	}
+	b.WriteString(onboardingTitles[m.step] + "\n\n")
+	for i, row := range m.options() {
+		fmt.Fprintf(&b, " %s %s\n", cursor(m.cursor == i), row)
+	}
+	b.WriteString("\nEnter to choose, ← to go back, q/esc to cancel.\n")
+	return tea.NewView(b.String())
+}
@@ -0,0 +1,165 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotInteractive is returned by the pickers in plain mode, where no
+// interactive terminal UI is started.
+var ErrNotInteractive = errors.New("interactive selection is not available without a terminal")
+
+var (
+	plain       bool
+	accessible  bool
+	activePlain *plainProgress
+)
+
+// accessibleInterval is how often accessible mode repeats the status while
+// a backend runs.
+const accessibleInterval = 10 * time.Second
+
+// SetPlain switches all UI to plain line output: no bubbletea programs, no
+// interactive menus and no access to /dev/tty.
+func SetPlain(v bool) { plain = v }
+
+// Plain reports whether plain mode is active.
+func Plain() bool { return plain }
+
+// DetectPlain reports whether plain mode should be used: GIT_AI_PLAIN is set
+// or stderr is not a terminal (CI, git hooks, redirected output). stdout is
+// not checked because it is normally captured by git.
+func DetectPlain() bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("GIT_AI_PLAIN"))) {
+	case "1", "true", "yes":
+		return true
+	}
+	info, err := os.Stderr.Stat()
+	return err != nil || info.Mode()&os.ModeCharDevice == 0
+}
+
+// SetAccessible switches progress to periodic plain status lines without
+// animation or redraws, for screen readers and dumb terminals. Interactive
+// menus are kept.
+func SetAccessible(v bool) { accessible = v }
+
+// DetectAccessible reports whether accessible mode should be used:
+// GIT_AI_ACCESSIBLE is set or TERM is "dumb".
+func DetectAccessible() bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("GIT_AI_ACCESSIBLE"))) {
+	case "1", "true", "yes":
+		return true
+	}
+	return os.Getenv("TERM") == "dumb"
+}
+
+// plainProgress prints spinner updates as plain lines on stderr.
+type plainProgress struct {
+	mu    sync.Mutex
+	start time.Time
+	last  string
+	usage SpinnerUsage
+	steps SpinnerProgress
+}
+
+func startPlainProgress(message, backend string) func() {
+	p := &plainProgress{start: time.Now()}
+	if backend != "" {
+		message += " (using " + backend + ")"
+	}
+	fmt.Fprintln(Status(), message)
+	activePlain = p
+	stopTicker := make(chan struct{})
+	if accessible {
+		go p.tick(stopTicker)
+	}
+	var stopOnce sync.Once
+	return func() {
+		stopOnce.Do(func() {
+			close(stopTicker)
+			activePlain = nil
+			p.mu.Lock()
+			u := p.usage
+			p.mu.Unlock()
+			details := make([]string, 0, 2)
+			if u.OutputTokens > 0 {
+				tokens := fmt.Sprintf("%d output tokens", u.OutputTokens)
+				if u.Estimated {
+					tokens = "~" + tokens
+				}
+				details = append(details, tokens)
+			}
+			if cost := formatCost(u); cost != "" {
+				details = append(details, cost)
+			}
+			if len(details) > 0 {
+				fmt.Fprintf(Status(), "done in %.1fs (%s)\n", time.Since(p.start).Seconds(), strings.Join(details, ", "))
+				return
+			}
+			fmt.Fprintf(Status(), "done in %.1fs\n", time.Since(p.start).Seconds())
+		})
+	}
+}
+
+// tick repeats the status every accessibleInterval until stop is closed.
+func (p *plainProgress) tick(stop <-chan struct{}) {
+	ticker := time.NewTicker(accessibleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			u, steps := p.usage, p.steps
+			p.mu.Unlock()
+			status := fmt.Sprintf("still working, %.0fs elapsed", time.Since(p.start).Seconds())
+			if steps.Total > 0 {
+				status += ", " + steps.String()
+			}
+			if u.OutputTokens > 0 {
+				status += fmt.Sprintf(", %d output tokens", u.OutputTokens)
+			}
+			fmt.Fprintln(Status(), status)
+		}
+	}
+}
+
+// setUsage records the latest totals for the final line.
+func (p *plainProgress) setUsage(u SpinnerUsage) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.usage = u
+}
+
+// setProgress prints the progress line when the count changes.
+func (p *plainProgress) setProgress(pr SpinnerProgress) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if pr.Done == p.steps.Done && pr.Total == p.steps.Total {
+		p.steps = pr
+		return
+	}
+	p.steps = pr
+	fmt.Fprintln(Status(), "  "+pr.String())
+}
+
+// reason prints the first line of text unless it repeats the previous one,
+// or all of it in verbose mode.
+func (p *plainProgress) reason(text string) {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	if IsVerbose() {
+		line = strings.ReplaceAll(strings.TrimSpace(text), "\n", "\n  ")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if line == "" || line == p.last {
+		return
+	}
+	p.last = line
+	fmt.Fprintln(Status(), "  "+line)
+}
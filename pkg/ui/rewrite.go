@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+// ErrDeclined is returned by ReviewRewrite when the user rejects the new
+// message.
+var ErrDeclined = errors.New("new message declined")
+
+// DiffSpan is a run of text in a word diff: Op is '-' for removed, '+' for
+// added and ' ' for kept text.
+type DiffSpan struct {
+	Op   byte
+	Text string
+}
+
+// RenderWordDiff renders spans with removed text in red and added text in
+// green, or with git's [-removed-]{+added+} markers in plain mode. Styles
+// are applied per line so that they do not run into the border.
+func RenderWordDiff(spans []DiffSpan) string {
+	var b strings.Builder
+	for _, s := range spans {
+		switch {
+		case s.Op == ' ':
+			b.WriteString(s.Text)
+		case plain && s.Op == '-':
+			b.WriteString("[-" + s.Text + "-]")
+		case plain:
+			b.WriteString("{+" + s.Text + "+}")
+		default:
+			style := diffAdded
+			if s.Op == '-' {
+				style = diffRemoved
+			}
+			lines := strings.Split(s.Text, "\n")
+			for i, line := range lines {
+				if line != "" {
+					lines[i] = style.Render(line)
+				}
+			}
+			b.WriteString(strings.Join(lines, "\n"))
+		}
+	}
+	return b.String()
+}
+
+type rewriteModel struct {
+	title      string
+	diff       string
+	accepted   bool
+	regenerate bool
+	done       bool
+}
+
+// ReviewRewrite shows the word diff from a previous message to a new one
+// under title and waits for a decision: nil to accept the new message,
+// ErrRegenerate to ask for another one and ErrDeclined to keep neither. In
+// plain mode it returns ErrNotInteractive.
+func ReviewRewrite(title string, spans []DiffSpan) error {
+	if plain {
+		return ErrNotInteractive
+	}
+	m := rewriteModel{title: title, diff: strings.TrimSpace(RenderWordDiff(spans))}
+	p := tea.NewProgram(m, tea.WithOutput(getTerminalOutput()))
+	final, err := p.Run()
+	if err != nil {
+		return err
+	}
+	switch fm := final.(rewriteModel); {
+	case fm.regenerate:
+		return ErrRegenerate
+	case !fm.accepted:
+		return ErrDeclined
+	}
+	return nil
+}
+
+func (m rewriteModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m rewriteModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyPressMsg); ok {
+		switch msg.String() {
+		case "ctrl+c", "esc", "q", "n":
+			m.done = true
+			return m, tea.Quit
+		case "enter", "y":
+			m.accepted = true
+			m.done = true
+			return m, tea.Quit
+		case "r":
+			m.regenerate = true
+			m.done = true
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func (m rewriteModel) View() tea.View {
+	if m.done {
+		return tea.NewView("\r\033[2K")
+	}
+	var b strings.Builder
+	b.WriteString("\n" + m.title + "\n\n")
+	b.WriteString(compareBorder.Render(m.diff))
+	b.WriteString("\n\nEnter/y to accept, r to regenerate, q/esc to decline.\n")
+	return tea.NewView(b.String())
+}
@@ -3,15 +3,18 @@ package ui
 import (
 	"fmt"
 	"io"
+	"math"
+	"math/rand/v2"
 	"os"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	"charm.land/bubbles/v2/progress"
 	"charm.land/bubbles/v2/spinner"
 	tea "charm.land/bubbletea/v2"
-	"charm.land/lipgloss/v2"
 	"github.com/charmbracelet/glamour"
 )
 
@@ -23,47 +26,134 @@ type spinnerDoneMsg struct{}
 
 type spinnerReasoningMsg string
 
+type spinnerPreviewMsg string
+
+type spinnerUsageMsg SpinnerUsage
+
+type spinnerProgressMsg SpinnerProgress
+
+// SpinnerUsage is the token and cost accounting shown next to the spinner.
+type SpinnerUsage struct {
+	OutputTokens int
+	// Estimated marks OutputTokens counted from the streamed text rather
+	// than reported by the backend.
+	Estimated bool
+	// CostUSD is the running cost estimate; zero when the price of the
+	// model is unknown.
+	CostUSD float64
+	// BudgetUSD is the spending limit of the run, or zero.
+	BudgetUSD float64
+}
+
+// SpinnerProgress is the progress of a run made of several steps, shown as
+// a bar below the spinner.
+type SpinnerProgress struct {
+	Done  int
+	Total int
+	// Label follows the count, e.g. "directories summarized".
+	Label string
+	// Current names the steps in progress, e.g. the directories being
+	// summarized.
+	Current string
+}
+
+// progressWidth is the width of the progress bar in cells.
+const progressWidth = 30
+
+// typicalMessageTokens is the expected length of a streamed commit message,
+// from which the remaining time is estimated at the current token rate.
+const typicalMessageTokens = 150
+
 type spinnerModel struct {
 	spinner           spinner.Model
 	message           string
 	backend           string
 	reasoning         string
 	reasoningRendered string
+	previewRendered   string
+	usage             SpinnerUsage
+	progress          SpinnerProgress
+	bar               progress.Model
+	firstToken        time.Time
 	done              bool
 	start             time.Time
 	forwarder         SignalForwarder
 }
 
 type spinnerHandle struct {
-	program  *tea.Program
-	reasonCh chan string
-	doneCh   chan struct{}
+	program   *tea.Program
+	reasonCh  chan string
+	previewCh chan string
+	usageCh   chan SpinnerUsage
+	stepCh    chan SpinnerProgress
+	doneCh    chan struct{}
 }
 
-var spinnerMessages = []string{
+// Spinner personalities (GIT_AI_SPINNER).
+const (
+	// SpinnerFun mixes jokes into the messages and animations.
+	SpinnerFun = "fun"
+	// SpinnerProfessional only uses plain status messages and animations,
+	// e.g. for screen-shared demos.
+	SpinnerProfessional = "professional"
+	// SpinnerOff shows no spinner at all, like --no-spinner.
+	SpinnerOff = "off"
+)
+
+// SpinnerModes lists the accepted spinner personalities.
+var SpinnerModes = []string{SpinnerFun, SpinnerProfessional, SpinnerOff}
+
+var professionalMessages = []string{
 	"Generating commit message...",
 	"Summarizing staged changes...",
 	"Drafting Conventional Commit...",
-	"Giving birth to skynet",
 	"Analyzing diff hunks...",
 	"Composing commit summary...",
-	"Buying Sam Altman a new ferrari...",
 }
 
-var spinnerStyles = []spinner.Spinner{
+var funMessages = append(slices.Clone(professionalMessages),
+	"Giving birth to skynet",
+	"Buying Sam Altman a new ferrari...",
+)
+
+var professionalStyles = []spinner.Spinner{
 	spinner.Line,
 	spinner.Dot,
 	spinner.MiniDot,
-	spinner.Jump,
 	spinner.Pulse,
 	spinner.Points,
+}
+
+var funStyles = append(slices.Clone(professionalStyles),
+	spinner.Jump,
 	spinner.Globe,
 	spinner.Moon,
 	spinner.Monkey,
+)
+
+var (
+	spinnerMode     = SpinnerFun
+	spinnerMessages = funMessages
+	spinnerStyles   = funStyles
+)
+
+// SetSpinner selects the spinner personality and, when messages is not
+// empty, replaces the built-in status messages with it. Unknown modes
+// keep the fun personality.
+func SetSpinner(mode string, messages []string) {
+	spinnerMode, spinnerMessages, spinnerStyles = SpinnerFun, funMessages, funStyles
+	switch mode {
+	case SpinnerProfessional:
+		spinnerMode, spinnerMessages, spinnerStyles = mode, professionalMessages, professionalStyles
+	case SpinnerOff:
+		spinnerMode = mode
+	}
+	if len(messages) > 0 {
+		spinnerMessages = messages
+	}
 }
 
 var (
-	reasoningStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render
 	markdownRenderer *glamour.TermRenderer
 	activeSpinner    *spinnerHandle
 )
@@ -90,13 +180,21 @@ func getTerminalOutput() io.Writer {
 }
 
 func StartSpinner(message string, backend string, forwarder SignalForwarder) func() {
-	_ = os.Setenv("CLICOLOR_FORCE", "1")
+	if IsQuiet() || spinnerMode == SpinnerOff {
+		return func() {}
+	}
+	if plain || accessible {
+		return startPlainProgress(message, backend)
+	}
 	markdownRenderer = newMarkdownRenderer()
 	p := tea.NewProgram(newSpinnerModel(message, backend, forwarder), tea.WithOutput(getTerminalOutput()))
 	handle := &spinnerHandle{
-		program:  p,
-		reasonCh: make(chan string, 8),
-		doneCh:   make(chan struct{}),
+		program:   p,
+		reasonCh:  make(chan string, 8),
+		previewCh: make(chan string, 8),
+		usageCh:   make(chan SpinnerUsage, 8),
+		stepCh:    make(chan SpinnerProgress, 8),
+		doneCh:    make(chan struct{}),
 	}
 	activeSpinner = handle
 	done := make(chan struct{})
@@ -111,6 +209,14 @@ func StartSpinner(message string, backend string, forwarder SignalForwarder) fun
 				if strings.TrimSpace(text) != "" {
 					handle.program.Send(spinnerReasoningMsg(text))
 				}
+			case text := <-handle.previewCh:
+				if strings.TrimSpace(text) != "" {
+					handle.program.Send(spinnerPreviewMsg(text))
+				}
+			case u := <-handle.usageCh:
+				handle.program.Send(spinnerUsageMsg(u))
+			case pr := <-handle.stepCh:
+				handle.program.Send(spinnerProgressMsg(pr))
 			case <-handle.doneCh:
 				return
 			}
@@ -128,6 +234,10 @@ func StartSpinner(message string, backend string, forwarder SignalForwarder) fun
 }
 
 func SendSpinnerReasoning(text string) {
+	if p := activePlain; p != nil {
+		p.reason(text)
+		return
+	}
 	if activeSpinner == nil {
 		return
 	}
@@ -137,19 +247,63 @@ func SendSpinnerReasoning(text string) {
 	}
 }
 
-func RandomSpinnerMessage() string {
-	if len(spinnerMessages) == 0 {
-		return "Generating commit message with Codex..."
+// SendSpinnerPreview replaces the streamed message preview shown below the
+// spinner. text is the full message accumulated so far, so dropped updates
+// are harmless.
+func SendSpinnerPreview(text string) {
+	if activeSpinner == nil {
+		return
+	}
+	select {
+	case activeSpinner.previewCh <- text:
+	default:
+	}
+}
+
+// SendSpinnerUsage updates the token count, throughput and cost shown
+// next to the spinner. u holds the totals so far, so dropped updates are
+// harmless.
+func SendSpinnerUsage(u SpinnerUsage) {
+	if p := activePlain; p != nil {
+		p.setUsage(u)
+		return
+	}
+	if activeSpinner == nil {
+		return
+	}
+	select {
+	case activeSpinner.usageCh <- u:
+	default:
 	}
-	seed := time.Now().UnixNano()
-	return spinnerMessages[int(seed%int64(len(spinnerMessages)))]
+}
+
+// SendSpinnerProgress replaces the progress bar shown below the spinner.
+// pr holds the totals so far, so dropped updates are harmless.
+func SendSpinnerProgress(pr SpinnerProgress) {
+	if p := activePlain; p != nil {
+		p.setProgress(pr)
+		return
+	}
+	if activeSpinner == nil {
+		return
+	}
+	select {
+	case activeSpinner.stepCh <- pr:
+	default:
+	}
+}
+
+// RandomSpinnerMessage returns one of the status messages of the current
+// personality.
+func RandomSpinnerMessage() string {
+	return spinnerMessages[rand.IntN(len(spinnerMessages))]
 }
 
 func newSpinnerModel(message string, backend string, forwarder SignalForwarder) spinnerModel {
 	s := spinner.New()
 	s.Spinner = randomSpinnerStyle()
-	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
-	return spinnerModel{spinner: s, message: message, backend: backend, start: time.Now(), forwarder: forwarder}
+	styleSpinner(&s)
+	return spinnerModel{spinner: s, bar: newProgressBar(), message: message, backend: backend, start: time.Now(), forwarder: forwarder}
 }
 
 func (m spinnerModel) Init() tea.Cmd {
@@ -165,6 +319,18 @@ func (m spinnerModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.reasoning = string(msg)
 		m.reasoningRendered = renderReasoning(m.reasoning)
 		return m, nil
+	case spinnerPreviewMsg:
+		m.previewRendered = renderReasoning(string(msg))
+		return m, nil
+	case spinnerUsageMsg:
+		if m.firstToken.IsZero() && msg.OutputTokens > 0 {
+			m.firstToken = time.Now()
+		}
+		m.usage = SpinnerUsage(msg)
+		return m, nil
+	case spinnerProgressMsg:
+		m.progress = SpinnerProgress(msg)
+		return m, nil
 	case tea.KeyPressMsg:
 		if msg.String() == "ctrl+c" && m.forwarder != nil {
 			m.forwarder.ForwardSignal(os.Interrupt)
@@ -182,19 +348,87 @@ func (m spinnerModel) View() tea.View {
 	}
 	elapsed := time.Since(m.start).Seconds()
 	elapsedStr := fmt.Sprintf("%.1fs", elapsed)
+	if details := m.usageDetails(); details != "" {
+		elapsedStr += " · " + details
+	}
 	backendTag := ""
 	if m.backend != "" {
 		backendTag = " " + reasoningStyle("(using "+m.backend+")")
 	}
+	if m.progress.Total > 0 {
+		return tea.NewView(fmt.Sprintf("\n  %s %s%s (%s)\n  %s %s\n", m.spinner.View(), m.message, backendTag, elapsedStr, m.bar.ViewAs(m.progress.fraction()), m.progress))
+	}
+	if strings.TrimSpace(m.previewRendered) != "" {
+		return tea.NewView(fmt.Sprintf("\n  %s %s%s (%s)\n%s\n", m.spinner.View(), m.message, backendTag, elapsedStr, m.previewRendered))
+	}
 	if strings.TrimSpace(m.reasoningRendered) != "" {
 		return tea.NewView(fmt.Sprintf("\n  %s %s%s (%s)\n  %s\n", m.spinner.View(), m.message, backendTag, elapsedStr, m.reasoningRendered))
 	}
 	return tea.NewView(fmt.Sprintf("\n  %s %s%s (%s)\n", m.spinner.View(), m.message, backendTag, elapsedStr))
 }
 
+// fraction returns the completed share of the steps, between 0 and 1.
+func (pr SpinnerProgress) fraction() float64 {
+	if pr.Total <= 0 {
+		return 0
+	}
+	return min(float64(pr.Done)/float64(pr.Total), 1)
+}
+
+// String returns the count with its label and the steps in progress, e.g.
+// "3/7 directories summarized · pkg/ui".
+func (pr SpinnerProgress) String() string {
+	s := fmt.Sprintf("%d/%d", pr.Done, pr.Total)
+	if pr.Label != "" {
+		s += " " + pr.Label
+	}
+	if pr.Current != "" {
+		s += " · " + pr.Current
+	}
+	return s
+}
+
+// usageDetails returns the token count, the output rate since the first
+// token, the remaining time while a message streams and the cost so far,
+// joined by " · ".
+func (m spinnerModel) usageDetails() string {
+	u := m.usage
+	parts := make([]string, 0, 4)
+	if u.OutputTokens > 0 {
+		tokens := fmt.Sprintf("%d tok", u.OutputTokens)
+		if u.Estimated {
+			tokens = "~" + tokens
+		}
+		parts = append(parts, tokens)
+		if since := time.Since(m.firstToken).Seconds(); since >= 1 {
+			rate := float64(u.OutputTokens) / since
+			parts = append(parts, fmt.Sprintf("%.0f tok/s", rate))
+			if u.Estimated && u.OutputTokens < typicalMessageTokens {
+				parts = append(parts, fmt.Sprintf("~%.0fs left", math.Ceil(float64(typicalMessageTokens-u.OutputTokens)/rate)))
+			}
+		}
+	}
+	if cost := formatCost(u); cost != "" {
+		parts = append(parts, cost)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// formatCost returns the cost estimate of u with its budget, or an empty
+// string when the cost is unknown.
+func formatCost(u SpinnerUsage) string {
+	if u.CostUSD <= 0 {
+		return ""
+	}
+	if u.BudgetUSD > 0 {
+		return fmt.Sprintf("~$%.4f of $%.2f", u.CostUSD, u.BudgetUSD)
+	}
+	return fmt.Sprintf("~$%.4f", u.CostUSD)
+}
+
 func newMarkdownRenderer() *glamour.TermRenderer {
 	renderer, err := glamour.NewTermRenderer(
-		glamour.WithAutoStyle(),
+		markdownStyle(),
 		glamour.WithWordWrap(0),
 	)
 	if err != nil {
@@ -215,9 +449,5 @@ func renderReasoning(text string) string {
 }
 
 func randomSpinnerStyle() spinner.Spinner {
-	if len(spinnerStyles) == 0 {
-		return spinner.Dot
-	}
-	seed := time.Now().UnixNano()
-	return spinnerStyles[int(seed%int64(len(spinnerStyles)))]
+	return spinnerStyles[rand.IntN(len(spinnerStyles))]
 }
@@ -0,0 +1,132 @@
+package ui
+
+import (
+	"image/color"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"charm.land/bubbles/v2/progress"
+	"charm.land/bubbles/v2/spinner"
+	"charm.land/lipgloss/v2"
+	"github.com/charmbracelet/glamour"
+)
+
+// Theme holds the colors of the terminal UI as ANSI color numbers ("205")
+// or hex values ("#ff5f87").
+type Theme struct {
+	// Accent colors the spinner, the menu cursor and the selected
+	// candidate.
+	Accent string
+	// Reasoning colors the reasoning text below the spinner and the
+	// borders of message previews.
+	Reasoning string
+}
+
+// DefaultTheme is the theme used when none is configured.
+var DefaultTheme = Theme{Accent: "205", Reasoning: "241"}
+
+var colorPattern = regexp.MustCompile(`^(#[0-9a-fA-F]{3}|#[0-9a-fA-F]{6}|\d{1,3})$`)
+
+var (
+	theme  = DefaultTheme
+	colors = true
+)
+
+var (
+	accentStyle     lipgloss.Style
+	reasoningStyle  func(...string) string
+	compareBorder   lipgloss.Style
+	compareSelected lipgloss.Style
+	diffRemoved     lipgloss.Style
+	diffAdded       lipgloss.Style
+)
+
+func init() { applyTheme() }
+
+// ValidColor reports whether s is an ANSI color number (0-255) or a hex
+// color.
+func ValidColor(s string) bool {
+	if !colorPattern.MatchString(s) {
+		return false
+	}
+	n, err := strconv.Atoi(s)
+	return err != nil || n <= 255
+}
+
+// SetTheme sets the colors of the UI. Empty or invalid fields keep the
+// default.
+func SetTheme(t Theme) {
+	theme = DefaultTheme
+	if ValidColor(t.Accent) {
+		theme.Accent = t.Accent
+	}
+	if ValidColor(t.Reasoning) {
+		theme.Reasoning = t.Reasoning
+	}
+	applyTheme()
+}
+
+// SetColor turns colors on or off. Text decoration such as bold is kept.
+func SetColor(v bool) {
+	colors = v
+	applyTheme()
+}
+
+// DetectColor reports whether colors should be used, following NO_COLOR
+// (any value disables them) and CLICOLOR=0, unless CLICOLOR_FORCE is set.
+func DetectColor() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if force := strings.TrimSpace(os.Getenv("CLICOLOR_FORCE")); force != "" && force != "0" {
+		return true
+	}
+	return strings.TrimSpace(os.Getenv("CLICOLOR")) != "0"
+}
+
+// themeColor returns the color for code, or no color when colors are off.
+func themeColor(code string) color.Color {
+	if !colors {
+		return lipgloss.NoColor{}
+	}
+	return lipgloss.Color(code)
+}
+
+func applyTheme() {
+	accentStyle = lipgloss.NewStyle().Foreground(themeColor(theme.Accent))
+	reasoningStyle = lipgloss.NewStyle().Foreground(themeColor(theme.Reasoning)).Render
+	compareBorder = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(themeColor(theme.Reasoning)).Padding(0, 1)
+	compareSelected = compareBorder.BorderForeground(themeColor(theme.Accent))
+	diffRemoved = lipgloss.NewStyle().Foreground(themeColor("203")).Strikethrough(true)
+	diffAdded = lipgloss.NewStyle().Foreground(themeColor("42")).Underline(true)
+}
+
+// markdownStyle returns the glamour style option for rendered Markdown:
+// the plain "notty" style without colors, otherwise the one for the
+// terminal background.
+func markdownStyle() glamour.TermRendererOption {
+	if !colors {
+		return glamour.WithStandardStyle("notty")
+	}
+	return glamour.WithAutoStyle()
+}
+
+// cursor returns the menu cursor for the highlighted row.
+func cursor(selected bool) string {
+	if selected {
+		return accentStyle.Render(">")
+	}
+	return " "
+}
+
+// styleSpinner colors s with the accent color.
+func styleSpinner(s *spinner.Model) {
+	s.Style = accentStyle
+}
+
+// newProgressBar returns a progress bar filled with the accent color.
+func newProgressBar() progress.Model {
+	return progress.New(progress.WithColors(themeColor(theme.Accent)), progress.WithWidth(progressWidth), progress.WithoutPercentage())
+}
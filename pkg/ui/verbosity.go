@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Verbosity controls how much the tool writes besides the final message.
+type Verbosity int
+
+const (
+	// Quiet writes nothing but errors: no spinner, progress, reasoning or
+	// warnings (-q).
+	Quiet Verbosity = iota - 1
+	// Normal shows the spinner (or plain progress) and warnings.
+	Normal
+	// Verbose also prints every reasoning update and diagnostics such as
+	// the resolved backend and model (-v).
+	Verbose
+)
+
+var verbosity = Normal
+
+// SetVerbosity sets the verbosity for all UI output.
+func SetVerbosity(v Verbosity) { verbosity = v }
+
+// IsQuiet reports whether -q is active.
+func IsQuiet() bool { return verbosity <= Quiet }
+
+// IsVerbose reports whether -v is active.
+func IsVerbose() bool { return verbosity >= Verbose }
+
+// Status returns the writer for progress, warnings and other diagnostics:
+// stderr, or io.Discard in quiet mode. Errors that make the run fail are
+// written to stderr regardless.
+func Status() io.Writer {
+	if IsQuiet() {
+		return io.Discard
+	}
+	return os.Stderr
+}
+
+// Debugf prints a diagnostic line to stderr in verbose mode.
+func Debugf(format string, args ...any) {
+	if IsVerbose() {
+		fmt.Fprintf(os.Stderr, format+"\n", args...)
+	}
+}
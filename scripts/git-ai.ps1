@@ -3,18 +3,7 @@ param(
     [string[]]$ArgsList
 )
 
-$ErrorActionPreference = "Stop"
-
-try {
-    $msg = & git-cc-ai @ArgsList
-    $exitCode = $LASTEXITCODE
-    if ($exitCode -ne 0) {
-        exit $exitCode
-    }
-
-    $messageText = ($msg -join [Environment]::NewLine)
-    $messageText | git commit -F - --edit
-    exit $LASTEXITCODE
-} catch {
-    throw
-}
+# Kept for existing installs and aliases: git-cc-ai commit generates the
+# message and opens it in the editor for git commit.
+& git-cc-ai commit @ArgsList
+exit $LASTEXITCODE
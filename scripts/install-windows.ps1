@@ -22,4 +22,4 @@ Copy-Item (Join-Path $PSScriptRoot "git-ai.ps1") (Join-Path $BinDir "git-ai.ps1"
 
 Write-Host ""
 Write-Host "Done."
-Write-Host "Ensure '$BinDir' is on your PATH, then run: git-ai"
+Write-Host "Ensure '$BinDir' is on your PATH, then run: git-cc-ai alias install --global"